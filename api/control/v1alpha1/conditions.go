@@ -28,6 +28,8 @@ const (
 	TypeSynced ConditionType = "Synced"
 	// TypeStatusCollectorsAvailable indicates whether all required statuscollectors of the bindingpolicy are available.
 	TypeStatusCollectorsAvailable ConditionType = "StatusCollectorsAvailable"
+	// TypeSuspended indicates whether the bindingpolicy is suspended (see BindingPolicySpec.Suspend).
+	TypeSuspended ConditionType = "Suspended"
 )
 
 type ConditionReason string
@@ -45,6 +47,16 @@ const (
 	ReasonReconcilePaused  ConditionReason = "ReconcilePaused"
 )
 
+const (
+	ReasonValidConfiguration      ConditionReason = "ValidConfiguration"
+	ReasonInvalidObjectExpression ConditionReason = "InvalidObjectExpression"
+)
+
+const (
+	ReasonSuspendRequested ConditionReason = "SuspendRequested"
+	ReasonNotSuspended     ConditionReason = "NotSuspended"
+)
+
 // BindingPolicyCondition describes the state of a bindingpolicy at a certain point.
 type BindingPolicyCondition struct {
 	Type               ConditionType          `json:"type"`
@@ -197,3 +209,47 @@ func ConditionReconcileError(err error) BindingPolicyCondition {
 		Message:            err.Error(),
 	}
 }
+
+// ConditionMisconfigured returns a condition indicating that the BindingPolicy
+// is misconfigured, for the given reason.
+func ConditionMisconfigured(reason ConditionReason, message string) BindingPolicyCondition {
+	return BindingPolicyCondition{
+		Type:               ConditionType(BindingPolicyConditionMisconfigured),
+		Status:             corev1.ConditionTrue,
+		LastTransitionTime: metav1.Now(),
+		Reason:             reason,
+		Message:            message,
+	}
+}
+
+// ConditionNotMisconfigured returns a condition indicating that no misconfiguration
+// of the BindingPolicy was found.
+func ConditionNotMisconfigured() BindingPolicyCondition {
+	return BindingPolicyCondition{
+		Type:               ConditionType(BindingPolicyConditionMisconfigured),
+		Status:             corev1.ConditionFalse,
+		LastTransitionTime: metav1.Now(),
+		Reason:             ReasonValidConfiguration,
+	}
+}
+
+// ConditionSuspended returns a condition indicating that the BindingPolicy is suspended.
+func ConditionSuspended() BindingPolicyCondition {
+	return BindingPolicyCondition{
+		Type:               TypeSuspended,
+		Status:             corev1.ConditionTrue,
+		LastTransitionTime: metav1.Now(),
+		Reason:             ReasonSuspendRequested,
+		Message:            "Changes are not propagated to the WECs",
+	}
+}
+
+// ConditionNotSuspended returns a condition indicating that the BindingPolicy is not suspended.
+func ConditionNotSuspended() BindingPolicyCondition {
+	return BindingPolicyCondition{
+		Type:               TypeSuspended,
+		Status:             corev1.ConditionFalse,
+		LastTransitionTime: metav1.Now(),
+		Reason:             ReasonNotSuspended,
+	}
+}
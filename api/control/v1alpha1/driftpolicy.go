@@ -0,0 +1,34 @@
+/*
+Copyright 2024 The KubeStellar Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package v1alpha1
+
+// driftPolicyPrecedence ranks the drift policies by which wins when several clauses
+// match an object. Unknown policies, and the empty one, rank like Ignore.
+var driftPolicyPrecedence = map[DriftPolicy]int{
+	DriftPolicyIgnore:    0,
+	DriftPolicyRemediate: 1,
+	DriftPolicyReport:    2,
+}
+
+// PrevailingDriftPolicy returns the one of the two given policies that wins.
+// The result is empty only if both are empty.
+func PrevailingDriftPolicy(left, right DriftPolicy) DriftPolicy {
+	if driftPolicyPrecedence[right] > driftPolicyPrecedence[left] || left == "" {
+		return right
+	}
+	return left
+}
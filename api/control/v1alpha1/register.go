@@ -49,6 +49,8 @@ func addKnownTypes(scheme *runtime.Scheme) error {
 		&StatusCollectorList{},
 		&CombinedStatus{},
 		&CombinedStatusList{},
+		&ReportedState{},
+		&ReportedStateList{},
 	)
 	metav1.AddToGroupVersion(scheme, SchemeGroupVersion)
 	return nil
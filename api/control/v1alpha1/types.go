@@ -20,6 +20,7 @@ import (
 	v1 "k8s.io/apiextensions-apiserver/pkg/apis/apiextensions/v1"
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/util/intstr"
 )
 
 // TemplateExpansionAnnotationKey, when paired with the value "true" in an annotation of
@@ -43,6 +44,10 @@ import (
 // when the name (AKA key) of that label is valid as a Go language identifier.
 // The fourth source is some built-in definitions, of which there is presently just one:
 // the value of the property named "clusterName" is the name of the WEC's inventory object.
+// See PropertyConfigMapJSONAnnotationKey regarding properties whose values are not strings.
+//
+// The functions available in the templates are a curated subset of the Sprig library;
+// see `FuncMap` in github.com/kubestellar/kubestellar/pkg/customize.
 //
 // Any failure in any template expansion for a given Binding suppresses propagation of
 // desired state from that Binding; the previosly propagated desired state from that Binding,
@@ -54,10 +59,45 @@ import (
 
 const TemplateExpansionAnnotationKey string = "control.kubestellar.io/expand-templates"
 
+// TemplateExpansionStructured, as the value of the annotation named by TemplateExpansionAnnotationKey,
+// requests template expansion in which a leaf string that consists entirely of one template
+// action (e.g., "{{ .replicas }}") is replaced by the value obtained by parsing the
+// result of expanding it as YAML (of which JSON is a subset). Thus a leaf string
+// can be customized into a number, boolean, null, map or array.
+// Other leaf strings are expanded as usual.
+const TemplateExpansionStructured string = "structured"
+
 // PropertyConfigMapNamespace is the namespace in the ITS that holds ConfigMap objects that provide
 // WEC properties to be used in customization.
 const PropertyConfigMapNamespace = "customization-properties"
 
+// PropertyConfigMapJSONAnnotationKey, when paired with the value "true" in an annotation of
+// a property ConfigMap, indicates that the string data items of that ConfigMap hold JSON.
+// Each such data item that parses as JSON defines a property whose value is the parsed JSON
+// (e.g., a number or a list) rather than a string.
+const PropertyConfigMapJSONAnnotationKey string = "control.kubestellar.io/json-properties"
+
+// SyncWaveAnnotationKey is the key of an annotation of a workload object in a WDS
+// whose value is an integer (e.g., "-1" or "5") that orders the propagation of the
+// object relative to the other workload objects of the same Binding. The objects of a
+// Binding are propagated in waves of increasing value, and, when the transport can tell,
+// the objects of a wave are propagated to a WEC only after the objects of the earlier
+// waves have been applied there. An object without this annotation is in a wave that
+// depends on its kind: -4 for Namespaces, -3 for CustomResourceDefinitions,
+// -2 for ServiceAccounts and RBAC objects, -1 for ConfigMaps and Secrets,
+// and 0 for everything else.
+const SyncWaveAnnotationKey string = "control.kubestellar.io/sync-wave"
+
+// DeletionPolicyAnnotationKey is the key of an annotation of a workload object in a WDS
+// whose value is a DeletionPolicy ("Delete" or "Orphan"). When present, it overrides the
+// `deletionPolicy` of the downsync clauses that match the object.
+const DeletionPolicyAnnotationKey string = "control.kubestellar.io/deletion-policy"
+
+// AdoptionPolicyAnnotationKey is the key of an annotation of a workload object in a WDS
+// whose value is an AdoptionPolicy ("Adopt" or "Report"). When present, it overrides the
+// `adoptionPolicy` of the downsync clauses that match the object.
+const AdoptionPolicyAnnotationKey string = "control.kubestellar.io/adoption-policy"
+
 // BindingPolicy defines in which ways the workload objects ('what') and the destinations ('where') are bound together.
 // +genclient
 // +genclient:nonNamespaced
@@ -82,28 +122,369 @@ type BindingPolicySpec struct {
 	// A Cluster is relevant if and only if it passes any of the LabelSelectors in this field.
 	ClusterSelectors []metav1.LabelSelector `json:"clusterSelectors,omitempty"`
 
-	// We agreed not to expose NumberOfClusters in release 0.20, to avoid confusions.
-	// We may or may not support it in later releases per future discussions.
-	// NumberOfClusters represents the desired number of ManagedClusters to be selected which meet the
-	// BindingPolicy's requirements.
-	// 1) If not specified, all Clusters which meet the BindingPolicy's requirements will be selected;
-	// 2) Otherwise if the number of Clusters meet the BindingPolicy's requirements is larger than
-	//    NumberOfClusters, a random subset with desired number of ManagedClusters will be selected;
-	// 3) If the number of Clusters meet the BindingPolicy's requirements is equal to NumberOfClusters,
-	//    all of them will be selected;
-	// 4) If the number of Clusters meet the BindingPolicy's requirements is less than NumberOfClusters,
-	//    all of them will be selected, and the status of condition `BindingPolicyConditionSatisfied` will be
-	//    set to false;
-	// +optional
-	// NumberOfClusters *int32 `json:"numberOfClusters,omitempty"`
+	// `excludeClusterSelectors` identifies Cluster objects that are never relevant,
+	// regardless of `clusterSelectors` and `clusterSelectionExpression`.
+	// A Cluster is excluded if it passes any of the LabelSelectors in this field.
+	// +optional
+	ExcludeClusterSelectors []metav1.LabelSelector `json:"excludeClusterSelectors,omitempty"`
+
+	// `clusterSelectionExpression` is an optional CEL expression that further
+	// restricts the relevant Cluster objects. The expression can reference the variable
+	// `cluster`, which holds the whole ManagedCluster object (including its status, and
+	// thus its ClusterClaims, Kubernetes version, allocatable capacity and conditions),
+	// and must evaluate to a boolean.
+	// When this field is set, a Cluster is relevant if and only if it passes
+	// `clusterSelectors` (or `clusterSelectors` is empty) and this expression
+	// evaluates to true; evaluation failure counts as false.
+	// Parsing and type checking errors are posted to the status.Errors of the BindingPolicy.
+	// +optional
+	ClusterSelectionExpression *Expression `json:"clusterSelectionExpression,omitempty"`
+
+	// `placement` optionally narrows the set of Clusters selected by `clusterSelectors`.
+	// When omitted, every selected Cluster is a destination.
+	// +optional
+	Placement *ClusterPlacement `json:"placement,omitempty"`
+
+	// `replicaScheduling` says how the `spec.replicas` of the workload objects
+	// is treated when there are multiple destinations.
+	// When omitted, every destination gets the full `spec.replicas`.
+	// +optional
+	ReplicaScheduling *ReplicaScheduling `json:"replicaScheduling,omitempty"`
+
+	// `rollout`, when set, makes changes reach the destinations progressively,
+	// one wave of destinations at a time.
+	// When omitted, every change is propagated to all destinations at once.
+	// +optional
+	Rollout *RolloutStrategy `json:"rollout,omitempty"`
 
 	// `downsync` selects the objects to bind with the selected WECs for downsync,
 	// and modulates their downsync.
 	// An object is selected if it matches at least one member of this list.
 	// When multiple DownsyncPolicyClause match the same workload object:
-	// the `createOnly` bits are ORed together, and the StatusCollector reference
-	// sets are combined by union.
+	// the most restrictive `updateStrategy` wins, the Report `adoptionPolicy` wins,
+	// the `driftPolicy` that is first in the order Report, Remediate, Ignore wins,
+	// and the StatusCollector reference sets are combined by union.
 	Downsync []DownsyncPolicyClause `json:"downsync,omitempty"`
+
+	// `excludeDownsync` identifies objects that are never selected, regardless of `downsync`.
+	// An object is excluded if it matches at least one member of this list.
+	// Note that a DownsyncObjectTest with no fields set matches every object.
+	// +optional
+	ExcludeDownsync []DownsyncObjectTest `json:"excludeDownsync,omitempty"`
+
+	// `upsync` selects objects in the selected WECs to copy into the WDS,
+	// such as objects created there by operators and other in-cluster controllers.
+	// An object is selected if it matches at least one member of this list.
+	// Objects that KubeStellar downsynced are never upsynced.
+	// +optional
+	Upsync []UpsyncClause `json:"upsync,omitempty"`
+
+	// `suspend`, when true, freezes the corresponding Binding: while suspended,
+	// no new objects, updates or deletions are propagated to the WECs,
+	// although the selection of objects and WECs continues to be tracked.
+	// When set back to false, the accumulated changes are reconciled in one pass.
+	// Deleting the BindingPolicy still removes the workload from the WECs.
+	// +optional
+	Suspend bool `json:"suspend,omitempty"`
+}
+
+// UpsyncClause selects objects in the WECs to copy into the WDS.
+// Each copy is renamed, according to `naming`, so that the copies from different
+// WECs do not collide; a copy that would collide with a different object in the WDS
+// is not made and the collision is reported in `status.upsyncConflicts`.
+type UpsyncClause struct {
+	// `apiGroup` is the API group of the objects to upsync, empty string (the default)
+	// for the core API group.
+	// +optional
+	APIGroup string `json:"apiGroup,omitempty"`
+
+	// `resources` is a list of lowercase plural names for the sorts of objects to upsync.
+	// +kubebuilder:validation:MinItems=1
+	Resources []string `json:"resources"`
+
+	// `namespaces` is a list of acceptable names for the object's namespace.
+	// Empty list is a special case, it matches every object.
+	// +optional
+	Namespaces []string `json:"namespaces,omitempty"`
+
+	// `objectSelectors` is a list of label selectors.
+	// At least one of them must match the labels of the object being tested.
+	// Empty list is a special case, it matches every object.
+	// +optional
+	ObjectSelectors []metav1.LabelSelector `json:"objectSelectors,omitempty"`
+
+	// `objectNames` is a list of object names that match.
+	// Empty list is a special case, it matches every object.
+	// +optional
+	ObjectNames []string `json:"objectNames,omitempty"`
+
+	// `naming` says how the copy in the WDS is named. The default is PrefixName.
+	// +optional
+	Naming UpsyncNaming `json:"naming,omitempty"`
+}
+
+// UpsyncNaming identifies how the WDS copy of an upsynced object is named.
+// +kubebuilder:validation:Enum=PrefixName;PrefixNamespace
+type UpsyncNaming string
+
+const (
+	// UpsyncPrefixName keeps the object's namespace and prefixes its name
+	// with the name of the WEC and a dash.
+	UpsyncPrefixName UpsyncNaming = "PrefixName"
+
+	// UpsyncPrefixNamespace keeps the object's name and prefixes its namespace
+	// with the name of the WEC and a dash. A cluster-scoped object is treated as for PrefixName.
+	UpsyncPrefixNamespace UpsyncNaming = "PrefixNamespace"
+)
+
+// ClusterPlacement narrows the set of Clusters that pass the `clusterSelectors`
+// down to the ones that are actually used as destinations.
+// The choice is deterministic and sticky: a Cluster that is currently a destination
+// remains one as long as it still passes the `clusterSelectors` and fits within
+// `maxClusters`, so that re-resolution does not shuffle workload between Clusters.
+type ClusterPlacement struct {
+	// `maxClusters` is the maximum number of Clusters to use as destinations.
+	// When omitted, every selected Cluster is used.
+	// +optional
+	// +kubebuilder:validation:Minimum=1
+	MaxClusters *int32 `json:"maxClusters,omitempty"`
+
+	// `spreadByLabelKey` is the key of a Cluster label (e.g., a zone label) whose values
+	// partition the selected Clusters into groups. Destinations are taken from those groups
+	// round-robin, so that they are spread as evenly as possible across the label's values.
+	// Clusters lacking the label form one group of their own.
+	// Only meaningful together with `maxClusters`.
+	// +optional
+	SpreadByLabelKey string `json:"spreadByLabelKey,omitempty"`
+
+	// `preferences` orders the selected Clusters.
+	// The score of a Cluster is the sum of the weights of the preferences whose
+	// selector matches the Cluster's labels; higher scores are preferred.
+	// Ties are broken by Cluster name.
+	// Only meaningful together with `maxClusters`.
+	// +optional
+	Preferences []ClusterPreference `json:"preferences,omitempty"`
+
+	// `unavailableClusterGracePeriod`, when set, makes availability matter.
+	// A Cluster is unavailable while its `ManagedClusterConditionAvailable`
+	// condition is missing or not True. A Cluster that has been unavailable for
+	// at least this long is not used as a destination.
+	// Together with `maxClusters`, this moves the workload from such a Cluster
+	// to an available one that passes the `clusterSelectors` (failover).
+	// When omitted, availability is not considered.
+	// +optional
+	UnavailableClusterGracePeriod *metav1.Duration `json:"unavailableClusterGracePeriod,omitempty"`
+}
+
+// ClusterPreference gives a weight to the Clusters that match a label selector.
+type ClusterPreference struct {
+	// `selector` identifies the Clusters that this preference applies to.
+	Selector metav1.LabelSelector `json:"selector"`
+
+	// `weight` is added to the score of every Cluster matched by the selector.
+	// +kubebuilder:validation:Minimum=1
+	// +kubebuilder:validation:Maximum=100
+	Weight int32 `json:"weight"`
+}
+
+// ReplicaSchedulingMode identifies a way of treating `spec.replicas` across destinations.
+// +kubebuilder:validation:Enum=Duplicated;StaticWeighted;PropertyWeighted
+type ReplicaSchedulingMode string
+
+const (
+	// ReplicaSchedulingDuplicated gives every destination the full `spec.replicas`.
+	ReplicaSchedulingDuplicated ReplicaSchedulingMode = "Duplicated"
+
+	// ReplicaSchedulingStaticWeighted divides `spec.replicas` among the destinations
+	// in proportion to weights given in `staticWeights`.
+	ReplicaSchedulingStaticWeighted ReplicaSchedulingMode = "StaticWeighted"
+
+	// ReplicaSchedulingPropertyWeighted divides `spec.replicas` among the destinations
+	// in proportion to weights given by a property of each destination.
+	ReplicaSchedulingPropertyWeighted ReplicaSchedulingMode = "PropertyWeighted"
+)
+
+// ReplicaScheduling says how the `spec.replicas` of workload objects is treated
+// when there are multiple destinations.
+// This applies to every workload object whose `spec.replicas` is an integer
+// (e.g., Deployment, StatefulSet, ReplicaSet). The total is taken from the object
+// as transformed by the destination-independent CustomTransforms, and the
+// destination's share is set after all other customization.
+// When dividing, each destination gets the floor of its share and the remainder is
+// given out one at a time to the destinations with the largest fractional shares,
+// ties broken by cluster name. If all the weights are zero, the replicas are
+// divided evenly.
+type ReplicaScheduling struct {
+	// `mode` is how `spec.replicas` is treated.
+	Mode ReplicaSchedulingMode `json:"mode"`
+
+	// `staticWeights` gives the weights for the StaticWeighted mode.
+	// The weight of a destination comes from the first member whose selector
+	// matches the labels of the destination's inventory object; a destination
+	// matched by none has weight zero.
+	// +optional
+	StaticWeights []ClusterWeight `json:"staticWeights,omitempty"`
+
+	// `weightProperty` is the name of the cluster property that gives the weight of each
+	// destination in the PropertyWeighted mode. Cluster properties are as for
+	// template expansion (see TemplateExpansionAnnotationKey). The value must be a
+	// non-negative integer or a string holding one; a destination lacking the property
+	// has weight zero.
+	// +optional
+	WeightProperty string `json:"weightProperty,omitempty"`
+}
+
+// ClusterWeight gives a weight to the Clusters that match a label selector.
+type ClusterWeight struct {
+	// `selector` identifies the Clusters that this weight applies to.
+	Selector metav1.LabelSelector `json:"selector"`
+
+	// +kubebuilder:validation:Minimum=0
+	Weight int32 `json:"weight"`
+}
+
+// RolloutStrategy says how a change is rolled out to the destinations progressively.
+// The destinations are grouped into ordered waves, and a change is propagated to the
+// destinations of a wave only after the destinations of the previous waves are healthy
+// with that change. A rollout starts at the first wave whenever the Binding changes
+// (that is, whenever its `metadata.generation` changes).
+// Health is judged from the CombinedStatus objects of the workload objects.
+// The referenced StatusCollector must not aggregate; its `select` must include a
+// column named "wec" holding the name of the WEC (e.g., `inventory.name`) and a column
+// named "health" holding "Healthy" or "Failed" (anything else means that the health
+// is not known yet). A destination is healthy when every CombinedStatus
+// that has a result from the StatusCollector has a row for it that says "Healthy".
+type RolloutStrategy struct {
+	// `waves` groups the destinations. Each destination belongs to the first wave
+	// that admits it; destinations admitted by no wave form an implicit last wave.
+	// +kubebuilder:validation:MinItems=1
+	Waves []RolloutWave `json:"waves"`
+
+	// `statusCollector` is the name of the StatusCollector whose results judge health.
+	// It must also be referenced by the `statusCollectors` of the downsync clauses.
+	StatusCollector string `json:"statusCollector"`
+
+	// `waveInterval` is how long a wave soaks after being given the change before its
+	// health is judged. It should be long enough for the change to reach the WECs and
+	// their state to be returned to the CombinedStatus objects. The default is 30s.
+	// +optional
+	WaveInterval *metav1.Duration `json:"waveInterval,omitempty"`
+
+	// `onFailure` says what to do when a destination of the current wave
+	// is reported "Failed". The default is Pause.
+	// +optional
+	OnFailure RolloutFailureAction `json:"onFailure,omitempty"`
+}
+
+// RolloutWave admits some of the destinations not admitted by earlier waves.
+// When both fields are omitted, the wave admits all of them.
+type RolloutWave struct {
+	// `clusterSelector`, when set, admits only the destinations whose inventory
+	// object's labels match.
+	// +optional
+	ClusterSelector *metav1.LabelSelector `json:"clusterSelector,omitempty"`
+
+	// `size`, when set, limits the number of destinations admitted, either as a number
+	// (e.g., 2) or as a percentage of all the destinations (e.g., "25%", rounded up).
+	// The destinations are admitted in order of cluster name.
+	// +optional
+	Size *intstr.IntOrString `json:"size,omitempty"`
+}
+
+// DeletionPolicy identifies what to do with a workload object in a WEC
+// when it stops being propagated there.
+// +kubebuilder:validation:Enum=Delete;Orphan
+type DeletionPolicy string
+
+const (
+	// DeletionPolicyDelete deletes the object from the WEC.
+	DeletionPolicyDelete DeletionPolicy = "Delete"
+
+	// DeletionPolicyOrphan leaves the object in the WEC, no longer managed by KubeStellar.
+	DeletionPolicyOrphan DeletionPolicy = "Orphan"
+)
+
+// AdoptionPolicy identifies what to do with a workload object that already exists
+// in a WEC, and was not put there by KubeStellar, when KubeStellar first propagates it there.
+// +kubebuilder:validation:Enum=Adopt;Report
+type AdoptionPolicy string
+
+const (
+	// AdoptionPolicyAdopt takes over the existing object, writing it as for any other.
+	AdoptionPolicyAdopt AdoptionPolicy = "Adopt"
+
+	// AdoptionPolicyReport leaves the existing object alone and reports, in the Binding's
+	// `status.adoptionReports`, how it differs from the desired object.
+	AdoptionPolicyReport AdoptionPolicy = "Report"
+)
+
+// DriftPolicy identifies what to do when a workload object in a WEC has drifted, that is,
+// has been changed there (for example, by `kubectl edit`) so that it no longer matches
+// the desired object while the desired object has not changed.
+// +kubebuilder:validation:Enum=Ignore;Report;Remediate
+type DriftPolicy string
+
+const (
+	// DriftPolicyIgnore does not look for drift. The transport may or may not correct it.
+	DriftPolicyIgnore DriftPolicy = "Ignore"
+
+	// DriftPolicyReport reports drift, in the Binding's `status.driftReports`, and leaves
+	// the object as it is until the desired object changes.
+	DriftPolicyReport DriftPolicy = "Report"
+
+	// DriftPolicyRemediate reports drift and writes the desired object over it.
+	DriftPolicyRemediate DriftPolicy = "Remediate"
+)
+
+// RolloutFailureAction identifies what to do when a rollout wave fails.
+// +kubebuilder:validation:Enum=Pause;Abort
+type RolloutFailureAction string
+
+const (
+	// RolloutPause stops the rollout at the failed wave until its destinations become healthy.
+	RolloutPause RolloutFailureAction = "Pause"
+
+	// RolloutAbort stops the rollout for good; it only restarts when the Binding changes again.
+	RolloutAbort RolloutFailureAction = "Abort"
+)
+
+// RolloutPhase describes the progress of a rollout.
+type RolloutPhase string
+
+const (
+	// RolloutProgressing means that the rollout is waiting for the current wave to become healthy.
+	RolloutProgressing RolloutPhase = "Progressing"
+
+	// RolloutPaused means that a destination of the current wave is reported "Failed"
+	// and the rollout resumes when it becomes healthy.
+	RolloutPaused RolloutPhase = "Paused"
+
+	// RolloutAborted means that a destination of the current wave was reported "Failed"
+	// and the rollout does not resume.
+	RolloutAborted RolloutPhase = "Aborted"
+
+	// RolloutCompleted means that every wave has the change and is healthy.
+	RolloutCompleted RolloutPhase = "Completed"
+)
+
+// RolloutStatus reports the progress of a rollout.
+type RolloutStatus struct {
+	// `generation` is the generation of the Binding being rolled out.
+	Generation int64 `json:"generation"`
+
+	// `currentWave` is the index of the last wave whose destinations have been given the change.
+	CurrentWave int32 `json:"currentWave"`
+
+	// `currentWaveStartTime` is when the current wave was given the change.
+	CurrentWaveStartTime metav1.Time `json:"currentWaveStartTime"`
+
+	// `numWaves` is the number of non-empty waves, including the implicit last one.
+	NumWaves int32 `json:"numWaves"`
+
+	Phase RolloutPhase `json:"phase"`
+
+	// +optional
+	Message string `json:"message,omitempty"`
 }
 
 const (
@@ -128,13 +509,72 @@ type DownsyncPolicyClause struct {
 type DownsyncModulation struct {
 	// `createOnly` indicates that in a given WEC, the object is not to be updated
 	// if it already exists.
+	// Deprecated: use `updateStrategy: CreateOnly`, which this is equivalent to.
+	// In a Binding made from a BindingPolicy, this is true exactly when the
+	// `updateStrategy` is CreateOnly.
 	// +optional
 	CreateOnly bool `json:"createOnly,omitempty"`
 
+	// `readOnly` indicates that KubeStellar only observes the object in a given WEC.
+	// Deprecated: use `updateStrategy: ReadOnly`, which this is equivalent to.
+	// In a Binding made from a BindingPolicy, this is true exactly when the
+	// `updateStrategy` is ReadOnly.
+	// +optional
+	ReadOnly bool `json:"readOnly,omitempty"`
+
+	// `updateStrategy` says how the object is written in a given WEC. The default is Update.
+	// When several clauses match an object, the most restrictive strategy wins,
+	// in this order: ReadOnly, CreateOnly, ServerSideApply, Update.
+	// +optional
+	UpdateStrategy UpdateStrategy `json:"updateStrategy,omitempty"`
+
+	// `serverSideApply` configures the server-side apply, when `updateStrategy` is ServerSideApply.
+	// When several clauses with that strategy match an object, `force` is true only if it is
+	// true in all of them and the `fieldManager` is the least (in string order) of the given ones.
+	// +optional
+	ServerSideApply *ServerSideApplyOptions `json:"serverSideApply,omitempty"`
+
 	// `statusCollectors` is a list of references of StatusCollectors to apply.
 	// +optional
 	StatusCollectors []string `json:"statusCollectors,omitempty"`
 
+	// `includeDependencies` indicates that the objects that a matching object references
+	// through well-known references are also bound: the object's Namespace and, for an object
+	// that is or has a template for a Pod, the ConfigMaps, Secrets, ServiceAccount (other than
+	// "default") and PersistentVolumeClaims that the pod spec references through its volumes,
+	// `envFrom`, `env`, `imagePullSecrets` and `serviceAccountName`.
+	// Such a dependency is bound only while some matching object references it, it exists in
+	// the WDS and it is not excluded by `excludeDownsync`; it is bound without any modulation
+	// unless it matches a clause itself.
+	// +optional
+	IncludeDependencies bool `json:"includeDependencies,omitempty"`
+
+	// `deletionPolicy` says what happens to the object in a WEC when the object stops being
+	// propagated there (because the object or WEC stops matching, or the object is deleted from
+	// the WDS). The default is Delete. When several clauses match an object, Orphan wins.
+	// The object's `control.kubestellar.io/deletion-policy` annotation, when present,
+	// overrides this. Whether the object is orphaned is decided by the deletion policy in
+	// effect when it was last propagated.
+	// +optional
+	DeletionPolicy DeletionPolicy `json:"deletionPolicy,omitempty"`
+
+	// `adoptionPolicy` says what happens when the object already exists in a WEC, and was
+	// not put there by KubeStellar, when KubeStellar first propagates it there.
+	// The default is Adopt. When several clauses match an object, Report wins.
+	// The object's `control.kubestellar.io/adoption-policy` annotation, when present,
+	// overrides this; changing it (or this) to Adopt is how an object that has been
+	// reported is allowed to be taken over.
+	// +optional
+	AdoptionPolicy AdoptionPolicy `json:"adoptionPolicy,omitempty"`
+
+	// `driftPolicy` says whether to look for drift of the object in the WECs and what
+	// to do about it. The default is Ignore. When several clauses match an object,
+	// Report wins over Remediate, which wins over Ignore. Drift is only looked for
+	// in the objects that KubeStellar writes, not in read-only or unadopted ones, and
+	// is not corrected when the update strategy is CreateOnly.
+	// +optional
+	DriftPolicy DriftPolicy `json:"driftPolicy,omitempty"`
+
 	// WantSingletonReportedState, in short, indicates an expectation
 	// that the matching workload objects are distributed to exactly one WEC
 	// and requests that the `.status` of such objects propagate from the WEC
@@ -167,6 +607,40 @@ type DownsyncModulation struct {
 	WantSingletonReportedState bool `json:"wantSingletonReportedState,omitempty"`
 }
 
+// UpdateStrategy identifies how a workload object is written in a WEC.
+// +kubebuilder:validation:Enum=Update;CreateOnly;ServerSideApply;ReadOnly
+type UpdateStrategy string
+
+const (
+	// UpdateStrategyUpdate means that the object is created, and updated to match the WDS.
+	UpdateStrategyUpdate UpdateStrategy = "Update"
+
+	// UpdateStrategyCreateOnly means that the object is created but not updated if it already exists.
+	UpdateStrategyCreateOnly UpdateStrategy = "CreateOnly"
+
+	// UpdateStrategyServerSideApply means that the object is written by server-side apply,
+	// so that other field managers in the WEC can own some of its fields.
+	UpdateStrategyServerSideApply UpdateStrategy = "ServerSideApply"
+
+	// UpdateStrategyReadOnly means that KubeStellar only observes the object:
+	// it is never created, updated or deleted, but its state is returned as if it had
+	// been propagated, for StatusCollectors and singleton status. This is for monitoring
+	// objects that KubeStellar does not manage, such as the Deployments in `kube-system`.
+	UpdateStrategyReadOnly UpdateStrategy = "ReadOnly"
+)
+
+// ServerSideApplyOptions configures server-side apply in the WECs.
+type ServerSideApplyOptions struct {
+	// `fieldManager` is the field manager to apply as. The default is chosen by the transport.
+	// The OCM transport requires this to start with "work-agent" and adds that prefix if missing.
+	// +optional
+	FieldManager string `json:"fieldManager,omitempty"`
+
+	// `force` means to take ownership of the fields that conflict with other field managers.
+	// +optional
+	Force bool `json:"force,omitempty"`
+}
+
 // DownsyncObjectTest is a set of criteria that characterize matching objects.
 // An object matches if:
 // - the `apiGroup` criterion is satisfied;
@@ -220,6 +694,15 @@ type DownsyncObjectTest struct {
 	// Empty list is a special case, it matches every object.
 	// +optional
 	ObjectNames []string `json:"objectNames,omitempty"`
+
+	// `objectExpression` is an optional CEL expression that tests the content of the object.
+	// The expression can reference the variable `obj`, which holds the whole object,
+	// and must evaluate to a boolean; the object matches only if it evaluates to true.
+	// Evaluation failure counts as false.
+	// Parsing and type checking errors are reported in the BindingPolicyMisconfigured
+	// condition of the BindingPolicy.
+	// +optional
+	ObjectExpression *Expression `json:"objectExpression,omitempty"`
 }
 
 // BindingPolicyStatus defines the observed state of BindingPolicy
@@ -231,6 +714,45 @@ type BindingPolicyStatus struct {
 
 	// +optional
 	Errors []string `json:"errors,omitempty"`
+
+	// `failovers` records the most recent times that a destination was dropped
+	// because it was unavailable for longer than `placement.unavailableClusterGracePeriod`,
+	// oldest first. At most MaxRecordedFailovers are kept.
+	// +optional
+	Failovers []ClusterFailover `json:"failovers,omitempty"`
+
+	// `rollout` is copied from the Binding's status.
+	// +optional
+	Rollout *RolloutStatus `json:"rollout,omitempty"`
+
+	// `upsyncConflicts` is copied from the Binding's status.
+	// +optional
+	UpsyncConflicts []UpsyncConflict `json:"upsyncConflicts,omitempty"`
+
+	// `adoptionReports` is copied from the Binding's status.
+	// +optional
+	AdoptionReports []AdoptionReport `json:"adoptionReports,omitempty"`
+
+	// `driftReports` is copied from the Binding's status.
+	// +optional
+	DriftReports []DriftReport `json:"driftReports,omitempty"`
+}
+
+// MaxRecordedFailovers is the maximum length of `BindingPolicyStatus.Failovers`.
+const MaxRecordedFailovers = 10
+
+// ClusterFailover records the replacement of an unavailable destination.
+type ClusterFailover struct {
+	// `from` is the name of the unavailable Cluster that stopped being a destination.
+	From string `json:"from"`
+
+	// `to` is the name of the Cluster that took its place.
+	// It is empty when no replacement was available.
+	// +optional
+	To string `json:"to,omitempty"`
+
+	// `time` is when the failover was decided.
+	Time metav1.Time `json:"time"`
 }
 
 // +kubebuilder:object:root=true
@@ -279,6 +801,24 @@ type BindingSpec struct {
 	// +listType=map
 	// +listMapKey=clusterId
 	Destinations []Destination `json:"destinations,omitempty"`
+
+	// `replicaScheduling` is copied from the BindingPolicy.
+	// +optional
+	ReplicaScheduling *ReplicaScheduling `json:"replicaScheduling,omitempty"`
+
+	// `rollout` is copied from the BindingPolicy.
+	// +optional
+	Rollout *RolloutStrategy `json:"rollout,omitempty"`
+
+	// `upsync` is copied from the BindingPolicy.
+	// +optional
+	Upsync []UpsyncClause `json:"upsync,omitempty"`
+
+	// `suspend`, when true, means that nothing is propagated for this Binding.
+	// The BindingPolicy controller sets this from the BindingPolicy and otherwise
+	// leaves the Binding unchanged while it is true.
+	// +optional
+	Suspend bool `json:"suspend,omitempty"`
 }
 
 // DownsyncObjectClauses defines the objects to be down-synced, grouping them by scope.
@@ -355,6 +895,93 @@ type BindingStatus struct {
 
 	ObservedGeneration int64    `json:"observedGeneration"`
 	Errors             []string `json:"errors,omitempty"`
+
+	// `replicaAssignments` reports, for each workload object whose replicas
+	// were divided among the destinations, how many each destination got.
+	// +optional
+	ReplicaAssignments []ReplicaAssignment `json:"replicaAssignments,omitempty"`
+
+	// `rollout` reports the progress of the rollout, when the Binding has a rollout strategy.
+	// +optional
+	Rollout *RolloutStatus `json:"rollout,omitempty"`
+
+	// `upsyncConflicts` lists the upsynced objects whose copy could not be made in the WDS,
+	// ordered by cluster and then source object.
+	// +optional
+	UpsyncConflicts []UpsyncConflict `json:"upsyncConflicts,omitempty"`
+
+	// `adoptionReports` lists the workload objects that already existed in a WEC and have not
+	// been taken over there because their adoption policy is Report, ordered by cluster and then object.
+	// +optional
+	AdoptionReports []AdoptionReport `json:"adoptionReports,omitempty"`
+
+	// `driftReports` lists the workload objects that were found to have drifted in a WEC, when
+	// their drift policy is Report or Remediate, ordered by cluster and then object. A drift that
+	// was remediated stays listed until the desired object changes or the object drifts again.
+	// +optional
+	DriftReports []DriftReport `json:"driftReports,omitempty"`
+}
+
+// AdoptionReport compares a workload object that exists in a WEC, and has not been adopted
+// there, with the object that KubeStellar would write there.
+type AdoptionReport struct {
+	// `cluster` is the name of the WEC.
+	Cluster string `json:"cluster"`
+
+	// `sourceRef` identifies the object.
+	SourceRef SourceObjectReference `json:"sourceRef"`
+
+	// `differences` lists the paths (such as `spec.replicas`) of the fields whose values in the
+	// WEC differ from the desired ones. Fields that are not in the desired object, such as
+	// those defaulted by the server, are not compared. Empty means that adopting the object
+	// would not change it.
+	// +optional
+	Differences []string `json:"differences,omitempty"`
+}
+
+// DriftReport says how a workload object in a WEC has drifted from the desired object.
+type DriftReport struct {
+	// `cluster` is the name of the WEC.
+	Cluster string `json:"cluster"`
+
+	// `sourceRef` identifies the object.
+	SourceRef SourceObjectReference `json:"sourceRef"`
+
+	// `differences` lists the paths (such as `spec.replicas`) of the fields whose values in the
+	// WEC differ from the desired ones. Fields that are not in the desired object, such as
+	// those defaulted by the server, are not compared.
+	Differences []string `json:"differences"`
+
+	// `remediated` tells whether the desired object has been written over the drift.
+	// +optional
+	Remediated bool `json:"remediated,omitempty"`
+}
+
+// UpsyncConflict reports an object in a WEC that could not be copied into the WDS.
+type UpsyncConflict struct {
+	// `cluster` is the name of the WEC.
+	Cluster string `json:"cluster"`
+
+	// `sourceRef` identifies the object in the WEC.
+	SourceRef SourceObjectReference `json:"sourceRef"`
+
+	// `message` says what the problem is.
+	Message string `json:"message"`
+}
+
+// ReplicaAssignment reports how the `spec.replicas` of one workload object
+// was divided among the destinations.
+type ReplicaAssignment struct {
+	metav1.GroupResource `json:",inline"`
+
+	// `namespace` is empty for a cluster-scoped object.
+	// +optional
+	Namespace string `json:"namespace,omitempty"`
+
+	Name string `json:"name"`
+
+	// `replicas` maps the name of each destination to the number of replicas it got.
+	Replicas map[string]int32 `json:"replicas"`
 }
 
 // BindingList is the API type for a list of Binding
@@ -636,6 +1263,61 @@ type CombinedStatusList struct {
 	Items           []CombinedStatus `json:"items"`
 }
 
+// ReportedState holds the reported state of one workload object in one WEC.
+// A ReportedState object lives in the WEC's mailbox namespace in the ITS.
+// This is KubeStellar's own alternative to the WorkStatus objects that the OCM status add-on
+// maintains, for use by transports that do not involve OCM; the status controller
+// consumes both in the same way.
+//
+// +genclient
+// +kubebuilder:object:root=true
+// +kubebuilder:subresource:status
+// +kubebuilder:printcolumn:name="SUBJECT_GROUP",type="string",JSONPath=".spec.sourceRef.group"
+// +kubebuilder:printcolumn:name="SUBJECT_KIND",type="string",JSONPath=".spec.sourceRef.kind"
+// +kubebuilder:printcolumn:name="SUBJECT_NS",type="string",JSONPath=".spec.sourceRef.namespace"
+// +kubebuilder:printcolumn:name="SUBJECT_NAME",type="string",JSONPath=".spec.sourceRef.name"
+type ReportedState struct {
+	metav1.TypeMeta   `json:",inline"`
+	metav1.ObjectMeta `json:"metadata,omitempty"`
+
+	Spec ReportedStateSpec `json:"spec"`
+
+	// `status` is the `.status` of the workload object in the WEC.
+	// +kubebuilder:validation:Type=object
+	// +optional
+	Status *v1.JSON `json:"status,omitempty"`
+}
+
+type ReportedStateSpec struct {
+	// `sourceRef` identifies the workload object.
+	SourceRef SourceObjectReference `json:"sourceRef"`
+}
+
+// SourceObjectReference identifies a workload object.
+type SourceObjectReference struct {
+	Group    string `json:"group"`
+	Version  string `json:"version"`
+	Resource string `json:"resource"`
+	Kind     string `json:"kind"`
+
+	// `namespace` is the empty string for a cluster-scoped object.
+	Namespace string `json:"namespace"`
+
+	Name string `json:"name"`
+}
+
+// ReportedStateList is the API type for a list of ReportedState.
+//
+// +kubebuilder:object:root=true
+type ReportedStateList struct {
+	metav1.TypeMeta `json:",inline"`
+	// Standard list metadata.
+	// More info: https://git.k8s.io/community/contributors/devel/sig-architecture/api-conventions.md#types-kinds
+	// +optional
+	metav1.ListMeta `json:"metadata,omitempty"`
+	Items           []ReportedState `json:"items"`
+}
+
 // CustomTransform describes how to select and transform some objects
 // on their way from WDS to WEC, without regard to the WEC (i.e.,
 // not changes that are specific to the individual WEC).
@@ -667,15 +1349,71 @@ type CustomTransformSpec struct {
 	// "subresources" can not be directly bound to, only whole (top-level) objects.
 	Resource string `json:"resource"`
 
-	// `remove` is a list of JSONPath expressions (https://goessner.net/articles/JsonPath/)
-	// that identify part of the object to remove if present.
-	// Only a subset of JSONPath is supported.
-	// The expression used in a filter must be a conjunction of field == literal tests.
+	// `bindingPolicySelector`, if present, restricts this transform to the workload
+	// of the BindingPolicy objects whose labels match this selector.
+	// When absent, the transform applies to the workload of every BindingPolicy.
+	// +optional
+	BindingPolicySelector *metav1.LabelSelector `json:"bindingPolicySelector,omitempty"`
+
+	// `clusterSelector`, if present, restricts this transform to the destinations
+	// whose inventory objects' labels match this selector.
+	// When absent, the transform applies to every destination.
+	// +optional
+	ClusterSelector *metav1.LabelSelector `json:"clusterSelector,omitempty"`
+
+	// `phase` says when this transform is applied.
+	// In the `BeforeCustomization` phase (the default) the transform is applied
+	// before template expansion; if there is no `clusterSelector` then this is
+	// done once for all destinations.
+	// In the `AfterCustomization` phase the transform is applied separately
+	// for each destination, after template expansion; each leaf string of
+	// `remove`, `set` (both paths and values) and `mergePatch` is first subjected
+	// to template expansion using the properties of the destination.
+	// +kubebuilder:validation:Enum=BeforeCustomization;AfterCustomization
+	// +optional
+	Phase CustomTransformPhase `json:"phase,omitempty"`
+
+	// `remove` is a list of JSONPath expressions (RFC 9535)
+	// that identify parts of the object to remove if present.
+	// An expression may select any number of parts, including array elements,
+	// but not the whole object.
 	// Examples:
 	// - "$.spec.resources.GenericItems[*].generictemplate.metadata.resourceVersion"
 	// - "$.store.book[?(@.author == 'Kilgore Trout' && @.category == 'fiction')].price"
 	// +optional
 	Remove []string `json:"remove,omitempty"`
+
+	// `set` is a list of assignments, applied in order after the removals.
+	// Each replaces the value at every part of the object identified by a JSONPath expression.
+	// When the expression is singular (RFC 9535) and identifies nothing in the object,
+	// the missing object members along the path are created.
+	// +optional
+	Set []CustomTransformSet `json:"set,omitempty"`
+
+	// `mergePatch` is a JSON merge patch (RFC 7386) applied to the object after
+	// the removals and the assignments.
+	// It must be a JSON object.
+	// +optional
+	MergePatch *v1.JSON `json:"mergePatch,omitempty"`
+}
+
+// CustomTransformPhase identifies when, in the processing of workload objects
+// from WDS to WEC, a CustomTransform is applied.
+type CustomTransformPhase string
+
+const (
+	CustomTransformPhaseBeforeCustomization CustomTransformPhase = "BeforeCustomization"
+	CustomTransformPhaseAfterCustomization  CustomTransformPhase = "AfterCustomization"
+)
+
+// CustomTransformSet says to set some part(s) of an object to a given value.
+type CustomTransformSet struct {
+	// `path` is a JSONPath expression (RFC 9535) that identifies the parts to set.
+	// It may not identify the whole object.
+	Path string `json:"path"`
+
+	// `value` is the JSON value to set.
+	Value v1.JSON `json:"value"`
 }
 
 type CustomTransformStatus struct {
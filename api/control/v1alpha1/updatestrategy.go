@@ -0,0 +1,50 @@
+/*
+Copyright 2024 The KubeStellar Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package v1alpha1
+
+// updateStrategyRestrictiveness ranks the update strategies by how little they write.
+// Unknown strategies rank like Update.
+var updateStrategyRestrictiveness = map[UpdateStrategy]int{
+	UpdateStrategyUpdate:          0,
+	UpdateStrategyServerSideApply: 1,
+	UpdateStrategyCreateOnly:      2,
+	UpdateStrategyReadOnly:        3,
+}
+
+// MoreRestrictiveUpdateStrategy returns the more restrictive of the two given strategies.
+func MoreRestrictiveUpdateStrategy(left, right UpdateStrategy) UpdateStrategy {
+	if updateStrategyRestrictiveness[right] > updateStrategyRestrictiveness[left] {
+		return right
+	}
+	return left
+}
+
+// EffectiveUpdateStrategy returns the update strategy that the modulation calls for,
+// taking the deprecated `createOnly` and `readOnly` into account. The result is never empty.
+func (dm *DownsyncModulation) EffectiveUpdateStrategy() UpdateStrategy {
+	strategy := dm.UpdateStrategy
+	if strategy == "" {
+		strategy = UpdateStrategyUpdate
+	}
+	if dm.CreateOnly {
+		strategy = MoreRestrictiveUpdateStrategy(strategy, UpdateStrategyCreateOnly)
+	}
+	if dm.ReadOnly {
+		strategy = MoreRestrictiveUpdateStrategy(strategy, UpdateStrategyReadOnly)
+	}
+	return strategy
+}
@@ -24,8 +24,30 @@ import (
 	apiextensionsv1 "k8s.io/apiextensions-apiserver/pkg/apis/apiextensions/v1"
 	"k8s.io/apimachinery/pkg/apis/meta/v1"
 	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/util/intstr"
 )
 
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *AdoptionReport) DeepCopyInto(out *AdoptionReport) {
+	*out = *in
+	out.SourceRef = in.SourceRef
+	if in.Differences != nil {
+		in, out := &in.Differences, &out.Differences
+		*out = make([]string, len(*in))
+		copy(*out, *in)
+	}
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new AdoptionReport.
+func (in *AdoptionReport) DeepCopy() *AdoptionReport {
+	if in == nil {
+		return nil
+	}
+	out := new(AdoptionReport)
+	in.DeepCopyInto(out)
+	return out
+}
+
 // DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
 func (in *Binding) DeepCopyInto(out *Binding) {
 	*out = *in
@@ -170,6 +192,33 @@ func (in *BindingPolicySpec) DeepCopyInto(out *BindingPolicySpec) {
 			(*in)[i].DeepCopyInto(&(*out)[i])
 		}
 	}
+	if in.ExcludeClusterSelectors != nil {
+		in, out := &in.ExcludeClusterSelectors, &out.ExcludeClusterSelectors
+		*out = make([]v1.LabelSelector, len(*in))
+		for i := range *in {
+			(*in)[i].DeepCopyInto(&(*out)[i])
+		}
+	}
+	if in.ClusterSelectionExpression != nil {
+		in, out := &in.ClusterSelectionExpression, &out.ClusterSelectionExpression
+		*out = new(Expression)
+		**out = **in
+	}
+	if in.Placement != nil {
+		in, out := &in.Placement, &out.Placement
+		*out = new(ClusterPlacement)
+		(*in).DeepCopyInto(*out)
+	}
+	if in.ReplicaScheduling != nil {
+		in, out := &in.ReplicaScheduling, &out.ReplicaScheduling
+		*out = new(ReplicaScheduling)
+		(*in).DeepCopyInto(*out)
+	}
+	if in.Rollout != nil {
+		in, out := &in.Rollout, &out.Rollout
+		*out = new(RolloutStrategy)
+		(*in).DeepCopyInto(*out)
+	}
 	if in.Downsync != nil {
 		in, out := &in.Downsync, &out.Downsync
 		*out = make([]DownsyncPolicyClause, len(*in))
@@ -177,6 +226,20 @@ func (in *BindingPolicySpec) DeepCopyInto(out *BindingPolicySpec) {
 			(*in)[i].DeepCopyInto(&(*out)[i])
 		}
 	}
+	if in.ExcludeDownsync != nil {
+		in, out := &in.ExcludeDownsync, &out.ExcludeDownsync
+		*out = make([]DownsyncObjectTest, len(*in))
+		for i := range *in {
+			(*in)[i].DeepCopyInto(&(*out)[i])
+		}
+	}
+	if in.Upsync != nil {
+		in, out := &in.Upsync, &out.Upsync
+		*out = make([]UpsyncClause, len(*in))
+		for i := range *in {
+			(*in)[i].DeepCopyInto(&(*out)[i])
+		}
+	}
 }
 
 // DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new BindingPolicySpec.
@@ -204,6 +267,37 @@ func (in *BindingPolicyStatus) DeepCopyInto(out *BindingPolicyStatus) {
 		*out = make([]string, len(*in))
 		copy(*out, *in)
 	}
+	if in.Failovers != nil {
+		in, out := &in.Failovers, &out.Failovers
+		*out = make([]ClusterFailover, len(*in))
+		for i := range *in {
+			(*in)[i].DeepCopyInto(&(*out)[i])
+		}
+	}
+	if in.Rollout != nil {
+		in, out := &in.Rollout, &out.Rollout
+		*out = new(RolloutStatus)
+		(*in).DeepCopyInto(*out)
+	}
+	if in.UpsyncConflicts != nil {
+		in, out := &in.UpsyncConflicts, &out.UpsyncConflicts
+		*out = make([]UpsyncConflict, len(*in))
+		copy(*out, *in)
+	}
+	if in.AdoptionReports != nil {
+		in, out := &in.AdoptionReports, &out.AdoptionReports
+		*out = make([]AdoptionReport, len(*in))
+		for i := range *in {
+			(*in)[i].DeepCopyInto(&(*out)[i])
+		}
+	}
+	if in.DriftReports != nil {
+		in, out := &in.DriftReports, &out.DriftReports
+		*out = make([]DriftReport, len(*in))
+		for i := range *in {
+			(*in)[i].DeepCopyInto(&(*out)[i])
+		}
+	}
 }
 
 // DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new BindingPolicyStatus.
@@ -225,6 +319,23 @@ func (in *BindingSpec) DeepCopyInto(out *BindingSpec) {
 		*out = make([]Destination, len(*in))
 		copy(*out, *in)
 	}
+	if in.ReplicaScheduling != nil {
+		in, out := &in.ReplicaScheduling, &out.ReplicaScheduling
+		*out = new(ReplicaScheduling)
+		(*in).DeepCopyInto(*out)
+	}
+	if in.Rollout != nil {
+		in, out := &in.Rollout, &out.Rollout
+		*out = new(RolloutStrategy)
+		(*in).DeepCopyInto(*out)
+	}
+	if in.Upsync != nil {
+		in, out := &in.Upsync, &out.Upsync
+		*out = make([]UpsyncClause, len(*in))
+		for i := range *in {
+			(*in)[i].DeepCopyInto(&(*out)[i])
+		}
+	}
 }
 
 // DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new BindingSpec.
@@ -252,6 +363,37 @@ func (in *BindingStatus) DeepCopyInto(out *BindingStatus) {
 		*out = make([]string, len(*in))
 		copy(*out, *in)
 	}
+	if in.ReplicaAssignments != nil {
+		in, out := &in.ReplicaAssignments, &out.ReplicaAssignments
+		*out = make([]ReplicaAssignment, len(*in))
+		for i := range *in {
+			(*in)[i].DeepCopyInto(&(*out)[i])
+		}
+	}
+	if in.Rollout != nil {
+		in, out := &in.Rollout, &out.Rollout
+		*out = new(RolloutStatus)
+		(*in).DeepCopyInto(*out)
+	}
+	if in.UpsyncConflicts != nil {
+		in, out := &in.UpsyncConflicts, &out.UpsyncConflicts
+		*out = make([]UpsyncConflict, len(*in))
+		copy(*out, *in)
+	}
+	if in.AdoptionReports != nil {
+		in, out := &in.AdoptionReports, &out.AdoptionReports
+		*out = make([]AdoptionReport, len(*in))
+		for i := range *in {
+			(*in)[i].DeepCopyInto(&(*out)[i])
+		}
+	}
+	if in.DriftReports != nil {
+		in, out := &in.DriftReports, &out.DriftReports
+		*out = make([]DriftReport, len(*in))
+		for i := range *in {
+			(*in)[i].DeepCopyInto(&(*out)[i])
+		}
+	}
 }
 
 // DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new BindingStatus.
@@ -264,6 +406,70 @@ func (in *BindingStatus) DeepCopy() *BindingStatus {
 	return out
 }
 
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *ClusterFailover) DeepCopyInto(out *ClusterFailover) {
+	*out = *in
+	in.Time.DeepCopyInto(&out.Time)
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new ClusterFailover.
+func (in *ClusterFailover) DeepCopy() *ClusterFailover {
+	if in == nil {
+		return nil
+	}
+	out := new(ClusterFailover)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *ClusterPlacement) DeepCopyInto(out *ClusterPlacement) {
+	*out = *in
+	if in.MaxClusters != nil {
+		in, out := &in.MaxClusters, &out.MaxClusters
+		*out = new(int32)
+		**out = **in
+	}
+	if in.Preferences != nil {
+		in, out := &in.Preferences, &out.Preferences
+		*out = make([]ClusterPreference, len(*in))
+		for i := range *in {
+			(*in)[i].DeepCopyInto(&(*out)[i])
+		}
+	}
+	if in.UnavailableClusterGracePeriod != nil {
+		in, out := &in.UnavailableClusterGracePeriod, &out.UnavailableClusterGracePeriod
+		*out = new(v1.Duration)
+		**out = **in
+	}
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new ClusterPlacement.
+func (in *ClusterPlacement) DeepCopy() *ClusterPlacement {
+	if in == nil {
+		return nil
+	}
+	out := new(ClusterPlacement)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *ClusterPreference) DeepCopyInto(out *ClusterPreference) {
+	*out = *in
+	in.Selector.DeepCopyInto(&out.Selector)
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new ClusterPreference.
+func (in *ClusterPreference) DeepCopy() *ClusterPreference {
+	if in == nil {
+		return nil
+	}
+	out := new(ClusterPreference)
+	in.DeepCopyInto(out)
+	return out
+}
+
 // DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
 func (in *ClusterScopeDownsyncClause) DeepCopyInto(out *ClusterScopeDownsyncClause) {
 	*out = *in
@@ -297,6 +503,22 @@ func (in *ClusterScopeDownsyncObject) DeepCopy() *ClusterScopeDownsyncObject {
 	return out
 }
 
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *ClusterWeight) DeepCopyInto(out *ClusterWeight) {
+	*out = *in
+	in.Selector.DeepCopyInto(&out.Selector)
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new ClusterWeight.
+func (in *ClusterWeight) DeepCopy() *ClusterWeight {
+	if in == nil {
+		return nil
+	}
+	out := new(ClusterWeight)
+	in.DeepCopyInto(out)
+	return out
+}
+
 // DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
 func (in *CombinedStatus) DeepCopyInto(out *CombinedStatus) {
 	*out = *in
@@ -420,14 +642,52 @@ func (in *CustomTransformList) DeepCopyObject() runtime.Object {
 	return nil
 }
 
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *CustomTransformSet) DeepCopyInto(out *CustomTransformSet) {
+	*out = *in
+	in.Value.DeepCopyInto(&out.Value)
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new CustomTransformSet.
+func (in *CustomTransformSet) DeepCopy() *CustomTransformSet {
+	if in == nil {
+		return nil
+	}
+	out := new(CustomTransformSet)
+	in.DeepCopyInto(out)
+	return out
+}
+
 // DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
 func (in *CustomTransformSpec) DeepCopyInto(out *CustomTransformSpec) {
 	*out = *in
+	if in.BindingPolicySelector != nil {
+		in, out := &in.BindingPolicySelector, &out.BindingPolicySelector
+		*out = new(v1.LabelSelector)
+		(*in).DeepCopyInto(*out)
+	}
+	if in.ClusterSelector != nil {
+		in, out := &in.ClusterSelector, &out.ClusterSelector
+		*out = new(v1.LabelSelector)
+		(*in).DeepCopyInto(*out)
+	}
 	if in.Remove != nil {
 		in, out := &in.Remove, &out.Remove
 		*out = make([]string, len(*in))
 		copy(*out, *in)
 	}
+	if in.Set != nil {
+		in, out := &in.Set, &out.Set
+		*out = make([]CustomTransformSet, len(*in))
+		for i := range *in {
+			(*in)[i].DeepCopyInto(&(*out)[i])
+		}
+	}
+	if in.MergePatch != nil {
+		in, out := &in.MergePatch, &out.MergePatch
+		*out = new(apiextensionsv1.JSON)
+		(*in).DeepCopyInto(*out)
+	}
 }
 
 // DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new CustomTransformSpec.
@@ -483,6 +743,11 @@ func (in *Destination) DeepCopy() *Destination {
 // DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
 func (in *DownsyncModulation) DeepCopyInto(out *DownsyncModulation) {
 	*out = *in
+	if in.ServerSideApply != nil {
+		in, out := &in.ServerSideApply, &out.ServerSideApply
+		*out = new(ServerSideApplyOptions)
+		**out = **in
+	}
 	if in.StatusCollectors != nil {
 		in, out := &in.StatusCollectors, &out.StatusCollectors
 		*out = make([]string, len(*in))
@@ -566,6 +831,11 @@ func (in *DownsyncObjectTest) DeepCopyInto(out *DownsyncObjectTest) {
 		*out = make([]string, len(*in))
 		copy(*out, *in)
 	}
+	if in.ObjectExpression != nil {
+		in, out := &in.ObjectExpression, &out.ObjectExpression
+		*out = new(Expression)
+		**out = **in
+	}
 }
 
 // DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new DownsyncObjectTest.
@@ -595,6 +865,27 @@ func (in *DownsyncPolicyClause) DeepCopy() *DownsyncPolicyClause {
 	return out
 }
 
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *DriftReport) DeepCopyInto(out *DriftReport) {
+	*out = *in
+	out.SourceRef = in.SourceRef
+	if in.Differences != nil {
+		in, out := &in.Differences, &out.Differences
+		*out = make([]string, len(*in))
+		copy(*out, *in)
+	}
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new DriftReport.
+func (in *DriftReport) DeepCopy() *DriftReport {
+	if in == nil {
+		return nil
+	}
+	out := new(DriftReport)
+	in.DeepCopyInto(out)
+	return out
+}
+
 // DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
 func (in *ErrorInColumn) DeepCopyInto(out *ErrorInColumn) {
 	*out = *in
@@ -765,6 +1056,130 @@ func (in *PropagationData) DeepCopy() *PropagationData {
 	return out
 }
 
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *ReplicaAssignment) DeepCopyInto(out *ReplicaAssignment) {
+	*out = *in
+	out.GroupResource = in.GroupResource
+	if in.Replicas != nil {
+		in, out := &in.Replicas, &out.Replicas
+		*out = make(map[string]int32, len(*in))
+		for key, val := range *in {
+			(*out)[key] = val
+		}
+	}
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new ReplicaAssignment.
+func (in *ReplicaAssignment) DeepCopy() *ReplicaAssignment {
+	if in == nil {
+		return nil
+	}
+	out := new(ReplicaAssignment)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *ReplicaScheduling) DeepCopyInto(out *ReplicaScheduling) {
+	*out = *in
+	if in.StaticWeights != nil {
+		in, out := &in.StaticWeights, &out.StaticWeights
+		*out = make([]ClusterWeight, len(*in))
+		for i := range *in {
+			(*in)[i].DeepCopyInto(&(*out)[i])
+		}
+	}
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new ReplicaScheduling.
+func (in *ReplicaScheduling) DeepCopy() *ReplicaScheduling {
+	if in == nil {
+		return nil
+	}
+	out := new(ReplicaScheduling)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *ReportedState) DeepCopyInto(out *ReportedState) {
+	*out = *in
+	out.TypeMeta = in.TypeMeta
+	in.ObjectMeta.DeepCopyInto(&out.ObjectMeta)
+	out.Spec = in.Spec
+	if in.Status != nil {
+		in, out := &in.Status, &out.Status
+		*out = new(apiextensionsv1.JSON)
+		(*in).DeepCopyInto(*out)
+	}
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new ReportedState.
+func (in *ReportedState) DeepCopy() *ReportedState {
+	if in == nil {
+		return nil
+	}
+	out := new(ReportedState)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyObject is an autogenerated deepcopy function, copying the receiver, creating a new runtime.Object.
+func (in *ReportedState) DeepCopyObject() runtime.Object {
+	if c := in.DeepCopy(); c != nil {
+		return c
+	}
+	return nil
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *ReportedStateList) DeepCopyInto(out *ReportedStateList) {
+	*out = *in
+	out.TypeMeta = in.TypeMeta
+	in.ListMeta.DeepCopyInto(&out.ListMeta)
+	if in.Items != nil {
+		in, out := &in.Items, &out.Items
+		*out = make([]ReportedState, len(*in))
+		for i := range *in {
+			(*in)[i].DeepCopyInto(&(*out)[i])
+		}
+	}
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new ReportedStateList.
+func (in *ReportedStateList) DeepCopy() *ReportedStateList {
+	if in == nil {
+		return nil
+	}
+	out := new(ReportedStateList)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyObject is an autogenerated deepcopy function, copying the receiver, creating a new runtime.Object.
+func (in *ReportedStateList) DeepCopyObject() runtime.Object {
+	if c := in.DeepCopy(); c != nil {
+		return c
+	}
+	return nil
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *ReportedStateSpec) DeepCopyInto(out *ReportedStateSpec) {
+	*out = *in
+	out.SourceRef = in.SourceRef
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new ReportedStateSpec.
+func (in *ReportedStateSpec) DeepCopy() *ReportedStateSpec {
+	if in == nil {
+		return nil
+	}
+	out := new(ReportedStateSpec)
+	in.DeepCopyInto(out)
+	return out
+}
+
 // DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
 func (in *ReturnedState) DeepCopyInto(out *ReturnedState) {
 	*out = *in
@@ -781,6 +1196,74 @@ func (in *ReturnedState) DeepCopy() *ReturnedState {
 	return out
 }
 
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *RolloutStatus) DeepCopyInto(out *RolloutStatus) {
+	*out = *in
+	in.CurrentWaveStartTime.DeepCopyInto(&out.CurrentWaveStartTime)
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new RolloutStatus.
+func (in *RolloutStatus) DeepCopy() *RolloutStatus {
+	if in == nil {
+		return nil
+	}
+	out := new(RolloutStatus)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *RolloutStrategy) DeepCopyInto(out *RolloutStrategy) {
+	*out = *in
+	if in.Waves != nil {
+		in, out := &in.Waves, &out.Waves
+		*out = make([]RolloutWave, len(*in))
+		for i := range *in {
+			(*in)[i].DeepCopyInto(&(*out)[i])
+		}
+	}
+	if in.WaveInterval != nil {
+		in, out := &in.WaveInterval, &out.WaveInterval
+		*out = new(v1.Duration)
+		**out = **in
+	}
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new RolloutStrategy.
+func (in *RolloutStrategy) DeepCopy() *RolloutStrategy {
+	if in == nil {
+		return nil
+	}
+	out := new(RolloutStrategy)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *RolloutWave) DeepCopyInto(out *RolloutWave) {
+	*out = *in
+	if in.ClusterSelector != nil {
+		in, out := &in.ClusterSelector, &out.ClusterSelector
+		*out = new(v1.LabelSelector)
+		(*in).DeepCopyInto(*out)
+	}
+	if in.Size != nil {
+		in, out := &in.Size, &out.Size
+		*out = new(intstr.IntOrString)
+		**out = **in
+	}
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new RolloutWave.
+func (in *RolloutWave) DeepCopy() *RolloutWave {
+	if in == nil {
+		return nil
+	}
+	out := new(RolloutWave)
+	in.DeepCopyInto(out)
+	return out
+}
+
 // DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
 func (in *RowEvaluationError) DeepCopyInto(out *RowEvaluationError) {
 	*out = *in
@@ -797,6 +1280,36 @@ func (in *RowEvaluationError) DeepCopy() *RowEvaluationError {
 	return out
 }
 
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *ServerSideApplyOptions) DeepCopyInto(out *ServerSideApplyOptions) {
+	*out = *in
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new ServerSideApplyOptions.
+func (in *ServerSideApplyOptions) DeepCopy() *ServerSideApplyOptions {
+	if in == nil {
+		return nil
+	}
+	out := new(ServerSideApplyOptions)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *SourceObjectReference) DeepCopyInto(out *SourceObjectReference) {
+	*out = *in
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new SourceObjectReference.
+func (in *SourceObjectReference) DeepCopy() *SourceObjectReference {
+	if in == nil {
+		return nil
+	}
+	out := new(SourceObjectReference)
+	in.DeepCopyInto(out)
+	return out
+}
+
 // DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
 func (in *StatusCollector) DeepCopyInto(out *StatusCollector) {
 	*out = *in
@@ -935,6 +1448,59 @@ func (in *StatusCombinationRow) DeepCopy() *StatusCombinationRow {
 	return out
 }
 
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *UpsyncClause) DeepCopyInto(out *UpsyncClause) {
+	*out = *in
+	if in.Resources != nil {
+		in, out := &in.Resources, &out.Resources
+		*out = make([]string, len(*in))
+		copy(*out, *in)
+	}
+	if in.Namespaces != nil {
+		in, out := &in.Namespaces, &out.Namespaces
+		*out = make([]string, len(*in))
+		copy(*out, *in)
+	}
+	if in.ObjectSelectors != nil {
+		in, out := &in.ObjectSelectors, &out.ObjectSelectors
+		*out = make([]v1.LabelSelector, len(*in))
+		for i := range *in {
+			(*in)[i].DeepCopyInto(&(*out)[i])
+		}
+	}
+	if in.ObjectNames != nil {
+		in, out := &in.ObjectNames, &out.ObjectNames
+		*out = make([]string, len(*in))
+		copy(*out, *in)
+	}
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new UpsyncClause.
+func (in *UpsyncClause) DeepCopy() *UpsyncClause {
+	if in == nil {
+		return nil
+	}
+	out := new(UpsyncClause)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *UpsyncConflict) DeepCopyInto(out *UpsyncConflict) {
+	*out = *in
+	out.SourceRef = in.SourceRef
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new UpsyncConflict.
+func (in *UpsyncConflict) DeepCopy() *UpsyncConflict {
+	if in == nil {
+		return nil
+	}
+	out := new(UpsyncConflict)
+	in.DeepCopyInto(out)
+	return out
+}
+
 // DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
 func (in *Value) DeepCopyInto(out *Value) {
 	*out = *in
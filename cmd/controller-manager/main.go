@@ -24,7 +24,6 @@ import (
 	"fmt"
 	"os"
 	"strings"
-	"time"
 
 	"github.com/spf13/pflag"
 
@@ -171,17 +170,6 @@ func main() {
 			setupLog.Error(nil, "Status controller does not work without binding controller")
 			os.Exit(1)
 		}
-		// check if status add-on present before starting the status controller
-		for i := 1; true; i++ {
-			if util.CheckWorkStatusPresence(itsRestConfig) {
-				break
-			}
-			if (i & (i - 1)) == 0 {
-				setupLog.Info("Not creating status controller yet because WorkStatus is not defined in the ITS")
-			}
-			i++
-			time.Sleep(15 * time.Second)
-		}
 		setupLog.Info("Creating controller", "name", status.ControllerName)
 		statusController, err = status.NewController(logger, wdsClientMetrics, itsClientMetrics, wdsRestConfig, itsRestConfig, wdsName,
 			bindingController.GetBindingPolicyResolver())
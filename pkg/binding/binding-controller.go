@@ -33,6 +33,7 @@ import (
 	k8scoreapi "k8s.io/api/core/v1"
 	apiextensionsv1 "k8s.io/apiextensions-apiserver/pkg/apis/apiextensions/v1"
 	apiextensionsclientset "k8s.io/apiextensions-apiserver/pkg/client/clientset/clientset"
+	apiequality "k8s.io/apimachinery/pkg/api/equality"
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
 	"k8s.io/apimachinery/pkg/runtime"
@@ -51,6 +52,7 @@ import (
 
 	"github.com/kubestellar/kubestellar/api/control/v1alpha1"
 	"github.com/kubestellar/kubestellar/pkg/abstract"
+	"github.com/kubestellar/kubestellar/pkg/celeval"
 	"github.com/kubestellar/kubestellar/pkg/crd"
 	ksclient "github.com/kubestellar/kubestellar/pkg/generated/clientset/versioned"
 	controlclient "github.com/kubestellar/kubestellar/pkg/generated/clientset/versioned/typed/control/v1alpha1"
@@ -58,6 +60,7 @@ import (
 	controlinformers "github.com/kubestellar/kubestellar/pkg/generated/informers/externalversions/control/v1alpha1"
 	controllisters "github.com/kubestellar/kubestellar/pkg/generated/listers/control/v1alpha1"
 	ksmetrics "github.com/kubestellar/kubestellar/pkg/metrics"
+	"github.com/kubestellar/kubestellar/pkg/ocm"
 	"github.com/kubestellar/kubestellar/pkg/util"
 )
 
@@ -145,6 +148,12 @@ type Controller struct {
 
 	bindingPolicyResolver BindingPolicyResolver
 
+	// clusterSelectionEvaluator evaluates clusterSelectionExpressions
+	clusterSelectionEvaluator *celeval.Evaluator
+
+	// objectSelectionEvaluator evaluates the objectExpressions in DownsyncObjectTests
+	objectSelectionEvaluator *celeval.Evaluator
+
 	// Contains bindingPolicyRef, bindingRef, util.ObjectIdentifier
 	workqueue        workqueue.RateLimitingInterface
 	initializedTs    time.Time
@@ -293,6 +302,15 @@ func makeController(logger logr.Logger,
 		&workqueue.BucketRateLimiter{Limiter: rate.NewLimiter(rate.Limit(50), 300)},
 	)
 
+	clusterSelectionEvaluator, err := newClusterSelectionEvaluator()
+	if err != nil {
+		return nil, err
+	}
+	objectSelectionEvaluator, err := newObjectSelectionEvaluator()
+	if err != nil {
+		return nil, err
+	}
+
 	clusterInformer := clusterPreInformer.Informer()
 	controller := &Controller{
 		wdsName:                     wdsName,
@@ -318,6 +336,8 @@ func makeController(logger logr.Logger,
 		informers:                   util.NewConcurrentMap[schema.GroupVersionResource, cache.SharedIndexInformer](),
 		stoppers:                    util.NewConcurrentMap[schema.GroupVersionResource, chan struct{}](),
 		bindingPolicyResolver:       NewBindingPolicyResolver(),
+		clusterSelectionEvaluator:   clusterSelectionEvaluator,
+		objectSelectionEvaluator:    objectSelectionEvaluator,
 		workqueue:                   workqueue.NewRateLimitingQueueWithConfig(ratelimiter, workqueue.RateLimitingQueueConfig{Name: ControllerName + "-" + wdsName}),
 		allowedGroupsSet:            allowedGroupsSet,
 	}
@@ -506,17 +526,22 @@ func (c *Controller) setupManagedClustersInformer(ctx context.Context) error {
 	_, err := c.clusterInformer.AddEventHandler(cache.ResourceEventHandlerFuncs{
 		AddFunc: func(obj interface{}) {
 			objM := obj.(metav1.Object)
-			c.evaluateBindingPolicies(ctx, objM.GetName(), objM.GetLabels())
+			c.evaluateBindingPolicies(ctx, objM.GetName(), objM)
 		},
 		UpdateFunc: func(old, new interface{}) {
 			oldM := old.(metav1.Object)
 			newM := new.(metav1.Object)
-			// Re-evaluateBindingPolicies iff labels have changed.
-			oldLabels := oldM.GetLabels()
-			newLabels := newM.GetLabels()
-			if !reflect.DeepEqual(oldLabels, newLabels) {
+			// Re-evaluate the label selectors iff labels have changed;
+			// a clusterSelectionExpression can depend on anything.
+			if !reflect.DeepEqual(oldM.GetLabels(), newM.GetLabels()) {
 				c.logger.V(5).Info("Handling labels change", "old", old, "new", new)
-				c.evaluateBindingPoliciesForUpdate(ctx, newM.GetName(), oldLabels, newLabels)
+				c.evaluateBindingPoliciesForUpdate(ctx, newM.GetName(), oldM, newM, true)
+			} else {
+				if availabilityChanged(old, new) {
+					c.logger.V(5).Info("Handling availability change", "name", newM.GetName())
+					c.evaluateBindingPoliciesForAvailability(ctx, newM.GetName(), newM)
+				}
+				c.evaluateBindingPoliciesForUpdate(ctx, newM.GetName(), oldM, newM, false)
 			}
 		},
 		DeleteFunc: func(obj interface{}) {
@@ -524,7 +549,7 @@ func (c *Controller) setupManagedClustersInformer(ctx context.Context) error {
 				obj = typed.Obj
 			}
 			objM := obj.(metav1.Object)
-			c.evaluateBindingPolicies(ctx, objM.GetName(), objM.GetLabels())
+			c.evaluateBindingPolicies(ctx, objM.GetName(), objM)
 		},
 	})
 	if err != nil {
@@ -538,6 +563,19 @@ func (c *Controller) setupManagedClustersInformer(ctx context.Context) error {
 	return nil
 }
 
+// availabilityChanged tells whether the given two versions of a ManagedCluster
+// differ in availability or in the time since which the cluster has been unavailable.
+func availabilityChanged(old, new any) bool {
+	oldCluster, isCluster := old.(*managedclusterapi.ManagedCluster)
+	if !isCluster {
+		return false
+	}
+	newCluster := new.(*managedclusterapi.ManagedCluster)
+	oldUnavailable, oldSince := ocm.UnavailableSince(oldCluster)
+	newUnavailable, newSince := ocm.UnavailableSince(newCluster)
+	return oldUnavailable != newUnavailable || !oldSince.Equal(&newSince)
+}
+
 func (c *Controller) setupBindingPolicyInformer(ctx context.Context) error {
 	logger := klog.FromContext(ctx)
 	_, err := c.bindingPolicyInformer.AddEventHandler(cache.ResourceEventHandlerFuncs{
@@ -615,7 +653,11 @@ func shouldQueueBindingOnUpdate(old, new *v1alpha1.Binding) bool {
 			return true
 		}
 	}
-	return !abstract.SliceEqual(old.Status.Errors, new.Status.Errors)
+	return !abstract.SliceEqual(old.Status.Errors, new.Status.Errors) ||
+		!apiequality.Semantic.DeepEqual(old.Status.Rollout, new.Status.Rollout) ||
+		!apiequality.Semantic.DeepEqual(old.Status.UpsyncConflicts, new.Status.UpsyncConflicts) ||
+		!apiequality.Semantic.DeepEqual(old.Status.AdoptionReports, new.Status.AdoptionReports) ||
+		!apiequality.Semantic.DeepEqual(old.Status.DriftReports, new.Status.DriftReports)
 }
 
 func shouldSkipUpdate(old, new interface{}) bool {
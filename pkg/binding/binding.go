@@ -20,9 +20,11 @@ import (
 	"context"
 	"encoding/json"
 	"fmt"
+	"strings"
 
 	"k8s.io/apimachinery/pkg/api/errors"
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/util/sets"
 	"k8s.io/klog/v2"
 
 	"github.com/kubestellar/kubestellar/api/control/v1alpha1"
@@ -75,8 +77,17 @@ func (c *Controller) syncBinding(ctx context.Context, bindingName string) error
 		return fmt.Errorf("syncing Binding was stopped because it has no counterpart resolution")
 	}
 
+	generatedBindingSpec.Suspend = policy.Spec.Suspend
+
 	// calculate if the resolved decision is different from the current one
-	if c.bindingPolicyResolver.CompareBinding(bindingPolicyIdentifier, &binding.Spec) {
+	if policy.Spec.Suspend && binding.ResourceVersion != "" {
+		// freeze the existing Binding, except for saying that it is suspended
+		if binding.Spec.Suspend {
+			logger.V(4).Info("Binding is suspended", "name", binding.GetName())
+		} else if err := c.updateOrCreateBinding(ctx, binding, suspendedBindingSpec(&binding.Spec)); err != nil {
+			return fmt.Errorf("failed to suspend binding: %w", err)
+		}
+	} else if binding.Spec.Suspend == policy.Spec.Suspend && c.bindingPolicyResolver.CompareBinding(bindingPolicyIdentifier, &binding.Spec) {
 		logger.V(4).Info("Binding is up to date", "name", binding.GetName())
 	} else {
 		// update the binding object in the cluster by updating spec
@@ -88,7 +99,9 @@ func (c *Controller) syncBinding(ctx context.Context, bindingName string) error
 		c.bindingPolicyResolver.Broker().NotifyBindingPolicyCallbacks(bindingPolicyIdentifier)
 	}
 	srPerObj := c.bindingPolicyResolver.GetSingletonReportedStateRequestsForBinding(bindingPolicyIdentifier)
-	policyErrors := []string{}
+	policyErrors := append(placementErrors(policy.Spec.Placement), c.clusterSelectionErrors(policy.Spec.ClusterSelectionExpression)...)
+	policyErrors = append(policyErrors, excludeClusterSelectorErrors(policy.Spec.ExcludeClusterSelectors)...)
+	policyErrors = append(policyErrors, c.bindingPolicyResolver.GetExclusionErrors(bindingPolicyIdentifier)...)
 	badSR := []objectWithNumWECs{}
 	for _, srStatus := range srPerObj {
 		if srStatus.WantSingletonReportedState && srStatus.NumWECs != 1 {
@@ -109,8 +122,13 @@ func (c *Controller) syncBinding(ctx context.Context, bindingName string) error
 	policyWithStatus := policy.DeepCopy()
 	policyWithStatus.Status = v1alpha1.BindingPolicyStatus{
 		ObservedGeneration: policy.Generation,
-		Conditions:         binding.Status.Conditions,
+		Conditions:         c.policyConditions(policy, binding),
 		Errors:             append(policyErrors, binding.Status.Errors...),
+		Failovers:          mergeFailovers(policy.Status.Failovers, c.bindingPolicyResolver.GetFailovers(bindingPolicyIdentifier)),
+		Rollout:            binding.Status.Rollout.DeepCopy(),
+		UpsyncConflicts:    binding.Status.UpsyncConflicts,
+		AdoptionReports:    binding.Status.AdoptionReports,
+		DriftReports:       binding.Status.DriftReports,
 	}
 	policyEcho, updateErr := c.bindingPolicyClient.UpdateStatus(ctx, policyWithStatus, metav1.UpdateOptions{FieldManager: ControllerName})
 	if updateErr == nil {
@@ -165,3 +183,43 @@ func (c *Controller) updateOrCreateBinding(ctx context.Context, bdg *v1alpha1.Bi
 	logger.V(2).Info("updated binding", "name", bdg.GetName(), "resourceVersion", bdgEcho.ResourceVersion)
 	return nil
 }
+
+// suspendedBindingSpec returns a copy of the given BindingSpec that says it is suspended.
+func suspendedBindingSpec(spec *v1alpha1.BindingSpec) *v1alpha1.BindingSpec {
+	spec = spec.DeepCopy()
+	spec.Suspend = true
+	return spec
+}
+
+// policyConditionTypes are the types of the conditions that this controller
+// maintains directly in the status of a BindingPolicy.
+var policyConditionTypes = sets.New(v1alpha1.ConditionType(v1alpha1.BindingPolicyConditionMisconfigured), v1alpha1.TypeSuspended)
+
+// policyConditions returns the conditions to put in the status of the given BindingPolicy.
+// These are the conditions of the corresponding Binding plus the BindingPolicyMisconfigured
+// and Suspended conditions, whose LastTransitionTime is preserved from the BindingPolicy's
+// status when the condition has not changed.
+func (c *Controller) policyConditions(policy *v1alpha1.BindingPolicy, binding *v1alpha1.Binding) []v1alpha1.BindingPolicyCondition {
+	conditions := make([]v1alpha1.BindingPolicyCondition, 0, len(binding.Status.Conditions)+policyConditionTypes.Len())
+	for _, cond := range binding.Status.Conditions {
+		if !policyConditionTypes.Has(cond.Type) {
+			conditions = append(conditions, cond)
+		}
+	}
+	for _, cond := range policy.Status.Conditions {
+		if policyConditionTypes.Has(cond.Type) {
+			conditions = append(conditions, cond)
+		}
+	}
+	misconfigured := v1alpha1.ConditionNotMisconfigured()
+	if exprErrors := c.objectExpressionErrors(&policy.Spec); len(exprErrors) > 0 {
+		misconfigured = v1alpha1.ConditionMisconfigured(v1alpha1.ReasonInvalidObjectExpression, strings.Join(exprErrors, "; "))
+	}
+	conditions, _ = v1alpha1.SetCondition(conditions, misconfigured)
+	suspended := v1alpha1.ConditionNotSuspended()
+	if policy.Spec.Suspend {
+		suspended = v1alpha1.ConditionSuspended()
+	}
+	conditions, _ = v1alpha1.SetCondition(conditions, suspended)
+	return conditions
+}
@@ -0,0 +1,74 @@
+/*
+Copyright 2024 The KubeStellar Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package binding
+
+import (
+	"testing"
+
+	corev1 "k8s.io/api/core/v1"
+
+	"github.com/kubestellar/kubestellar/api/control/v1alpha1"
+)
+
+func TestPolicyConditions(t *testing.T) {
+	evaluator, err := newObjectSelectionEvaluator()
+	if err != nil {
+		t.Fatalf("Failed to create evaluator: %v", err)
+	}
+	c := &Controller{objectSelectionEvaluator: evaluator}
+	policy := &v1alpha1.BindingPolicy{Spec: v1alpha1.BindingPolicySpec{Suspend: true}}
+	binding := &v1alpha1.Binding{Status: v1alpha1.BindingStatus{
+		Conditions: []v1alpha1.BindingPolicyCondition{v1alpha1.ConditionReconcileSuccess()},
+	}}
+	conditionStatus := func(conditions []v1alpha1.BindingPolicyCondition, condType v1alpha1.ConditionType) corev1.ConditionStatus {
+		for _, cond := range conditions {
+			if cond.Type == condType {
+				return cond.Status
+			}
+		}
+		return corev1.ConditionUnknown
+	}
+
+	conditions := c.policyConditions(policy, binding)
+	if len(conditions) != 3 {
+		t.Errorf("Expected 3 conditions, got %#v", conditions)
+	}
+	if status := conditionStatus(conditions, v1alpha1.TypeSuspended); status != corev1.ConditionTrue {
+		t.Errorf("Expected Suspended to be True, got %q", status)
+	}
+	if status := conditionStatus(conditions, v1alpha1.ConditionType(v1alpha1.BindingPolicyConditionMisconfigured)); status != corev1.ConditionFalse {
+		t.Errorf("Expected BindingPolicyMisconfigured to be False, got %q", status)
+	}
+
+	// the previous Suspended condition is kept, with its transition time, while it does not change
+	policy.Status.Conditions = conditions
+	again := c.policyConditions(policy, binding)
+	for _, cond := range again {
+		if cond.Type == v1alpha1.TypeSuspended {
+			for _, prev := range conditions {
+				if prev.Type == v1alpha1.TypeSuspended && !prev.LastTransitionTime.Equal(&cond.LastTransitionTime) {
+					t.Errorf("Expected LastTransitionTime to be kept, got %v then %v", prev.LastTransitionTime, cond.LastTransitionTime)
+				}
+			}
+		}
+	}
+
+	policy.Spec.Suspend = false
+	if status := conditionStatus(c.policyConditions(policy, binding), v1alpha1.TypeSuspended); status != corev1.ConditionFalse {
+		t.Errorf("Expected Suspended to be False after resuming, got %q", status)
+	}
+}
@@ -23,6 +23,7 @@ import (
 	"github.com/go-logr/logr"
 	"golang.org/x/exp/slices"
 
+	apiequality "k8s.io/apimachinery/pkg/api/equality"
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 	"k8s.io/apimachinery/pkg/runtime/schema"
 	"k8s.io/apimachinery/pkg/util/sets"
@@ -48,9 +49,36 @@ type bindingPolicyResolution struct {
 	// No `*ObjectData` in this map is nil.
 	objectIdentifierToData map[util.ObjectIdentifier]*ObjectData
 
+	// dependencyToReferrers maps each object that is referenced as a dependency (see
+	// `DownsyncModulation.IncludeDependencies`) to the non-empty set of objects referencing it,
+	// and referrerToDependencies is the inverse.
+	// These maps and the sets in them are mutable.
+	dependencyToReferrers  map[util.ObjectIdentifier]sets.Set[util.ObjectIdentifier]
+	referrerToDependencies map[util.ObjectIdentifier]sets.Set[util.ObjectIdentifier]
+
+	// exclusionErrors maps each workload object whose exclusion could not be evaluated,
+	// and which is therefore excluded, to the error. This map is mutable.
+	exclusionErrors map[util.ObjectIdentifier]string
+
 	// Every Set ever stored here is immutable from the time it is stored here.
 	destinations sets.Set[string]
 
+	// failovers are the recent failovers made in setting destinations, oldest first.
+	// Every slice ever stored here is immutable from the time it is stored here.
+	failovers []v1alpha1.ClusterFailover
+
+	// replicaScheduling is copied from the bindingpolicy's spec.
+	// The `ReplicaScheduling` is immutable.
+	replicaScheduling *v1alpha1.ReplicaScheduling
+
+	// rollout is copied from the bindingpolicy's spec.
+	// The `RolloutStrategy` is immutable.
+	rollout *v1alpha1.RolloutStrategy
+
+	// upsync is copied from the bindingpolicy's spec.
+	// The slice is immutable.
+	upsync []v1alpha1.UpsyncClause
+
 	// ownerReference identifies the bindingpolicy that this resolution is
 	// associated with as an owning object.
 	// This pointer is never nil (why is it a pointer?).
@@ -152,6 +180,108 @@ func (resolution *bindingPolicyResolution) removeObjectIdentifier(objIdentifier
 	return true
 }
 
+// setDependencies records that the given object references exactly the given dependencies,
+// and returns the dependencies that gained or lost this reference.
+// The given set is not mutated.
+// This function is thread-safe.
+func (resolution *bindingPolicyResolution) setDependencies(objIdentifier util.ObjectIdentifier,
+	dependencies sets.Set[util.ObjectIdentifier]) sets.Set[util.ObjectIdentifier] {
+	resolution.Lock()
+	defer resolution.Unlock()
+
+	oldDependencies := resolution.referrerToDependencies[objIdentifier]
+	changed := oldDependencies.SymmetricDifference(dependencies)
+	for dependency := range changed {
+		referrers := resolution.dependencyToReferrers[dependency]
+		if dependencies.Has(dependency) {
+			if referrers == nil {
+				referrers = sets.New[util.ObjectIdentifier]()
+				resolution.dependencyToReferrers[dependency] = referrers
+			}
+			referrers.Insert(objIdentifier)
+		} else {
+			referrers.Delete(objIdentifier)
+			if referrers.Len() == 0 {
+				delete(resolution.dependencyToReferrers, dependency)
+			}
+		}
+	}
+	if dependencies.Len() == 0 {
+		delete(resolution.referrerToDependencies, objIdentifier)
+	} else {
+		resolution.referrerToDependencies[objIdentifier] = dependencies.Clone()
+	}
+	return changed
+}
+
+// isDependency tells whether some object references the given one as a dependency.
+// This function is thread-safe.
+func (resolution *bindingPolicyResolution) isDependency(objIdentifier util.ObjectIdentifier) bool {
+	resolution.RLock()
+	defer resolution.RUnlock()
+
+	return resolution.dependencyToReferrers[objIdentifier].Len() > 0
+}
+
+// setExclusionError records the given failure to evaluate the exclusions for the given object,
+// or the absence of one when the given message is empty, and tells whether that changed anything.
+// This function is thread-safe.
+func (resolution *bindingPolicyResolution) setExclusionError(objIdentifier util.ObjectIdentifier, errMsg string) bool {
+	resolution.Lock()
+	defer resolution.Unlock()
+
+	if resolution.exclusionErrors[objIdentifier] == errMsg {
+		return false
+	}
+	if errMsg == "" {
+		delete(resolution.exclusionErrors, objIdentifier)
+	} else {
+		resolution.exclusionErrors[objIdentifier] = errMsg
+	}
+	return true
+}
+
+// getExclusionErrors returns the recorded failures to evaluate exclusions, sorted.
+// This function is thread-safe.
+func (resolution *bindingPolicyResolution) getExclusionErrors() []string {
+	resolution.RLock()
+	defer resolution.RUnlock()
+
+	errs := make([]string, 0, len(resolution.exclusionErrors))
+	for _, errMsg := range resolution.exclusionErrors {
+		errs = append(errs, errMsg)
+	}
+	slices.Sort(errs)
+	return errs
+}
+
+// setReplicaScheduling sets the replica scheduling of the resolution.
+// The given `*ReplicaScheduling` must not be mutated during or after this call.
+// This function is thread-safe.
+func (resolution *bindingPolicyResolution) setReplicaScheduling(replicaScheduling *v1alpha1.ReplicaScheduling) {
+	resolution.Lock()
+	defer resolution.Unlock()
+	resolution.replicaScheduling = replicaScheduling
+}
+
+// setRollout sets the rollout strategy of the resolution.
+// The given `*RolloutStrategy` must not be mutated during or after this call.
+// This function is thread-safe.
+func (resolution *bindingPolicyResolution) setRollout(rollout *v1alpha1.RolloutStrategy) {
+	resolution.Lock()
+	defer resolution.Unlock()
+	resolution.rollout = rollout
+}
+
+// setUpsync sets the upsync clauses of the resolution.
+// The given slice must not be mutated during or after this call.
+// This function is thread-safe.
+func (resolution *bindingPolicyResolution) setUpsync(upsync []v1alpha1.UpsyncClause) {
+	resolution.Lock()
+	defer resolution.Unlock()
+	resolution.upsync = upsync
+}
+
 // toBindingSpec converts the resolution to a binding
 // spec. This function is thread-safe.
 func (resolution *bindingPolicyResolution) toBindingSpec() *v1alpha1.BindingSpec {
@@ -195,8 +325,11 @@ func (resolution *bindingPolicyResolution) toBindingSpec() *v1alpha1.BindingSpec
 	sortBindingWorkloadObjects(&workload)
 
 	return &v1alpha1.BindingSpec{
-		Workload:     workload,
-		Destinations: destinationsStringSetToSortedDestinations(resolution.destinations),
+		Workload:          workload,
+		Destinations:      destinationsStringSetToSortedDestinations(resolution.destinations),
+		ReplicaScheduling: resolution.replicaScheduling.DeepCopy(),
+		Rollout:           resolution.rollout.DeepCopy(),
+		Upsync:            deepCopyUpsync(resolution.upsync),
 	}
 }
 
@@ -209,6 +342,18 @@ func (resolution *bindingPolicyResolution) matchesBindingSpec(bindingSpec *v1alp
 		return false
 	}
 
+	if !apiequality.Semantic.DeepEqual(resolution.replicaScheduling, bindingSpec.ReplicaScheduling) {
+		return false
+	}
+
+	if !apiequality.Semantic.DeepEqual(resolution.rollout, bindingSpec.Rollout) {
+		return false
+	}
+
+	if !apiequality.Semantic.DeepEqual(resolution.upsync, bindingSpec.Upsync) {
+		return false
+	}
+
 	// check workload
 	if len(resolution.objectIdentifierToData) != len(bindingSpec.Workload.ClusterScope)+
 		len(bindingSpec.Workload.NamespaceScope) {
@@ -357,3 +502,15 @@ func sortBindingWorkloadObjects(bindingWorkload *v1alpha1.DownsyncObjectClauses)
 		return a.ResourceVersion < b.ResourceVersion
 	})
 }
+
+// deepCopyUpsync returns a deep copy of the given upsync clauses, nil for an empty slice.
+func deepCopyUpsync(upsync []v1alpha1.UpsyncClause) []v1alpha1.UpsyncClause {
+	if len(upsync) == 0 {
+		return nil
+	}
+	ans := make([]v1alpha1.UpsyncClause, len(upsync))
+	for idx := range upsync {
+		upsync[idx].DeepCopyInto(&ans[idx])
+	}
+	return ans
+}
@@ -22,6 +22,7 @@ import (
 	"sync"
 
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/labels"
 	"k8s.io/apimachinery/pkg/util/sets"
 	"k8s.io/klog/v2"
 
@@ -62,7 +63,8 @@ type BindingPolicyResolver interface {
 		bindingSpec *v1alpha1.BindingSpec) bool
 
 	// NoteBindingPolicy ensures that the resolver has an entry whose key
-	// is the given BindingPolicy's name.
+	// is the given BindingPolicy's name, and that the entry has the
+	// BindingPolicy's replica scheduling.
 	// If an entry is introduced, it is introduced with empty destination set
 	// and no workload references.
 	// `*bindingPolicy` is immutable.
@@ -86,6 +88,32 @@ type BindingPolicyResolver interface {
 	// changed. If no resolution is associated with the given key, false is
 	// returned.
 	RemoveObjectIdentifier(bindingPolicyKey string, objIdentifier util.ObjectIdentifier) bool
+
+	// SetDependencies records, in the resolution for the given bindingpolicy key,
+	// that the given object references exactly the given dependencies
+	// (an empty or nil set for an object that is not selected with includeDependencies).
+	// The returned set holds the dependencies that gained or lost this reference.
+	// If no resolution is associated with the given key, nil is returned.
+	// The given set is not mutated by this call.
+	SetDependencies(bindingPolicyKey string, objIdentifier util.ObjectIdentifier, dependencies sets.Set[util.ObjectIdentifier]) sets.Set[util.ObjectIdentifier]
+
+	// IsDependency tells whether, in the resolution for the given bindingpolicy key,
+	// some object references the given object as a dependency.
+	IsDependency(bindingPolicyKey string, objIdentifier util.ObjectIdentifier) bool
+
+	// SetExclusionError records, in the resolution for the given bindingpolicy key,
+	// that the exclusions of the bindingpolicy could not be evaluated for the given object
+	// (which is therefore excluded) with the given error message, or that they could be
+	// evaluated if the message is empty.
+	// The returned bool indicates whether this changed the resolution.
+	// If no resolution is associated with the given key, false is returned.
+	SetExclusionError(bindingPolicyKey string, objIdentifier util.ObjectIdentifier, errMsg string) bool
+
+	// GetExclusionErrors returns the error messages recorded by SetExclusionError
+	// for the given bindingpolicy key, sorted.
+	// If no resolution is associated with the given key, nil is returned.
+	GetExclusionErrors(bindingPolicyKey string) []string
+
 	// GetObjectIdentifiers returns the object identifiers associated with the
 	// given bindingpolicy key.
 	// If no resolution is associated with the given key, an error is returned.
@@ -93,12 +121,23 @@ type BindingPolicyResolver interface {
 
 	// SetDestinations updates the maintained bindingpolicy's
 	// destinations resolution for the given bindingpolicy key.
-	// The given destinations set is expected not to be mutated during and
-	// after this call by the caller.
+	// The given candidates map the names of the clusters that pass the
+	// bindingpolicy's cluster selectors to their labels; the destinations
+	// are chosen among them according to the given placement (which may be nil),
+	// favoring the current destinations.
+	// The candidates named in `unavailable` are not eligible; dropping a current
+	// destination for that reason is recorded as a failover (see GetFailovers).
+	// The given map and set are not mutated by this call.
 	// If no resolution is associated with the given key, an error is returned.
 	// Must not be called concurrently with any call that can add a resolution
 	// with the same name.
-	SetDestinations(bindingPolicyKey string, destinations sets.Set[string]) error
+	SetDestinations(bindingPolicyKey string, candidates map[string]labels.Set, unavailable sets.Set[string], placement *v1alpha1.ClusterPlacement) error
+
+	// GetFailovers returns the recent failovers made by SetDestinations
+	// for the given bindingpolicy key, oldest first.
+	// The returned slice is immutable.
+	// If no resolution is associated with the given key, nil is returned.
+	GetFailovers(bindingPolicyKey string) []v1alpha1.ClusterFailover
 
 	// ResolutionExists returns true if a resolution is associated with the
 	// given bindingpolicy key.
@@ -127,39 +166,130 @@ type BindingPolicyResolver interface {
 
 // DownsyncModulation is a convenient internal representation of v1alpha1.DownsyncModulation
 type DownsyncModulation struct {
-	CreateOnly                 bool
+	// UpdateStrategy is never empty.
+	UpdateStrategy v1alpha1.UpdateStrategy
+	// ServerSideApply is meaningful only when UpdateStrategy is ServerSideApply.
+	ServerSideApply            v1alpha1.ServerSideApplyOptions
 	StatusCollectors           sets.Set[string]
 	WantSingletonReportedState bool
+	IncludeDependencies        bool
+	Orphan                     bool
+	ReportAdoption             bool
+	// DriftPolicy is empty rather than Ignore.
+	DriftPolicy v1alpha1.DriftPolicy
 }
 
 func ZeroDownsyncModulation() DownsyncModulation {
-	return DownsyncModulation{StatusCollectors: sets.New[string]()}
+	return DownsyncModulation{UpdateStrategy: v1alpha1.UpdateStrategyUpdate, StatusCollectors: sets.New[string]()}
 }
 
 func DownsyncModulationFromExternal(external v1alpha1.DownsyncModulation) DownsyncModulation {
+	strategy := external.EffectiveUpdateStrategy()
 	return DownsyncModulation{
-		CreateOnly:                 external.CreateOnly,
+		UpdateStrategy:             strategy,
+		ServerSideApply:            serverSideApplyFor(strategy, external.ServerSideApply),
 		StatusCollectors:           sets.New(external.StatusCollectors...),
 		WantSingletonReportedState: external.WantSingletonReportedState,
+		IncludeDependencies:        external.IncludeDependencies,
+		Orphan:                     external.DeletionPolicy == v1alpha1.DeletionPolicyOrphan,
+		ReportAdoption:             external.AdoptionPolicy == v1alpha1.AdoptionPolicyReport,
+		DriftPolicy:                normalDriftPolicy(external.DriftPolicy),
 	}
 }
 
 func (dm *DownsyncModulation) ToExternal() v1alpha1.DownsyncModulation {
-	return v1alpha1.DownsyncModulation{
-		CreateOnly:                 dm.CreateOnly,
+	ans := v1alpha1.DownsyncModulation{
+		// CreateOnly and ReadOnly are kept for consumers that have not yet learned about UpdateStrategy.
+		CreateOnly:                 dm.UpdateStrategy == v1alpha1.UpdateStrategyCreateOnly,
+		ReadOnly:                   dm.UpdateStrategy == v1alpha1.UpdateStrategyReadOnly,
 		StatusCollectors:           sets.List(dm.StatusCollectors),
 		WantSingletonReportedState: dm.WantSingletonReportedState,
+		IncludeDependencies:        dm.IncludeDependencies,
+		DeletionPolicy:             deletionPolicyFor(dm.Orphan),
+		AdoptionPolicy:             adoptionPolicyFor(dm.ReportAdoption),
+		DriftPolicy:                dm.DriftPolicy,
+	}
+	if dm.UpdateStrategy != v1alpha1.UpdateStrategyUpdate {
+		ans.UpdateStrategy = dm.UpdateStrategy
+	}
+	if dm.UpdateStrategy == v1alpha1.UpdateStrategyServerSideApply && dm.ServerSideApply != (v1alpha1.ServerSideApplyOptions{}) {
+		ssa := dm.ServerSideApply
+		ans.ServerSideApply = &ssa
 	}
+	return ans
+}
+
+// deletionPolicyFor returns the external form of the given orphan bit,
+// leaving the default implicit.
+func deletionPolicyFor(orphan bool) v1alpha1.DeletionPolicy {
+	if orphan {
+		return v1alpha1.DeletionPolicyOrphan
+	}
+	return ""
+}
+
+// adoptionPolicyFor returns the external form of the given report-adoption bit,
+// leaving the default implicit.
+func adoptionPolicyFor(report bool) v1alpha1.AdoptionPolicy {
+	if report {
+		return v1alpha1.AdoptionPolicyReport
+	}
+	return ""
+}
+
+// normalDriftPolicy returns the given drift policy, leaving the default implicit.
+func normalDriftPolicy(policy v1alpha1.DriftPolicy) v1alpha1.DriftPolicy {
+	if policy == v1alpha1.DriftPolicyIgnore {
+		return ""
+	}
+	return policy
+}
+
+// serverSideApplyFor returns the server-side apply options that are meaningful
+// for the given strategy.
+func serverSideApplyFor(strategy v1alpha1.UpdateStrategy, options *v1alpha1.ServerSideApplyOptions) v1alpha1.ServerSideApplyOptions {
+	if strategy != v1alpha1.UpdateStrategyServerSideApply || options == nil {
+		return v1alpha1.ServerSideApplyOptions{}
+	}
+	return *options
 }
 
 func (left *DownsyncModulation) Equal(right DownsyncModulation) bool {
-	return left.CreateOnly == right.CreateOnly && left.WantSingletonReportedState == right.WantSingletonReportedState && left.StatusCollectors.Equal(right.StatusCollectors)
+	return left.UpdateStrategy == right.UpdateStrategy && left.ServerSideApply == right.ServerSideApply &&
+		left.WantSingletonReportedState == right.WantSingletonReportedState &&
+		left.IncludeDependencies == right.IncludeDependencies && left.Orphan == right.Orphan &&
+		left.ReportAdoption == right.ReportAdoption && left.DriftPolicy == right.DriftPolicy &&
+		left.StatusCollectors.Equal(right.StatusCollectors)
 }
 
+// AddExternal merges the given modulation into this one.
+// The merge does not depend on the order in which modulations are added.
+// The most restrictive update strategy wins. When several modulations call for
+// server-side apply, conflicts are forced only if all of them ask for that, and
+// the lexicographically least non-empty field manager is used. A Report drift policy
+// wins over Remediate, which wins over Ignore.
 func (dm *DownsyncModulation) AddExternal(external v1alpha1.DownsyncModulation) {
-	dm.CreateOnly = dm.CreateOnly || external.CreateOnly
+	if dm.UpdateStrategy == "" {
+		dm.UpdateStrategy = v1alpha1.UpdateStrategyUpdate
+	}
+	strategy := external.EffectiveUpdateStrategy()
+	ssa := serverSideApplyFor(strategy, external.ServerSideApply)
+	switch merged := v1alpha1.MoreRestrictiveUpdateStrategy(dm.UpdateStrategy, strategy); {
+	case merged != dm.UpdateStrategy:
+		dm.UpdateStrategy = merged
+		dm.ServerSideApply = ssa
+	case merged == v1alpha1.UpdateStrategyServerSideApply && strategy == merged:
+		dm.ServerSideApply.Force = dm.ServerSideApply.Force && ssa.Force
+		if dm.ServerSideApply.FieldManager == "" || ssa.FieldManager != "" && ssa.FieldManager < dm.ServerSideApply.FieldManager {
+			dm.ServerSideApply.FieldManager = ssa.FieldManager
+		}
+	}
 	dm.StatusCollectors.Insert(external.StatusCollectors...)
 	dm.WantSingletonReportedState = dm.WantSingletonReportedState || external.WantSingletonReportedState
+	dm.IncludeDependencies = dm.IncludeDependencies || external.IncludeDependencies
+	dm.Orphan = dm.Orphan || external.DeletionPolicy == v1alpha1.DeletionPolicyOrphan
+	dm.ReportAdoption = dm.ReportAdoption || external.AdoptionPolicy == v1alpha1.AdoptionPolicyReport
+	dm.DriftPolicy = normalDriftPolicy(v1alpha1.PrevailingDriftPolicy(dm.DriftPolicy, external.DriftPolicy))
 }
 
 // SingletonReportedStateReturnStatus reports the resolver's state regarding
@@ -242,13 +372,15 @@ func (resolver *bindingPolicyResolver) CompareBinding(bindingPolicyKey string,
 }
 
 func (resolver *bindingPolicyResolver) NoteBindingPolicy(bindingpolicy *v1alpha1.BindingPolicy) {
-	if resolution := resolver.getResolution(bindingpolicy.GetName()); resolution != nil {
-		return
+	resolution := resolver.getResolution(bindingpolicy.GetName())
+	if resolution == nil {
+		// Because concurrent calls with the same BindingPolicy name are not allowed,
+		// it is guaranteed that createResolution will not find an existing entry.
+		resolution = resolver.createResolution(bindingpolicy)
 	}
-	// Because concurrent calls with the same BindingPolicy name are not allowed,
-	// it is guaranteed that createResolution will not find an existing entry ---
-	// which means that we do not have to worry about updating that existing entry.
-	resolver.createResolution(bindingpolicy)
+	resolution.setReplicaScheduling(bindingpolicy.Spec.ReplicaScheduling)
+	resolution.setRollout(bindingpolicy.Spec.Rollout)
+	resolution.setUpsync(deepCopyUpsync(bindingpolicy.Spec.Upsync))
 }
 
 // EnsureObjectData ensures that an object's identifier is
@@ -302,6 +434,39 @@ func (resolver *bindingPolicyResolver) RemoveObjectIdentifier(bindingPolicyKey s
 	return bindingPolicyResolution.removeObjectIdentifier(objIdentifier)
 }
 
+func (resolver *bindingPolicyResolver) SetDependencies(bindingPolicyKey string, objIdentifier util.ObjectIdentifier,
+	dependencies sets.Set[util.ObjectIdentifier]) sets.Set[util.ObjectIdentifier] {
+	bindingPolicyResolution := resolver.getResolution(bindingPolicyKey) // thread-safe
+	if bindingPolicyResolution == nil {
+		return nil
+	}
+	return bindingPolicyResolution.setDependencies(objIdentifier, dependencies)
+}
+
+func (resolver *bindingPolicyResolver) IsDependency(bindingPolicyKey string, objIdentifier util.ObjectIdentifier) bool {
+	bindingPolicyResolution := resolver.getResolution(bindingPolicyKey) // thread-safe
+	if bindingPolicyResolution == nil {
+		return false
+	}
+	return bindingPolicyResolution.isDependency(objIdentifier)
+}
+
+func (resolver *bindingPolicyResolver) SetExclusionError(bindingPolicyKey string, objIdentifier util.ObjectIdentifier, errMsg string) bool {
+	bindingPolicyResolution := resolver.getResolution(bindingPolicyKey) // thread-safe
+	if bindingPolicyResolution == nil {
+		return false
+	}
+	return bindingPolicyResolution.setExclusionError(objIdentifier, errMsg)
+}
+
+func (resolver *bindingPolicyResolver) GetExclusionErrors(bindingPolicyKey string) []string {
+	bindingPolicyResolution := resolver.getResolution(bindingPolicyKey) // thread-safe
+	if bindingPolicyResolution == nil {
+		return nil
+	}
+	return bindingPolicyResolution.getExclusionErrors()
+}
+
 // GetObjectIdentifiers returns a copy of the object identifiers associated
 // with the given bindingpolicy key.
 // If no resolution is associated with the given key, an error is returned.
@@ -321,7 +486,7 @@ func (resolver *bindingPolicyResolver) GetObjectIdentifiers(bindingPolicyKey str
 }
 
 func (resolver *bindingPolicyResolver) SetDestinations(bindingPolicyKey string,
-	destinations sets.Set[string]) error {
+	candidates map[string]labels.Set, unavailable sets.Set[string], placement *v1alpha1.ClusterPlacement) error {
 	bindingPolicyResolution := resolver.getResolution(bindingPolicyKey) // thread-safe
 	// Now the resolver's mutex is not held, so the resolution just fetched could be removed.
 	// The prohibition against calling concurrently with methods that add a resolution ensures
@@ -334,10 +499,37 @@ func (resolver *bindingPolicyResolver) SetDestinations(bindingPolicyKey string,
 	bindingPolicyResolution.Lock()
 	defer bindingPolicyResolution.Unlock()
 
+	// A malformed placement is reported in the BindingPolicy's status by syncBinding,
+	// applyPlacement still makes a usable selection in that case.
+	if unavailable.Len() > 0 {
+		available := make(map[string]labels.Set, len(candidates))
+		for name, clusterLabels := range candidates {
+			if !unavailable.Has(name) {
+				available[name] = clusterLabels
+			}
+		}
+		candidates = available
+	}
+	destinations, _ := applyPlacement(candidates, bindingPolicyResolution.destinations, placement)
+	failovers := failoversFor(bindingPolicyResolution.destinations, destinations, unavailable, metav1.Now().Rfc3339Copy())
+	if len(failovers) > 0 {
+		bindingPolicyResolution.failovers = mergeFailovers(bindingPolicyResolution.failovers, failovers)
+	}
 	bindingPolicyResolution.destinations = destinations
 	return nil
 }
 
+func (resolver *bindingPolicyResolver) GetFailovers(bindingPolicyKey string) []v1alpha1.ClusterFailover {
+	bindingPolicyResolution := resolver.getResolution(bindingPolicyKey) // thread-safe
+	if bindingPolicyResolution == nil {
+		return nil
+	}
+
+	bindingPolicyResolution.RLock()
+	defer bindingPolicyResolution.RUnlock()
+	return bindingPolicyResolution.failovers
+}
+
 // ResolutionExists returns true if a resolution is associated with the
 // given bindingpolicy key.
 func (resolver *bindingPolicyResolver) ResolutionExists(bindingPolicyKey string) bool {
@@ -455,6 +647,9 @@ func (resolver *bindingPolicyResolver) createResolution(bindingpolicy *v1alpha1.
 			resolver.broker.NotifySingletonRequestCallbacks(bindingpolicy.Name, objId)
 		},
 		objectIdentifierToData: make(map[util.ObjectIdentifier]*ObjectData),
+		dependencyToReferrers:  make(map[util.ObjectIdentifier]sets.Set[util.ObjectIdentifier]),
+		referrerToDependencies: make(map[util.ObjectIdentifier]sets.Set[util.ObjectIdentifier]),
+		exclusionErrors:        make(map[util.ObjectIdentifier]string),
 		destinations:           sets.New[string](),
 		ownerReference:         ownerReference,
 	}
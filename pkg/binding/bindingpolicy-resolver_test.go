@@ -0,0 +1,118 @@
+/*
+Copyright 2024 The KubeStellar Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package binding
+
+import (
+	"testing"
+
+	"github.com/kubestellar/kubestellar/api/control/v1alpha1"
+)
+
+func TestAddExternalUpdateStrategy(t *testing.T) {
+	ssa := func(fieldManager string, force bool) v1alpha1.DownsyncModulation {
+		return v1alpha1.DownsyncModulation{UpdateStrategy: v1alpha1.UpdateStrategyServerSideApply,
+			ServerSideApply: &v1alpha1.ServerSideApplyOptions{FieldManager: fieldManager, Force: force}}
+	}
+	for _, testCase := range []struct {
+		name         string
+		modulations  []v1alpha1.DownsyncModulation
+		expected     v1alpha1.UpdateStrategy
+		expectedSSA  v1alpha1.ServerSideApplyOptions
+		expectCreate bool
+		expectRead   bool
+	}{
+		{name: "none", expected: v1alpha1.UpdateStrategyUpdate},
+		{name: "deprecated createOnly", modulations: []v1alpha1.DownsyncModulation{{CreateOnly: true}, {}},
+			expected: v1alpha1.UpdateStrategyCreateOnly, expectCreate: true},
+		{name: "readOnly wins", modulations: []v1alpha1.DownsyncModulation{{CreateOnly: true}, {UpdateStrategy: v1alpha1.UpdateStrategyReadOnly}, ssa("a", true)},
+			expected: v1alpha1.UpdateStrategyReadOnly, expectRead: true},
+		{name: "deprecated readOnly", modulations: []v1alpha1.DownsyncModulation{{UpdateStrategy: v1alpha1.UpdateStrategyCreateOnly}, {ReadOnly: true}},
+			expected: v1alpha1.UpdateStrategyReadOnly, expectRead: true},
+		{name: "createOnly beats ssa", modulations: []v1alpha1.DownsyncModulation{ssa("a", true), {UpdateStrategy: v1alpha1.UpdateStrategyCreateOnly}},
+			expected: v1alpha1.UpdateStrategyCreateOnly, expectCreate: true},
+		{name: "ssa merge", modulations: []v1alpha1.DownsyncModulation{{UpdateStrategy: v1alpha1.UpdateStrategyUpdate}, ssa("b", true), ssa("", true), ssa("a", false)},
+			expected: v1alpha1.UpdateStrategyServerSideApply, expectedSSA: v1alpha1.ServerSideApplyOptions{FieldManager: "a"}},
+		{name: "ssa force", modulations: []v1alpha1.DownsyncModulation{ssa("b", true), ssa("", true)},
+			expected: v1alpha1.UpdateStrategyServerSideApply, expectedSSA: v1alpha1.ServerSideApplyOptions{FieldManager: "b", Force: true}},
+	} {
+		// The result must not depend on the order of the modulations.
+		for _, reverse := range []bool{false, true} {
+			mod := ZeroDownsyncModulation()
+			for idx := range testCase.modulations {
+				if reverse {
+					idx = len(testCase.modulations) - 1 - idx
+				}
+				mod.AddExternal(testCase.modulations[idx])
+			}
+			if mod.UpdateStrategy != testCase.expected || mod.ServerSideApply != testCase.expectedSSA {
+				t.Errorf("For %s (reverse=%v): expected %v %#v, got %v %#v", testCase.name, reverse, testCase.expected, testCase.expectedSSA, mod.UpdateStrategy, mod.ServerSideApply)
+			}
+			external := mod.ToExternal()
+			if external.CreateOnly != testCase.expectCreate || external.ReadOnly != testCase.expectRead || external.EffectiveUpdateStrategy() != testCase.expected {
+				t.Errorf("For %s (reverse=%v): wrong external form %#v", testCase.name, reverse, external)
+			}
+			if roundTrip := DownsyncModulationFromExternal(external); !roundTrip.Equal(mod) {
+				t.Errorf("For %s (reverse=%v): round trip changed %#v to %#v", testCase.name, reverse, mod, roundTrip)
+			}
+		}
+	}
+}
+
+func TestAddExternalAdoptionPolicy(t *testing.T) {
+	for _, policies := range [][]v1alpha1.AdoptionPolicy{{}, {""}, {v1alpha1.AdoptionPolicyAdopt, ""}, {v1alpha1.AdoptionPolicyAdopt, v1alpha1.AdoptionPolicyReport}} {
+		mod := ZeroDownsyncModulation()
+		expected := false
+		for _, policy := range policies {
+			mod.AddExternal(v1alpha1.DownsyncModulation{AdoptionPolicy: policy})
+			expected = expected || policy == v1alpha1.AdoptionPolicyReport
+		}
+		if mod.ReportAdoption != expected {
+			t.Errorf("For %v: expected ReportAdoption=%v, got %v", policies, expected, mod.ReportAdoption)
+		}
+		if roundTrip := DownsyncModulationFromExternal(mod.ToExternal()); !roundTrip.Equal(mod) {
+			t.Errorf("For %v: round trip changed %#v to %#v", policies, mod, roundTrip)
+		}
+	}
+}
+
+func TestAddExternalDriftPolicy(t *testing.T) {
+	for _, testCase := range []struct {
+		policies []v1alpha1.DriftPolicy
+		expected v1alpha1.DriftPolicy
+	}{
+		{policies: nil, expected: ""},
+		{policies: []v1alpha1.DriftPolicy{v1alpha1.DriftPolicyIgnore, ""}, expected: ""},
+		{policies: []v1alpha1.DriftPolicy{v1alpha1.DriftPolicyIgnore, v1alpha1.DriftPolicyRemediate}, expected: v1alpha1.DriftPolicyRemediate},
+		{policies: []v1alpha1.DriftPolicy{v1alpha1.DriftPolicyRemediate, v1alpha1.DriftPolicyReport, ""}, expected: v1alpha1.DriftPolicyReport},
+	} {
+		for _, reverse := range []bool{false, true} {
+			mod := ZeroDownsyncModulation()
+			for idx := range testCase.policies {
+				if reverse {
+					idx = len(testCase.policies) - 1 - idx
+				}
+				mod.AddExternal(v1alpha1.DownsyncModulation{DriftPolicy: testCase.policies[idx]})
+			}
+			if mod.DriftPolicy != testCase.expected {
+				t.Errorf("For %v (reverse=%v): expected %q, got %q", testCase.policies, reverse, testCase.expected, mod.DriftPolicy)
+			}
+			if roundTrip := DownsyncModulationFromExternal(mod.ToExternal()); !roundTrip.Equal(mod) {
+				t.Errorf("For %v (reverse=%v): round trip changed %#v to %#v", testCase.policies, reverse, mod, roundTrip)
+			}
+		}
+	}
+}
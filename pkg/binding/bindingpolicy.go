@@ -19,6 +19,7 @@ package binding
 import (
 	"context"
 	"fmt"
+	"time"
 
 	"github.com/go-logr/logr"
 
@@ -32,7 +33,6 @@ import (
 	"sigs.k8s.io/controller-runtime/pkg/controller/controllerutil"
 
 	"github.com/kubestellar/kubestellar/api/control/v1alpha1"
-	"github.com/kubestellar/kubestellar/pkg/ocm"
 	"github.com/kubestellar/kubestellar/pkg/util"
 )
 
@@ -72,20 +72,26 @@ func (c *Controller) syncBindingPolicy(ctx context.Context, bindingPolicyName st
 		logger.V(5).Info("Noted BindingPolicy", "bindingPolicy", bindingPolicy)
 
 		// update bindingpolicy resolution destinations since bindingpolicy was updated
-		clusterSet, err := ocm.FindClustersBySelectors(ctx, c.managedClusterClient, bindingPolicy.Spec.ClusterSelectors)
+		clusters, err := c.findSelectedClusters(ctx, bindingPolicy)
 		if err != nil {
-			return fmt.Errorf("failed to ocm.FindClustersBySelectors: %w", err)
+			return fmt.Errorf("failed to find selected clusters: %w", err)
 		}
-		if len(clusterSet) == 0 {
+		if len(clusters) == 0 {
 			logger.V(4).Info("No clusters are selected by BindingPolicy", "name", bindingPolicy.Name)
 		}
+		clusterLabels, unavailable, recheckAfter := classifyClusters(clusters, unavailableClusterGracePeriod(bindingPolicy), time.Now())
 
 		// set destinations and enqueue binding for syncing
 		// we can skip handling the error since the call to BindingPolicyResolver::NoteBindingPolicy above
 		// guarantees that an error won't be returned here
-		_ = c.bindingPolicyResolver.SetDestinations(bindingPolicy.GetName(), clusterSet)
-		logger.V(5).Info("Enqueued Binding for syncing, while handling BindingPolicy", "name", bindingPolicy.Name)
+		_ = c.bindingPolicyResolver.SetDestinations(bindingPolicy.GetName(), clusterLabels, unavailable, bindingPolicy.Spec.Placement)
+		logger.V(5).Info("Enqueued Binding for syncing, while handling BindingPolicy", "name", bindingPolicy.Name, "numUnavailable", unavailable.Len())
 		c.enqueueBinding(bindingPolicy.GetName())
+		if recheckAfter > 0 {
+			// revisit when the grace period of an unavailable cluster runs out
+			logger.V(5).Info("Enqueuing reference to BindingPolicy for end of grace period", "name", bindingPolicy.Name, "after", recheckAfter)
+			c.workqueue.AddAfter(bindingPolicyRef(bindingPolicy.Name), recheckAfter)
+		}
 
 		// requeue all objects to account for changes in bindingpolicy.
 		// this does not include bindingpolicy/binding objects.
@@ -129,8 +135,13 @@ func (c *Controller) requeueSelectedWorkloadObjects(ctx context.Context, binding
 	return nil
 }
 
-func (c *Controller) evaluateBindingPoliciesForUpdate(ctx context.Context, clusterId string, oldLabels labels.Set, newLabels labels.Set) {
+// evaluateBindingPoliciesForUpdate enqueues the BindingPolicies whose cluster selection
+// may be affected by the given update of a cluster.
+// When the labels did not change, only the BindingPolicies with a
+// clusterSelectionExpression can be affected.
+func (c *Controller) evaluateBindingPoliciesForUpdate(ctx context.Context, clusterId string, oldCluster, newCluster metav1.Object, labelsChanged bool) {
 	logger := klog.FromContext(ctx)
+	oldLabels, newLabels := oldCluster.GetLabels(), newCluster.GetLabels()
 
 	logger.V(5).Info("Evaluating BindingPolicies for cluster", "clusterId", clusterId)
 	bindingPolicies, err := c.listBindingPolicies()
@@ -139,12 +150,15 @@ func (c *Controller) evaluateBindingPoliciesForUpdate(ctx context.Context, clust
 		return
 	}
 	for _, bindingPolicy := range bindingPolicies {
-		match1, err := util.SelectorsMatchLabels(bindingPolicy.Spec.ClusterSelectors, oldLabels)
+		if !labelsChanged && bindingPolicy.Spec.ClusterSelectionExpression == nil {
+			continue
+		}
+		match1, err := c.clusterSelected(logger, bindingPolicy, oldCluster)
 		if err != nil {
 			utilruntime.HandleError(err)
 			return
 		}
-		match2, err := util.SelectorsMatchLabels(bindingPolicy.Spec.ClusterSelectors, newLabels)
+		match2, err := c.clusterSelected(logger, bindingPolicy, newCluster)
 		if err != nil {
 			utilruntime.HandleError(err)
 			return
@@ -152,11 +166,15 @@ func (c *Controller) evaluateBindingPoliciesForUpdate(ctx context.Context, clust
 		if match1 != match2 {
 			logger.V(5).Info("Enqueuing reference to bindingPolicy because of changing match with cluster", "clusterId", clusterId, "bindingPolicyName", bindingPolicy.Name, "oldMatch", match1, "newMatch", match2, "oldLabels", oldLabels, "newLabels", newLabels)
 			c.workqueue.Add(bindingPolicyRef(bindingPolicy.Name))
+		} else if match1 && labelsChanged && bindingPolicy.Spec.Placement != nil {
+			// the placement may depend on the labels of the matching clusters
+			logger.V(5).Info("Enqueuing reference to bindingPolicy because of label change on matching cluster", "clusterId", clusterId, "bindingPolicyName", bindingPolicy.Name, "oldLabels", oldLabels, "newLabels", newLabels)
+			c.workqueue.Add(bindingPolicyRef(bindingPolicy.Name))
 		}
 	}
 }
 
-func (c *Controller) evaluateBindingPolicies(ctx context.Context, clusterId string, labelsSet labels.Set) {
+func (c *Controller) evaluateBindingPolicies(ctx context.Context, clusterId string, cluster metav1.Object) {
 	logger := klog.FromContext(ctx)
 
 	logger.V(5).Info("evaluating BindingPolicies", "clusterId", clusterId)
@@ -166,7 +184,7 @@ func (c *Controller) evaluateBindingPolicies(ctx context.Context, clusterId stri
 		return
 	}
 	for _, bindingPolicy := range bindingPolicies {
-		match, err := util.SelectorsMatchLabels(bindingPolicy.Spec.ClusterSelectors, labelsSet)
+		match, err := c.clusterSelected(logger, bindingPolicy, cluster)
 		if err != nil {
 			utilruntime.HandleError(err)
 			return
@@ -178,6 +196,40 @@ func (c *Controller) evaluateBindingPolicies(ctx context.Context, clusterId stri
 	}
 }
 
+// evaluateBindingPoliciesForAvailability enqueues the BindingPolicies that select the given cluster
+// and care about its availability.
+func (c *Controller) evaluateBindingPoliciesForAvailability(ctx context.Context, clusterId string, cluster metav1.Object) {
+	logger := klog.FromContext(ctx)
+
+	logger.V(5).Info("Evaluating BindingPolicies for change in cluster availability", "clusterId", clusterId)
+	bindingPolicies, err := c.listBindingPolicies()
+	if err != nil {
+		utilruntime.HandleError(err)
+		return
+	}
+	for _, bindingPolicy := range bindingPolicies {
+		if unavailableClusterGracePeriod(bindingPolicy) == nil {
+			continue
+		}
+		match, err := c.clusterSelected(logger, bindingPolicy, cluster)
+		if err != nil {
+			utilruntime.HandleError(err)
+			return
+		}
+		if match {
+			logger.V(5).Info("Enqueuing reference to BindingPolicy due to change in cluster availability", "clusterId", clusterId, "bindingPolicyName", bindingPolicy.Name)
+			c.workqueue.Add(bindingPolicyRef(bindingPolicy.Name))
+		}
+	}
+}
+
+func unavailableClusterGracePeriod(bindingPolicy *v1alpha1.BindingPolicy) *metav1.Duration {
+	if bindingPolicy.Spec.Placement == nil {
+		return nil
+	}
+	return bindingPolicy.Spec.Placement.UnavailableClusterGracePeriod
+}
+
 // Returns all the BindingPolicy objects in the informer's local cache.
 // These are immutable.
 func (c *Controller) listBindingPolicies() ([]*v1alpha1.BindingPolicy, error) {
@@ -0,0 +1,137 @@
+/*
+Copyright 2024 The KubeStellar Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package binding
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/go-logr/logr"
+	"github.com/google/cel-go/cel"
+	"github.com/google/cel-go/checker/decls"
+	managedclusterapi "open-cluster-management.io/api/cluster/v1"
+
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/labels"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/klog/v2"
+
+	"github.com/kubestellar/kubestellar/api/control/v1alpha1"
+	"github.com/kubestellar/kubestellar/pkg/celeval"
+	"github.com/kubestellar/kubestellar/pkg/ocm"
+	"github.com/kubestellar/kubestellar/pkg/util"
+)
+
+// clusterKey is the name of the variable, in a clusterSelectionExpression,
+// that holds the ManagedCluster object.
+const clusterKey = "cluster"
+
+func newClusterSelectionEvaluator() (*celeval.Evaluator, error) {
+	return celeval.NewEvaluator(
+		cel.Declarations(
+			decls.NewVar(clusterKey, decls.NewMapType(decls.String, decls.Dyn)),
+		),
+	)
+}
+
+// clusterSelectionErrors returns the user errors in the given clusterSelectionExpression.
+func (c *Controller) clusterSelectionErrors(expression *v1alpha1.Expression) []string {
+	if err := c.clusterSelectionEvaluator.CheckBoolExpression(expression); err != nil {
+		return []string{"invalid clusterSelectionExpression: " + err.Error()}
+	}
+	return []string{}
+}
+
+// findSelectedClusters returns the clusters that pass the cluster selection of the given BindingPolicy,
+// as a map from cluster name to the cluster.
+func (c *Controller) findSelectedClusters(ctx context.Context, bindingPolicy *v1alpha1.BindingPolicy) (map[string]*managedclusterapi.ManagedCluster, error) {
+	selectors := bindingPolicy.Spec.ClusterSelectors
+	expression := bindingPolicy.Spec.ClusterSelectionExpression
+	if expression != nil && len(selectors) == 0 {
+		selectors = []metav1.LabelSelector{{}} // the expression is tested on every cluster
+	}
+	clusters, err := ocm.FindClustersBySelectorsFull(ctx, c.managedClusterClient, selectors)
+	if err != nil {
+		return clusters, err
+	}
+	logger := klog.FromContext(ctx)
+	for name, cluster := range clusters {
+		if clusterExcluded(bindingPolicy, cluster) || expression != nil && !c.clusterPassesExpression(logger, *expression, cluster) {
+			delete(clusters, name)
+		}
+	}
+	return clusters, nil
+}
+
+// clusterExcluded tells whether the given cluster matches any of the
+// excludeClusterSelectors of the given BindingPolicy.
+// An invalid selector excludes every cluster, so that a mistake in an exclusion
+// never selects a cluster that was meant to be excluded;
+// excludeClusterSelectorErrors reports it in the BindingPolicy's status.
+func clusterExcluded(bindingPolicy *v1alpha1.BindingPolicy, cluster metav1.Object) bool {
+	clusterLabels := labels.Set(cluster.GetLabels())
+	for _, ls := range bindingPolicy.Spec.ExcludeClusterSelectors {
+		selector, err := metav1.LabelSelectorAsSelector(&ls)
+		if err != nil || selector.Matches(clusterLabels) {
+			return true
+		}
+	}
+	return false
+}
+
+// excludeClusterSelectorErrors returns the user errors in the given excludeClusterSelectors.
+func excludeClusterSelectorErrors(selectors []metav1.LabelSelector) []string {
+	errs := []string{}
+	for idx := range selectors {
+		if _, err := metav1.LabelSelectorAsSelector(&selectors[idx]); err != nil {
+			errs = append(errs, fmt.Sprintf("invalid excludeClusterSelectors[%d], so every cluster is excluded: %s", idx, err))
+		}
+	}
+	return errs
+}
+
+// clusterSelected tells whether the given cluster passes the cluster selection
+// of the given BindingPolicy, as judged by the event handlers.
+func (c *Controller) clusterSelected(logger logr.Logger, bindingPolicy *v1alpha1.BindingPolicy, cluster metav1.Object) (bool, error) {
+	match, err := util.SelectorsMatchLabels(bindingPolicy.Spec.ClusterSelectors, cluster.GetLabels())
+	if err != nil || !match {
+		return match, err
+	}
+	if clusterExcluded(bindingPolicy, cluster) {
+		return false, nil
+	}
+	if bindingPolicy.Spec.ClusterSelectionExpression == nil {
+		return true, nil
+	}
+	return c.clusterPassesExpression(logger, *bindingPolicy.Spec.ClusterSelectionExpression, cluster), nil
+}
+
+// clusterPassesExpression tells whether the given clusterSelectionExpression evaluates to true
+// for the given cluster. Failure to evaluate counts as false.
+func (c *Controller) clusterPassesExpression(logger logr.Logger, expression v1alpha1.Expression, cluster metav1.Object) bool {
+	clusterMap, err := runtime.DefaultUnstructuredConverter.ToUnstructured(cluster)
+	if err != nil {
+		logger.Error(err, "Failed to convert cluster to unstructured", "cluster", cluster.GetName())
+		return false
+	}
+	ans, err := c.clusterSelectionEvaluator.EvaluateBool(expression, map[string]interface{}{clusterKey: clusterMap})
+	if err != nil {
+		logger.V(4).Info("Treating cluster as not selected because clusterSelectionExpression failed", "cluster", cluster.GetName(), "expression", expression, "err", err)
+		return false
+	}
+	return ans
+}
@@ -0,0 +1,93 @@
+/*
+Copyright 2024 The KubeStellar Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package binding
+
+import (
+	"testing"
+
+	managedclusterapi "open-cluster-management.io/api/cluster/v1"
+
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/klog/v2/ktesting"
+	"k8s.io/utils/ptr"
+
+	"github.com/kubestellar/kubestellar/api/control/v1alpha1"
+)
+
+func TestClusterSelected(t *testing.T) {
+	logger, _ := ktesting.NewTestContext(t)
+	evaluator, err := newClusterSelectionEvaluator()
+	if err != nil {
+		t.Fatalf("Failed to create evaluator: %v", err)
+	}
+	c := &Controller{clusterSelectionEvaluator: evaluator}
+	cluster := &managedclusterapi.ManagedCluster{
+		ObjectMeta: metav1.ObjectMeta{Name: "c1", Labels: map[string]string{"location-group": "edge"}},
+		Status: managedclusterapi.ManagedClusterStatus{
+			Version: managedclusterapi.ManagedClusterVersion{Kubernetes: "v1.30.2"},
+			ClusterClaims: []managedclusterapi.ManagedClusterClaim{
+				{Name: "platform.open-cluster-management.io", Value: "AWS"},
+			},
+		},
+	}
+	edge := []metav1.LabelSelector{{MatchLabels: map[string]string{"location-group": "edge"}}}
+	cloud := []metav1.LabelSelector{{MatchLabels: map[string]string{"location-group": "cloud"}}}
+	invalid := []metav1.LabelSelector{{MatchExpressions: []metav1.LabelSelectorRequirement{{Key: "location-group", Operator: "Near"}}}}
+	for _, testCase := range []struct {
+		name       string
+		selectors  []metav1.LabelSelector
+		excludes   []metav1.LabelSelector
+		expression string
+		expected   bool
+	}{
+		{"labels only", edge, nil, "", true},
+		{"excluded", edge, edge, "", false},
+		{"excluded despite expression", nil, edge, `true`, false},
+		{"not excluded", edge, cloud, "", true},
+		{"invalid exclusion", edge, invalid, "", false},
+		{"labels mismatch", cloud, nil, "", false},
+		{"version", edge, nil, `cluster.status.version.kubernetes.startsWith("v1.30")`, true},
+		{"claim", nil, nil, `cluster.status.clusterClaims.exists(c, c.name == "platform.open-cluster-management.io" && c.value == "AWS")`, true},
+		{"claim mismatch", nil, nil, `cluster.status.clusterClaims.exists(c, c.value == "GCP")`, false},
+		{"labels mismatch with expression", cloud, nil, `true`, false},
+		{"evaluation failure", edge, nil, `cluster.status.allocatable.cpu == "4"`, false},
+		{"not bool", edge, nil, `cluster.metadata.name`, false},
+	} {
+		bindingPolicy := &v1alpha1.BindingPolicy{Spec: v1alpha1.BindingPolicySpec{ClusterSelectors: testCase.selectors, ExcludeClusterSelectors: testCase.excludes}}
+		if testCase.expression != "" {
+			bindingPolicy.Spec.ClusterSelectionExpression = ptr.To(v1alpha1.Expression(testCase.expression))
+		}
+		actual, err := c.clusterSelected(logger, bindingPolicy, cluster)
+		if err != nil {
+			t.Errorf("Case %q: unexpected error: %v", testCase.name, err)
+		} else if actual != testCase.expected {
+			t.Errorf("Case %q: expected %v, got %v", testCase.name, testCase.expected, actual)
+		}
+	}
+	if errs := c.clusterSelectionErrors(ptr.To(v1alpha1.Expression("cluster.metadata.name =="))); len(errs) != 1 {
+		t.Errorf("Expected one error for malformed expression, got %v", errs)
+	}
+	if errs := c.clusterSelectionErrors(ptr.To(v1alpha1.Expression("cluster.metadata.name"))); len(errs) != 1 {
+		t.Errorf("Expected one error for non-boolean expression, got %v", errs)
+	}
+	if errs := c.clusterSelectionErrors(ptr.To(v1alpha1.Expression(`cluster.metadata.name == "c1"`))); len(errs) != 0 {
+		t.Errorf("Expected no errors for boolean expression, got %v", errs)
+	}
+	if errs := excludeClusterSelectorErrors(append(cloud, invalid...)); len(errs) != 1 {
+		t.Errorf("Expected one error for invalid excludeClusterSelectors, got %v", errs)
+	}
+}
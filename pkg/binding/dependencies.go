@@ -0,0 +1,130 @@
+/*
+Copyright 2024 The KubeStellar Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package binding
+
+import (
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+	"k8s.io/apimachinery/pkg/util/sets"
+	"k8s.io/client-go/tools/cache"
+
+	"github.com/kubestellar/kubestellar/pkg/util"
+)
+
+// podSpecPaths are the places where a workload object can hold a pod spec.
+var podSpecPaths = [][]string{
+	{"spec", "template", "spec"},                        // Deployment, ReplicaSet, StatefulSet, DaemonSet, Job
+	{"spec", "jobTemplate", "spec", "template", "spec"}, // CronJob
+}
+
+// defaultServiceAccountName is the ServiceAccount that every namespace gets,
+// which therefore need not be downsynced.
+const defaultServiceAccountName = "default"
+
+// coreIdentifier returns the identifier of a core/v1 object.
+func coreIdentifier(kind, resource, namespace, name string) util.ObjectIdentifier {
+	return util.ObjectIdentifier{
+		GVK:        schema.GroupVersionKind{Version: "v1", Kind: kind},
+		Resource:   resource,
+		ObjectName: cache.ObjectName{Namespace: namespace, Name: name},
+	}
+}
+
+// dependenciesOf returns the objects that the given object references through
+// well-known references: its Namespace and, if it is or has a template for a Pod,
+// the ConfigMaps, Secrets, ServiceAccount and PersistentVolumeClaims that the pod spec references.
+func dependenciesOf(objIdentifier util.ObjectIdentifier, objContent map[string]any) sets.Set[util.ObjectIdentifier] {
+	ans := sets.New[util.ObjectIdentifier]()
+	namespace := objIdentifier.ObjectName.Namespace
+	if namespace == "" {
+		return ans
+	}
+	ans.Insert(coreIdentifier("Namespace", "namespaces", "", namespace))
+
+	var podSpec map[string]any
+	if objIdentifier.GVK.Group == "" && objIdentifier.GVK.Kind == "Pod" {
+		podSpec, _, _ = unstructured.NestedMap(objContent, "spec")
+	} else {
+		for _, path := range podSpecPaths {
+			if found, ok, _ := unstructured.NestedMap(objContent, path...); ok {
+				podSpec = found
+				break
+			}
+		}
+	}
+	if podSpec == nil {
+		return ans
+	}
+	add := func(kind, resource, name string) {
+		if name != "" {
+			ans.Insert(coreIdentifier(kind, resource, namespace, name))
+		}
+	}
+	addConfigMap := func(name string) { add("ConfigMap", "configmaps", name) }
+	addSecret := func(name string) { add("Secret", "secrets", name) }
+
+	serviceAccountName, _, _ := unstructured.NestedString(podSpec, "serviceAccountName")
+	if serviceAccountName == "" { // the deprecated synonym
+		serviceAccountName, _, _ = unstructured.NestedString(podSpec, "serviceAccount")
+	}
+	if serviceAccountName != defaultServiceAccountName {
+		add("ServiceAccount", "serviceaccounts", serviceAccountName)
+	}
+	for _, ref := range nestedMaps(podSpec, "imagePullSecrets") {
+		addSecret(nestedString(ref, "name"))
+	}
+	for _, volume := range nestedMaps(podSpec, "volumes") {
+		addConfigMap(nestedString(volume, "configMap", "name"))
+		addSecret(nestedString(volume, "secret", "secretName"))
+		add("PersistentVolumeClaim", "persistentvolumeclaims", nestedString(volume, "persistentVolumeClaim", "claimName"))
+		for _, source := range nestedMaps(volume, "projected", "sources") {
+			addConfigMap(nestedString(source, "configMap", "name"))
+			addSecret(nestedString(source, "secret", "name"))
+		}
+	}
+	for _, containersField := range []string{"initContainers", "containers", "ephemeralContainers"} {
+		for _, container := range nestedMaps(podSpec, containersField) {
+			for _, envFrom := range nestedMaps(container, "envFrom") {
+				addConfigMap(nestedString(envFrom, "configMapRef", "name"))
+				addSecret(nestedString(envFrom, "secretRef", "name"))
+			}
+			for _, env := range nestedMaps(container, "env") {
+				addConfigMap(nestedString(env, "valueFrom", "configMapKeyRef", "name"))
+				addSecret(nestedString(env, "valueFrom", "secretKeyRef", "name"))
+			}
+		}
+	}
+	return ans
+}
+
+// nestedMaps returns the members of the list at the given path that are maps.
+func nestedMaps(obj map[string]any, fields ...string) []map[string]any {
+	list, _, _ := unstructured.NestedSlice(obj, fields...)
+	ans := make([]map[string]any, 0, len(list))
+	for _, member := range list {
+		if memberMap, ok := member.(map[string]any); ok {
+			ans = append(ans, memberMap)
+		}
+	}
+	return ans
+}
+
+// nestedString returns the string at the given path, or "" if there is none.
+func nestedString(obj map[string]any, fields ...string) string {
+	str, _, _ := unstructured.NestedString(obj, fields...)
+	return str
+}
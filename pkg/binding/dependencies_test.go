@@ -0,0 +1,106 @@
+/*
+Copyright 2024 The KubeStellar Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package binding
+
+import (
+	"testing"
+
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/util/sets"
+
+	"github.com/kubestellar/kubestellar/api/control/v1alpha1"
+	"github.com/kubestellar/kubestellar/pkg/util"
+)
+
+func TestDependenciesOf(t *testing.T) {
+	obj := &unstructured.Unstructured{Object: map[string]interface{}{
+		"apiVersion": "apps/v1",
+		"kind":       "Deployment",
+		"metadata":   map[string]interface{}{"namespace": "ns1", "name": "d1"},
+		"spec": map[string]interface{}{"template": map[string]interface{}{"spec": map[string]interface{}{
+			"serviceAccountName": "sa1",
+			"imagePullSecrets":   []interface{}{map[string]interface{}{"name": "pull"}},
+			"volumes": []interface{}{
+				map[string]interface{}{"name": "v1", "configMap": map[string]interface{}{"name": "cm1"}},
+				map[string]interface{}{"name": "v2", "secret": map[string]interface{}{"secretName": "s1"}},
+				map[string]interface{}{"name": "v3", "persistentVolumeClaim": map[string]interface{}{"claimName": "pvc1"}},
+				map[string]interface{}{"name": "v4", "projected": map[string]interface{}{"sources": []interface{}{
+					map[string]interface{}{"configMap": map[string]interface{}{"name": "cm2"}},
+				}}},
+				map[string]interface{}{"name": "v5", "emptyDir": map[string]interface{}{}},
+			},
+			"containers": []interface{}{map[string]interface{}{
+				"name":    "c1",
+				"envFrom": []interface{}{map[string]interface{}{"secretRef": map[string]interface{}{"name": "s2"}}},
+				"env": []interface{}{
+					map[string]interface{}{"name": "X", "valueFrom": map[string]interface{}{"configMapKeyRef": map[string]interface{}{"name": "cm3", "key": "x"}}},
+					map[string]interface{}{"name": "Y", "value": "y"},
+				},
+			}},
+		}}},
+	}}
+	expected := sets.New(
+		coreIdentifier("Namespace", "namespaces", "", "ns1"),
+		coreIdentifier("ServiceAccount", "serviceaccounts", "ns1", "sa1"),
+		coreIdentifier("Secret", "secrets", "ns1", "pull"),
+		coreIdentifier("ConfigMap", "configmaps", "ns1", "cm1"),
+		coreIdentifier("Secret", "secrets", "ns1", "s1"),
+		coreIdentifier("PersistentVolumeClaim", "persistentvolumeclaims", "ns1", "pvc1"),
+		coreIdentifier("ConfigMap", "configmaps", "ns1", "cm2"),
+		coreIdentifier("Secret", "secrets", "ns1", "s2"),
+		coreIdentifier("ConfigMap", "configmaps", "ns1", "cm3"),
+	)
+	actual := dependenciesOf(util.IdentifierForObject(obj, "deployments"), obj.Object)
+	if !actual.Equal(expected) {
+		t.Errorf("Expected %v, got %v", expected, actual)
+	}
+
+	cm := &unstructured.Unstructured{Object: map[string]interface{}{
+		"apiVersion": "v1",
+		"kind":       "ConfigMap",
+		"metadata":   map[string]interface{}{"namespace": "ns1", "name": "cm1"},
+	}}
+	if actual := dependenciesOf(util.IdentifierForObject(cm, "configmaps"), cm.Object); !actual.Equal(sets.New(coreIdentifier("Namespace", "namespaces", "", "ns1"))) {
+		t.Errorf("Expected only the namespace for a ConfigMap, got %v", actual)
+	}
+}
+
+func TestSetDependencies(t *testing.T) {
+	resolver := NewBindingPolicyResolver()
+	resolver.NoteBindingPolicy(&v1alpha1.BindingPolicy{ObjectMeta: metav1.ObjectMeta{Name: "bp"}})
+	p1 := coreIdentifier("Pod", "pods", "ns1", "p1")
+	p2 := coreIdentifier("Pod", "pods", "ns1", "p2")
+	cm := coreIdentifier("ConfigMap", "configmaps", "ns1", "cm1")
+	ns := coreIdentifier("Namespace", "namespaces", "", "ns1")
+
+	if changed := resolver.SetDependencies("bp", p1, sets.New(cm, ns)); !changed.Equal(sets.New(cm, ns)) {
+		t.Errorf("Expected both to change, got %v", changed)
+	}
+	if changed := resolver.SetDependencies("bp", p2, sets.New(ns)); !changed.Equal(sets.New(ns)) {
+		t.Errorf("Expected the namespace to change, got %v", changed)
+	}
+	if changed := resolver.SetDependencies("bp", p1, nil); !changed.Equal(sets.New(cm, ns)) {
+		t.Errorf("Expected both to change, got %v", changed)
+	}
+	if resolver.IsDependency("bp", cm) || !resolver.IsDependency("bp", ns) {
+		t.Errorf("Expected only the namespace to remain a dependency")
+	}
+	if changed := resolver.SetDependencies("other", p1, sets.New(cm)); changed != nil {
+		t.Errorf("Expected nil for a missing resolution, got %v", changed)
+	}
+}
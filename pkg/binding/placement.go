@@ -0,0 +1,236 @@
+/*
+Copyright 2024 The KubeStellar Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package binding
+
+import (
+	"fmt"
+	"sort"
+	"time"
+
+	managedclusterapi "open-cluster-management.io/api/cluster/v1"
+
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/labels"
+	"k8s.io/apimachinery/pkg/util/sets"
+
+	"github.com/kubestellar/kubestellar/api/control/v1alpha1"
+	"github.com/kubestellar/kubestellar/pkg/ocm"
+)
+
+// placementCandidate is a cluster that passes the clusterSelectors of a BindingPolicy,
+// annotated with what the placement algorithm needs to know about it.
+type placementCandidate struct {
+	name     string
+	current  bool
+	score    int64
+	spreadBy string
+}
+
+// better tells whether `left` is preferred over `right`.
+// Current destinations come first (for stability), then higher scores, then lower names.
+func (left *placementCandidate) better(right *placementCandidate) bool {
+	if left.current != right.current {
+		return left.current
+	}
+	if left.score != right.score {
+		return left.score > right.score
+	}
+	return left.name < right.name
+}
+
+// placementGroup is the candidates that share a value of the spread-by label,
+// ordered from most to least preferred.
+type placementGroup struct {
+	candidates []*placementCandidate
+	numPicked  int
+}
+
+func (group *placementGroup) next() *placementCandidate {
+	if group.numPicked >= len(group.candidates) {
+		return nil
+	}
+	return group.candidates[group.numPicked]
+}
+
+// applyPlacement chooses the destinations among the given candidate clusters
+// according to the given placement.
+// The choice is a pure function of its inputs, and favors the current destinations
+// so that re-resolution does not needlessly move workload between clusters.
+// A nil placement or one without MaxClusters selects all the candidates.
+// The returned error reports a problem in the placement (e.g., a malformed preference selector);
+// in that case the preferences are ignored but a selection is still returned.
+func applyPlacement(candidates map[string]labels.Set, current sets.Set[string], placement *v1alpha1.ClusterPlacement) (sets.Set[string], error) {
+	if placement == nil || placement.MaxClusters == nil || int(*placement.MaxClusters) >= len(candidates) {
+		return sets.KeySet(candidates), nil
+	}
+	maxClusters := int(*placement.MaxClusters)
+	preferences, err := parsePreferences(placement.Preferences)
+	groups := map[string]*placementGroup{}
+	for name, clusterLabels := range candidates {
+		candidate := &placementCandidate{name: name, current: current.Has(name)}
+		for _, pref := range preferences {
+			if pref.selector.Matches(clusterLabels) {
+				candidate.score += int64(pref.weight)
+			}
+		}
+		if placement.SpreadByLabelKey != "" {
+			candidate.spreadBy = clusterLabels[placement.SpreadByLabelKey]
+		}
+		group := groups[candidate.spreadBy]
+		if group == nil {
+			group = &placementGroup{}
+			groups[candidate.spreadBy] = group
+		}
+		group.candidates = append(group.candidates, candidate)
+	}
+	for _, group := range groups {
+		sort.Slice(group.candidates, func(i, j int) bool { return group.candidates[i].better(group.candidates[j]) })
+	}
+	// First seat the current destinations (spread among themselves, in case there are
+	// more of them than maxClusters), then spread the remaining slots over all the groups.
+	ans := sets.New[string]()
+	pickRoundRobin(groups, ans, maxClusters, true)
+	pickRoundRobin(groups, ans, maxClusters, false)
+	return ans, err
+}
+
+// pickRoundRobin adds candidates to `ans` until it has `maxClusters` members or the groups run out.
+// Each time it takes from a group that has had the fewest picks so far, breaking ties by
+// the merit of the group's next candidate. When `currentOnly`, only current destinations
+// are taken; these come first in each group.
+func pickRoundRobin(groups map[string]*placementGroup, ans sets.Set[string], maxClusters int, currentOnly bool) {
+	for ans.Len() < maxClusters {
+		var chosen *placementGroup
+		for _, group := range groups {
+			next := group.next()
+			if next == nil || currentOnly && !next.current {
+				continue
+			}
+			if chosen == nil || group.numPicked < chosen.numPicked ||
+				group.numPicked == chosen.numPicked && next.better(chosen.next()) {
+				chosen = group
+			}
+		}
+		if chosen == nil {
+			return
+		}
+		ans.Insert(chosen.next().name)
+		chosen.numPicked++
+	}
+}
+
+type parsedPreference struct {
+	selector labels.Selector
+	weight   int32
+}
+
+func parsePreferences(preferences []v1alpha1.ClusterPreference) ([]parsedPreference, error) {
+	ans := make([]parsedPreference, 0, len(preferences))
+	for idx, pref := range preferences {
+		selector, err := metav1.LabelSelectorAsSelector(&pref.Selector)
+		if err != nil {
+			return nil, fmt.Errorf("invalid selector in placement.preferences[%d]: %w", idx, err)
+		}
+		ans = append(ans, parsedPreference{selector: selector, weight: pref.Weight})
+	}
+	return ans, nil
+}
+
+// placementErrors returns the problems, if any, in the given placement.
+func placementErrors(placement *v1alpha1.ClusterPlacement) []string {
+	if placement == nil {
+		return []string{}
+	}
+	if _, err := parsePreferences(placement.Preferences); err != nil {
+		return []string{err.Error()}
+	}
+	return []string{}
+}
+
+// classifyClusters extracts the labels of the given clusters and, if `gracePeriod` is not nil,
+// identifies the ones that have been unavailable for at least `gracePeriod` as of `now`.
+// The returned duration is how long until the grace period of another currently
+// unavailable cluster runs out, or zero if there is no such cluster.
+func classifyClusters(clusters map[string]*managedclusterapi.ManagedCluster, gracePeriod *metav1.Duration, now time.Time) (map[string]labels.Set, sets.Set[string], time.Duration) {
+	clusterLabels := make(map[string]labels.Set, len(clusters))
+	unavailable := sets.New[string]()
+	var recheckAfter time.Duration
+	for name, cluster := range clusters {
+		clusterLabels[name] = labels.Set(cluster.GetLabels())
+		if gracePeriod == nil {
+			continue
+		}
+		isUnavailable, since := ocm.UnavailableSince(cluster)
+		if !isUnavailable {
+			continue
+		}
+		remaining := since.Add(gracePeriod.Duration).Sub(now)
+		if remaining <= 0 {
+			unavailable.Insert(name)
+		} else if recheckAfter == 0 || remaining < recheckAfter {
+			recheckAfter = remaining
+		}
+	}
+	return clusterLabels, unavailable, recheckAfter
+}
+
+// failoversFor describes the dropping of the previous destinations that are unavailable.
+// Each of them is paired with a new destination, in name order, while those last.
+func failoversFor(previous, next, unavailable sets.Set[string], now metav1.Time) []v1alpha1.ClusterFailover {
+	dropped := sets.List(previous.Intersection(unavailable).Difference(next))
+	if len(dropped) == 0 {
+		return nil
+	}
+	added := sets.List(next.Difference(previous))
+	ans := make([]v1alpha1.ClusterFailover, len(dropped))
+	for idx, from := range dropped {
+		ans[idx] = v1alpha1.ClusterFailover{From: from, Time: now}
+		if idx < len(added) {
+			ans[idx].To = added[idx]
+		}
+	}
+	return ans
+}
+
+// mergeFailovers returns the union of the given failover records, oldest first,
+// limited to the newest v1alpha1.MaxRecordedFailovers.
+// The given slices are not mutated.
+func mergeFailovers(recorded, more []v1alpha1.ClusterFailover) []v1alpha1.ClusterFailover {
+	ans := append([]v1alpha1.ClusterFailover{}, recorded...)
+	for _, failover := range more {
+		if !containsFailover(ans, failover) {
+			ans = append(ans, failover)
+		}
+	}
+	sort.SliceStable(ans, func(i, j int) bool { return ans[i].Time.Before(&ans[j].Time) })
+	if len(ans) > v1alpha1.MaxRecordedFailovers {
+		ans = ans[len(ans)-v1alpha1.MaxRecordedFailovers:]
+	}
+	if len(ans) == 0 {
+		return nil
+	}
+	return ans
+}
+
+func containsFailover(failovers []v1alpha1.ClusterFailover, sought v1alpha1.ClusterFailover) bool {
+	for _, failover := range failovers {
+		if failover.From == sought.From && failover.To == sought.To && failover.Time.Equal(&sought.Time) {
+			return true
+		}
+	}
+	return false
+}
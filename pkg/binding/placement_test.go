@@ -0,0 +1,138 @@
+/*
+Copyright 2024 The KubeStellar Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package binding
+
+import (
+	"testing"
+	"time"
+
+	managedclusterapi "open-cluster-management.io/api/cluster/v1"
+
+	apiequality "k8s.io/apimachinery/pkg/api/equality"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/labels"
+	"k8s.io/apimachinery/pkg/util/sets"
+	"k8s.io/utils/ptr"
+
+	"github.com/kubestellar/kubestellar/api/control/v1alpha1"
+)
+
+func TestApplyPlacement(t *testing.T) {
+	candidates := map[string]labels.Set{
+		"a1": {"zone": "a", "tier": "gold"},
+		"a2": {"zone": "a"},
+		"a3": {"zone": "a", "tier": "gold"},
+		"b1": {"zone": "b"},
+		"b2": {"zone": "b", "tier": "gold"},
+		"x":  {},
+	}
+	gold := []v1alpha1.ClusterPreference{{Selector: metav1.LabelSelector{MatchLabels: map[string]string{"tier": "gold"}}, Weight: 10}}
+	for _, testCase := range []struct {
+		name      string
+		current   sets.Set[string]
+		placement *v1alpha1.ClusterPlacement
+		expected  sets.Set[string]
+	}{
+		{name: "nil placement", current: sets.New[string](), placement: nil,
+			expected: sets.KeySet(candidates)},
+		{name: "uncapped", current: sets.New[string](), placement: &v1alpha1.ClusterPlacement{SpreadByLabelKey: "zone"},
+			expected: sets.KeySet(candidates)},
+		{name: "capped by name", current: sets.New[string](), placement: &v1alpha1.ClusterPlacement{MaxClusters: ptr.To[int32](2)},
+			expected: sets.New("a1", "a2")},
+		{name: "preferred", current: sets.New[string](), placement: &v1alpha1.ClusterPlacement{MaxClusters: ptr.To[int32](2), Preferences: gold},
+			expected: sets.New("a1", "a3")},
+		{name: "spread", current: sets.New[string](), placement: &v1alpha1.ClusterPlacement{MaxClusters: ptr.To[int32](4), SpreadByLabelKey: "zone", Preferences: gold},
+			expected: sets.New("a1", "b2", "x", "a3")},
+		{name: "sticky", current: sets.New("a2", "b1", "gone"), placement: &v1alpha1.ClusterPlacement{MaxClusters: ptr.To[int32](2), SpreadByLabelKey: "zone", Preferences: gold},
+			expected: sets.New("a2", "b1")},
+		{name: "sticky within one group", current: sets.New("a1", "a2"), placement: &v1alpha1.ClusterPlacement{MaxClusters: ptr.To[int32](2), SpreadByLabelKey: "zone", Preferences: gold},
+			expected: sets.New("a1", "a2")},
+		{name: "sticky then spread", current: sets.New("a1", "a2"), placement: &v1alpha1.ClusterPlacement{MaxClusters: ptr.To[int32](4), SpreadByLabelKey: "zone", Preferences: gold},
+			expected: sets.New("a1", "a2", "b2", "x")},
+		{name: "current spread when over the cap", current: sets.New("a1", "a2", "b1"), placement: &v1alpha1.ClusterPlacement{MaxClusters: ptr.To[int32](2), SpreadByLabelKey: "zone", Preferences: gold},
+			expected: sets.New("a1", "b1")},
+	} {
+		t.Run(testCase.name, func(t *testing.T) {
+			for try := 0; try < 5; try++ {
+				actual, err := applyPlacement(candidates, testCase.current, testCase.placement)
+				if err != nil {
+					t.Fatalf("Unexpected error: %v", err)
+				}
+				if !actual.Equal(testCase.expected) {
+					t.Fatalf("Expected %v, got %v", sets.List(testCase.expected), sets.List(actual))
+				}
+			}
+		})
+	}
+}
+
+func TestClassifyClusters(t *testing.T) {
+	now := time.Date(2024, 6, 1, 12, 0, 0, 0, time.UTC)
+	cluster := func(name string, status metav1.ConditionStatus, downFor time.Duration) *managedclusterapi.ManagedCluster {
+		ans := &managedclusterapi.ManagedCluster{ObjectMeta: metav1.ObjectMeta{Name: name, Labels: map[string]string{"name": name},
+			CreationTimestamp: metav1.NewTime(now.Add(-time.Hour))}}
+		if status != "" {
+			ans.Status.Conditions = []metav1.Condition{{Type: managedclusterapi.ManagedClusterConditionAvailable,
+				Status: status, LastTransitionTime: metav1.NewTime(now.Add(-downFor))}}
+		}
+		return ans
+	}
+	clusters := map[string]*managedclusterapi.ManagedCluster{
+		"up":        cluster("up", metav1.ConditionTrue, 2*time.Hour),
+		"down-long": cluster("down-long", metav1.ConditionFalse, 10*time.Minute),
+		"down-new":  cluster("down-new", metav1.ConditionUnknown, 2*time.Minute),
+		"no-cond":   cluster("no-cond", "", 0),
+	}
+	clusterLabels, unavailable, recheckAfter := classifyClusters(clusters, nil, now)
+	if len(clusterLabels) != 4 || clusterLabels["up"]["name"] != "up" || unavailable.Len() != 0 || recheckAfter != 0 {
+		t.Errorf("Without grace period got labels=%v, unavailable=%v, recheckAfter=%v", clusterLabels, unavailable, recheckAfter)
+	}
+	_, unavailable, recheckAfter = classifyClusters(clusters, &metav1.Duration{Duration: 5 * time.Minute}, now)
+	if expected := sets.New("down-long", "no-cond"); !unavailable.Equal(expected) {
+		t.Errorf("Expected unavailable=%v, got %v", sets.List(expected), sets.List(unavailable))
+	}
+	if recheckAfter != 3*time.Minute {
+		t.Errorf("Expected recheckAfter=3m, got %v", recheckAfter)
+	}
+}
+
+func TestFailovers(t *testing.T) {
+	t1 := metav1.NewTime(time.Date(2024, 6, 1, 12, 0, 0, 0, time.UTC))
+	t2 := metav1.NewTime(t1.Add(time.Minute))
+	failovers := failoversFor(sets.New("a", "b", "c"), sets.New("b", "d"), sets.New("a", "c", "x"), t1)
+	expected := []v1alpha1.ClusterFailover{{From: "a", To: "d", Time: t1}, {From: "c", Time: t1}}
+	if !apiequality.Semantic.DeepEqual(failovers, expected) {
+		t.Fatalf("Expected failovers %v, got %v", expected, failovers)
+	}
+	if none := failoversFor(sets.New("b"), sets.New("b"), sets.New("a"), t1); len(none) != 0 {
+		t.Errorf("Expected no failovers, got %v", none)
+	}
+
+	more := []v1alpha1.ClusterFailover{{From: "d", To: "e", Time: t2}, expected[0]}
+	merged := mergeFailovers(expected, more)
+	if expectedMerge := append(expected, more[0]); !apiequality.Semantic.DeepEqual(merged, expectedMerge) {
+		t.Errorf("Expected merge %v, got %v", expectedMerge, merged)
+	}
+	var many []v1alpha1.ClusterFailover
+	for i := 0; i < v1alpha1.MaxRecordedFailovers+3; i++ {
+		many = append(many, v1alpha1.ClusterFailover{From: "a", Time: metav1.NewTime(t1.Add(time.Duration(i) * time.Second))})
+	}
+	merged = mergeFailovers(nil, many)
+	if len(merged) != v1alpha1.MaxRecordedFailovers || !merged[0].Time.Equal(&many[3].Time) {
+		t.Errorf("Expected the newest %d failovers, got %v", v1alpha1.MaxRecordedFailovers, merged)
+	}
+}
@@ -19,13 +19,22 @@ package binding
 import (
 	"context"
 	"fmt"
+	"slices"
+
+	"github.com/google/cel-go/cel"
+	"github.com/google/cel-go/checker/decls"
 
 	corev1 "k8s.io/api/core/v1"
 	"k8s.io/apimachinery/pkg/api/errors"
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/util/sets"
 	"k8s.io/klog/v2"
 
 	"github.com/kubestellar/kubestellar/api/control/v1alpha1"
+	"github.com/kubestellar/kubestellar/pkg/celeval"
+	"github.com/kubestellar/kubestellar/pkg/upsync"
 	"github.com/kubestellar/kubestellar/pkg/util"
 )
 
@@ -57,7 +66,30 @@ func (c *Controller) updateResolutions(ctx context.Context, objIdentifier util.O
 			continue // resolution does not exist, skip
 		}
 
-		matchedAny, modFromPolicy := c.testObject(ctx, bindingPolicy.GetName(), objIdentifier, objMR.GetLabels(), bindingPolicy.Spec.Downsync)
+		tests := bindingPolicy.Spec.Downsync
+		if c.bindingPolicyResolver.IsDependency(bindingPolicy.GetName(), objIdentifier) {
+			// referenced by a selected object, so matched (without modulation) unless excluded
+			tests = append(slices.Clip(tests), v1alpha1.DownsyncPolicyClause{})
+		}
+		matchedAny, modFromPolicy, exclusionErr := c.testObject(ctx, bindingPolicy.GetName(), objIdentifier, objMR, tests, bindingPolicy.Spec.ExcludeDownsync)
+		var exclusionErrMsg string
+		if exclusionErr != nil {
+			exclusionErrMsg = exclusionErr.Error()
+		}
+		if c.bindingPolicyResolver.SetExclusionError(bindingPolicy.GetName(), objIdentifier, exclusionErrMsg) {
+			// the error is reported in the status of the bindingpolicy, which is written when syncing the binding
+			logger.V(4).Info("Enqueuing Binding for syncing due to change in exclusion error", "binding", bindingPolicy.GetName(),
+				"objectIdentifier", objIdentifier, "exclusionErr", exclusionErr)
+			c.enqueueBinding(bindingPolicy.GetName())
+		}
+		var dependencies sets.Set[util.ObjectIdentifier]
+		if matchedAny && modFromPolicy.IncludeDependencies {
+			dependencies, err = c.dependenciesOf(objIdentifier, obj)
+			if err != nil {
+				return err
+			}
+		}
+		c.setDependencies(ctx, bindingPolicy.GetName(), objIdentifier, dependencies)
 		if !matchedAny {
 			// if previously selected, remove
 			if resolutionUpdated := c.bindingPolicyResolver.RemoveObjectIdentifier(bindingPolicy.GetName(),
@@ -117,6 +149,10 @@ func (c *Controller) removeObjectFromBindingPolicies(ctx context.Context, objIde
 	bindingPolicies []*v1alpha1.BindingPolicy) error {
 	logger := klog.FromContext(ctx)
 	for _, bindingPolicy := range bindingPolicies {
+		c.setDependencies(ctx, bindingPolicy.GetName(), objIdentifier, nil)
+		if c.bindingPolicyResolver.SetExclusionError(bindingPolicy.GetName(), objIdentifier, "") {
+			c.enqueueBinding(bindingPolicy.GetName())
+		}
 		if resolutionUpdated := c.bindingPolicyResolver.RemoveObjectIdentifier(bindingPolicy.GetName(),
 			objIdentifier); resolutionUpdated {
 			// enqueue binding to be synced since object was removed from its bindingpolicy's resolution
@@ -130,63 +166,195 @@ func (c *Controller) removeObjectFromBindingPolicies(ctx context.Context, objIde
 	return nil
 }
 
-// testObject tests if the object matches the given tests.
+// dependenciesOf returns the dependencies of the given object (see `DownsyncModulation.IncludeDependencies`)
+// whose kind of object is watched.
+func (c *Controller) dependenciesOf(objIdentifier util.ObjectIdentifier, obj runtime.Object) (sets.Set[util.ObjectIdentifier], error) {
+	objContent, err := objectContent(obj)
+	if err != nil {
+		return nil, fmt.Errorf("failed to convert object (identifier: %v) to unstructured: %w", objIdentifier, err)
+	}
+	dependencies := dependenciesOf(objIdentifier, objContent)
+	for dependency := range dependencies {
+		if _, watched := c.listers.Get(dependency.GVR()); !watched {
+			dependencies.Delete(dependency)
+		}
+	}
+	return dependencies, nil
+}
+
+// setDependencies records the dependencies of the given object in the resolution of the given
+// bindingpolicy and enqueues a reference to each object that gained or lost a referrer,
+// so that its membership in the resolution gets re-evaluated.
+func (c *Controller) setDependencies(ctx context.Context, bindingPolicyName string, objIdentifier util.ObjectIdentifier,
+	dependencies sets.Set[util.ObjectIdentifier]) {
+	logger := klog.FromContext(ctx)
+	changed := c.bindingPolicyResolver.SetDependencies(bindingPolicyName, objIdentifier, dependencies)
+	for dependency := range changed {
+		logger.V(5).Info("Enqueuing dependency for re-evaluation", "bindingPolicy", bindingPolicyName,
+			"referrer", objIdentifier, "dependency", dependency)
+		c.enqueueObjectIdentifier(dependency)
+	}
+}
+
+// testObject tests if the object matches the given tests and none of the given exclusions.
+// Copies made by upsync never match.
+// An exclusion that can not be evaluated for the object counts as matching it,
+// so that a failure never propagates an object that was meant to be excluded.
 // The returned tuple is:
-//   - bool: whether the object matches ANY of the tests
-//   - bool: whether any test that matches the object also says CreateOnly==true
-//   - sets.Set[string]: the UNION of the statuscollector names that appear within
-//     EACH of the tests that the object matches
-func (c *Controller) testObject(ctx context.Context, bindingName string, objIdentifier util.ObjectIdentifier, objLabels map[string]string,
-	tests []v1alpha1.DownsyncPolicyClause) (bool, DownsyncModulation) {
+//   - bool: whether the object matches ANY of the tests and NONE of the exclusions
+//   - DownsyncModulation: the combination of the modulations of the tests that the object matches
+//   - error: the failure to evaluate an exclusion, if that is why the object is excluded
+func (c *Controller) testObject(ctx context.Context, bindingName string, objIdentifier util.ObjectIdentifier, obj mrObject,
+	tests []v1alpha1.DownsyncPolicyClause, exclusions []v1alpha1.DownsyncObjectTest) (bool, DownsyncModulation, error) {
 
 	logger := klog.FromContext(ctx)
 
 	var matched bool
 	mod := ZeroDownsyncModulation()
+	tester := &objectTester{c: c, ctx: ctx, logger: logger, bindingName: bindingName, objIdentifier: objIdentifier, obj: obj}
 
-	var objNS *corev1.Namespace
-	for _, test := range tests {
-		if test.APIGroup != nil && (*test.APIGroup) != objIdentifier.GVK.Group {
-			continue
+	if upsync.IsCopy(obj) {
+		logger.V(5).Info("Not downsyncing upsync copy", "objIdentifier", objIdentifier, "binding", bindingName)
+		return false, mod, nil
+	}
+
+	for idx := range exclusions {
+		excluded, err := tester.matches(&exclusions[idx])
+		if err != nil {
+			logger.V(3).Info("Treating workload object as excluded because exclusion could not be evaluated", "objIdentifier", objIdentifier, "exclusion", exclusions[idx], "binding", bindingName, "err", err)
+			return false, mod, fmt.Errorf("excludeDownsync[%d] could not be evaluated for %v, so the object is excluded: %w", idx, objIdentifier, err)
 		}
-		if len(test.Resources) > 0 && !(SliceContains(test.Resources, "*") ||
-			SliceContains(test.Resources, objIdentifier.Resource)) {
-			continue
+		if excluded {
+			logger.V(5).Info("Workload object matched exclusion", "objIdentifier", objIdentifier, "exclusion", exclusions[idx], "binding", bindingName)
+			return false, mod, nil
 		}
-		if len(test.Namespaces) > 0 && !(SliceContains(test.Namespaces, "*") ||
-			SliceContains(test.Namespaces, objIdentifier.ObjectName.Namespace)) {
+	}
+	for _, test := range tests {
+		if pass, err := tester.matches(&test.DownsyncObjectTest); err != nil {
+			logger.V(4).Info("Assuming workload object does not match clause that could not be evaluated", "objIdentifier", objIdentifier, "clause", test, "binding", bindingName, "err", err)
 			continue
-		}
-		if len(test.ObjectNames) > 0 && !(SliceContains(test.ObjectNames, "*") ||
-			SliceContains(test.ObjectNames, objIdentifier.ObjectName.Name)) {
+		} else if !pass {
 			continue
 		}
-		if len(test.ObjectSelectors) > 0 && !labelsMatchAny(c.logger, objLabels, test.ObjectSelectors) {
-			continue
+		logger.V(5).Info("Workload object matched clause", "objIdentifier", objIdentifier, "objLabels", obj.GetLabels(), "clause", test, "binding", bindingName)
+		// test is a match
+		matched = true
+		mod.AddExternal(test.DownsyncModulation)
+	}
+
+	return matched, mod, nil
+}
+
+// objectTester tests one workload object against DownsyncObjectTests,
+// fetching the object's Namespace and unstructured content only when needed.
+type objectTester struct {
+	c             *Controller
+	ctx           context.Context
+	logger        klog.Logger
+	bindingName   string
+	objIdentifier util.ObjectIdentifier
+	obj           mrObject
+
+	objNS      *corev1.Namespace
+	objContent map[string]interface{}
+}
+
+// matches tells whether the object passes the given test.
+// The returned error reports a failure to fetch what the test needs or to evaluate the test;
+// the returned bool is false in that case.
+func (ot *objectTester) matches(test *v1alpha1.DownsyncObjectTest) (bool, error) {
+	objIdentifier := ot.objIdentifier
+	if test.APIGroup != nil && (*test.APIGroup) != objIdentifier.GVK.Group {
+		return false, nil
+	}
+	if len(test.Resources) > 0 && !(SliceContains(test.Resources, "*") ||
+		SliceContains(test.Resources, objIdentifier.Resource)) {
+		return false, nil
+	}
+	if len(test.Namespaces) > 0 && !(SliceContains(test.Namespaces, "*") ||
+		SliceContains(test.Namespaces, objIdentifier.ObjectName.Namespace)) {
+		return false, nil
+	}
+	if len(test.ObjectNames) > 0 && !(SliceContains(test.ObjectNames, "*") ||
+		SliceContains(test.ObjectNames, objIdentifier.ObjectName.Name)) {
+		return false, nil
+	}
+	if len(test.ObjectSelectors) > 0 && !labelsMatchAny(ot.c.logger, ot.obj.GetLabels(), test.ObjectSelectors) {
+		return false, nil
+	}
+	if len(test.NamespaceSelectors) > 0 && !ALabelSelectorIsEmpty(test.NamespaceSelectors...) {
+		if objIdentifier.ObjectName.Namespace == "" {
+			// a cluster-scoped object has no namespace to match
+			return false, nil
 		}
-		if len(test.NamespaceSelectors) > 0 && !ALabelSelectorIsEmpty(test.NamespaceSelectors...) {
-			if objNS == nil {
-				var err error
-				objNS, err = c.namespaceClient.Get(ctx,
-					objIdentifier.ObjectName.Namespace, metav1.GetOptions{})
-				if err != nil {
-					logger.V(3).Info("Object namespace not found, assuming object does not match",
-						"object identifier", objIdentifier, "binding", bindingName)
-					continue
-				}
+		if ot.objNS == nil {
+			objNS, err := ot.c.namespaceClient.Get(ot.ctx,
+				objIdentifier.ObjectName.Namespace, metav1.GetOptions{})
+			if err != nil {
+				return false, fmt.Errorf("failed to get the object's namespace: %w", err)
 			}
-			if !labelsMatchAny(logger, objNS.Labels, test.NamespaceSelectors) {
-				continue
+			ot.objNS = objNS
+		}
+		if !labelsMatchAny(ot.logger, ot.objNS.Labels, test.NamespaceSelectors) {
+			return false, nil
+		}
+	}
+	if test.ObjectExpression != nil {
+		if ot.objContent == nil {
+			var err error
+			ot.objContent, err = objectContent(ot.obj)
+			if err != nil {
+				ot.logger.Error(err, "Failed to convert object to unstructured",
+					"object identifier", objIdentifier, "binding", ot.bindingName)
+				return false, fmt.Errorf("failed to convert the object to unstructured: %w", err)
 			}
 		}
+		pass, err := ot.c.objectSelectionEvaluator.EvaluateBool(*test.ObjectExpression, map[string]interface{}{objKey: ot.objContent})
+		if err != nil {
+			return false, fmt.Errorf("objectExpression failed: %w", err)
+		}
+		if !pass {
+			return false, nil
+		}
+	}
+	return true, nil
+}
 
-		klog.FromContext(ctx).V(5).Info("Workload object matched clause", "objIdentifier", objIdentifier, "objLabels", objLabels, "clause", test, "binding", bindingName)
-		// test is a match
-		matched = true
-		mod.AddExternal(test.DownsyncModulation)
+// objKey is the name of the variable, in an objectExpression,
+// that holds the workload object.
+const objKey = "obj"
+
+func newObjectSelectionEvaluator() (*celeval.Evaluator, error) {
+	return celeval.NewEvaluator(
+		cel.Declarations(
+			decls.NewVar(objKey, decls.NewMapType(decls.String, decls.Dyn)),
+		),
+	)
+}
+
+// objectExpressionErrors returns the user errors in the objectExpressions of the given BindingPolicySpec.
+func (c *Controller) objectExpressionErrors(spec *v1alpha1.BindingPolicySpec) []string {
+	errs := []string{}
+	for idx, clause := range spec.Downsync {
+		if err := c.objectSelectionEvaluator.CheckBoolExpression(clause.ObjectExpression); err != nil {
+			errs = append(errs, fmt.Sprintf("invalid downsync[%d].objectExpression: %s", idx, err))
+		}
 	}
+	for idx, test := range spec.ExcludeDownsync {
+		if err := c.objectSelectionEvaluator.CheckBoolExpression(test.ObjectExpression); err != nil {
+			errs = append(errs, fmt.Sprintf("invalid excludeDownsync[%d].objectExpression: %s", idx, err))
+		}
+	}
+	return errs
+}
 
-	return matched, mod
+// objectContent returns the given object in unstructured form.
+// The returned map must not be mutated.
+func objectContent(obj runtime.Object) (map[string]interface{}, error) {
+	if objU, isU := obj.(*unstructured.Unstructured); isU {
+		return objU.Object, nil
+	}
+	return runtime.DefaultUnstructuredConverter.ToUnstructured(obj)
 }
 
 func minInt(a, b int32) int32 {
@@ -0,0 +1,132 @@
+/*
+Copyright 2024 The KubeStellar Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package binding
+
+import (
+	"testing"
+
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/klog/v2/ktesting"
+	"k8s.io/utils/ptr"
+
+	"github.com/kubestellar/kubestellar/api/control/v1alpha1"
+	"github.com/kubestellar/kubestellar/pkg/util"
+)
+
+func TestObjectExpression(t *testing.T) {
+	_, ctx := ktesting.NewTestContext(t)
+	evaluator, err := newObjectSelectionEvaluator()
+	if err != nil {
+		t.Fatalf("Failed to create evaluator: %v", err)
+	}
+	c := &Controller{objectSelectionEvaluator: evaluator}
+	obj := &unstructured.Unstructured{Object: map[string]interface{}{
+		"apiVersion": "apps/v1",
+		"kind":       "Deployment",
+		"metadata": map[string]interface{}{
+			"namespace":   "ns1",
+			"name":        "d1",
+			"annotations": map[string]interface{}{"example.com/x": "y"},
+		},
+		"spec": map[string]interface{}{"replicas": int64(5)},
+	}}
+	objId := util.IdentifierForObject(obj, "deployments")
+	for _, testCase := range []struct {
+		expression string
+		expected   bool
+	}{
+		{`obj.spec.replicas > 3`, true},
+		{`obj.spec.replicas > 5`, false},
+		{`"example.com/x" in obj.metadata.annotations`, true},
+		{`obj.spec.type == "LoadBalancer"`, false}, // evaluation failure
+		{`obj.metadata.name`, false},               // not a bool
+	} {
+		clauses := []v1alpha1.DownsyncPolicyClause{{
+			DownsyncObjectTest: v1alpha1.DownsyncObjectTest{ObjectExpression: ptr.To(v1alpha1.Expression(testCase.expression))},
+			DownsyncModulation: v1alpha1.DownsyncModulation{CreateOnly: true},
+		}}
+		actual, mod, err := c.testObject(ctx, "b1", objId, obj, clauses, nil)
+		if err != nil {
+			t.Errorf("For %q: unexpected error %v", testCase.expression, err)
+		}
+		if actual != testCase.expected || (mod.UpdateStrategy == v1alpha1.UpdateStrategyCreateOnly) != testCase.expected {
+			t.Errorf("For %q: expected match=%v, got %v with modulation %#v", testCase.expression, testCase.expected, actual, mod)
+		}
+	}
+	spec := &v1alpha1.BindingPolicySpec{
+		Downsync: []v1alpha1.DownsyncPolicyClause{
+			{DownsyncObjectTest: v1alpha1.DownsyncObjectTest{ObjectExpression: ptr.To(v1alpha1.Expression(`obj.spec.replicas >`))}},
+			{DownsyncObjectTest: v1alpha1.DownsyncObjectTest{ObjectExpression: ptr.To(v1alpha1.Expression(`true`))}},
+			{DownsyncObjectTest: v1alpha1.DownsyncObjectTest{ObjectExpression: ptr.To(v1alpha1.Expression(`obj.metadata.name`))}},
+		},
+		ExcludeDownsync: []v1alpha1.DownsyncObjectTest{
+			{ObjectExpression: ptr.To(v1alpha1.Expression(`cluster.metadata.name == "x"`))},
+		},
+	}
+	for _, testCase := range []struct {
+		expression  string
+		expected    bool
+		expectedErr bool
+	}{
+		{`obj.spec.replicas > 5`, true, false},
+		{`obj.spec.replicas > 3`, false, false},
+		{`obj.spec.type == "LoadBalancer"`, false, true}, // evaluation failure excludes
+		{`obj.metadata.name`, false, true},               // not a bool
+	} {
+		include := []v1alpha1.DownsyncPolicyClause{{DownsyncObjectTest: v1alpha1.DownsyncObjectTest{ObjectNames: []string{"d1"}}}}
+		exclude := []v1alpha1.DownsyncObjectTest{{ObjectExpression: ptr.To(v1alpha1.Expression(testCase.expression))}}
+		actual, _, err := c.testObject(ctx, "b1", objId, obj, include, exclude)
+		if actual != testCase.expected || (err != nil) != testCase.expectedErr {
+			t.Errorf("For exclusion %q: expected match=%v and error=%v, got %v and %v", testCase.expression, testCase.expected, testCase.expectedErr, actual, err)
+		}
+	}
+	if errs := c.objectExpressionErrors(spec); len(errs) != 3 {
+		t.Errorf("Expected three errors, got %v", errs)
+	}
+}
+
+func TestExcludeDownsync(t *testing.T) {
+	_, ctx := ktesting.NewTestContext(t)
+	c := &Controller{}
+	newDeployment := func(name string, labels map[string]interface{}) *unstructured.Unstructured {
+		return &unstructured.Unstructured{Object: map[string]interface{}{
+			"apiVersion": "apps/v1",
+			"kind":       "Deployment",
+			"metadata":   map[string]interface{}{"namespace": "foo", "name": name, "labels": labels},
+		}}
+	}
+	include := []v1alpha1.DownsyncPolicyClause{{
+		DownsyncObjectTest: v1alpha1.DownsyncObjectTest{Resources: []string{"deployments"}, Namespaces: []string{"foo"}},
+	}}
+	exclude := []v1alpha1.DownsyncObjectTest{{
+		ObjectSelectors: []metav1.LabelSelector{{MatchLabels: map[string]string{"local-only": "true"}}},
+	}}
+	for _, testCase := range []struct {
+		obj      *unstructured.Unstructured
+		expected bool
+	}{
+		{newDeployment("d1", nil), true},
+		{newDeployment("d2", map[string]interface{}{"local-only": "true"}), false},
+		{newDeployment("d3", map[string]interface{}{"local-only": "false"}), true},
+	} {
+		objId := util.IdentifierForObject(testCase.obj, "deployments")
+		if actual, _, err := c.testObject(ctx, "b1", objId, testCase.obj, include, exclude); err != nil || actual != testCase.expected {
+			t.Errorf("For %s: expected %v, got %v (err=%v)", testCase.obj.GetName(), testCase.expected, actual, err)
+		}
+	}
+}
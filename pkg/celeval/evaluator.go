@@ -0,0 +1,132 @@
+/*
+Copyright 2024 The KubeStellar Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+// Package celeval evaluates the CEL expressions that appear in the KubeStellar API.
+package celeval
+
+import (
+	"fmt"
+
+	"github.com/google/cel-go/cel"
+	"github.com/google/cel-go/common/types/ref"
+
+	"github.com/kubestellar/kubestellar/api/control/v1alpha1"
+)
+
+// Evaluator is a struct that holds the CEL environment
+// and provides a method to evaluate an expression with given values
+// for the variables of the environment.
+type Evaluator struct {
+	env *cel.Env
+}
+
+// NewEvaluator initializes the CEL environment.
+// The options typically declare the variables that expressions can reference.
+func NewEvaluator(opts ...cel.EnvOption) (*Evaluator, error) {
+	env, err := cel.NewEnv(opts...)
+	if err != nil {
+		return nil, fmt.Errorf("failed to create CEL environment: %v", err)
+	}
+
+	return &Evaluator{env: env}, nil
+}
+
+// CheckExpression checks if an expression is valid.
+// If the expression is nil, it returns nil.
+func (e *Evaluator) CheckExpression(expression *v1alpha1.Expression) error {
+	if expression == nil {
+		return nil
+	}
+
+	ast, issues := e.env.Parse(string(*expression))
+	if issues != nil && issues.Err() != nil {
+		return fmt.Errorf("failed to parse expression: %w", issues.Err())
+	}
+
+	_, issues = e.env.Check(ast)
+	if issues != nil && issues.Err() != nil {
+		return fmt.Errorf("failed to check expression: %w", issues.Err())
+	}
+
+	return nil
+}
+
+// CheckBoolExpression is like CheckExpression but also requires the expression to
+// be statically of type bool. A field of a `map(string, dyn)` variable is of type dyn,
+// so an expression that is just such a field has to be compared explicitly
+// (e.g., `obj.spec.paused == true`).
+func (e *Evaluator) CheckBoolExpression(expression *v1alpha1.Expression) error {
+	if expression == nil {
+		return nil
+	}
+
+	ast, issues := e.env.Parse(string(*expression))
+	if issues != nil && issues.Err() != nil {
+		return fmt.Errorf("failed to parse expression: %w", issues.Err())
+	}
+
+	checked, issues := e.env.Check(ast)
+	if issues != nil && issues.Err() != nil {
+		return fmt.Errorf("failed to check expression: %w", issues.Err())
+	}
+
+	if outputType := checked.OutputType(); !outputType.IsExactType(cel.BoolType) {
+		return fmt.Errorf("expression has type %s rather than bool", outputType)
+	}
+	return nil
+}
+
+// Evaluate takes an expression and the values of the variables, and returns the
+// evaluation of the expression with those values.
+func (e *Evaluator) Evaluate(expression v1alpha1.Expression, vars map[string]interface{}) (ref.Val, error) {
+	ast, issues := e.env.Parse(string(expression))
+	if issues != nil && issues.Err() != nil {
+		return nil, fmt.Errorf("failed to parse expression: %w", issues.Err())
+	}
+
+	checked, issues := e.env.Check(ast)
+	if issues != nil && issues.Err() != nil {
+		return nil, fmt.Errorf("failed to check expression: %w", issues.Err())
+	}
+
+	// create the program
+	prog, err := e.env.Program(checked)
+	if err != nil {
+		return nil, fmt.Errorf("failed to create program: %w", err)
+	}
+
+	// evaluate the expression with the given variable values
+	result, _, err := prog.Eval(vars)
+
+	if err != nil {
+		return nil, fmt.Errorf("failed to evaluate expression: %w", err)
+	}
+
+	return result, nil
+}
+
+// EvaluateBool is like Evaluate but requires the result to be a boolean.
+func (e *Evaluator) EvaluateBool(expression v1alpha1.Expression, vars map[string]interface{}) (bool, error) {
+	result, err := e.Evaluate(expression, vars)
+	if err != nil {
+		return false, err
+	}
+	ans, ok := result.Value().(bool)
+	if !ok {
+		return false, fmt.Errorf("expression evaluated to a %T rather than a bool", result.Value())
+	}
+	return ans, nil
+}
@@ -51,6 +51,11 @@ var crdNames = sets.New(
 	"combinedstatuses.control.kubestellar.io",
 )
 
+// CRDs to apply in an ITS, which come from config/crd/its rather than the WDS set in config/crd
+var itsCRDNames = sets.New(
+	"reportedstates.control.kubestellar.io",
+)
+
 //go:embed files/*
 var embeddedFiles embed.FS
 
@@ -62,6 +67,19 @@ const (
 func ApplyCRDs(ctx context.Context, controllerName string,
 	clientsetExt ksmetrics.ClientModNamespace[*apiextensionsv1.CustomResourceDefinition, *apiextensionsv1.CustomResourceDefinitionList],
 	logger logr.Logger) error {
+	return applyCRDs(ctx, controllerName, clientsetExt, logger, crdNames)
+}
+
+// ApplyITSCRDs ensures that the KubeStellar CRDs that belong in an ITS exist and are "established".
+func ApplyITSCRDs(ctx context.Context, controllerName string,
+	clientsetExt ksmetrics.ClientModNamespace[*apiextensionsv1.CustomResourceDefinition, *apiextensionsv1.CustomResourceDefinitionList],
+	logger logr.Logger) error {
+	return applyCRDs(ctx, controllerName, clientsetExt, logger, itsCRDNames)
+}
+
+func applyCRDs(ctx context.Context, controllerName string,
+	clientsetExt ksmetrics.ClientModNamespace[*apiextensionsv1.CustomResourceDefinition, *apiextensionsv1.CustomResourceDefinitionList],
+	logger logr.Logger, names sets.Set[string]) error {
 	ctxLimited, cancel := context.WithTimeout(ctx, time.Minute)
 	defer cancel()
 
@@ -70,7 +88,7 @@ func ApplyCRDs(ctx context.Context, controllerName string,
 		return err
 	}
 
-	crdUnstructureds = filterCRDsByNames(crdUnstructureds, crdNames)
+	crdUnstructureds = filterCRDsByNames(crdUnstructureds, names)
 
 	for _, crdU := range crdUnstructureds {
 		logger.V(1).Info("Applying CRD", "name", crdU.GetName())
@@ -19,8 +19,12 @@ package customize
 import (
 	"bytes"
 	"fmt"
+	"strconv"
 	"strings"
 	"text/template"
+	"text/template/parse"
+
+	"k8s.io/apimachinery/pkg/util/json"
 )
 
 // ExpandTemplates crawls over the input data structure and does
@@ -29,19 +33,33 @@ import (
 // This side-effects every map and slice in the input.
 // The template expansion treats an input `string` as a template
 // as in `text/template` and expands it using the given `templateData`,
-// which nothing mutates during this call.
+// which nothing mutates during this call, and the functions in `FuncMap()`.
+// The values in `templateData` are unmarshaled JSON (with integers represented as int64).
 // The given path is whatever the caller wants, and is extended in
 // JSONPath style as the input data structure is traversed, ultimately being used
 // as input to `text/template` to identify the template --- hence appearing in
 // the resulting errors (if any).
 // The returned `wantedChange` indicates whether there was any template syntax
 // anywhere in the input.
-func ExpandTemplates(path string, input any, templateData map[string]string) (output any, wantedChange bool, errors []string) {
+func ExpandTemplates(path string, input any, templateData map[string]any) (output any, wantedChange bool, errors []string) {
 	exp := expander{defs: templateData}
 	output = exp.expandAny(path, input)
 	return output, exp.wantedChange, exp.errors
 }
 
+// ExpandTemplatesToStructures is like ExpandTemplates except for the treatment
+// of a leaf string that consists entirely of one template action (e.g., "{{ .replicas }}").
+// Such a string is replaced by the value of the action's pipeline, converted to
+// its JSON data form (with integers represented as int64), rather than by its printed form.
+// Thus, such a string can expand into a number, boolean, null, map or array.
+// A string value stays a string; nothing is re-parsed unless the template
+// explicitly asks for it (e.g., with `fromJson` or `int`).
+func ExpandTemplatesToStructures(path string, input any, templateData map[string]any) (output any, wantedChange bool, errors []string) {
+	exp := expander{defs: templateData, structured: true}
+	output = exp.expandAny(path, input)
+	return output, exp.wantedChange, exp.errors
+}
+
 // expander is something that can do template expansion on unmarshaled JSON data.
 type expander struct {
 	// errors is the `.Error()` of the errors encountered
@@ -51,13 +69,23 @@ type expander struct {
 	// anywhere in the input
 	wantedChange bool
 
-	defs map[string]string
+	defs map[string]any
+
+	// structured indicates whether a string consisting of one action
+	// expands into the value of the action's pipeline.
+	structured bool
+
+	// captured holds the value of the pipeline of the last structured action executed.
+	captured any
 }
 
 // expandAny side-effects the given JSON data to expand templates in leaf strings
 func (exp *expander) expandAny(path string, data any) any {
 	switch typed := data.(type) {
 	case string:
+		if exp.structured {
+			return exp.expandStringToValue(path, typed)
+		}
 		return exp.expandString(path, typed)
 	case map[string]any:
 		for key, val := range typed {
@@ -82,19 +110,180 @@ func (exp *expander) expandString(path, input string) string {
 		return input
 	}
 	exp.wantedChange = true
-	tmpl := template.New(path).Option("missingkey=error")
+	tmpl, ok := exp.parse(path, input)
+	if !ok {
+		return ""
+	}
+	ans, _ := exp.execute(tmpl)
+	return ans
+}
+
+// expandStringToValue does template expansion on one string,
+// returning the value of the action's pipeline if the string consists of exactly one action.
+func (exp *expander) expandStringToValue(path, input string) any {
+	if !strings.Contains(input, "{{") {
+		return input
+	}
+	exp.wantedChange = true
+	tmpl, ok := exp.parse(path, input)
+	if !ok {
+		return ""
+	}
+	var action *parse.ActionNode
+	isAction := false
+	if tmpl.Tree != nil && len(tmpl.Tree.Root.Nodes) == 1 {
+		action, isAction = tmpl.Tree.Root.Nodes[0].(*parse.ActionNode)
+	}
+	if !isAction || len(action.Pipe.Decl) != 0 {
+		expanded, _ := exp.execute(tmpl)
+		return expanded
+	}
+	action.Pipe = &parse.PipeNode{NodeType: parse.NodePipe, Pos: action.Pos,
+		Cmds: []*parse.CommandNode{{NodeType: parse.NodeCommand, Pos: action.Pos,
+			Args: []parse.Node{parse.NewIdentifier(captureFuncName).SetPos(action.Pos), action.Pipe}}}}
+	exp.captured = nil
+	if expanded, ok := exp.execute(tmpl); !ok {
+		return expanded
+	}
+	// Convert to the JSON data form, as found in unstructured objects.
+	jsonBytes, err := json.Marshal(exp.captured)
+	var value any
+	if err == nil {
+		err = json.Unmarshal(jsonBytes, &value)
+	}
+	if err != nil {
+		exp.errors = append(exp.errors, fmt.Sprintf("%s: failed to convert value of type %T to JSON data: %s", path, exp.captured, err.Error()))
+		return ""
+	}
+	return value
+}
+
+// captureFuncName is the name under which the expander's capture function is available
+// to the rewritten template of a structured action.
+const captureFuncName = "_capture"
+
+func (exp *expander) parse(path, input string) (*template.Template, bool) {
+	tmpl := template.New(path).Option("missingkey=error").Funcs(FuncMap()).
+		Funcs(template.FuncMap{lookupFuncName: lookup, captureFuncName: exp.capture})
 	tmpl, err := tmpl.Parse(input)
 	if err != nil {
 		exp.errors = append(exp.errors, peel(err).Error())
-		return ""
+		return nil, false
+	}
+	for _, defined := range tmpl.Templates() {
+		allowMissingInDefaults(defined.Tree.Root)
+	}
+	return tmpl, true
+}
+
+// capture records the given value as the value of a structured action.
+func (exp *expander) capture(value any) string {
+	exp.captured = value
+	return ""
+}
+
+// defaultingFuncs are the functions that supply defaults for undefined properties.
+// A reference to an undefined property is an error, except where it is an argument of
+// one of these functions or is piped into one of them; there it evaluates to nil.
+var defaultingFuncs = map[string]bool{"default": true, "coalesce": true, "empty": true}
+
+// lookupFuncName is the name under which `lookup` is available to the rewritten templates.
+const lookupFuncName = "_lookup"
+
+// lookup returns the value at the given path of keys in the given data,
+// or nil if there is none.
+func lookup(data any, keys ...string) any {
+	for _, key := range keys {
+		dataMap, isMap := data.(map[string]any)
+		if !isMap {
+			return nil
+		}
+		data = dataMap[key]
+	}
+	return data
+}
+
+// allowMissingInDefaults rewrites the given parse tree so that each field reference that is
+// an argument of, or is piped into, a function in `defaultingFuncs` is evaluated by `lookup`.
+func allowMissingInDefaults(node parse.Node) {
+	switch typed := node.(type) {
+	case *parse.ListNode:
+		if typed == nil {
+			return
+		}
+		for _, item := range typed.Nodes {
+			allowMissingInDefaults(item)
+		}
+	case *parse.ActionNode:
+		allowMissingInDefaults(typed.Pipe)
+	case *parse.IfNode:
+		allowMissingInBranch(&typed.BranchNode)
+	case *parse.RangeNode:
+		allowMissingInBranch(&typed.BranchNode)
+	case *parse.WithNode:
+		allowMissingInBranch(&typed.BranchNode)
+	case *parse.TemplateNode:
+		allowMissingInDefaults(typed.Pipe)
+	case *parse.PipeNode:
+		if typed == nil {
+			return
+		}
+		for idx, cmd := range typed.Cmds {
+			if ident, isIdent := cmd.Args[0].(*parse.IdentifierNode); isIdent && defaultingFuncs[ident.Ident] {
+				for argIdx := 1; argIdx < len(cmd.Args); argIdx++ {
+					cmd.Args[argIdx] = lenientReference(cmd.Args[argIdx])
+				}
+				if prev := typed.Cmds[max(idx-1, 0)]; idx > 0 && len(prev.Args) == 1 {
+					prev.Args[0] = lenientReference(prev.Args[0])
+				}
+			}
+			for _, arg := range cmd.Args {
+				allowMissingInDefaults(arg)
+			}
+		}
+	}
+}
+
+func allowMissingInBranch(branch *parse.BranchNode) {
+	allowMissingInDefaults(branch.Pipe)
+	allowMissingInDefaults(branch.List)
+	allowMissingInDefaults(branch.ElseList)
+}
+
+// lenientReference returns a replacement for the given node, which evaluates to nil
+// rather than failing when the node is a reference to an undefined field.
+func lenientReference(node parse.Node) parse.Node {
+	var base parse.Node
+	var keys []string
+	switch typed := node.(type) {
+	case *parse.FieldNode:
+		base, keys = &parse.DotNode{NodeType: parse.NodeDot, Pos: typed.Pos}, typed.Ident
+	case *parse.VariableNode:
+		if len(typed.Ident) < 2 {
+			return node
+		}
+		base = &parse.VariableNode{NodeType: parse.NodeVariable, Pos: typed.Pos, Ident: typed.Ident[:1]}
+		keys = typed.Ident[1:]
+	default:
+		return node
+	}
+	args := []parse.Node{parse.NewIdentifier(lookupFuncName).SetPos(node.Position()), base}
+	for _, key := range keys {
+		args = append(args, &parse.StringNode{NodeType: parse.NodeString, Pos: node.Position(), Quoted: strconv.Quote(key), Text: key})
 	}
+	return &parse.PipeNode{NodeType: parse.NodePipe, Pos: node.Position(),
+		Cmds: []*parse.CommandNode{{NodeType: parse.NodeCommand, Pos: node.Position(), Args: args}}}
+}
+
+func (exp *expander) execute(tmpl *template.Template) (string, bool) {
 	var builder bytes.Buffer
-	err = tmpl.Execute(&builder, exp.defs)
+	err := tmpl.Execute(&builder, exp.defs)
 	ans := builder.String()
 	if err != nil {
 		exp.errors = append(exp.errors, peel(err).Error())
+		return ans, false
 	}
-	return ans
+	return ans, true
 }
 
 func peel(err error) error {
@@ -34,7 +34,7 @@ func TestCustomize(t *testing.T) {
 	rg.Uint64()
 	rg.Uint64()
 	for try := 1; try <= 100; try++ {
-		gen := &generator{rg: rg, defs: map[string]string{}, undefined: sets.New[string]()}
+		gen := &generator{rg: rg, defs: map[string]any{}, undefined: sets.New[string]()}
 		input, expected := gen.generateData()
 		inputCopy := runtime.DeepCopyJSONValue(input)
 		actual, wantedChange, errs := ExpandTemplates(fmt.Sprintf("try%d", try), inputCopy, gen.defs)
@@ -65,7 +65,7 @@ func TestCustomize(t *testing.T) {
 
 type generator struct {
 	rg         *rand.Rand
-	defs       map[string]string
+	defs       map[string]any
 	undefined  sets.Set[string]
 	errors     []error
 	changeSome bool
@@ -133,7 +133,8 @@ func (gen *generator) generateString(withParm bool) (string, string) {
 			gendParm = true
 			var parmVal *string
 			if val, have := gen.defs[parmName]; have { // value already decided
-				parmVal = &val
+				valS := val.(string)
+				parmVal = &valS
 			} else if gen.undefined.Has(parmName) { // already decided to be undefined
 				if err == nil {
 					err = fmt.Errorf("Undefined: %q", parmName)
@@ -172,3 +173,51 @@ func (gen *generator) generateString(withParm bool) (string, string) {
 	}
 	return input.String(), expected.String()
 }
+
+func TestExpandTemplatesToStructures(t *testing.T) {
+	defs := map[string]any{
+		"replicas":    int64(3),
+		"tolerations": []any{map[string]any{"key": "edge", "operator": "Exists"}},
+		"version":     "1.20",
+	}
+	input := map[string]any{
+		"replicas":    "{{ .replicas }}",
+		"tolerations": "{{ .tolerations }}",
+		"parsed":      "{{ .tolerations | toJson | fromJson }}",
+		"paused":      "{{ false }}",
+		"version":     "{{ .version }}",
+		"answer":      "{{ \"no\" }}",
+		"count":       "{{ int \"42\" }}",
+		"missing":     "{{ .region | default nil }}",
+		"image":       "registry/app:{{ .version }}",
+	}
+	expected := map[string]any{
+		"replicas":    int64(3),
+		"tolerations": []any{map[string]any{"key": "edge", "operator": "Exists"}},
+		"parsed":      []any{map[string]any{"key": "edge", "operator": "Exists"}},
+		"paused":      false,
+		"version":     "1.20",
+		"answer":      "no",
+		"count":       int64(42),
+		"missing":     nil,
+		"image":       "registry/app:1.20",
+	}
+	actual, wantedChange, errs := ExpandTemplatesToStructures("test", input, defs)
+	if len(errs) != 0 || !wantedChange {
+		t.Fatalf("Expected no errors and a wanted change, got errs=%v, wantedChange=%v", errs, wantedChange)
+	}
+	if !apiequality.Semantic.DeepEqual(expected, actual) {
+		t.Errorf("Expected %#v, got %#v", expected, actual)
+	}
+	// Templates with no nodes at the top level are expanded as strings.
+	for _, input := range []string{"{{/* c */}}", "{{define \"x\"}}a{{end}}"} {
+		actual, _, errs := ExpandTemplatesToStructures("test", input, defs)
+		if len(errs) != 0 || actual != "" {
+			t.Errorf("For %q: expected the empty string and no errors, got %#v and %v", input, actual, errs)
+		}
+	}
+	_, _, errs = ExpandTemplatesToStructures("test", "{{ fromJson \"[unbalanced\" }}", defs)
+	if len(errs) != 1 {
+		t.Errorf("Expected one error from unparseable JSON, got %v", errs)
+	}
+}
@@ -0,0 +1,344 @@
+/*
+Copyright 2024 The KubeStellar Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package customize
+
+import (
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"math"
+	"reflect"
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+	"text/template"
+
+	"k8s.io/apimachinery/pkg/util/json"
+)
+
+// FuncMap returns the functions available in template expansion.
+// These are a curated subset of the ones popularized by the Sprig library
+// (https://masterminds.github.io/sprig/), with the same names and argument orders.
+// They are pure functions of their arguments: none of them accesses the
+// environment, the filesystem, the network, the clock or a source of randomness.
+func FuncMap() template.FuncMap {
+	return template.FuncMap{
+		// strings
+		"upper":      strings.ToUpper,
+		"lower":      strings.ToLower,
+		"trim":       strings.TrimSpace,
+		"trimPrefix": func(prefix, str string) string { return strings.TrimPrefix(str, prefix) },
+		"trimSuffix": func(suffix, str string) string { return strings.TrimSuffix(str, suffix) },
+		"replace":    func(old, new, str string) string { return strings.ReplaceAll(str, old, new) },
+		"contains":   func(substr, str string) bool { return strings.Contains(str, substr) },
+		"hasPrefix":  func(prefix, str string) bool { return strings.HasPrefix(str, prefix) },
+		"hasSuffix":  func(suffix, str string) bool { return strings.HasSuffix(str, suffix) },
+		"splitList":  splitList,
+		"join":       join,
+		"quote":      func(val any) string { return strconv.Quote(toString(val)) },
+		"squote":     func(val any) string { return "'" + toString(val) + "'" },
+		"trunc":      trunc,
+		"indent":     indent,
+		"nindent":    nindent,
+		"toString":   toString,
+
+		// defaults and conditionals
+		"default":  func(dflt any, given ...any) any { return firstNonEmpty(append(given, dflt)...) },
+		"empty":    isEmpty,
+		"coalesce": firstNonEmpty,
+		"ternary": func(ifTrue, ifFalse any, cond bool) any {
+			if cond {
+				return ifTrue
+			}
+			return ifFalse
+		},
+
+		// JSON
+		"toJson":   toJSON,
+		"fromJson": fromJSON,
+
+		// numbers
+		"int":     toInt64,
+		"int64":   toInt64,
+		"float64": toFloat64,
+		"add":     func(left, right any) (int64, error) { return intOp(left, right, checkedAdd) },
+		"sub":     func(left, right any) (int64, error) { return intOp(left, right, checkedSub) },
+		"mul":     func(left, right any) (int64, error) { return intOp(left, right, checkedMul) },
+		"div":     func(left, right any) (int64, error) { return intOp(left, right, checkedDiv) },
+		"mod": func(left, right any) (int64, error) {
+			return intOp(left, right, func(l, r int64) (int64, error) {
+				if r == 0 {
+					return 0, errors.New("modulus by zero")
+				}
+				return l % r, nil
+			})
+		},
+		"max": func(left, right any) (int64, error) { return intOp(left, right, func(l, r int64) (int64, error) { return max(l, r), nil }) },
+		"min": func(left, right any) (int64, error) { return intOp(left, right, func(l, r int64) (int64, error) { return min(l, r), nil }) },
+
+		// lists and dictionaries
+		"list":   func(items ...any) []any { return items },
+		"dict":   dict,
+		"hasKey": func(dict map[string]any, key string) bool { _, has := dict[key]; return has },
+		"get":    func(dict map[string]any, key string) any { return dict[key] },
+		"keys":   keys,
+		"has":    has,
+		"first":  func(list []any) any { return listItem(list, 0) },
+		"last":   func(list []any) any { return listItem(list, len(list)-1) },
+
+		// encodings and hashing
+		"b64enc":    func(str string) string { return base64.StdEncoding.EncodeToString([]byte(str)) },
+		"b64dec":    func(str string) (string, error) { ans, err := base64.StdEncoding.DecodeString(str); return string(ans), err },
+		"sha256sum": func(str string) string { sum := sha256.Sum256([]byte(str)); return hex.EncodeToString(sum[:]) },
+
+		// regular expressions, as in Go's regexp package (which runs in linear time)
+		"regexMatch": func(regex, str string) (bool, error) { return regexp.MatchString(regex, str) },
+		"regexReplaceAll": func(regex, str, repl string) (string, error) {
+			re, err := regexp.Compile(regex)
+			if err != nil {
+				return "", err
+			}
+			return re.ReplaceAllString(str, repl), nil
+		},
+	}
+}
+
+func splitList(sep, str string) []any {
+	return stringsToAnys(strings.Split(str, sep))
+}
+
+func join(sep string, list any) string {
+	listVal := reflect.ValueOf(list)
+	if listVal.Kind() != reflect.Slice {
+		return toString(list)
+	}
+	parts := make([]string, listVal.Len())
+	for idx := range parts {
+		parts[idx] = toString(listVal.Index(idx).Interface())
+	}
+	return strings.Join(parts, sep)
+}
+
+func trunc(length int, str string) string {
+	if length < 0 || length >= len(str) {
+		return str
+	}
+	return str[:length]
+}
+
+// maxIndent is the largest number of spaces that indent and nindent accept,
+// so that a template can not make the expansion allocate without bound.
+const maxIndent = 1024
+
+func indent(spaces int, str string) (string, error) {
+	if spaces > maxIndent {
+		return "", fmt.Errorf("indentation of %d spaces exceeds the maximum of %d", spaces, maxIndent)
+	}
+	pad := strings.Repeat(" ", max(spaces, 0))
+	return pad + strings.ReplaceAll(str, "\n", "\n"+pad), nil
+}
+
+func nindent(spaces int, str string) (string, error) {
+	indented, err := indent(spaces, str)
+	return "\n" + indented, err
+}
+
+// toString renders a value the way that template expansion does,
+// except that nil renders as the empty string.
+func toString(val any) string {
+	switch typed := val.(type) {
+	case nil:
+		return ""
+	case string:
+		return typed
+	case []byte:
+		return string(typed)
+	default:
+		return fmt.Sprint(val)
+	}
+}
+
+// isEmpty tells whether the given value is the zero value of its type
+// or an empty string, slice or map.
+func isEmpty(val any) bool {
+	if val == nil {
+		return true
+	}
+	rv := reflect.ValueOf(val)
+	switch rv.Kind() {
+	case reflect.String, reflect.Slice, reflect.Map, reflect.Array:
+		return rv.Len() == 0
+	default:
+		return rv.IsZero()
+	}
+}
+
+func firstNonEmpty(vals ...any) any {
+	for _, val := range vals {
+		if !isEmpty(val) {
+			return val
+		}
+	}
+	return nil
+}
+
+func toJSON(val any) (string, error) {
+	ans, err := json.Marshal(val)
+	return string(ans), err
+}
+
+// fromJSON parses the given JSON text, representing integers as int64.
+func fromJSON(str string) (any, error) {
+	var ans any
+	err := json.Unmarshal([]byte(str), &ans)
+	return ans, err
+}
+
+func toInt64(val any) (int64, error) {
+	switch typed := val.(type) {
+	case int:
+		return int64(typed), nil
+	case int32:
+		return int64(typed), nil
+	case int64:
+		return typed, nil
+	case float64:
+		if typed != math.Trunc(typed) || typed >= math.MaxInt64 || typed < math.MinInt64 {
+			return 0, fmt.Errorf("%v is not an integer", typed)
+		}
+		return int64(typed), nil
+	case string:
+		return strconv.ParseInt(strings.TrimSpace(typed), 10, 64)
+	case bool:
+		if typed {
+			return 1, nil
+		}
+		return 0, nil
+	default:
+		return 0, fmt.Errorf("can not convert %T to an integer", val)
+	}
+}
+
+func toFloat64(val any) (float64, error) {
+	switch typed := val.(type) {
+	case float64:
+		return typed, nil
+	case string:
+		return strconv.ParseFloat(strings.TrimSpace(typed), 64)
+	default:
+		asInt, err := toInt64(val)
+		return float64(asInt), err
+	}
+}
+
+func intOp(left, right any, op func(int64, int64) (int64, error)) (int64, error) {
+	leftInt, err := toInt64(left)
+	if err != nil {
+		return 0, err
+	}
+	rightInt, err := toInt64(right)
+	if err != nil {
+		return 0, err
+	}
+	return op(leftInt, rightInt)
+}
+
+var errOverflow = errors.New("integer overflow")
+
+func checkedAdd(left, right int64) (int64, error) {
+	sum := left + right
+	if (left > 0 && right > 0 && sum < 0) || (left < 0 && right < 0 && sum >= 0) {
+		return 0, errOverflow
+	}
+	return sum, nil
+}
+
+func checkedSub(left, right int64) (int64, error) {
+	diff := left - right
+	if (left >= 0 && right < 0 && diff < 0) || (left < 0 && right > 0 && diff >= 0) {
+		return 0, errOverflow
+	}
+	return diff, nil
+}
+
+func checkedMul(left, right int64) (int64, error) {
+	if left == 0 || right == 0 {
+		return 0, nil
+	}
+	prod := left * right
+	if prod/right != left || (left == -1 && right == math.MinInt64) || (right == -1 && left == math.MinInt64) {
+		return 0, errOverflow
+	}
+	return prod, nil
+}
+
+func checkedDiv(left, right int64) (int64, error) {
+	if right == 0 {
+		return 0, errors.New("division by zero")
+	}
+	if left == math.MinInt64 && right == -1 {
+		return 0, errOverflow
+	}
+	return left / right, nil
+}
+
+func dict(keysAndVals ...any) (map[string]any, error) {
+	if len(keysAndVals)%2 != 0 {
+		return nil, errors.New("dict requires an even number of arguments")
+	}
+	ans := make(map[string]any, len(keysAndVals)/2)
+	for idx := 0; idx < len(keysAndVals); idx += 2 {
+		ans[toString(keysAndVals[idx])] = keysAndVals[idx+1]
+	}
+	return ans, nil
+}
+
+func keys(dict map[string]any) []any {
+	ans := make([]string, 0, len(dict))
+	for key := range dict {
+		ans = append(ans, key)
+	}
+	sort.Strings(ans)
+	return stringsToAnys(ans)
+}
+
+func stringsToAnys(strs []string) []any {
+	ans := make([]any, len(strs))
+	for idx, str := range strs {
+		ans[idx] = str
+	}
+	return ans
+}
+
+func has(needle any, haystack []any) bool {
+	for _, item := range haystack {
+		if reflect.DeepEqual(item, needle) {
+			return true
+		}
+	}
+	return false
+}
+
+func listItem(list []any, idx int) any {
+	if idx < 0 || idx >= len(list) {
+		return nil
+	}
+	return list[idx]
+}
@@ -0,0 +1,83 @@
+/*
+Copyright 2024 The KubeStellar Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package customize
+
+import (
+	"testing"
+)
+
+func TestFuncs(t *testing.T) {
+	defs := map[string]any{
+		"clusterName": "virgo",
+		"replicas":    int64(3),
+		"zones":       []any{"us-east-1a", "us-east-1b"},
+		"limits":      map[string]any{"cpu": "2", "memory": "4Gi"},
+		"edge":        true,
+	}
+	for _, testCase := range []struct {
+		template  string
+		expected  string
+		expectErr bool
+	}{
+		{`{{ .clusterName | upper }}`, "VIRGO", false},
+		{`{{ index . "region" | default "us-east" }}`, "us-east", false},
+		{`{{ .clusterName | default "none" }}`, "virgo", false},
+		{`{{ .region | default "us-east" }}`, "us-east", false},
+		{`{{ default "us-east" .region }}`, "us-east", false},
+		{`{{ .limits.gpu | default "0" }}`, "0", false},
+		{`{{ coalesce .region .clusterName }}`, "virgo", false},
+		{`{{ if empty .region }}none{{ end }}`, "none", false},
+		{`{{ with .limits }}{{ .gpu | default "0" }}{{ end }}`, "0", false},
+		{`{{ $l := .limits }}{{ $l.gpu | default "0" }}`, "0", false},
+		{`{{ .region }}`, "", true},
+		{`{{ .region | upper | default "none" }}`, "", true},
+		{`{{ add .replicas 2 }}`, "5", false},
+		{`{{ mul .replicas "4" }}`, "12", false},
+		{`{{ div .replicas 0 }}`, "", true},
+		{`{{ add 9223372036854775807 1 }}`, "", true},
+		{`{{ sub -9223372036854775807 2 }}`, "", true},
+		{`{{ mul 4611686018427387904 2 }}`, "", true},
+		{`{{ mul -1 -9223372036854775808 }}`, "", true},
+		{`{{ div -9223372036854775808 -1 }}`, "", true},
+		{`{{ sub -1 9223372036854775807 }}`, "-9223372036854775808", false},
+		{`{{ add (fromJson "9223372036854775808.0") 0 }}`, "", true},
+		{`{{ add (fromJson "-9223372036854775808.0") 0 }}`, "-9223372036854775808", false},
+		{`{{ indent 2 "a\nb" }}`, "  a\n  b", false},
+		{`{{ nindent 1 "a" }}`, "\n a", false},
+		{`{{ indent 2000000000 "x" }}`, "", true},
+		{`{{ nindent 1025 "x" }}`, "", true},
+		{`{{ .zones | toJson }}`, `["us-east-1a","us-east-1b"]`, false},
+		{`{{ join "," .zones }}`, "us-east-1a,us-east-1b", false},
+		{`{{ (fromJson "{\"a\": [1, 2]}").a | last }}`, "2", false},
+		{`{{ keys .limits | toJson }}`, `["cpu","memory"]`, false},
+		{`{{ get .limits "memory" }}`, "4Gi", false},
+		{`{{ ternary "small" "big" .edge }}`, "small", false},
+		{`{{ .clusterName | replace "v" "V" | quote }}`, `"Virgo"`, false},
+		{`{{ .clusterName | b64enc }}`, "dmlyZ28=", false},
+		{`{{ regexReplaceAll "[aeiou]" .clusterName "_" }}`, "v_rg_", false},
+		{`{{ has "us-east-1b" .zones }}`, "true", false},
+		{`{{ dict "a" 1 | toJson }}`, `{"a":1}`, false},
+		{`{{ env "HOME" }}`, "", true},
+	} {
+		actual, _, errs := ExpandTemplates("test", testCase.template, defs)
+		if (len(errs) > 0) != testCase.expectErr {
+			t.Errorf("For template %q, expected error=%v but got errors %v", testCase.template, testCase.expectErr, errs)
+		} else if !testCase.expectErr && actual != testCase.expected {
+			t.Errorf("For template %q, expected %q but got %q", testCase.template, testCase.expected, actual)
+		}
+	}
+}
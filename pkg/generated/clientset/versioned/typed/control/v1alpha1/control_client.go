@@ -33,6 +33,7 @@ type ControlV1alpha1Interface interface {
 	BindingPoliciesGetter
 	CombinedStatusesGetter
 	CustomTransformsGetter
+	ReportedStatesGetter
 	StatusCollectorsGetter
 }
 
@@ -57,6 +58,10 @@ func (c *ControlV1alpha1Client) CustomTransforms() CustomTransformInterface {
 	return newCustomTransforms(c)
 }
 
+func (c *ControlV1alpha1Client) ReportedStates(namespace string) ReportedStateInterface {
+	return newReportedStates(c, namespace)
+}
+
 func (c *ControlV1alpha1Client) StatusCollectors() StatusCollectorInterface {
 	return newStatusCollectors(c)
 }
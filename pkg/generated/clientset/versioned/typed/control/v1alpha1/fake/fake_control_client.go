@@ -45,6 +45,10 @@ func (c *FakeControlV1alpha1) CustomTransforms() v1alpha1.CustomTransformInterfa
 	return &FakeCustomTransforms{c}
 }
 
+func (c *FakeControlV1alpha1) ReportedStates(namespace string) v1alpha1.ReportedStateInterface {
+	return &FakeReportedStates{c, namespace}
+}
+
 func (c *FakeControlV1alpha1) StatusCollectors() v1alpha1.StatusCollectorInterface {
 	return &FakeStatusCollectors{c}
 }
@@ -0,0 +1,142 @@
+/*
+Copyright The KubeStellar Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+// Code generated by client-gen. DO NOT EDIT.
+
+package fake
+
+import (
+	"context"
+
+	v1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	labels "k8s.io/apimachinery/pkg/labels"
+	types "k8s.io/apimachinery/pkg/types"
+	watch "k8s.io/apimachinery/pkg/watch"
+	testing "k8s.io/client-go/testing"
+
+	v1alpha1 "github.com/kubestellar/kubestellar/api/control/v1alpha1"
+)
+
+// FakeReportedStates implements ReportedStateInterface
+type FakeReportedStates struct {
+	Fake *FakeControlV1alpha1
+	ns   string
+}
+
+var reportedstatesResource = v1alpha1.SchemeGroupVersion.WithResource("reportedstates")
+
+var reportedstatesKind = v1alpha1.SchemeGroupVersion.WithKind("ReportedState")
+
+// Get takes name of the reportedState, and returns the corresponding reportedState object, and an error if there is any.
+func (c *FakeReportedStates) Get(ctx context.Context, name string, options v1.GetOptions) (result *v1alpha1.ReportedState, err error) {
+	obj, err := c.Fake.
+		Invokes(testing.NewGetAction(reportedstatesResource, c.ns, name), &v1alpha1.ReportedState{})
+
+	if obj == nil {
+		return nil, err
+	}
+	return obj.(*v1alpha1.ReportedState), err
+}
+
+// List takes label and field selectors, and returns the list of ReportedStates that match those selectors.
+func (c *FakeReportedStates) List(ctx context.Context, opts v1.ListOptions) (result *v1alpha1.ReportedStateList, err error) {
+	obj, err := c.Fake.
+		Invokes(testing.NewListAction(reportedstatesResource, reportedstatesKind, c.ns, opts), &v1alpha1.ReportedStateList{})
+
+	if obj == nil {
+		return nil, err
+	}
+
+	label, _, _ := testing.ExtractFromListOptions(opts)
+	if label == nil {
+		label = labels.Everything()
+	}
+	list := &v1alpha1.ReportedStateList{ListMeta: obj.(*v1alpha1.ReportedStateList).ListMeta}
+	for _, item := range obj.(*v1alpha1.ReportedStateList).Items {
+		if label.Matches(labels.Set(item.Labels)) {
+			list.Items = append(list.Items, item)
+		}
+	}
+	return list, err
+}
+
+// Watch returns a watch.Interface that watches the requested reportedStates.
+func (c *FakeReportedStates) Watch(ctx context.Context, opts v1.ListOptions) (watch.Interface, error) {
+	return c.Fake.
+		InvokesWatch(testing.NewWatchAction(reportedstatesResource, c.ns, opts))
+
+}
+
+// Create takes the representation of a reportedState and creates it.  Returns the server's representation of the reportedState, and an error, if there is any.
+func (c *FakeReportedStates) Create(ctx context.Context, reportedState *v1alpha1.ReportedState, opts v1.CreateOptions) (result *v1alpha1.ReportedState, err error) {
+	obj, err := c.Fake.
+		Invokes(testing.NewCreateAction(reportedstatesResource, c.ns, reportedState), &v1alpha1.ReportedState{})
+
+	if obj == nil {
+		return nil, err
+	}
+	return obj.(*v1alpha1.ReportedState), err
+}
+
+// Update takes the representation of a reportedState and updates it. Returns the server's representation of the reportedState, and an error, if there is any.
+func (c *FakeReportedStates) Update(ctx context.Context, reportedState *v1alpha1.ReportedState, opts v1.UpdateOptions) (result *v1alpha1.ReportedState, err error) {
+	obj, err := c.Fake.
+		Invokes(testing.NewUpdateAction(reportedstatesResource, c.ns, reportedState), &v1alpha1.ReportedState{})
+
+	if obj == nil {
+		return nil, err
+	}
+	return obj.(*v1alpha1.ReportedState), err
+}
+
+// UpdateStatus was generated because the type contains a Status member.
+// Add a +genclient:noStatus comment above the type to avoid generating UpdateStatus().
+func (c *FakeReportedStates) UpdateStatus(ctx context.Context, reportedState *v1alpha1.ReportedState, opts v1.UpdateOptions) (*v1alpha1.ReportedState, error) {
+	obj, err := c.Fake.
+		Invokes(testing.NewUpdateSubresourceAction(reportedstatesResource, "status", c.ns, reportedState), &v1alpha1.ReportedState{})
+
+	if obj == nil {
+		return nil, err
+	}
+	return obj.(*v1alpha1.ReportedState), err
+}
+
+// Delete takes name of the reportedState and deletes it. Returns an error if one occurs.
+func (c *FakeReportedStates) Delete(ctx context.Context, name string, opts v1.DeleteOptions) error {
+	_, err := c.Fake.
+		Invokes(testing.NewDeleteActionWithOptions(reportedstatesResource, c.ns, name, opts), &v1alpha1.ReportedState{})
+
+	return err
+}
+
+// DeleteCollection deletes a collection of objects.
+func (c *FakeReportedStates) DeleteCollection(ctx context.Context, opts v1.DeleteOptions, listOpts v1.ListOptions) error {
+	action := testing.NewDeleteCollectionAction(reportedstatesResource, c.ns, listOpts)
+
+	_, err := c.Fake.Invokes(action, &v1alpha1.ReportedStateList{})
+	return err
+}
+
+// Patch applies the patch and returns the patched reportedState.
+func (c *FakeReportedStates) Patch(ctx context.Context, name string, pt types.PatchType, data []byte, opts v1.PatchOptions, subresources ...string) (result *v1alpha1.ReportedState, err error) {
+	obj, err := c.Fake.
+		Invokes(testing.NewPatchSubresourceAction(reportedstatesResource, c.ns, name, pt, data, subresources...), &v1alpha1.ReportedState{})
+
+	if obj == nil {
+		return nil, err
+	}
+	return obj.(*v1alpha1.ReportedState), err
+}
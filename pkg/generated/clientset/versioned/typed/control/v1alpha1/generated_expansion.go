@@ -26,4 +26,6 @@ type CombinedStatusExpansion interface{}
 
 type CustomTransformExpansion interface{}
 
+type ReportedStateExpansion interface{}
+
 type StatusCollectorExpansion interface{}
@@ -0,0 +1,196 @@
+/*
+Copyright The KubeStellar Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+// Code generated by client-gen. DO NOT EDIT.
+
+package v1alpha1
+
+import (
+	"context"
+	"time"
+
+	v1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	types "k8s.io/apimachinery/pkg/types"
+	watch "k8s.io/apimachinery/pkg/watch"
+	rest "k8s.io/client-go/rest"
+
+	v1alpha1 "github.com/kubestellar/kubestellar/api/control/v1alpha1"
+	scheme "github.com/kubestellar/kubestellar/pkg/generated/clientset/versioned/scheme"
+)
+
+// ReportedStatesGetter has a method to return a ReportedStateInterface.
+// A group's client should implement this interface.
+type ReportedStatesGetter interface {
+	ReportedStates(namespace string) ReportedStateInterface
+}
+
+// ReportedStateInterface has methods to work with ReportedState resources.
+type ReportedStateInterface interface {
+	Create(ctx context.Context, reportedState *v1alpha1.ReportedState, opts v1.CreateOptions) (*v1alpha1.ReportedState, error)
+	Update(ctx context.Context, reportedState *v1alpha1.ReportedState, opts v1.UpdateOptions) (*v1alpha1.ReportedState, error)
+	UpdateStatus(ctx context.Context, reportedState *v1alpha1.ReportedState, opts v1.UpdateOptions) (*v1alpha1.ReportedState, error)
+	Delete(ctx context.Context, name string, opts v1.DeleteOptions) error
+	DeleteCollection(ctx context.Context, opts v1.DeleteOptions, listOpts v1.ListOptions) error
+	Get(ctx context.Context, name string, opts v1.GetOptions) (*v1alpha1.ReportedState, error)
+	List(ctx context.Context, opts v1.ListOptions) (*v1alpha1.ReportedStateList, error)
+	Watch(ctx context.Context, opts v1.ListOptions) (watch.Interface, error)
+	Patch(ctx context.Context, name string, pt types.PatchType, data []byte, opts v1.PatchOptions, subresources ...string) (result *v1alpha1.ReportedState, err error)
+	ReportedStateExpansion
+}
+
+// reportedStates implements ReportedStateInterface
+type reportedStates struct {
+	client rest.Interface
+	ns     string
+}
+
+// newReportedStates returns a ReportedStates
+func newReportedStates(c *ControlV1alpha1Client, namespace string) *reportedStates {
+	return &reportedStates{
+		client: c.RESTClient(),
+		ns:     namespace,
+	}
+}
+
+// Get takes name of the reportedState, and returns the corresponding reportedState object, and an error if there is any.
+func (c *reportedStates) Get(ctx context.Context, name string, options v1.GetOptions) (result *v1alpha1.ReportedState, err error) {
+	result = &v1alpha1.ReportedState{}
+	err = c.client.Get().
+		Namespace(c.ns).
+		Resource("reportedstates").
+		Name(name).
+		VersionedParams(&options, scheme.ParameterCodec).
+		Do(ctx).
+		Into(result)
+	return
+}
+
+// List takes label and field selectors, and returns the list of ReportedStates that match those selectors.
+func (c *reportedStates) List(ctx context.Context, opts v1.ListOptions) (result *v1alpha1.ReportedStateList, err error) {
+	var timeout time.Duration
+	if opts.TimeoutSeconds != nil {
+		timeout = time.Duration(*opts.TimeoutSeconds) * time.Second
+	}
+	result = &v1alpha1.ReportedStateList{}
+	err = c.client.Get().
+		Namespace(c.ns).
+		Resource("reportedstates").
+		VersionedParams(&opts, scheme.ParameterCodec).
+		Timeout(timeout).
+		Do(ctx).
+		Into(result)
+	return
+}
+
+// Watch returns a watch.Interface that watches the requested reportedStates.
+func (c *reportedStates) Watch(ctx context.Context, opts v1.ListOptions) (watch.Interface, error) {
+	var timeout time.Duration
+	if opts.TimeoutSeconds != nil {
+		timeout = time.Duration(*opts.TimeoutSeconds) * time.Second
+	}
+	opts.Watch = true
+	return c.client.Get().
+		Namespace(c.ns).
+		Resource("reportedstates").
+		VersionedParams(&opts, scheme.ParameterCodec).
+		Timeout(timeout).
+		Watch(ctx)
+}
+
+// Create takes the representation of a reportedState and creates it.  Returns the server's representation of the reportedState, and an error, if there is any.
+func (c *reportedStates) Create(ctx context.Context, reportedState *v1alpha1.ReportedState, opts v1.CreateOptions) (result *v1alpha1.ReportedState, err error) {
+	result = &v1alpha1.ReportedState{}
+	err = c.client.Post().
+		Namespace(c.ns).
+		Resource("reportedstates").
+		VersionedParams(&opts, scheme.ParameterCodec).
+		Body(reportedState).
+		Do(ctx).
+		Into(result)
+	return
+}
+
+// Update takes the representation of a reportedState and updates it. Returns the server's representation of the reportedState, and an error, if there is any.
+func (c *reportedStates) Update(ctx context.Context, reportedState *v1alpha1.ReportedState, opts v1.UpdateOptions) (result *v1alpha1.ReportedState, err error) {
+	result = &v1alpha1.ReportedState{}
+	err = c.client.Put().
+		Namespace(c.ns).
+		Resource("reportedstates").
+		Name(reportedState.Name).
+		VersionedParams(&opts, scheme.ParameterCodec).
+		Body(reportedState).
+		Do(ctx).
+		Into(result)
+	return
+}
+
+// UpdateStatus was generated because the type contains a Status member.
+// Add a +genclient:noStatus comment above the type to avoid generating UpdateStatus().
+func (c *reportedStates) UpdateStatus(ctx context.Context, reportedState *v1alpha1.ReportedState, opts v1.UpdateOptions) (result *v1alpha1.ReportedState, err error) {
+	result = &v1alpha1.ReportedState{}
+	err = c.client.Put().
+		Namespace(c.ns).
+		Resource("reportedstates").
+		Name(reportedState.Name).
+		SubResource("status").
+		VersionedParams(&opts, scheme.ParameterCodec).
+		Body(reportedState).
+		Do(ctx).
+		Into(result)
+	return
+}
+
+// Delete takes name of the reportedState and deletes it. Returns an error if one occurs.
+func (c *reportedStates) Delete(ctx context.Context, name string, opts v1.DeleteOptions) error {
+	return c.client.Delete().
+		Namespace(c.ns).
+		Resource("reportedstates").
+		Name(name).
+		Body(&opts).
+		Do(ctx).
+		Error()
+}
+
+// DeleteCollection deletes a collection of objects.
+func (c *reportedStates) DeleteCollection(ctx context.Context, opts v1.DeleteOptions, listOpts v1.ListOptions) error {
+	var timeout time.Duration
+	if listOpts.TimeoutSeconds != nil {
+		timeout = time.Duration(*listOpts.TimeoutSeconds) * time.Second
+	}
+	return c.client.Delete().
+		Namespace(c.ns).
+		Resource("reportedstates").
+		VersionedParams(&listOpts, scheme.ParameterCodec).
+		Timeout(timeout).
+		Body(&opts).
+		Do(ctx).
+		Error()
+}
+
+// Patch applies the patch and returns the patched reportedState.
+func (c *reportedStates) Patch(ctx context.Context, name string, pt types.PatchType, data []byte, opts v1.PatchOptions, subresources ...string) (result *v1alpha1.ReportedState, err error) {
+	result = &v1alpha1.ReportedState{}
+	err = c.client.Patch(pt).
+		Namespace(c.ns).
+		Resource("reportedstates").
+		Name(name).
+		SubResource(subresources...).
+		VersionedParams(&opts, scheme.ParameterCodec).
+		Body(data).
+		Do(ctx).
+		Into(result)
+	return
+}
@@ -32,6 +32,8 @@ type Interface interface {
 	CombinedStatuses() CombinedStatusInformer
 	// CustomTransforms returns a CustomTransformInformer.
 	CustomTransforms() CustomTransformInformer
+	// ReportedStates returns a ReportedStateInformer.
+	ReportedStates() ReportedStateInformer
 	// StatusCollectors returns a StatusCollectorInformer.
 	StatusCollectors() StatusCollectorInformer
 }
@@ -67,6 +69,11 @@ func (v *version) CustomTransforms() CustomTransformInformer {
 	return &customTransformInformer{factory: v.factory, tweakListOptions: v.tweakListOptions}
 }
 
+// ReportedStates returns a ReportedStateInformer.
+func (v *version) ReportedStates() ReportedStateInformer {
+	return &reportedStateInformer{factory: v.factory, namespace: v.namespace, tweakListOptions: v.tweakListOptions}
+}
+
 // StatusCollectors returns a StatusCollectorInformer.
 func (v *version) StatusCollectors() StatusCollectorInformer {
 	return &statusCollectorInformer{factory: v.factory, tweakListOptions: v.tweakListOptions}
@@ -38,6 +38,14 @@ type CombinedStatusNamespaceListerExpansion interface{}
 // CustomTransformLister.
 type CustomTransformListerExpansion interface{}
 
+// ReportedStateListerExpansion allows custom methods to be added to
+// ReportedStateLister.
+type ReportedStateListerExpansion interface{}
+
+// ReportedStateNamespaceListerExpansion allows custom methods to be added to
+// ReportedStateNamespaceLister.
+type ReportedStateNamespaceListerExpansion interface{}
+
 // StatusCollectorListerExpansion allows custom methods to be added to
 // StatusCollectorLister.
 type StatusCollectorListerExpansion interface{}
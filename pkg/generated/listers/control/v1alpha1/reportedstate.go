@@ -0,0 +1,100 @@
+/*
+Copyright The KubeStellar Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+// Code generated by lister-gen. DO NOT EDIT.
+
+package v1alpha1
+
+import (
+	"k8s.io/apimachinery/pkg/api/errors"
+	"k8s.io/apimachinery/pkg/labels"
+	"k8s.io/client-go/tools/cache"
+
+	v1alpha1 "github.com/kubestellar/kubestellar/api/control/v1alpha1"
+)
+
+// ReportedStateLister helps list ReportedStates.
+// All objects returned here must be treated as read-only.
+type ReportedStateLister interface {
+	// List lists all ReportedStates in the indexer.
+	// Objects returned here must be treated as read-only.
+	List(selector labels.Selector) (ret []*v1alpha1.ReportedState, err error)
+	// ReportedStates returns an object that can list and get ReportedStates.
+	ReportedStates(namespace string) ReportedStateNamespaceLister
+	ReportedStateListerExpansion
+}
+
+// reportedStateLister implements the ReportedStateLister interface.
+type reportedStateLister struct {
+	indexer cache.Indexer
+}
+
+// NewReportedStateLister returns a new ReportedStateLister.
+func NewReportedStateLister(indexer cache.Indexer) ReportedStateLister {
+	return &reportedStateLister{indexer: indexer}
+}
+
+// List lists all ReportedStates in the indexer.
+func (s *reportedStateLister) List(selector labels.Selector) (ret []*v1alpha1.ReportedState, err error) {
+	err = cache.ListAll(s.indexer, selector, func(m interface{}) {
+		ret = append(ret, m.(*v1alpha1.ReportedState))
+	})
+	return ret, err
+}
+
+// ReportedStates returns an object that can list and get ReportedStates.
+func (s *reportedStateLister) ReportedStates(namespace string) ReportedStateNamespaceLister {
+	return reportedStateNamespaceLister{indexer: s.indexer, namespace: namespace}
+}
+
+// ReportedStateNamespaceLister helps list and get ReportedStates.
+// All objects returned here must be treated as read-only.
+type ReportedStateNamespaceLister interface {
+	// List lists all ReportedStates in the indexer for a given namespace.
+	// Objects returned here must be treated as read-only.
+	List(selector labels.Selector) (ret []*v1alpha1.ReportedState, err error)
+	// Get retrieves the ReportedState from the indexer for a given namespace and name.
+	// Objects returned here must be treated as read-only.
+	Get(name string) (*v1alpha1.ReportedState, error)
+	ReportedStateNamespaceListerExpansion
+}
+
+// reportedStateNamespaceLister implements the ReportedStateNamespaceLister
+// interface.
+type reportedStateNamespaceLister struct {
+	indexer   cache.Indexer
+	namespace string
+}
+
+// List lists all ReportedStates in the indexer for a given namespace.
+func (s reportedStateNamespaceLister) List(selector labels.Selector) (ret []*v1alpha1.ReportedState, err error) {
+	err = cache.ListAllByNamespace(s.indexer, s.namespace, selector, func(m interface{}) {
+		ret = append(ret, m.(*v1alpha1.ReportedState))
+	})
+	return ret, err
+}
+
+// Get retrieves the ReportedState from the indexer for a given namespace and name.
+func (s reportedStateNamespaceLister) Get(name string) (*v1alpha1.ReportedState, error) {
+	obj, exists, err := s.indexer.GetByKey(s.namespace + "/" + name)
+	if err != nil {
+		return nil, err
+	}
+	if !exists {
+		return nil, errors.NewNotFound(v1alpha1.Resource("reportedstate"), name)
+	}
+	return obj.(*v1alpha1.ReportedState), nil
+}
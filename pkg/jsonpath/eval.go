@@ -16,8 +16,11 @@ limitations under the License.
 
 package jsonpath
 
-// This file implements JSONPath querying for the small subset
-// of JSONPath that this package currently supports.
+import (
+	"sort"
+)
+
+// This file implements JSONPath querying.
 
 // The algorithms and data structures in here are designed for serialized usage,
 // not concurrent usage.
@@ -26,6 +29,8 @@ package jsonpath
 // to a nil `any`.
 // That is: `bool`, `float64`, `string`, `nil`, `[]any`, or `map[string]any` --- where those
 // nested `any` have the same restriction.
+// Other numeric types (e.g., the `int64` found in unstructured Kubernetes objects)
+// are also accepted where a number is expected.
 type JSONValue = any
 
 // Node is a JSON document node.
@@ -34,6 +39,10 @@ type Node interface {
 	// (`nil`, `false`) otherwise.
 	Get() (JSONValue, bool)
 
+	// Set replaces the contents of the node.
+	// The node is expected to be in the document.
+	Set(JSONValue)
+
 	// Remove deletes the node from the JSON document.
 	Remove()
 }
@@ -54,11 +63,19 @@ func (vn *RootNode) Get() (JSONValue, bool) {
 	return *vn.Value, true
 }
 
+func (vn *RootNode) Set(val JSONValue) {
+	if vn.Value == nil {
+		vn.Value = &val
+		return
+	}
+	*vn.Value = val
+}
+
 func (vn *RootNode) Remove() {
 	vn.Value = nil
 }
 
-// FieldNode is a member of a JSON object
+// FieldNode is a member of a JSON object.
 type FieldNode struct {
 	Object map[string]any
 	Key    string
@@ -71,24 +88,359 @@ func (fn FieldNode) Get() (JSONValue, bool) {
 	return val, have
 }
 
+func (fn FieldNode) Set(val JSONValue) {
+	fn.Object[fn.Key] = val
+}
+
 func (fn FieldNode) Remove() {
 	delete(fn.Object, fn.Key)
 }
 
-// QueryValue applies `query` to `node`, invoking `yield` on each
-// of the nodes that the query produces, in a context where the document
-// root is `root`.
+// ElementNode is an element of a JSON array.
+// The array is accessed through its Node, because removing an element
+// means replacing the array.
+type ElementNode struct {
+	Array Node
+	Index int
+}
+
+var _ Node = ElementNode{}
+
+func (en ElementNode) Get() (JSONValue, bool) {
+	arr, ok := en.getArray()
+	if !ok || en.Index < 0 || en.Index >= len(arr) {
+		return nil, false
+	}
+	return arr[en.Index], true
+}
+
+func (en ElementNode) Set(val JSONValue) {
+	arr, ok := en.getArray()
+	if !ok || en.Index < 0 || en.Index >= len(arr) {
+		return
+	}
+	arr[en.Index] = val
+}
+
+// Remove deletes the element from the array,
+// which shifts the later elements down by one position.
+func (en ElementNode) Remove() {
+	arr, ok := en.getArray()
+	if !ok || en.Index < 0 || en.Index >= len(arr) {
+		return
+	}
+	newArr := make([]any, 0, len(arr)-1)
+	newArr = append(newArr, arr[:en.Index]...)
+	newArr = append(newArr, arr[en.Index+1:]...)
+	en.Array.Set(newArr)
+}
+
+func (en ElementNode) getArray() ([]any, bool) {
+	arrA, ok := en.Array.Get()
+	if !ok {
+		return nil, false
+	}
+	arr, ok := arrA.([]any)
+	return arr, ok
+}
+
+// QueryValue calls `yield` on each node selected by the given query,
+// in the order of the resulting nodelist.
+// All the selecting is done before the first call to `yield`.
+// Because removing an array element shifts the later ones,
+// use RemoveAll rather than calling Remove from `yield`.
 func QueryValue(query Query, node Node, yield func(Node)) {
-	for _, fieldName := range query {
-		objA, ok := node.Get()
-		if !ok {
-			return
+	for _, loc := range queryLocated(query, node) {
+		yield(loc.node)
+	}
+}
+
+// RemoveAll removes every node selected by the given query.
+// The removals are done in an order that keeps the
+// not-yet-removed nodes in their expected places: later array
+// elements before earlier ones, and descendants before ancestors.
+func RemoveAll(query Query, node Node) {
+	nodes := queryLocated(query, node)
+	sort.SliceStable(nodes, func(i, j int) bool { return comparePaths(nodes[i].path, nodes[j].path) > 0 })
+	for idx, loc := range nodes {
+		if idx > 0 && comparePaths(nodes[idx-1].path, loc.path) == 0 {
+			continue // same node selected twice
+		}
+		loc.node.Remove()
+	}
+}
+
+// SetAll sets the contents of every node selected by the given query
+// to a value returned by `makeValue`, which is called once per node.
+// When the query is singular and selects nothing, SetAll tries to create the
+// selected node: missing (or null) objects along the path are created as empty objects,
+// while array indices must already be in range.
+// The returned bool indicates whether anything was set.
+func SetAll(query Query, node Node, makeValue func() JSONValue) bool {
+	nodes := queryLocated(query, node)
+	if len(nodes) == 0 && query.IsSingular() {
+		if created, ok := createSingular(query, node); ok {
+			nodes = []located{{node: created}}
+		}
+	}
+	for _, loc := range nodes {
+		loc.node.Set(makeValue())
+	}
+	return len(nodes) > 0
+}
+
+// createSingular finds or creates the node selected by the given singular query,
+// returning `false` if that is not possible.
+// The returned node need not yet be in the document.
+func createSingular(query Query, node Node) (Node, bool) {
+	// first check that creation is possible, so that a failure leaves the document unchanged
+	val, ok := node.Get()
+	if !ok {
+		return nil, false
+	}
+	for _, segment := range query {
+		switch sel := segment.Selectors[0].(type) {
+		case NameSelector:
+			if val == nil {
+				continue
+			}
+			obj, isObj := val.(map[string]any)
+			if !isObj {
+				return nil, false
+			}
+			val = obj[string(sel)]
+		case IndexSelector:
+			arr, isArr := val.([]any)
+			idx := int(sel)
+			if idx < 0 {
+				idx += len(arr)
+			}
+			if !isArr || idx < 0 || idx >= len(arr) {
+				return nil, false
+			}
+			val = arr[idx]
 		}
-		objM, ok := objA.(map[string]any)
-		if !ok {
-			return
+	}
+	for idx, segment := range query {
+		val, _ := node.Get()
+		switch sel := segment.Selectors[0].(type) {
+		case NameSelector:
+			obj, isObj := val.(map[string]any)
+			if !isObj {
+				obj = map[string]any{}
+				node.Set(obj)
+			}
+			node = FieldNode{obj, string(sel)}
+			if _, has := obj[string(sel)]; !has && idx+1 < len(query) {
+				obj[string(sel)] = nil
+			}
+		case IndexSelector:
+			arr := val.([]any)
+			index := int(sel)
+			if index < 0 {
+				index += len(arr)
+			}
+			node = ElementNode{node, index}
+		}
+	}
+	return node, true
+}
+
+func queryLocated(query Query, node Node) []located {
+	root, ok := node.Get()
+	if !ok {
+		return nil
+	}
+	ev := &evaluator{root: root}
+	ans := []located{}
+	ev.evalSegments(query, located{node: node}, func(loc located) { ans = append(ans, loc) })
+	return ans
+}
+
+// located is a node together with its path from the query argument.
+// Each path element is either a `string` (member name) or an `int` (array index).
+type located struct {
+	node Node
+	path []any
+}
+
+func (loc located) child(node Node, pathElt any) located {
+	path := make([]any, len(loc.path), len(loc.path)+1)
+	copy(path, loc.path)
+	return located{node: node, path: append(path, pathElt)}
+}
+
+// comparePaths orders paths lexicographically, comparing array indices numerically.
+// A path is ordered before its extensions.
+func comparePaths(left, right []any) int {
+	for idx := 0; idx < len(left) && idx < len(right); idx++ {
+		switch leftElt := left[idx].(type) {
+		case int:
+			rightElt, _ := right[idx].(int)
+			if leftElt != rightElt {
+				if leftElt < rightElt {
+					return -1
+				}
+				return 1
+			}
+		case string:
+			rightElt, _ := right[idx].(string)
+			if leftElt != rightElt {
+				if leftElt < rightElt {
+					return -1
+				}
+				return 1
+			}
 		}
-		node = FieldNode{objM, fieldName}
 	}
-	yield(node)
+	return len(left) - len(right)
+}
+
+// evaluator holds the context of the evaluation of one query.
+type evaluator struct {
+	// root is the value of the query argument, which `$` refers to in filters.
+	root JSONValue
+}
+
+func (ev *evaluator) evalSegments(segments []Segment, input located, yield func(located)) {
+	if len(segments) == 0 {
+		yield(input)
+		return
+	}
+	segment, rest := segments[0], segments[1:]
+	next := func(loc located) { ev.evalSegments(rest, loc, yield) }
+	if !segment.Descendant {
+		for _, selector := range segment.Selectors {
+			selector.selectFrom(ev, input, next)
+		}
+		return
+	}
+	visitDescendants(input, func(loc located) {
+		for _, selector := range segment.Selectors {
+			selector.selectFrom(ev, loc, next)
+		}
+	})
+}
+
+// visitDescendants calls `visit` on the given node and then, recursively, on its children.
+func visitDescendants(input located, visit func(located)) {
+	visit(input)
+	forEachChild(input, func(child located, _ JSONValue) { visitDescendants(child, visit) })
+}
+
+// forEachChild calls `visit` on each member of an object (in order of name)
+// or each element of an array (in order of index).
+// Other values have no children.
+func forEachChild(parent located, visit func(located, JSONValue)) {
+	val, ok := parent.node.Get()
+	if !ok {
+		return
+	}
+	switch typed := val.(type) {
+	case map[string]any:
+		keys := make([]string, 0, len(typed))
+		for key := range typed {
+			keys = append(keys, key)
+		}
+		sort.Strings(keys)
+		for _, key := range keys {
+			visit(parent.child(FieldNode{typed, key}, key), typed[key])
+		}
+	case []any:
+		for idx, elt := range typed {
+			visit(parent.child(ElementNode{parent.node, idx}, idx), elt)
+		}
+	}
+}
+
+func (sel NameSelector) selectFrom(ev *evaluator, parent located, yield func(located)) {
+	val, ok := parent.node.Get()
+	if !ok {
+		return
+	}
+	if obj, ok := val.(map[string]any); ok {
+		if _, has := obj[string(sel)]; has {
+			yield(parent.child(FieldNode{obj, string(sel)}, string(sel)))
+		}
+	}
+}
+
+func (sel WildcardSelector) selectFrom(ev *evaluator, parent located, yield func(located)) {
+	forEachChild(parent, func(child located, _ JSONValue) { yield(child) })
+}
+
+func (sel IndexSelector) selectFrom(ev *evaluator, parent located, yield func(located)) {
+	arr, ok := getArray(parent)
+	if !ok {
+		return
+	}
+	idx := int(sel)
+	if idx < 0 {
+		idx += len(arr)
+	}
+	if idx >= 0 && idx < len(arr) {
+		yield(parent.child(ElementNode{parent.node, idx}, idx))
+	}
+}
+
+func (sel SliceSelector) selectFrom(ev *evaluator, parent located, yield func(located)) {
+	arr, ok := getArray(parent)
+	if !ok {
+		return
+	}
+	n := len(arr)
+	step := 1
+	if sel.Step != nil {
+		step = *sel.Step
+	}
+	normalize := func(idx int) int {
+		if idx < 0 {
+			return n + idx
+		}
+		return idx
+	}
+	clamp := func(idx, lower, upper int) int {
+		return min(max(idx, lower), upper)
+	}
+	switch {
+	case step > 0:
+		start, end := 0, n
+		if sel.Start != nil {
+			start = normalize(*sel.Start)
+		}
+		if sel.End != nil {
+			end = normalize(*sel.End)
+		}
+		for idx := clamp(start, 0, n); idx < clamp(end, 0, n); idx += step {
+			yield(parent.child(ElementNode{parent.node, idx}, idx))
+		}
+	case step < 0:
+		start, end := n-1, -n-1
+		if sel.Start != nil {
+			start = normalize(*sel.Start)
+		}
+		if sel.End != nil {
+			end = normalize(*sel.End)
+		}
+		for idx := clamp(start, -1, n-1); clamp(end, -1, n-1) < idx; idx += step {
+			yield(parent.child(ElementNode{parent.node, idx}, idx))
+		}
+	}
+}
+
+func (sel FilterSelector) selectFrom(ev *evaluator, parent located, yield func(located)) {
+	forEachChild(parent, func(child located, val JSONValue) {
+		if sel.expr.test(ev, val) {
+			yield(child)
+		}
+	})
+}
+
+func getArray(loc located) ([]any, bool) {
+	val, ok := loc.node.Get()
+	if !ok {
+		return nil, false
+	}
+	arr, ok := val.([]any)
+	return arr, ok
 }
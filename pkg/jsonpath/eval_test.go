@@ -46,6 +46,120 @@ func TestEval(t *testing.T) {
 	}
 }
 
+func TestEvalSelectors(t *testing.T) {
+	doc := `{"store": {"book": [
+		{"category": "reference", "author": "Nigel Rees", "title": "Sayings of the Century", "price": 8.95},
+		{"category": "fiction", "author": "Evelyn Waugh", "title": "Sword of Honour", "price": 12.99},
+		{"category": "fiction", "author": "Herman Melville", "title": "Moby Dick", "isbn": "0-553-21311-3", "price": 8.99},
+		{"category": "fiction", "author": "J. R. R. Tolkien", "title": "The Lord of the Rings", "isbn": "0-395-19395-8", "price": 22.99}
+	], "bicycle": {"color": "red", "price": 399}}, "limit": 10}`
+	for _, testCase := range []struct {
+		query    string
+		expected []JSONValue
+	}{
+		{`$.store.book[*].author`, []JSONValue{"Nigel Rees", "Evelyn Waugh", "Herman Melville", "J. R. R. Tolkien"}},
+		{`$..author`, []JSONValue{"Nigel Rees", "Evelyn Waugh", "Herman Melville", "J. R. R. Tolkien"}},
+		{`$.store.*.color`, []JSONValue{"red"}},
+		{`$.store..price`, []JSONValue{float64(399), 8.95, 12.99, 8.99, 22.99}},
+		{`$..book[2].title`, []JSONValue{"Moby Dick"}},
+		{`$..book[-1].title`, []JSONValue{"The Lord of the Rings"}},
+		{`$..book[0,1].price`, []JSONValue{8.95, 12.99}},
+		{`$..book[:2].price`, []JSONValue{8.95, 12.99}},
+		{`$..book[::-2].price`, []JSONValue{22.99, 12.99}},
+		{`$..book[?@.isbn].title`, []JSONValue{"Moby Dick", "The Lord of the Rings"}},
+		{`$..book[?@.price<10].title`, []JSONValue{"Sayings of the Century", "Moby Dick"}},
+		{`$..book[?@.price > $.limit].title`, []JSONValue{"Sword of Honour", "The Lord of the Rings"}},
+		{`$..book[?(@.author == 'Herman Melville' && @.category == 'fiction')].price`, []JSONValue{8.99}},
+		{`$..book[?!(@.category == "fiction" || @.price > 20)].title`, []JSONValue{"Sayings of the Century"}},
+		{`$..book[?match(@.author, "[A-Z]. R. R. .*")].title`, []JSONValue{"The Lord of the Rings"}},
+		{`$..book[?search(@.title, "Honour")].price`, []JSONValue{12.99}},
+		{`$..book[?length(@.title) == 9].title`, []JSONValue{"Moby Dick"}},
+		{`$.store[?count(@.*) == 2].color`, []JSONValue{"red"}},
+		{`$[?value(@..color) == "red"].bicycle.price`, []JSONValue{float64(399)}},
+	} {
+		var root RootNode
+		if err := json.Unmarshal([]byte(doc), &root.Value); err != nil {
+			t.Fatalf("Failed to parse doc, err=%s", err.Error())
+		}
+		actual := GetQuery(&root, testCase.query)
+		if !jsonEqualities.DeepEqual(testCase.expected, actual) {
+			t.Errorf("For query %q, expected %#v, got %#v", testCase.query, testCase.expected, actual)
+		}
+	}
+}
+
+func TestRemoveAll(t *testing.T) {
+	for _, testCase := range []struct {
+		doc      string
+		query    string
+		expected string
+	}{
+		{`{"a": [1, 2, 3, 4, 5]}`, `$.a[1,3]`, `{"a": [1, 3, 5]}`},
+		{`{"a": [1, 2, 3, 4, 5]}`, `$.a[3,1,3]`, `{"a": [1, 3, 5]}`},
+		{`{"a": [1, 2, 3, 4, 5]}`, `$.a[*]`, `{"a": []}`},
+		{`{"a": [1, 2, 3, 4, 5]}`, `$.a[?@ > 2]`, `{"a": [1, 2]}`},
+		{`{"c": [{"n": "x", "r": 1}, {"n": "y", "r": 2}]}`, `$.c[*].r`, `{"c": [{"n": "x"}, {"n": "y"}]}`},
+		{`{"c": [{"n": "x", "s": [{"n": "x"}]}, {"n": "y"}]}`, `$..[?@.n == "x"]`, `{"c": [{"n": "y"}]}`},
+	} {
+		var root RootNode
+		if err := json.Unmarshal([]byte(testCase.doc), &root.Value); err != nil {
+			t.Fatalf("Failed to parse doc %q, err=%s", testCase.doc, err.Error())
+		}
+		var expected JSONValue
+		if err := json.Unmarshal([]byte(testCase.expected), &expected); err != nil {
+			t.Fatalf("Failed to parse expected %q, err=%s", testCase.expected, err.Error())
+		}
+		query, err := ParseQuery(testCase.query)
+		if err != nil {
+			t.Fatalf("Failed to parse query %q, err=%s", testCase.query, err.Error())
+		}
+		RemoveAll(query, &root)
+		if !jsonEqualities.DeepEqual(expected, *root.Value) {
+			t.Errorf("For doc %q and query %q, expected %#v, got %#v", testCase.doc, testCase.query, expected, *root.Value)
+		}
+	}
+}
+
+func TestSetAll(t *testing.T) {
+	for _, testCase := range []struct {
+		doc      string
+		query    string
+		expected string
+		set      bool
+	}{
+		{`{"a": [{"b": 1}, {"b": 2}, {"c": 3}]}`, `$.a[*].b`, `{"a": [{"b": 0}, {"b": 0}, {"c": 3}]}`, true},
+		{`{"a": {}}`, `$.a.b.c`, `{"a": {"b": {"c": 0}}}`, true},
+		{`{"a": {"b": null}}`, `$.a.b.c`, `{"a": {"b": {"c": 0}}}`, true},
+		{`{"a": [1]}`, `$.a[0]`, `{"a": [0]}`, true},
+		{`{"a": [1]}`, `$.a[1]`, `{"a": [1]}`, false},
+		{`{"a": [1]}`, `$.a.b`, `{"a": [1]}`, false},
+		{`{"a": {"x": 2}}`, `$.a[?@ == 3]`, `{"a": {"x": 2}}`, false},
+		{`{"a": [1, 2]}`, `$.a[-1]`, `{"a": [1, 0]}`, true},
+		{`{"a": [1, 2]}`, `$.a[-3]`, `{"a": [1, 2]}`, false},
+		{`{"a": {}}`, `$.a.*`, `{"a": {}}`, false},
+		{`{"a": "x"}`, `$.a.b.c`, `{"a": "x"}`, false},
+		{`{}`, `$["k.d"]`, `{"k.d": 0}`, true},
+		{`{"a": [{"b": {}}]}`, `$.a[0].b.c`, `{"a": [{"b": {"c": 0}}]}`, true},
+	} {
+		var root RootNode
+		if err := json.Unmarshal([]byte(testCase.doc), &root.Value); err != nil {
+			t.Fatalf("Failed to parse doc %q, err=%s", testCase.doc, err.Error())
+		}
+		var expected JSONValue
+		if err := json.Unmarshal([]byte(testCase.expected), &expected); err != nil {
+			t.Fatalf("Failed to parse expected %q, err=%s", testCase.expected, err.Error())
+		}
+		query, err := ParseQuery(testCase.query)
+		if err != nil {
+			t.Fatalf("Failed to parse query %q, err=%s", testCase.query, err.Error())
+		}
+		set := SetAll(query, &root, func() JSONValue { return float64(0) })
+		if set != testCase.set || !jsonEqualities.DeepEqual(expected, *root.Value) {
+			t.Errorf("For doc %q and query %q, expected %v and %#v, got %v and %#v", testCase.doc, testCase.query, testCase.set, expected, set, *root.Value)
+		}
+	}
+}
+
 var jsonEqualities = k8sreflect.Equalities{}
 
 func GetQuery(root Node, pathS string) []JSONValue {
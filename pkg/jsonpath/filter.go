@@ -0,0 +1,649 @@
+/*
+Copyright 2024 The KubeStellar Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package jsonpath
+
+import (
+	"fmt"
+	"regexp"
+	"strconv"
+	"strings"
+	"unicode/utf8"
+)
+
+// This file implements the logical expressions used in filter selectors.
+
+// logicalExpr is an expression whose value is a boolean.
+type logicalExpr interface {
+	// test evaluates the expression with `@` bound to `current`.
+	test(ev *evaluator, current JSONValue) bool
+}
+
+// valueExpr is an expression whose value is a JSON value or Nothing.
+type valueExpr interface {
+	// value evaluates the expression with `@` bound to `current`.
+	// The returned bool is false when the result is Nothing.
+	value(ev *evaluator, current JSONValue) (JSONValue, bool)
+}
+
+type orExpr []logicalExpr
+
+func (expr orExpr) test(ev *evaluator, current JSONValue) bool {
+	for _, operand := range expr {
+		if operand.test(ev, current) {
+			return true
+		}
+	}
+	return false
+}
+
+type andExpr []logicalExpr
+
+func (expr andExpr) test(ev *evaluator, current JSONValue) bool {
+	for _, operand := range expr {
+		if !operand.test(ev, current) {
+			return false
+		}
+	}
+	return true
+}
+
+type notExpr struct {
+	operand logicalExpr
+}
+
+func (expr notExpr) test(ev *evaluator, current JSONValue) bool {
+	return !expr.operand.test(ev, current)
+}
+
+type comparisonExpr struct {
+	left, right valueExpr
+	op          string
+}
+
+func (expr comparisonExpr) test(ev *evaluator, current JSONValue) bool {
+	left, leftOK := expr.left.value(ev, current)
+	right, rightOK := expr.right.value(ev, current)
+	switch expr.op {
+	case "==":
+		return equalOrNothing(left, leftOK, right, rightOK)
+	case "!=":
+		return !equalOrNothing(left, leftOK, right, rightOK)
+	case "<":
+		return leftOK && rightOK && lessThan(left, right)
+	case ">":
+		return leftOK && rightOK && lessThan(right, left)
+	case "<=":
+		return leftOK && rightOK && lessThan(left, right) || equalOrNothing(left, leftOK, right, rightOK)
+	case ">=":
+		return leftOK && rightOK && lessThan(right, left) || equalOrNothing(left, leftOK, right, rightOK)
+	}
+	return false
+}
+
+type literalExpr struct {
+	val JSONValue
+}
+
+func (expr literalExpr) value(ev *evaluator, current JSONValue) (JSONValue, bool) {
+	return expr.val, true
+}
+
+// filterQuery is a query inside a filter, relative to either `@` or `$`.
+type filterQuery struct {
+	relative bool
+	query    Query
+}
+
+// test is an existence test
+func (expr filterQuery) test(ev *evaluator, current JSONValue) bool {
+	return len(expr.nodes(ev, current)) > 0
+}
+
+// value is only meaningful for a singular query
+func (expr filterQuery) value(ev *evaluator, current JSONValue) (JSONValue, bool) {
+	nodes := expr.nodes(ev, current)
+	if len(nodes) != 1 {
+		return nil, false
+	}
+	return nodes[0], true
+}
+
+func (expr filterQuery) nodes(ev *evaluator, current JSONValue) []JSONValue {
+	start := ev.root
+	if expr.relative {
+		start = current
+	}
+	ans := []JSONValue{}
+	ev.evalSegments(expr.query, located{node: &RootNode{Value: &start}}, func(loc located) {
+		if val, ok := loc.node.Get(); ok {
+			ans = append(ans, val)
+		}
+	})
+	return ans
+}
+
+// functionCall is an application of one of the functions defined in RFC 9535.
+type functionCall struct {
+	name string
+	args []any // each is a valueExpr or a filterQuery, according to the function's signature
+
+	// regexp is the compiled second argument of `match` or `search`, when it is a literal
+	regexp *regexp.Regexp
+}
+
+// functionSignature describes a function's parameters and result
+type functionSignature struct {
+	// nodesParams[i] says whether parameter i is NodesType (otherwise it is ValueType)
+	nodesParams []bool
+	// logicalResult says whether the result is LogicalType (otherwise it is ValueType)
+	logicalResult bool
+}
+
+var functionSignatures = map[string]functionSignature{
+	"length": {nodesParams: []bool{false}},
+	"count":  {nodesParams: []bool{true}},
+	"match":  {nodesParams: []bool{false, false}, logicalResult: true},
+	"search": {nodesParams: []bool{false, false}, logicalResult: true},
+	"value":  {nodesParams: []bool{true}},
+}
+
+func (call *functionCall) value(ev *evaluator, current JSONValue) (JSONValue, bool) {
+	switch call.name {
+	case "length":
+		arg, ok := call.args[0].(valueExpr).value(ev, current)
+		if !ok {
+			return nil, false
+		}
+		switch typed := arg.(type) {
+		case string:
+			return float64(utf8.RuneCountInString(typed)), true
+		case []any:
+			return float64(len(typed)), true
+		case map[string]any:
+			return float64(len(typed)), true
+		}
+		return nil, false
+	case "count":
+		return float64(len(call.args[0].(filterQuery).nodes(ev, current))), true
+	case "value":
+		nodes := call.args[0].(filterQuery).nodes(ev, current)
+		if len(nodes) != 1 {
+			return nil, false
+		}
+		return nodes[0], true
+	}
+	return nil, false
+}
+
+func (call *functionCall) test(ev *evaluator, current JSONValue) bool {
+	subject, ok := call.args[0].(valueExpr).value(ev, current)
+	if !ok {
+		return false
+	}
+	subjectS, ok := subject.(string)
+	if !ok {
+		return false
+	}
+	re := call.regexp
+	if re == nil {
+		pattern, ok := call.args[1].(valueExpr).value(ev, current)
+		if !ok {
+			return false
+		}
+		patternS, ok := pattern.(string)
+		if !ok {
+			return false
+		}
+		var err error
+		re, err = compileFunctionRegexp(call.name, patternS)
+		if err != nil {
+			return false
+		}
+	}
+	return re.MatchString(subjectS)
+}
+
+func compileFunctionRegexp(name, pattern string) (*regexp.Regexp, error) {
+	if name == "match" {
+		pattern = "^(?:" + pattern + ")$"
+	}
+	return regexp.Compile(pattern)
+}
+
+// equalOrNothing implements `==`, where two Nothings are equal.
+func equalOrNothing(left JSONValue, leftOK bool, right JSONValue, rightOK bool) bool {
+	if !leftOK || !rightOK {
+		return leftOK == rightOK
+	}
+	return jsonEqual(left, right)
+}
+
+func jsonEqual(left, right JSONValue) bool {
+	if leftN, ok := asNumber(left); ok {
+		rightN, ok := asNumber(right)
+		return ok && leftN == rightN
+	}
+	switch leftT := left.(type) {
+	case nil:
+		return right == nil
+	case bool:
+		rightT, ok := right.(bool)
+		return ok && leftT == rightT
+	case string:
+		rightT, ok := right.(string)
+		return ok && leftT == rightT
+	case []any:
+		rightT, ok := right.([]any)
+		if !ok || len(leftT) != len(rightT) {
+			return false
+		}
+		for idx := range leftT {
+			if !jsonEqual(leftT[idx], rightT[idx]) {
+				return false
+			}
+		}
+		return true
+	case map[string]any:
+		rightT, ok := right.(map[string]any)
+		if !ok || len(leftT) != len(rightT) {
+			return false
+		}
+		for key, leftVal := range leftT {
+			rightVal, has := rightT[key]
+			if !has || !jsonEqual(leftVal, rightVal) {
+				return false
+			}
+		}
+		return true
+	}
+	return false
+}
+
+// lessThan implements `<` for two values that are not Nothing;
+// only numbers and strings are ordered.
+func lessThan(left, right JSONValue) bool {
+	if leftN, ok := asNumber(left); ok {
+		rightN, ok := asNumber(right)
+		return ok && leftN < rightN
+	}
+	leftS, ok := left.(string)
+	if !ok {
+		return false
+	}
+	rightS, ok := right.(string)
+	// Go compares strings by bytes, which for UTF-8 is the same as comparing by code points.
+	return ok && leftS < rightS
+}
+
+func asNumber(val JSONValue) (float64, bool) {
+	switch typed := val.(type) {
+	case float64:
+		return typed, true
+	case float32:
+		return float64(typed), true
+	case int:
+		return float64(typed), true
+	case int32:
+		return float64(typed), true
+	case int64:
+		return float64(typed), true
+	case uint32:
+		return float64(typed), true
+	case uint64:
+		return float64(typed), true
+	}
+	return 0, false
+}
+
+// scanLogicalExpr consumes a logical-expr.
+func (lxr *Lexer) scanLogicalExpr() (logicalExpr, error) {
+	var disjuncts orExpr
+	for {
+		conjunct, err := lxr.scanAndExpr()
+		if err != nil {
+			return nil, err
+		}
+		disjuncts = append(disjuncts, conjunct)
+		if ok, err := lxr.scanOperator("||"); err != nil || !ok {
+			if len(disjuncts) == 1 {
+				return disjuncts[0], err
+			}
+			return disjuncts, err
+		}
+	}
+}
+
+// scanAndExpr consumes a logical-and-expr.
+func (lxr *Lexer) scanAndExpr() (logicalExpr, error) {
+	var conjuncts andExpr
+	for {
+		operand, err := lxr.scanBasicExpr()
+		if err != nil {
+			return nil, err
+		}
+		conjuncts = append(conjuncts, operand)
+		if ok, err := lxr.scanOperator("&&"); err != nil || !ok {
+			if len(conjuncts) == 1 {
+				return conjuncts[0], err
+			}
+			return conjuncts, err
+		}
+	}
+}
+
+// scanOperator skips blanks and then, if the given two-character operator is next,
+// consumes it and any following blanks.
+func (lxr *Lexer) scanOperator(operator string) (bool, error) {
+	if err := lxr.skipBlanks(); err != nil {
+		return false, err
+	}
+	if lxr.eof || !lxr.lookingAt(operator) {
+		return false, nil
+	}
+	if err := lxr.advance(); err != nil {
+		return false, err
+	}
+	if err := lxr.advance(); err != nil {
+		return false, err
+	}
+	return true, lxr.skipBlanks()
+}
+
+func (lxr *Lexer) lookingAt(prefix string) bool {
+	return len(lxr.source)-lxr.chrPos >= len(prefix) && lxr.source[lxr.chrPos:lxr.chrPos+len(prefix)] == prefix
+}
+
+// scanBasicExpr consumes a paren-expr, comparison-expr, or test-expr.
+func (lxr *Lexer) scanBasicExpr() (logicalExpr, error) {
+	negated := false
+	if !lxr.eof && lxr.chr == '!' {
+		negated = true
+		if err := lxr.advance(); err != nil {
+			return nil, err
+		}
+		if err := lxr.skipBlanks(); err != nil {
+			return nil, err
+		}
+	}
+	wrap := func(expr logicalExpr) logicalExpr {
+		if negated {
+			return notExpr{expr}
+		}
+		return expr
+	}
+	if lxr.eof {
+		return nil, fmt.Errorf("syntax error at %d: expected filter expression, got EOF", lxr.chrPos)
+	}
+	if lxr.chr == '(' {
+		if err := lxr.advance(); err != nil {
+			return nil, err
+		}
+		if err := lxr.skipBlanks(); err != nil {
+			return nil, err
+		}
+		expr, err := lxr.scanLogicalExpr()
+		if err != nil {
+			return nil, err
+		}
+		if lxr.eof || lxr.chr != ')' {
+			return nil, fmt.Errorf("syntax error at %d: missing close parenthesis", lxr.chrPos)
+		}
+		return wrap(expr), lxr.advance()
+	}
+	operandPos := lxr.chrPos
+	left, err := lxr.scanOperand()
+	if err != nil {
+		return nil, err
+	}
+	if err := lxr.skipBlanks(); err != nil {
+		return nil, err
+	}
+	op := lxr.scanComparisonOp()
+	if op == "" {
+		switch typed := left.(type) {
+		case filterQuery:
+			return wrap(typed), nil
+		case *functionCall:
+			if functionSignatures[typed.name].logicalResult {
+				return wrap(typed), nil
+			}
+		}
+		return nil, fmt.Errorf("syntax error at %d: expected a test or comparison", operandPos)
+	}
+	if negated {
+		return nil, fmt.Errorf("syntax error at %d: a comparison can not be negated without parentheses", operandPos)
+	}
+	for idx := 0; idx < len(op); idx++ {
+		if err := lxr.advance(); err != nil {
+			return nil, err
+		}
+	}
+	if err := lxr.skipBlanks(); err != nil {
+		return nil, err
+	}
+	rightPos := lxr.chrPos
+	right, err := lxr.scanOperand()
+	if err != nil {
+		return nil, err
+	}
+	leftV, err := asComparable(left, operandPos)
+	if err != nil {
+		return nil, err
+	}
+	rightV, err := asComparable(right, rightPos)
+	if err != nil {
+		return nil, err
+	}
+	return comparisonExpr{left: leftV, right: rightV, op: op}, nil
+}
+
+// scanComparisonOp returns the comparison operator that the Lexer is looking at,
+// or the empty string if there is none. It consumes nothing.
+func (lxr *Lexer) scanComparisonOp() string {
+	if lxr.eof {
+		return ""
+	}
+	for _, op := range []string{"==", "!=", "<=", ">=", "<", ">"} {
+		if lxr.lookingAt(op) {
+			return op
+		}
+	}
+	return ""
+}
+
+// asComparable checks that the given operand can be compared
+func asComparable(operand any, pos int) (valueExpr, error) {
+	switch typed := operand.(type) {
+	case literalExpr:
+		return typed, nil
+	case filterQuery:
+		if typed.query.IsSingular() {
+			return typed, nil
+		}
+		return nil, fmt.Errorf("type error at %d: only a singular query can be compared", pos)
+	case *functionCall:
+		if !functionSignatures[typed.name].logicalResult {
+			return typed, nil
+		}
+		return nil, fmt.Errorf("type error at %d: function %s does not return a value", pos, typed.name)
+	}
+	return nil, fmt.Errorf("type error at %d: operand can not be compared", pos)
+}
+
+// scanOperand consumes a literal, filter query, or function expression.
+// The result is a literalExpr, filterQuery, or *functionCall.
+func (lxr *Lexer) scanOperand() (any, error) {
+	if lxr.eof {
+		return nil, fmt.Errorf("syntax error at %d: expected operand, got EOF", lxr.chrPos)
+	}
+	switch {
+	case lxr.chr == '@' || lxr.chr == '$':
+		relative := lxr.chr == '@'
+		if err := lxr.advance(); err != nil {
+			return nil, err
+		}
+		query, err := lxr.scanSegments()
+		return filterQuery{relative: relative, query: query}, err
+	case lxr.chr == '"' || lxr.chr == '\'':
+		str, err := lxr.nextString()
+		return literalExpr{str}, err
+	case lxr.chr == '-' || isDigit(lxr.chr):
+		num, err := lxr.nextNumber()
+		return literalExpr{num}, err
+	case isAlpha(lxr.chr):
+		namePos := lxr.chrPos
+		name, err := lxr.nextIdentifier()
+		if err != nil {
+			return nil, err
+		}
+		switch name {
+		case "true":
+			return literalExpr{true}, nil
+		case "false":
+			return literalExpr{false}, nil
+		case "null":
+			return literalExpr{nil}, nil
+		}
+		if lxr.eof || lxr.chr != '(' {
+			return nil, fmt.Errorf("syntax error at %d: expected literal or function call, got %q", namePos, name)
+		}
+		return lxr.scanFunctionArgs(name, namePos)
+	}
+	return nil, fmt.Errorf("syntax error at %d: expected operand, got %q", lxr.chrPos, lxr.chr)
+}
+
+// scanFunctionArgs consumes the parenthesized arguments of a function call
+// and checks them against the function's signature.
+func (lxr *Lexer) scanFunctionArgs(name string, namePos int) (*functionCall, error) {
+	signature, known := functionSignatures[name]
+	if !known {
+		return nil, fmt.Errorf("syntax error at %d: unknown function %q", namePos, name)
+	}
+	call := &functionCall{name: name}
+	if err := lxr.advance(); err != nil {
+		return nil, err
+	}
+	for {
+		if err := lxr.skipBlanks(); err != nil {
+			return nil, err
+		}
+		if !lxr.eof && lxr.chr == ')' && len(call.args) == 0 {
+			break
+		}
+		argPos := lxr.chrPos
+		arg, err := lxr.scanOperand()
+		if err != nil {
+			return nil, err
+		}
+		argIdx := len(call.args)
+		if argIdx >= len(signature.nodesParams) {
+			return nil, fmt.Errorf("type error at %d: too many arguments for function %s", argPos, name)
+		}
+		if signature.nodesParams[argIdx] {
+			if _, ok := arg.(filterQuery); !ok {
+				return nil, fmt.Errorf("type error at %d: argument %d of function %s must be a query", argPos, argIdx+1, name)
+			}
+		} else {
+			arg, err = asComparable(arg, argPos)
+			if err != nil {
+				return nil, err
+			}
+		}
+		call.args = append(call.args, arg)
+		if err := lxr.skipBlanks(); err != nil {
+			return nil, err
+		}
+		if lxr.eof {
+			return nil, fmt.Errorf("syntax error at %d: missing close parenthesis", lxr.chrPos)
+		}
+		if lxr.chr == ')' {
+			break
+		}
+		if lxr.chr != ',' {
+			return nil, fmt.Errorf("syntax error at %d: expected comma or close parenthesis, got %q", lxr.chrPos, lxr.chr)
+		}
+		if err := lxr.advance(); err != nil {
+			return nil, err
+		}
+	}
+	if len(call.args) != len(signature.nodesParams) {
+		return nil, fmt.Errorf("type error at %d: function %s takes %d arguments but got %d", namePos, name, len(signature.nodesParams), len(call.args))
+	}
+	if signature.logicalResult {
+		if pattern, ok := call.args[1].(literalExpr); ok {
+			patternS, ok := pattern.val.(string)
+			if !ok {
+				return nil, fmt.Errorf("type error at %d: the regular expression given to function %s is not a string", namePos, name)
+			}
+			re, err := compileFunctionRegexp(name, patternS)
+			if err != nil {
+				return nil, fmt.Errorf("invalid regular expression at %d: %w", namePos, err)
+			}
+			call.regexp = re
+		}
+	}
+	return call, lxr.advance()
+}
+
+// nextNumber consumes a JSON number.
+func (lxr *Lexer) nextNumber() (float64, error) {
+	startPos := lxr.chrPos
+	consumeDigits := func() (int, error) {
+		count := 0
+		for !lxr.eof && isDigit(lxr.chr) {
+			count++
+			if err := lxr.advance(); err != nil {
+				return count, err
+			}
+		}
+		return count, nil
+	}
+	if lxr.chr == '-' {
+		if err := lxr.advance(); err != nil {
+			return 0, err
+		}
+	}
+	if count, err := consumeDigits(); err != nil || count == 0 {
+		return 0, fmt.Errorf("syntax error at %d: malformed number", startPos)
+	}
+	if !lxr.eof && lxr.chr == '.' {
+		if err := lxr.advance(); err != nil {
+			return 0, err
+		}
+		if count, err := consumeDigits(); err != nil || count == 0 {
+			return 0, fmt.Errorf("syntax error at %d: malformed number", startPos)
+		}
+	}
+	if !lxr.eof && (lxr.chr == 'e' || lxr.chr == 'E') {
+		if err := lxr.advance(); err != nil {
+			return 0, err
+		}
+		if !lxr.eof && (lxr.chr == '-' || lxr.chr == '+') {
+			if err := lxr.advance(); err != nil {
+				return 0, err
+			}
+		}
+		if count, err := consumeDigits(); err != nil || count == 0 {
+			return 0, fmt.Errorf("syntax error at %d: malformed number", startPos)
+		}
+	}
+	numS := lxr.source[startPos:lxr.chrPos]
+	if digits := strings.TrimPrefix(numS, "-"); len(digits) > 1 && digits[0] == '0' && isDigit(rune(digits[1])) {
+		return 0, fmt.Errorf("syntax error at %d: number %q has a leading zero", startPos, numS)
+	}
+	return strconv.ParseFloat(numS, 64)
+}
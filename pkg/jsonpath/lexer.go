@@ -19,20 +19,83 @@ package jsonpath
 import (
 	"fmt"
 	"io"
+	"strconv"
 	"strings"
 
 	"github.com/dop251/goja"
 	js_ast "github.com/dop251/goja/ast"
 )
 
-// Query represents a parsed query in a very restricted subset of JSONPath (RFC 9535).
-// The only supported segment functionality is selecting one definite member of a JSON object.
-type Query []string
+// Query represents a parsed JSONPath query (RFC 9535).
+// It holds the segments that follow the root identifier (`$`).
+type Query []Segment
 
-// ParseQuery parses a very restricted form of JSONPath expression into a Query.
-// This parser only accepts segments of the form `.name` or `[string]`.
-// A string must be enclosed with double-quotes (`"`).
-// No whitespace is allowed.
+// Segment is one segment of a Query.
+// It applies its selectors to each input node (for a child segment)
+// or to each input node and all of its descendants (for a descendant segment),
+// and produces the concatenation of the results.
+type Segment struct {
+	// Descendant distinguishes a descendant segment (`..`) from a child segment.
+	Descendant bool
+
+	// Selectors is never empty.
+	Selectors []Selector
+}
+
+// Selector is one of: NameSelector, WildcardSelector, IndexSelector, SliceSelector, FilterSelector.
+type Selector interface {
+	// selectFrom yields the children of the given node that this selector selects, in order.
+	selectFrom(ev *evaluator, parent located, yield func(located))
+}
+
+// NameSelector selects the member of an object that has the given name.
+type NameSelector string
+
+// WildcardSelector selects all the children of an object or array.
+type WildcardSelector struct{}
+
+// IndexSelector selects the element of an array at the given index.
+// A negative index counts back from the end of the array.
+type IndexSelector int
+
+// SliceSelector selects a slice of an array, in the way of Python slices.
+type SliceSelector struct {
+	// Start is nil when not given.
+	Start *int
+	// End is nil when not given.
+	End *int
+	// Step is nil when not given, which is equivalent to 1.
+	Step *int
+}
+
+// FilterSelector selects the children of an object or array for which a logical expression holds.
+type FilterSelector struct {
+	expr logicalExpr
+}
+
+// IsSingular tells whether the query can select at most one node,
+// as defined in RFC 9535 (only child segments, each holding one name or index selector).
+func (query Query) IsSingular() bool {
+	for _, segment := range query {
+		if segment.Descendant || len(segment.Selectors) != 1 {
+			return false
+		}
+		switch segment.Selectors[0].(type) {
+		case NameSelector, IndexSelector:
+		default:
+			return false
+		}
+	}
+	return true
+}
+
+// ParseQuery parses a JSONPath expression (RFC 9535) into a Query.
+// The entire grammar of the RFC is supported: child and descendant segments,
+// with name, wildcard, index, slice and filter selectors;
+// filters can use comparisons, existence tests, logical operators and
+// the functions `length`, `count`, `match`, `search` and `value`.
+// Regular expressions are interpreted by Go's `regexp` package
+// rather than strictly as I-Regexp.
 func ParseQuery(queryS string) (Query, error) {
 	lexer, err := NewLexer(queryS, 0)
 	if err != nil {
@@ -81,49 +144,206 @@ func (lxr *Lexer) GetPosition() (int, bool) { return lxr.chrPos, lxr.eof }
 // The Lexer is left looking at the first character after the Query
 // or EOF.
 func (lxr *Lexer) ScanQuery() (Query, error) {
-	query := Query{}
-	if lxr.chr != '$' {
-		return query, fmt.Errorf("syntax error at %d: missing root identifier (dollar sign)", lxr.chrPos)
+	if lxr.chr != '$' || lxr.eof {
+		return Query{}, fmt.Errorf("syntax error at %d: missing root identifier (dollar sign)", lxr.chrPos)
 	}
 	if err := lxr.advance(); err != nil {
-		return query, err
+		return Query{}, err
 	}
+	return lxr.scanSegments()
+}
+
+// scanSegments consumes as many segments as are available.
+func (lxr *Lexer) scanSegments() (Query, error) {
+	query := Query{}
 	for !lxr.eof {
 		if lxr.chr == '.' {
 			if err := lxr.advance(); err != nil {
 				return query, err
 			}
-			if !isNameFirst(lxr.chr) {
-				return query, fmt.Errorf("syntax error at %d: expected member-name-shorthand, got %q", lxr.chrPos, lxr.chr)
+			if !lxr.eof && lxr.chr == '.' {
+				if err := lxr.advance(); err != nil {
+					return query, err
+				}
+				segment, err := lxr.scanDescendantSegment()
+				if err != nil {
+					return query, err
+				}
+				query = append(query, segment)
+				continue
 			}
-			if next, err := lxr.nextIdentifier(); err != nil {
+			selector, err := lxr.scanShorthand()
+			if err != nil {
 				return query, err
-			} else {
-				query = append(query, next)
 			}
+			query = append(query, Segment{Selectors: []Selector{selector}})
 		} else if lxr.chr == '[' {
-			if err := lxr.advance(); err != nil {
+			selectors, err := lxr.scanBracketedSelection()
+			if err != nil {
 				return query, err
 			}
-			if lxr.chr != '"' {
-				return query, fmt.Errorf("syntax error at %d: expected open quote, got %q", lxr.chrPos, lxr.chr)
+			query = append(query, Segment{Selectors: selectors})
+		} else {
+			break
+		}
+	}
+	return query, nil
+}
+
+// scanDescendantSegment consumes what follows the `..` of a descendant segment.
+func (lxr *Lexer) scanDescendantSegment() (Segment, error) {
+	if !lxr.eof && lxr.chr == '[' {
+		selectors, err := lxr.scanBracketedSelection()
+		return Segment{Descendant: true, Selectors: selectors}, err
+	}
+	selector, err := lxr.scanShorthand()
+	return Segment{Descendant: true, Selectors: []Selector{selector}}, err
+}
+
+// scanShorthand consumes a wildcard or member-name-shorthand.
+func (lxr *Lexer) scanShorthand() (Selector, error) {
+	if !lxr.eof && lxr.chr == '*' {
+		return WildcardSelector{}, lxr.advance()
+	}
+	if lxr.eof || !isNameFirst(lxr.chr) {
+		return nil, fmt.Errorf("syntax error at %d: expected member-name-shorthand, got %q", lxr.chrPos, lxr.chr)
+	}
+	name, err := lxr.nextIdentifier()
+	return NameSelector(name), err
+}
+
+// scanBracketedSelection consumes a `[`, a comma-separated list of selectors, and the closing `]`.
+func (lxr *Lexer) scanBracketedSelection() ([]Selector, error) {
+	selectors := []Selector{}
+	if err := lxr.advance(); err != nil {
+		return selectors, err
+	}
+	for {
+		if err := lxr.skipBlanks(); err != nil {
+			return selectors, err
+		}
+		selector, err := lxr.scanSelector()
+		if err != nil {
+			return selectors, err
+		}
+		selectors = append(selectors, selector)
+		if err := lxr.skipBlanks(); err != nil {
+			return selectors, err
+		}
+		if lxr.eof {
+			return selectors, fmt.Errorf("syntax error at %d: missing close bracket", lxr.chrPos)
+		}
+		if lxr.chr == ']' {
+			return selectors, lxr.advance()
+		}
+		if lxr.chr != ',' {
+			return selectors, fmt.Errorf("syntax error at %d: expected comma or close bracket, got %q", lxr.chrPos, lxr.chr)
+		}
+		if err := lxr.advance(); err != nil {
+			return selectors, err
+		}
+	}
+}
+
+// scanSelector consumes one selector inside brackets.
+func (lxr *Lexer) scanSelector() (Selector, error) {
+	if lxr.eof {
+		return nil, fmt.Errorf("syntax error at %d: expected selector, got EOF", lxr.chrPos)
+	}
+	switch {
+	case lxr.chr == '"' || lxr.chr == '\'':
+		name, err := lxr.nextString()
+		return NameSelector(name), err
+	case lxr.chr == '*':
+		return WildcardSelector{}, lxr.advance()
+	case lxr.chr == '?':
+		if err := lxr.advance(); err != nil {
+			return nil, err
+		}
+		if err := lxr.skipBlanks(); err != nil {
+			return nil, err
+		}
+		expr, err := lxr.scanLogicalExpr()
+		return FilterSelector{expr: expr}, err
+	case lxr.chr == '-' || lxr.chr == ':' || isDigit(lxr.chr):
+		return lxr.scanIndexOrSlice()
+	default:
+		return nil, fmt.Errorf("syntax error at %d: expected selector, got %q", lxr.chrPos, lxr.chr)
+	}
+}
+
+// scanIndexOrSlice consumes an index selector or a slice selector.
+func (lxr *Lexer) scanIndexOrSlice() (Selector, error) {
+	var bounds [3]*int
+	for part := 0; part < 3; part++ {
+		if part > 0 {
+			if lxr.eof || lxr.chr != ':' {
+				break
+			}
+			if err := lxr.advance(); err != nil {
+				return nil, err
 			}
-			if next, err := lxr.nextString(); err != nil {
-				return query, err
-			} else {
-				query = append(query, next)
+			if err := lxr.skipBlanks(); err != nil {
+				return nil, err
 			}
-			if lxr.chr != ']' {
-				return query, fmt.Errorf("syntax error at %d: missing close bracket, got %q", lxr.chrPos, lxr.chr)
+		}
+		if !lxr.eof && (lxr.chr == '-' || isDigit(lxr.chr)) {
+			val, err := lxr.nextInt()
+			if err != nil {
+				return nil, err
 			}
-			if err := lxr.advance(); err != nil {
-				return query, err
+			bounds[part] = &val
+			if err := lxr.skipBlanks(); err != nil {
+				return nil, err
 			}
-		} else {
-			break
+		}
+		if part == 0 && (lxr.eof || lxr.chr != ':') {
+			if bounds[0] == nil {
+				return nil, fmt.Errorf("syntax error at %d: expected index", lxr.chrPos)
+			}
+			return IndexSelector(*bounds[0]), nil
 		}
 	}
-	return query, nil
+	return SliceSelector{Start: bounds[0], End: bounds[1], Step: bounds[2]}, nil
+}
+
+// nextInt consumes an integer, as restricted by RFC 9535.
+func (lxr *Lexer) nextInt() (int, error) {
+	startPos := lxr.chrPos
+	if lxr.chr == '-' {
+		if err := lxr.advance(); err != nil {
+			return 0, err
+		}
+	}
+	digitsPos := lxr.chrPos
+	for !lxr.eof && isDigit(lxr.chr) {
+		if err := lxr.advance(); err != nil {
+			return 0, err
+		}
+	}
+	intS := lxr.source[startPos:lxr.chrPos]
+	digits := lxr.source[digitsPos:lxr.chrPos]
+	if len(digits) == 0 || len(digits) > 1 && digits[0] == '0' || intS == "-0" {
+		return 0, fmt.Errorf("syntax error at %d: malformed integer %q", startPos, intS)
+	}
+	val, err := strconv.ParseInt(intS, 10, 64)
+	if err != nil || val > maxExactInt || val < -maxExactInt {
+		return 0, fmt.Errorf("syntax error at %d: integer %q out of range", startPos, intS)
+	}
+	return int(val), nil
+}
+
+// maxExactInt is the largest magnitude of integer allowed in a query
+const maxExactInt = 1<<53 - 1
+
+// skipBlanks advances past any space, tab, newline, and carriage return characters.
+func (lxr *Lexer) skipBlanks() error {
+	for !lxr.eof && isBlank(lxr.chr) {
+		if err := lxr.advance(); err != nil {
+			return err
+		}
+	}
+	return nil
 }
 
 func (lxr *Lexer) advance() error {
@@ -212,6 +432,10 @@ func isAlpha(r rune) bool {
 	return 'A' <= r && r <= 'Z' || 'a' <= r && r <= 'z'
 }
 
+func isBlank(r rune) bool {
+	return r == ' ' || r == '\t' || r == '\n' || r == '\r'
+}
+
 func isDigit(r rune) bool {
 	return '0' <= r && r <= '9'
 }
@@ -17,6 +17,7 @@ limitations under the License.
 package jsonpath
 
 import (
+	"reflect"
 	"testing"
 )
 
@@ -53,8 +54,8 @@ CaseLoop:
 				t.Errorf("For source %q, parse produced only %d results", testCase.source, len(query))
 				continue
 			}
-			if query[idx] != good {
-				t.Errorf("For source %q, segment %d is %q but expected token %q", testCase.source, idx, query[idx], good)
+			if !reflect.DeepEqual(query[idx], nameSegment(good)) {
+				t.Errorf("For source %q, segment %d is %#v but expected name %q", testCase.source, idx, query[idx], good)
 				continue CaseLoop
 			}
 		}
@@ -66,6 +67,47 @@ CaseLoop:
 	}
 }
 
+func TestParseSelectors(t *testing.T) {
+	intp := func(val int) *int { return &val }
+	for _, testCase := range []struct {
+		source   string
+		expected Query
+	}{
+		{`$`, Query{}},
+		{`$.a[0]`, Query{nameSegment("a"), {Selectors: []Selector{IndexSelector(0)}}}},
+		{`$[-1]`, Query{{Selectors: []Selector{IndexSelector(-1)}}}},
+		{`$.*`, Query{{Selectors: []Selector{WildcardSelector{}}}}},
+		{`$[*]`, Query{{Selectors: []Selector{WildcardSelector{}}}}},
+		{`$['a', "b", 3]`, Query{{Selectors: []Selector{NameSelector("a"), NameSelector("b"), IndexSelector(3)}}}},
+		{`$[1:3]`, Query{{Selectors: []Selector{SliceSelector{Start: intp(1), End: intp(3)}}}}},
+		{`$[::-1]`, Query{{Selectors: []Selector{SliceSelector{Step: intp(-1)}}}}},
+		{`$[ : 2 : 2 ]`, Query{{Selectors: []Selector{SliceSelector{End: intp(2), Step: intp(2)}}}}},
+		{`$..a`, Query{{Descendant: true, Selectors: []Selector{NameSelector("a")}}}},
+		{`$..*`, Query{{Descendant: true, Selectors: []Selector{WildcardSelector{}}}}},
+		{`$..[0]`, Query{{Descendant: true, Selectors: []Selector{IndexSelector(0)}}}},
+	} {
+		query, err := ParseQuery(testCase.source)
+		if err != nil {
+			t.Errorf("For source %q, got error %v", testCase.source, err)
+		} else if !reflect.DeepEqual(query, testCase.expected) {
+			t.Errorf("For source %q, expected %#v but got %#v", testCase.source, testCase.expected, query)
+		}
+	}
+	for _, source := range []string{
+		`$[01]`, `$[-0]`, `$[1`, `$[1,]`, `$[?]`, `$[?@.a ==]`, `$[?@.a = 1]`, `$[?@.* == 1]`, `$[?!@.a == 1]`,
+		`$[?length(@.*) == 1]`, `$[?count(1) == 1]`, `$[?length(@.a)]`, `$[?match(@.a, "(")]`, `$[?foo(@.a)]`,
+		`$[?(@.a == 1]`, `$[?@.a == 01]`, `$...a`, `$.a.`,
+	} {
+		if _, err := ParseQuery(source); err == nil {
+			t.Errorf("For source %q, expected an error", source)
+		}
+	}
+}
+
+func nameSegment(name string) Segment {
+	return Segment{Selectors: []Selector{NameSelector(name)}}
+}
+
 func cleanEOF(err error) bool {
 	return err == nil
 }
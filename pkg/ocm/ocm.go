@@ -22,30 +22,66 @@ import (
 
 	managedclusterapi "open-cluster-management.io/api/cluster/v1"
 
+	"k8s.io/apimachinery/pkg/api/meta"
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/labels"
 	"k8s.io/apimachinery/pkg/util/sets"
 
 	ksmetrics "github.com/kubestellar/kubestellar/pkg/metrics"
 )
 
+// FindClustersBySelectors returns the names of the clusters that match at least one of the given selectors.
 func FindClustersBySelectors(ctx context.Context, client ksmetrics.ClientModNamespace[*managedclusterapi.ManagedCluster, *managedclusterapi.ManagedClusterList], selectors []metav1.LabelSelector) (sets.Set[string], error) {
+	clusters, err := FindClusterLabelsBySelectors(ctx, client, selectors)
+	return sets.KeySet(clusters), err
+}
+
+// FindClusterLabelsBySelectors returns the clusters that match at least one of the given selectors,
+// as a map from cluster name to the cluster's labels.
+func FindClusterLabelsBySelectors(ctx context.Context, client ksmetrics.ClientModNamespace[*managedclusterapi.ManagedCluster, *managedclusterapi.ManagedClusterList], selectors []metav1.LabelSelector) (map[string]labels.Set, error) {
+	clusters, err := FindClustersBySelectorsFull(ctx, client, selectors)
+	clusterLabels := make(map[string]labels.Set, len(clusters))
+	for name, cluster := range clusters {
+		clusterLabels[name] = labels.Set(cluster.GetLabels())
+	}
+	return clusterLabels, err
+}
+
+// FindClustersBySelectorsFull returns the clusters that match at least one of the given selectors,
+// as a map from cluster name to the cluster.
+func FindClustersBySelectorsFull(ctx context.Context, client ksmetrics.ClientModNamespace[*managedclusterapi.ManagedCluster, *managedclusterapi.ManagedClusterList], selectors []metav1.LabelSelector) (map[string]*managedclusterapi.ManagedCluster, error) {
 	// in order to support OR between label selectors in a straightforward manner, we perform List for each selector.
 	// additionally, to support complex selectors (such as set selectors), we avoid conversion to maps.
-	clusterNames := sets.New[string]()
+	ans := map[string]*managedclusterapi.ManagedCluster{}
 	for _, s := range selectors {
 		ls, err := metav1.LabelSelectorAsSelector(&s)
 		if err != nil {
-			return clusterNames, fmt.Errorf("failed to convert metav1.LabelSelector to labels.Selector: %w", err)
+			return ans, fmt.Errorf("failed to convert metav1.LabelSelector to labels.Selector: %w", err)
 		}
 		clusters, err := client.List(ctx, metav1.ListOptions{LabelSelector: ls.String()})
 		if err != nil {
 			return nil, fmt.Errorf("error listing clusters with selector %s: %w", ls, err)
 		}
 
-		for _, cluster := range clusters.Items {
-			clusterNames.Insert(cluster.GetName())
+		for idx := range clusters.Items {
+			cluster := &clusters.Items[idx]
+			ans[cluster.GetName()] = cluster
 		}
 	}
 
-	return clusterNames, nil
+	return ans, nil
+}
+
+// UnavailableSince tells whether the given cluster is unavailable and, if so, since when.
+// A cluster is unavailable while its ManagedClusterConditionAvailable condition is not True;
+// when that condition is missing, the cluster is considered unavailable since its creation.
+func UnavailableSince(cluster *managedclusterapi.ManagedCluster) (bool, metav1.Time) {
+	cond := meta.FindStatusCondition(cluster.Status.Conditions, managedclusterapi.ManagedClusterConditionAvailable)
+	if cond == nil {
+		return true, cluster.CreationTimestamp
+	}
+	if cond.Status == metav1.ConditionTrue {
+		return false, metav1.Time{}
+	}
+	return true, cond.LastTransitionTime
 }
@@ -64,7 +64,7 @@ type CombinedStatusResolver interface {
 	// The returned set contains the identifiers of combinedstatus objects
 	// that should be queued for syncing.
 	NoteBindingResolution(ctx context.Context, bindingName string, bindingResolution binding.Resolution, deleted bool,
-		workStatusIndexer cache.Indexer,
+		workStatusIndexer workStatusIndex,
 		statusCollectorLister controllisters.StatusCollectorLister) sets.Set[util.ObjectIdentifier]
 
 	// NoteStatusCollector notes a statuscollector's spec.
@@ -80,7 +80,7 @@ type CombinedStatusResolver interface {
 	// The returned two sets identify combinedstatus objects and binding objects
 	// that should be queued for syncing, respectively.
 	NoteStatusCollector(ctx context.Context, statusCollector *v1alpha1.StatusCollector, deleted bool,
-		workStatusIndexer cache.Indexer,
+		workStatusIndexer workStatusIndex,
 	) (sets.Set[util.ObjectIdentifier], sets.Set[string])
 
 	// NoteWorkStatus notes a workstatus in the combinedstatus resolutions
@@ -190,7 +190,7 @@ func (c *combinedStatusResolver) CompareCombinedStatus(bindingName string,
 // The returned set contains the identifiers of combinedstatus objects
 // that should be queued for syncing.
 func (c *combinedStatusResolver) NoteBindingResolution(ctx context.Context, bindingName string, bindingResolution binding.Resolution,
-	deleted bool, workStatusIndexer cache.Indexer,
+	deleted bool, workStatusIndexer workStatusIndex,
 	statusCollectorLister controllisters.StatusCollectorLister) sets.Set[util.ObjectIdentifier] {
 	logger := klog.FromContext(ctx)
 	c.Lock()
@@ -369,7 +369,7 @@ func (c *combinedStatusResolver) NoteWorkStatus(ctx context.Context, workStatus
 // The returned two sets identify combinedstatus objects and binding objects
 // that should be queued for syncing, respectively.
 func (c *combinedStatusResolver) NoteStatusCollector(
-	ctx context.Context, statusCollector *v1alpha1.StatusCollector, deleted bool, workStatusIndexer cache.Indexer,
+	ctx context.Context, statusCollector *v1alpha1.StatusCollector, deleted bool, workStatusIndexer workStatusIndex,
 ) (sets.Set[util.ObjectIdentifier], sets.Set[string]) {
 	logger := klog.FromContext(ctx)
 	c.Lock()
@@ -471,7 +471,7 @@ func (c *combinedStatusResolver) MissingStatusCollectors(bindingName string) []s
 // The method is expected to be called with the read lock held.
 func (c *combinedStatusResolver) evaluateWorkStatusesPerBindingReadLocked(ctx context.Context, bindingName string,
 	workloadObjIdentifiersToEvaluate sets.Set[util.ObjectIdentifier], destinations sets.Set[string],
-	workStatusIndexer cache.Indexer) sets.Set[util.ObjectIdentifier] {
+	workStatusIndexer workStatusIndex) sets.Set[util.ObjectIdentifier] {
 	combinedStatusesToQueue := sets.Set[util.ObjectIdentifier]{}
 	logger := klog.FromContext(ctx)
 
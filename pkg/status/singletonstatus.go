@@ -21,16 +21,15 @@ import (
 
 	apierrors "k8s.io/apimachinery/pkg/api/errors"
 	"k8s.io/apimachinery/pkg/util/sets"
-	"k8s.io/client-go/tools/cache"
 	"k8s.io/klog/v2"
 
 	"github.com/kubestellar/kubestellar/pkg/util"
 )
 
-func (c *Controller) updateWorkStatusToObject(ctx context.Context, workStatusON cache.ObjectName) error {
+func (c *Controller) updateWorkStatusToObject(ctx context.Context, workStatusON reportName) error {
 	logger := klog.FromContext(ctx)
 	logger.V(4).Info("Reconciling singleton status due to workstatus changes", "workStatus", workStatusON)
-	wsObj, err := c.workStatusLister.ByNamespace(workStatusON.Namespace).Get(workStatusON.Name)
+	wsObj, err := c.getReport(workStatusON)
 	if err != nil {
 		if apierrors.IsNotFound(err) {
 			wsObj = nil
@@ -64,10 +63,10 @@ func (c *Controller) updateWorkStatusToObject(ctx context.Context, workStatusON
 func (c *Controller) syncWorkloadObject(ctx context.Context, wObjID util.ObjectIdentifier) error {
 	logger := klog.FromContext(ctx)
 	requested, nWECs := c.bindingPolicyResolver.GetSingletonReportedStateRequestForObject(wObjID)
-	var wsONs [2]cache.ObjectName
+	var wsONs [2]reportName
 	var numWS int
 	if requested && nWECs == 1 {
-		c.workStatusToObject.ReadInverse().ContGet(wObjID, func(wsONSet sets.Set[cache.ObjectName]) {
+		c.workStatusToObject.ReadInverse().ContGet(wObjID, func(wsONSet sets.Set[reportName]) {
 			numWS = wsONSet.Len()
 			var idx int
 			for it := range wsONSet {
@@ -88,7 +87,7 @@ func (c *Controller) syncWorkloadObject(ctx context.Context, wObjID util.ObjectI
 		return nil
 	}
 	wsON := wsONs[0]
-	wsObj, err := c.workStatusLister.ByNamespace(wsON.Namespace).Get(wsON.Name)
+	wsObj, err := c.getReport(wsON)
 	if err != nil {
 		return err
 	}
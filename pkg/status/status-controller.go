@@ -25,6 +25,8 @@ import (
 	"github.com/go-logr/logr"
 	"golang.org/x/time/rate"
 
+	apiextensionsv1 "k8s.io/apiextensions-apiserver/pkg/apis/apiextensions/v1"
+	apiextensionsclientset "k8s.io/apiextensions-apiserver/pkg/client/clientset/clientset"
 	"k8s.io/apimachinery/pkg/api/errors"
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
@@ -32,6 +34,7 @@ import (
 	"k8s.io/apimachinery/pkg/runtime/schema"
 	utilruntime "k8s.io/apimachinery/pkg/util/runtime"
 	"k8s.io/apimachinery/pkg/util/wait"
+	"k8s.io/client-go/discovery"
 	"k8s.io/client-go/dynamic"
 	"k8s.io/client-go/dynamic/dynamicinformer"
 	"k8s.io/client-go/rest"
@@ -42,6 +45,7 @@ import (
 	"github.com/kubestellar/kubestellar/api/control/v1alpha1"
 	"github.com/kubestellar/kubestellar/pkg/abstract"
 	"github.com/kubestellar/kubestellar/pkg/binding"
+	"github.com/kubestellar/kubestellar/pkg/crd"
 	ksclient "github.com/kubestellar/kubestellar/pkg/generated/clientset/versioned"
 	ksinformers "github.com/kubestellar/kubestellar/pkg/generated/informers/externalversions"
 	controllisters "github.com/kubestellar/kubestellar/pkg/generated/listers/control/v1alpha1"
@@ -53,12 +57,16 @@ const (
 	ControllerName      = "Status"
 	defaultResyncPeriod = time.Duration(0)
 	queueingDelay       = 5 * time.Second
+	apiPresencePeriod   = 15 * time.Second
 	originWdsLabelKey   = "transport.kubestellar.io/originWdsName"
 )
 
 // Controller watches workstatues and checks whether the corresponding
 // workload object asks for the singleton status returning. If yes,
 // the full status will be copied to the workload object in WDS.
+// The reported state from a WEC can come in either of two forms:
+// WorkStatus objects, maintained by the OCM status add-on, or
+// ReportedState objects, the KubeStellar-native form that other transports can maintain.
 type Controller struct {
 	wdsName               string
 	wdsDynClient          dynamic.Interface
@@ -68,16 +76,18 @@ type Controller struct {
 	statusCollectorClient ksmetrics.ClientModNamespace[*v1alpha1.StatusCollector, *v1alpha1.StatusCollectorList]
 	combinedStatusClient  ksmetrics.BasicNamespacedClient[*v1alpha1.CombinedStatus, *v1alpha1.CombinedStatusList]
 	itsDynClient          dynamic.Interface
+	itsDiscoveryClient    *discovery.DiscoveryClient
+	itsExtClient          ksmetrics.ClientModNamespace[*apiextensionsv1.CustomResourceDefinition, *apiextensionsv1.CustomResourceDefinitionList]
 
 	bindingLister           controllisters.BindingLister
 	statusCollectorInformer cache.SharedIndexInformer
 	statusCollectorLister   controllisters.StatusCollectorLister
 	combinedStatusInformer  cache.SharedIndexInformer
 	combinedStatusLister    controllisters.CombinedStatusLister
-	workStatusInformer      cache.SharedIndexInformer
-	workStatusLister        cache.GenericLister
-	workStatusIndexer       cache.Indexer
-	workqueue               workqueue.RateLimitingInterface
+	// reportSources maps resource name to the source of reported state of that resource
+	reportSources     map[string]*reportSource
+	workStatusIndexer workStatusIndex
+	workqueue         workqueue.RateLimitingInterface
 	// all wds listers are used to retrieve objects and update status
 	// without having to re-create new caches for this controller
 	listers util.ConcurrentMap[schema.GroupVersionResource, cache.GenericLister]
@@ -86,12 +96,13 @@ type Controller struct {
 	bindingPolicyResolver  binding.BindingPolicyResolver
 	combinedStatusResolver CombinedStatusResolver
 
-	// workStatusToObject maps the namespace/name of WorkStatus to the ID of its workload object.
-	// This map has entries for WorkStatus objects that exist.
+	// workStatusToObject maps the resource/namespace/name of WorkStatus or ReportedState
+	// to the ID of its workload object.
+	// This map has entries for WorkStatus and ReportedState objects that exist.
 	// This map is safe for concurrent access, but
 	// the Set values revealed by workStatusToObject.ReadInverse can not be retained outside of
 	// the funcs that are given them.
-	workStatusToObject abstract.MutableMapToComparable[cache.ObjectName, util.ObjectIdentifier]
+	workStatusToObject abstract.MutableMapToComparable[reportName, util.ObjectIdentifier]
 
 	mutex sync.RWMutex // used in workStatusToObject
 }
@@ -103,8 +114,18 @@ type bindingRef string
 
 type workStatusON cache.ObjectName
 
-// workStatusRef is a workqueue item that references a WorkStatus
+// reportName identifies a WorkStatus or ReportedState object
+type reportName struct {
+	// Resource is either util.WorkStatusResource or util.ReportedStateResource
+	Resource string
+	cache.ObjectName
+}
+
+// workStatusRef is a workqueue item that references a WorkStatus or ReportedState
 type workStatusRef struct {
+	// ReportResource is the resource of the referenced object,
+	// either util.WorkStatusResource or util.ReportedStateResource
+	ReportResource string
 	// Name is the Name of the WorkStatus object
 	Name string
 	// WECName is the WorkStatus namespace
@@ -113,8 +134,8 @@ type workStatusRef struct {
 	SourceObjectIdentifier util.ObjectIdentifier
 }
 
-func (wsr workStatusRef) ObjectName() cache.ObjectName {
-	return cache.ObjectName{Namespace: wsr.WECName, Name: wsr.Name}
+func (wsr workStatusRef) ReportName() reportName {
+	return reportName{Resource: wsr.ReportResource, ObjectName: cache.ObjectName{Namespace: wsr.WECName, Name: wsr.Name}}
 }
 
 // combinedStatusRef is a workqueue item that references a CombinedStatus
@@ -146,6 +167,16 @@ func NewController(logger logr.Logger,
 	}
 	itsDynClient := ksmetrics.NewWrappedDynamicClient(itsClientMetrics, itsDynClientBase)
 
+	itsDiscoveryClient, err := discovery.NewDiscoveryClientForConfig(itsRestConfig)
+	if err != nil {
+		return nil, err
+	}
+
+	itsExtClient, err := apiextensionsclientset.NewForConfig(itsRestConfig)
+	if err != nil {
+		return nil, err
+	}
+
 	wdsKsClient, err := ksclient.NewForConfig(wdsRestConfig)
 	if err != nil {
 		return nil, err
@@ -156,6 +187,8 @@ func NewController(logger logr.Logger,
 		wdsDynClient:          wdsDynClient,
 		wdsKsClient:           wdsKsClient,
 		itsDynClient:          itsDynClient,
+		itsDiscoveryClient:    itsDiscoveryClient,
+		itsExtClient:          ksmetrics.NewWrappedClusterScopedClient(itsClientMetrics, apiextensionsv1.SchemeGroupVersion.WithResource("customresourcedefinitions"), itsExtClient.ApiextensionsV1().CustomResourceDefinitions()),
 		bindingClient:         ksmetrics.NewWrappedClusterScopedClient(wdsClientMetrics, util.GetBindingGVR(), wdsKsClient.ControlV1alpha1().Bindings()),
 		bindingPolicyClient:   ksmetrics.NewWrappedClusterScopedClient(wdsClientMetrics, util.GetBindingPolicyGVR(), wdsKsClient.ControlV1alpha1().BindingPolicies()),
 		statusCollectorClient: ksmetrics.NewWrappedClusterScopedClient(wdsClientMetrics, v1alpha1.GroupVersion.WithResource("statuscollectors"), wdsKsClient.ControlV1alpha1().StatusCollectors()),
@@ -166,7 +199,8 @@ func NewController(logger logr.Logger,
 		bindingPolicyResolver: bindingPolicyResolver,
 	}
 	controller.workStatusToObject = abstract.NewLockedMapToComparable(&controller.mutex,
-		abstract.NewPrimitiveMapToComparable[cache.ObjectName, util.ObjectIdentifier]())
+		abstract.NewPrimitiveMapToComparable[reportName, util.ObjectIdentifier]())
+	controller.setupReportSources(logger)
 
 	broker := controller.bindingPolicyResolver.Broker()
 	klog.Infof("Registering callbacks with broker=%p=%v", broker, broker)
@@ -225,7 +259,16 @@ func (c *Controller) run(ctx context.Context, workers int, cListers chan interfa
 			"cluster-scoped objects: %w", util.ClusterScopedObjectsCombinedStatusNamespace, err)
 	}
 
-	go c.runWorkStatusInformer(ctx)
+	// The ReportedState CRD might also be applied by others, and
+	// the informer for ReportedState objects waits for the API to be present,
+	// so failure here is not fatal.
+	if err := crd.ApplyITSCRDs(ctx, ControllerName, c.itsExtClient, logger); err != nil {
+		logger.Error(err, "Failed to apply the KubeStellar CRDs in the ITS")
+	}
+
+	for _, source := range c.reportSources {
+		go c.runWorkStatusInformer(ctx, source)
+	}
 
 	ksInformerFactory := ksinformers.NewSharedInformerFactory(c.wdsKsClient, defaultResyncPeriod)
 	c.bindingLister = ksInformerFactory.Control().V1alpha1().Bindings().Lister()
@@ -340,18 +383,11 @@ func (c *Controller) enqueueCombinedStatus(obj metav1.Object) {
 	c.workqueue.AddAfter(combinedStatusRef(key), queueingDelay)
 }
 
-func (c *Controller) runWorkStatusInformer(ctx context.Context) {
-	logger := klog.FromContext(ctx)
-
-	informerFactory := dynamicinformer.NewDynamicSharedInformerFactory(c.itsDynClient, 0*time.Minute)
-
-	gvr := schema.GroupVersionResource{Group: util.WorkStatusGroup,
-		Version:  util.WorkStatusVersion,
-		Resource: util.WorkStatusResource}
-
-	c.workStatusInformer = informerFactory.ForResource(gvr).Informer()
-	// add indexer on key from (wecName, sourceRef) for workstatus fetching efficiency
-	c.workStatusInformer.AddIndexers(cache.Indexers{
+// setupReportSources creates the informers on the resources that can carry reported state.
+// The informers are not started here; see runWorkStatusInformer.
+func (c *Controller) setupReportSources(logger klog.Logger) {
+	indexers := cache.Indexers{
+		// add indexer on key from (wecName, sourceRef) for workstatus fetching efficiency
 		workStatusIdentificationIndexKey: func(obj interface{}) ([]string, error) {
 			wecName := obj.(metav1.Object).GetNamespace()
 			sourceRef, err := util.GetWorkStatusSourceRef(obj.(runtime.Object))
@@ -363,12 +399,38 @@ func (c *Controller) runWorkStatusInformer(ctx context.Context) {
 			}
 
 			return []string{util.KeyFromSourceRefAndWecName(sourceRef, wecName)}, nil
-		}})
-	c.workStatusIndexer = c.workStatusInformer.GetIndexer()
-	c.workStatusLister = cache.NewGenericLister(c.workStatusInformer.GetIndexer(), gvr.GroupResource())
+		}}
+	c.reportSources = map[string]*reportSource{}
+	var union unionIndex
+	for _, resource := range []string{util.WorkStatusResource, util.ReportedStateResource} {
+		gvr := schema.GroupVersionResource{Group: util.WorkStatusGroup,
+			Version:  util.WorkStatusVersion,
+			Resource: resource}
+		informer := dynamicinformer.NewFilteredDynamicInformer(c.itsDynClient, gvr, metav1.NamespaceAll, defaultResyncPeriod, indexers, nil)
+		c.reportSources[resource] = &reportSource{gvr: gvr, informer: informer.Informer(), lister: informer.Lister()}
+		union = append(union, informer.Informer().GetIndexer())
+	}
+	c.workStatusIndexer = union
+}
+
+// runWorkStatusInformer runs the informer on one source of reported state,
+// once that resource is defined in the ITS.
+func (c *Controller) runWorkStatusInformer(ctx context.Context, source *reportSource) {
+	logger := klog.FromContext(ctx).WithValues("resource", source.gvr.Resource)
+
+	for i := 1; !util.CheckAPIisPresent(c.itsDiscoveryClient, source.gvr); i++ {
+		if (i & (i - 1)) == 0 {
+			logger.Info("Not starting informer yet because the resource is not defined in the ITS")
+		}
+		select {
+		case <-ctx.Done():
+			return
+		case <-time.After(apiPresencePeriod):
+		}
+	}
 
 	// add the event handler functions
-	c.workStatusInformer.AddEventHandler(cache.ResourceEventHandlerFuncs{
+	source.informer.AddEventHandler(cache.ResourceEventHandlerFuncs{
 		AddFunc: func(obj interface{}) {
 			if objNotInThisWDS(obj, c.wdsName) {
 				return
@@ -388,10 +450,10 @@ func (c *Controller) runWorkStatusInformer(ctx context.Context) {
 			c.handleWorkStatus(ctx, "delete", obj)
 		},
 	})
-	informerFactory.Start(ctx.Done())
+	go source.informer.Run(ctx.Done())
 
 	logger.Info("waiting for workstatus cache to sync")
-	if ok := cache.WaitForCacheSync(ctx.Done(), c.workStatusInformer.HasSynced); !ok {
+	if ok := cache.WaitForCacheSync(ctx.Done(), source.informer.HasSynced); !ok {
 		logger.Info("failed to wait for workstatus caches to sync")
 	}
 	logger.Info("workstatus cache synced")
@@ -425,7 +487,7 @@ func (c *Controller) handleWorkStatus(ctx context.Context, eventType string, obj
 		return
 	}
 	logger.V(5).Info("Enqueuing reference to WorkStatus because of informer event", "eventType", eventType,
-		"sourceObjectName", wsRef.SourceObjectIdentifier.ObjectName,
+		"resource", wsRef.ReportResource, "sourceObjectName", wsRef.SourceObjectIdentifier.ObjectName,
 		"sourceObjectGVK", wsRef.SourceObjectIdentifier.GVK, "wecName", wsRef.WECName)
 	c.workqueue.Add(*wsRef)
 }
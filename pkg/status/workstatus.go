@@ -35,6 +35,45 @@ import (
 
 const workStatusIdentificationIndexKey = "workStatusIdentificationIndex"
 
+// reportSource is a resource, in the ITS, whose objects carry reported state.
+// The objects of every source have the same shape: a `.spec.sourceRef` that
+// identifies the workload object and a `.status` that holds its reported state.
+type reportSource struct {
+	gvr      schema.GroupVersionResource
+	informer cache.SharedIndexInformer
+	lister   cache.GenericLister
+}
+
+// workStatusIndex is the part of cache.Indexer that is used to look up
+// reported state by workStatusIdentificationIndexKey.
+type workStatusIndex interface {
+	ByIndex(indexName, indexedValue string) ([]any, error)
+}
+
+// unionIndex is a workStatusIndex that combines the results from several indexers.
+type unionIndex []cache.Indexer
+
+func (ui unionIndex) ByIndex(indexName, indexedValue string) ([]any, error) {
+	var ans []any
+	for _, indexer := range ui {
+		objs, err := indexer.ByIndex(indexName, indexedValue)
+		if err != nil {
+			return nil, err
+		}
+		ans = append(ans, objs...)
+	}
+	return ans, nil
+}
+
+// getReport fetches a WorkStatus or ReportedState object from the local cache.
+func (c *Controller) getReport(name reportName) (runtime.Object, error) {
+	source, ok := c.reportSources[name.Resource]
+	if !ok {
+		return nil, fmt.Errorf("unknown resource %q for reported state", name.Resource)
+	}
+	return source.lister.ByNamespace(name.Namespace).Get(name.Name)
+}
+
 type workStatus struct {
 	workStatusRef
 	status         map[string]interface{}
@@ -49,7 +88,7 @@ func (ws *workStatus) Content() map[string]interface{} {
 
 func (c *Controller) syncWorkStatus(ctx context.Context, ref workStatusRef) error {
 	logger := klog.FromContext(ctx)
-	wsON := ref.ObjectName()
+	wsON := ref.ReportName()
 
 	if err := c.updateWorkStatusToObject(ctx, wsON); err != nil {
 		return err
@@ -60,7 +99,7 @@ func (c *Controller) syncWorkStatus(ctx context.Context, ref workStatusRef) erro
 		status:        nil,
 	}
 
-	obj, err := c.workStatusLister.ByNamespace(ref.WECName).Get(ref.Name)
+	obj, err := c.getReport(wsON)
 	if err != nil {
 		if !errors.IsNotFound(err) {
 			return fmt.Errorf("failed to get workstatus (%v): %w", ref, err)
@@ -218,6 +257,10 @@ func runtimeObjectToWorkStatus(obj runtime.Object) (*workStatus, error) {
 func runtimeObjectToWorkStatusRef(obj runtime.Object) (*workStatusRef, error) {
 	name := obj.(metav1.Object).GetName()
 	wecName := obj.(metav1.Object).GetNamespace()
+	reportResource := util.WorkStatusResource
+	if obj.GetObjectKind().GroupVersionKind().Kind == util.ReportedStateKind {
+		reportResource = util.ReportedStateResource
+	}
 
 	sourceRef, err := util.GetWorkStatusSourceRef(obj)
 	if err != nil {
@@ -231,6 +274,7 @@ func runtimeObjectToWorkStatusRef(obj runtime.Object) (*workStatusRef, error) {
 	}
 
 	return &workStatusRef{
+		ReportResource:         reportResource,
 		Name:                   name,
 		WECName:                wecName,
 		SourceObjectIdentifier: objIdentifier,
@@ -0,0 +1,615 @@
+/*
+Copyright 2024 The KubeStellar Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package configmap
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/go-logr/logr"
+
+	corev1 "k8s.io/api/core/v1"
+	apiextensionsv1 "k8s.io/apiextensions-apiserver/pkg/apis/apiextensions/v1"
+	apiequality "k8s.io/apimachinery/pkg/api/equality"
+	"k8s.io/apimachinery/pkg/api/errors"
+	"k8s.io/apimachinery/pkg/api/meta"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/labels"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+	utilruntime "k8s.io/apimachinery/pkg/util/runtime"
+	"k8s.io/apimachinery/pkg/util/sets"
+	"k8s.io/apimachinery/pkg/util/validation"
+	"k8s.io/apimachinery/pkg/util/wait"
+	"k8s.io/client-go/dynamic"
+	corev1informers "k8s.io/client-go/informers/core/v1"
+	corev1client "k8s.io/client-go/kubernetes/typed/core/v1"
+	corev1listers "k8s.io/client-go/listers/core/v1"
+	"k8s.io/client-go/tools/cache"
+	"k8s.io/client-go/util/workqueue"
+	"k8s.io/klog/v2"
+
+	"github.com/kubestellar/kubestellar/api/control/v1alpha1"
+	controlclient "github.com/kubestellar/kubestellar/pkg/generated/clientset/versioned/typed/control/v1alpha1"
+	"github.com/kubestellar/kubestellar/pkg/util"
+	"github.com/kubestellar/kubestellar/pkg/wecreport"
+)
+
+const (
+	// AgentName is the name of the pull agent, used as its field manager.
+	AgentName = "configmap-pull-agent"
+
+	// AppliedRecordLabelKey is the key of a label, with value "true", on every applied record.
+	// An applied record is a ConfigMap, next to a bundle, in which the pull agent
+	// remembers which objects it has applied in the WEC for that bundle and
+	// reports the errors from doing so.
+	AppliedRecordLabelKey = "transport.kubestellar.io/applied-record"
+
+	// AppliedRecordNameSuffix is appended to the name of a bundle to get the name of its applied record.
+	AppliedRecordNameSuffix = ".applied"
+
+	// AppliedDataKey is the key, in the data of an applied record, whose value is
+	// the JSON rendering of the list of AppliedObjectRef.
+	AppliedDataKey = "applied"
+
+	// ErrorsDataKey is the key, in the data of an applied record, whose value is
+	// the JSON rendering of the list of errors from applying the bundle.
+	// This key is absent when there were no errors.
+	ErrorsDataKey = "errors"
+
+	// OriginWdsLabelKey is the key of the label, on a bundle, that identifies the WDS
+	// that the bundle came from. The pull agent copies this label onto the
+	// ReportedState objects so that the status controller of that WDS consumes them.
+	OriginWdsLabelKey = "transport.kubestellar.io/originWdsName"
+
+	// AppliedHashAnnotationKey is the key of an annotation, on each workload object that the pull agent
+	// creates or updates (other than by server-side apply), whose value fingerprints the desired object
+	// that was written. The agent rewrites the object only when that fingerprint changes, rather than
+	// comparing with the live object, which the server may have added defaults to.
+	AppliedHashAnnotationKey = "transport.kubestellar.io/applied-hash"
+)
+
+// AppliedObjectRef identifies a workload object in the WEC.
+type AppliedObjectRef struct {
+	Group     string `json:"group"`
+	Version   string `json:"version"`
+	Resource  string `json:"resource"`
+	Kind      string `json:"kind"`
+	Namespace string `json:"namespace,omitempty"`
+	Name      string `json:"name"`
+	// ReadOnly means that the object is only observed, never written, by the pull agent.
+	ReadOnly bool `json:"readOnly,omitempty"`
+	// Orphan means that the object is to be left in the WEC when it is no longer in the bundle.
+	Orphan bool `json:"orphan,omitempty"`
+	// Unadopted means that the object already existed in the WEC and has not been taken over,
+	// because the bundle asks to report on it instead; it is only observed, like a read-only one.
+	Unadopted bool `json:"unadopted,omitempty"`
+	// Differences lists the fields of an unadopted or drifted object that differ from the desired ones.
+	Differences []string `json:"differences,omitempty"`
+	// DesiredHash fingerprints the desired object that was applied, when the bundle asks for drift detection.
+	DesiredHash string `json:"desiredHash,omitempty"`
+	// Drifted means that the object was found to differ from the desired object although
+	// the desired object had not changed since it was last applied.
+	Drifted bool `json:"drifted,omitempty"`
+	// Remediated means that the desired object was written over the drift.
+	Remediated bool `json:"remediated,omitempty"`
+	// ReportedState is the name of the ReportedState object for this workload object.
+	ReportedState string `json:"reportedState,omitempty"`
+}
+
+func (ref AppliedObjectRef) GVR() schema.GroupVersionResource {
+	return schema.GroupVersionResource{Group: ref.Group, Version: ref.Version, Resource: ref.Resource}
+}
+
+func (ref AppliedObjectRef) SourceRef() v1alpha1.SourceObjectReference {
+	return v1alpha1.SourceObjectReference{
+		Group:     ref.Group,
+		Version:   ref.Version,
+		Resource:  ref.Resource,
+		Kind:      ref.Kind,
+		Namespace: ref.Namespace,
+		Name:      ref.Name,
+	}
+}
+
+// Agent is the pull agent for one WEC.
+// It watches the bundles in the WEC's mailbox namespace in the ITS,
+// applies their contents to the WEC, deletes what is no longer in any bundle,
+// and reports the status of each applied object in a ReportedState object in the mailbox namespace.
+type Agent struct {
+	logger                 logr.Logger
+	wecName                string
+	itsConfigMapClient     corev1client.ConfigMapInterface
+	itsReportedStateClient controlclient.ReportedStateInterface
+	mailboxLister          corev1listers.ConfigMapNamespaceLister
+	mailboxInformerSynced  cache.InformerSynced
+	wecDynamicClient       dynamic.Interface
+	wecMapper              meta.RESTMapper
+
+	// workqueue holds the names of bundles to sync.
+	workqueue workqueue.RateLimitingInterface
+}
+
+// NewAgent makes a new pull agent.
+// `mailboxInformer` must be limited to the WEC's mailbox namespace,
+// which has the same name as the WEC's inventory object.
+// A non-zero resync period of that informer makes the agent periodically
+// re-apply bundles and refresh the reported status.
+func NewAgent(ctx context.Context, wecName string,
+	itsConfigMapClient corev1client.ConfigMapInterface, itsReportedStateClient controlclient.ReportedStateInterface,
+	mailboxInformer corev1informers.ConfigMapInformer,
+	wecDynamicClient dynamic.Interface, wecMapper meta.RESTMapper) *Agent {
+	agent := &Agent{
+		logger:                 klog.FromContext(ctx),
+		wecName:                wecName,
+		itsConfigMapClient:     itsConfigMapClient,
+		itsReportedStateClient: itsReportedStateClient,
+		mailboxLister:          mailboxInformer.Lister().ConfigMaps(wecName),
+		mailboxInformerSynced:  mailboxInformer.Informer().HasSynced,
+		wecDynamicClient:       wecDynamicClient,
+		wecMapper:              wecMapper,
+		workqueue:              workqueue.NewNamedRateLimitingQueue(workqueue.DefaultControllerRateLimiter(), AgentName),
+	}
+	mailboxInformer.Informer().AddEventHandler(cache.ResourceEventHandlerFuncs{
+		AddFunc:    agent.handleConfigMap,
+		UpdateFunc: func(_, new any) { agent.handleConfigMap(new) },
+		DeleteFunc: agent.handleConfigMap,
+	})
+	return agent
+}
+
+func (agent *Agent) handleConfigMap(obj any) {
+	if dfsu, is := obj.(cache.DeletedFinalStateUnknown); is {
+		obj = dfsu.Obj
+	}
+	cm, ok := obj.(*corev1.ConfigMap)
+	if !ok {
+		return
+	}
+	switch {
+	case cm.Labels[BundleLabelKey] == "true":
+		agent.workqueue.Add(cm.Name)
+	case cm.Labels[AppliedRecordLabelKey] == "true":
+		// This catches bundles that were deleted while the agent was not running
+		agent.workqueue.Add(strings.TrimSuffix(cm.Name, AppliedRecordNameSuffix))
+	case cm.Labels[wecreport.ReportLabelKey] == "true":
+		agent.workqueue.Add(strings.TrimSuffix(cm.Name, wecreport.ReportNameSuffix))
+	}
+}
+
+func (agent *Agent) Run(ctx context.Context, workersCount int) error {
+	defer utilruntime.HandleCrash()
+	defer agent.workqueue.ShutDown()
+
+	agent.logger.Info("Starting pull agent", "wec", agent.wecName)
+	if ok := cache.WaitForCacheSync(ctx.Done(), agent.mailboxInformerSynced); !ok {
+		return fmt.Errorf("failed to wait for caches to sync")
+	}
+	for i := 1; i <= workersCount; i++ {
+		workerId := i
+		go wait.UntilWithContext(ctx, func(ctx context.Context) { agent.runWorker(ctx, workerId) }, time.Second)
+	}
+	agent.logger.Info("Started workers", "count", workersCount)
+	<-ctx.Done()
+	agent.logger.Info("Shutting down workers")
+	return nil
+}
+
+func (agent *Agent) runWorker(ctx context.Context, workerId int) {
+	logger := klog.FromContext(ctx).WithValues("workerID", workerId)
+	ctx = klog.NewContext(ctx, logger)
+	for agent.processNextWorkItem(ctx) {
+	}
+}
+
+func (agent *Agent) processNextWorkItem(ctx context.Context) bool {
+	logger := klog.FromContext(ctx)
+	obj, shutdown := agent.workqueue.Get()
+	if shutdown {
+		return false
+	}
+	defer agent.workqueue.Done(obj)
+	bundleName := obj.(string)
+	if err := agent.syncBundle(ctx, bundleName); err != nil {
+		agent.workqueue.AddRateLimited(obj)
+		logger.V(2).Info("Failed to sync bundle, will retry", "bundle", bundleName, "err", err)
+	} else {
+		agent.workqueue.Forget(obj)
+	}
+	return true
+}
+
+// syncBundle makes the WEC and the applied record agree with the named bundle,
+// which may not exist.
+func (agent *Agent) syncBundle(ctx context.Context, bundleName string) error {
+	logger := klog.FromContext(ctx).WithValues("bundle", bundleName)
+	record, err := agent.mailboxLister.Get(bundleName + AppliedRecordNameSuffix)
+	if err != nil && !errors.IsNotFound(err) {
+		return err
+	}
+	var previous []AppliedObjectRef
+	if record != nil {
+		if err := json.Unmarshal([]byte(record.Data[AppliedDataKey]), &previous); err != nil {
+			logger.Error(err, "Ignoring malformed applied record")
+		}
+	}
+	bundle, err := agent.mailboxLister.Get(bundleName)
+	if errors.IsNotFound(err) {
+		if err := agent.deleteObjects(ctx, bundleName, previous); err != nil {
+			return err
+		}
+		if err := agent.writeReport(ctx, bundleName, nil, nil); err != nil {
+			return err
+		}
+		if record == nil {
+			return nil
+		}
+		logger.V(2).Info("Deleting applied record of deleted bundle")
+		err := agent.itsConfigMapClient.Delete(ctx, record.Name, metav1.DeleteOptions{})
+		if errors.IsNotFound(err) {
+			return nil
+		}
+		return err
+	} else if err != nil {
+		return err
+	}
+	entries, err := DecodeBundle(bundle.Data)
+	if err != nil {
+		// Retrying will not help; keep what was applied and report the problem.
+		return agent.writeAppliedRecord(ctx, bundleName, record, previous, []string{err.Error()})
+	}
+	var applied []AppliedObjectRef
+	var applyErrs []string
+	for _, entry := range entries {
+		ref, err := agent.applyObject(ctx, entry, bundle.Labels[OriginWdsLabelKey], previous)
+		if ref != nil {
+			applied = append(applied, *ref)
+		}
+		if err != nil {
+			applyErrs = append(applyErrs, err.Error())
+		}
+	}
+	var toDelete []AppliedObjectRef
+	for _, ref := range previous {
+		if !containsRef(applied, ref) {
+			toDelete = append(toDelete, ref)
+		}
+	}
+	deleteErr := agent.deleteObjects(ctx, bundleName, toDelete)
+	if err := agent.writeAppliedRecord(ctx, bundleName, record, applied, applyErrs); err != nil {
+		return err
+	}
+	if err := agent.writeReport(ctx, bundleName, bundle, applied); err != nil {
+		return err
+	}
+	if deleteErr != nil {
+		return deleteErr
+	}
+	if len(applyErrs) > 0 {
+		return fmt.Errorf("failed to apply %d of %d objects", len(applyErrs), len(entries))
+	}
+	return nil
+}
+
+func containsRef(refs []AppliedObjectRef, sought AppliedObjectRef) bool {
+	return findRef(refs, sought) != nil
+}
+
+// findRef returns the member of refs that identifies the same object as sought, or nil if there is none.
+func findRef(refs []AppliedObjectRef, sought AppliedObjectRef) *AppliedObjectRef {
+	for idx, ref := range refs {
+		if ref.Group == sought.Group && ref.Resource == sought.Resource && ref.Namespace == sought.Namespace && ref.Name == sought.Name {
+			return &refs[idx]
+		}
+	}
+	return nil
+}
+
+// applyObject creates or updates one workload object in the WEC (or, for a read-only one,
+// one that is not to be adopted, or one whose drift is only to be reported, only reads it)
+// and then reports its status.
+// `previous` is what was applied for the bundle last time.
+// The returned reference is nil only if the object's kind is not known in the WEC.
+func (agent *Agent) applyObject(ctx context.Context, entry BundleEntry, originWds string, previous []AppliedObjectRef) (*AppliedObjectRef, error) {
+	logger := klog.FromContext(ctx)
+	obj := entry.Object
+	gvk := obj.GroupVersionKind()
+	objName := obj.GetName()
+	namespace := obj.GetNamespace()
+	mapping, err := agent.wecMapper.RESTMapping(gvk.GroupKind(), gvk.Version)
+	if err != nil && meta.IsNoMatchError(err) {
+		if resettable, ok := agent.wecMapper.(meta.ResettableRESTMapper); ok {
+			resettable.Reset()
+			mapping, err = agent.wecMapper.RESTMapping(gvk.GroupKind(), gvk.Version)
+		}
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to map %v to a resource in the WEC: %w", gvk, err)
+	}
+	readOnly := entry.UpdateStrategy == v1alpha1.UpdateStrategyReadOnly
+	ref := &AppliedObjectRef{Group: gvk.Group, Version: gvk.Version, Resource: mapping.Resource.Resource,
+		Kind: gvk.Kind, Namespace: namespace, Name: objName, ReadOnly: readOnly, Orphan: entry.Orphan}
+	ref.ReportedState = reportedStateName(*ref, originWds)
+	client := util.DynamicForResource(agent.wecDynamicClient, mapping.Resource, namespace)
+	existing, err := client.Get(ctx, objName, metav1.GetOptions{})
+	// The object has drifted if it differs from the desired object although the latter
+	// has not changed since it was last applied.
+	var drifted bool
+	var prevRef *AppliedObjectRef
+	if entry.DriftPolicy != "" && !readOnly {
+		ref.DesiredHash = desiredHash(obj)
+		prevRef = findRef(previous, *ref)
+		if err == nil && prevRef != nil && !prevRef.Unadopted && prevRef.DesiredHash == ref.DesiredHash {
+			ref.Differences = wecreport.Differences(obj, existing)
+			drifted = len(ref.Differences) > 0
+		}
+	}
+	remediate := drifted && entry.DriftPolicy == v1alpha1.DriftPolicyRemediate && entry.UpdateStrategy != v1alpha1.UpdateStrategyCreateOnly
+	var result *unstructured.Unstructured
+	switch {
+	case readOnly && errors.IsNotFound(err):
+		// Nothing to report until the object appears; the periodic resync will look again.
+		logger.V(4).Info("Read-only workload object does not exist", "gvk", gvk, "namespace", namespace, "name", objName)
+		return ref, nil
+	case readOnly && err == nil:
+		result = existing
+	case entry.ReportAdoption && err == nil && !putThereByAgent(existing, findRef(previous, *ref), entry):
+		ref.Unadopted = true
+		ref.Differences = wecreport.Differences(obj, existing)
+		result = existing
+		logger.V(4).Info("Not adopting existing workload object", "gvk", gvk, "namespace", namespace, "name", objName, "differences", ref.Differences)
+	case drifted && !remediate:
+		// Leave the drift in place until the desired object changes.
+		result = existing
+		logger.V(2).Info("Workload object has drifted", "gvk", gvk, "namespace", namespace, "name", objName, "differences", ref.Differences)
+	case entry.UpdateStrategy == v1alpha1.UpdateStrategyServerSideApply && (err == nil || errors.IsNotFound(err)):
+		// Applying is idempotent, and other field managers may own fields that
+		// the desired object leaves out, so there is no comparison to make first.
+		result, err = serverSideApply(ctx, client, obj, entry.ServerSideApply)
+		if err != nil {
+			return ref, fmt.Errorf("failed to apply %s %s/%s: %w", gvk.Kind, namespace, objName, err)
+		}
+		logger.V(4).Info("Applied workload object", "gvk", gvk, "namespace", namespace, "name", objName)
+	case errors.IsNotFound(err):
+		desired := withAppliedHash(obj)
+		desired.SetResourceVersion("")
+		result, err = client.Create(ctx, desired, metav1.CreateOptions{FieldManager: AgentName})
+		if err != nil {
+			return ref, fmt.Errorf("failed to create %s %s/%s: %w", gvk.Kind, namespace, objName, err)
+		}
+		logger.V(2).Info("Created workload object", "gvk", gvk, "namespace", namespace, "name", objName)
+	case err != nil:
+		return ref, fmt.Errorf("failed to get %s %s/%s: %w", gvk.Kind, namespace, objName, err)
+	case entry.UpdateStrategy == v1alpha1.UpdateStrategyCreateOnly || !remediate && !needsUpdate(existing, obj):
+		result = existing
+	default:
+		desired := withAppliedHash(obj)
+		desired.SetResourceVersion(existing.GetResourceVersion())
+		if status, has := existing.Object["status"]; has {
+			desired.Object["status"] = status
+		} else {
+			delete(desired.Object, "status")
+		}
+		result, err = client.Update(ctx, desired, metav1.UpdateOptions{FieldManager: AgentName})
+		if err != nil {
+			return ref, fmt.Errorf("failed to update %s %s/%s: %w", gvk.Kind, namespace, objName, err)
+		}
+		logger.V(4).Info("Updated workload object", "gvk", gvk, "namespace", namespace, "name", objName)
+	}
+	switch {
+	case remediate:
+		ref.Drifted, ref.Remediated = true, true
+		logger.V(2).Info("Remediated drift of workload object", "gvk", gvk, "namespace", namespace, "name", objName, "differences", ref.Differences)
+	case drifted:
+		ref.Drifted = true
+	case prevRef != nil && prevRef.Remediated && prevRef.DesiredHash == ref.DesiredHash:
+		// Keep reporting the remediated drift until the desired object changes or the object drifts again.
+		ref.Drifted, ref.Remediated, ref.Differences = true, true, prevRef.Differences
+	}
+	return ref, agent.reportStatus(ctx, *ref, result, originWds)
+}
+
+// serverSideApply applies the desired object in the WEC, as AgentName unless the options say otherwise.
+func serverSideApply(ctx context.Context, client dynamic.ResourceInterface, obj *unstructured.Unstructured, options *v1alpha1.ServerSideApplyOptions) (*unstructured.Unstructured, error) {
+	applyOptions := metav1.ApplyOptions{FieldManager: AgentName}
+	if options != nil {
+		applyOptions.Force = options.Force
+		if options.FieldManager != "" {
+			applyOptions.FieldManager = options.FieldManager
+		}
+	}
+	desired := obj.DeepCopy()
+	desired.SetResourceVersion("")
+	delete(desired.Object, "status")
+	return client.Apply(ctx, desired.GetName(), desired, applyOptions)
+}
+
+// needsUpdate tells whether the desired object has changed since it was last written
+// over the existing object in the WEC, according to the existing object's AppliedHashAnnotationKey annotation.
+// Changes made in the WEC since then are drift, which is the business of the drift policy.
+func needsUpdate(existing, desired *unstructured.Unstructured) bool {
+	return existing.GetAnnotations()[AppliedHashAnnotationKey] != desiredHash(desired)
+}
+
+// withAppliedHash returns a copy of the given desired object that is annotated with its fingerprint.
+func withAppliedHash(obj *unstructured.Unstructured) *unstructured.Unstructured {
+	desired := obj.DeepCopy()
+	annotations := desired.GetAnnotations()
+	if annotations == nil {
+		annotations = map[string]string{}
+	}
+	annotations[AppliedHashAnnotationKey] = desiredHash(obj)
+	desired.SetAnnotations(annotations)
+	return desired
+}
+
+// reportStatus writes the status of the given workload object into its ReportedState.
+func (agent *Agent) reportStatus(ctx context.Context, ref AppliedObjectRef, obj *unstructured.Unstructured, originWds string) error {
+	name := ref.ReportedState
+	status, _, _ := unstructured.NestedMap(obj.Object, "status")
+	existing, err := agent.itsReportedStateClient.Get(ctx, name, metav1.GetOptions{})
+	if errors.IsNotFound(err) {
+		reportedState := &v1alpha1.ReportedState{
+			ObjectMeta: metav1.ObjectMeta{Name: name, Namespace: agent.wecName},
+			Spec:       v1alpha1.ReportedStateSpec{SourceRef: ref.SourceRef()},
+		}
+		if originWds != "" {
+			reportedState.Labels = map[string]string{OriginWdsLabelKey: originWds}
+		}
+		existing, err = agent.itsReportedStateClient.Create(ctx, reportedState, metav1.CreateOptions{FieldManager: AgentName})
+	}
+	if err != nil {
+		return fmt.Errorf("failed to get or create ReportedState %s: %w", name, err)
+	}
+	if status == nil {
+		return nil
+	}
+	if existing.Status != nil {
+		var oldStatus map[string]any
+		if err := json.Unmarshal(existing.Status.Raw, &oldStatus); err == nil && apiequality.Semantic.DeepEqual(oldStatus, status) {
+			return nil
+		}
+	}
+	statusJSON, err := json.Marshal(status)
+	if err != nil {
+		return fmt.Errorf("failed to render status of %s %s/%s as JSON: %w", ref.Kind, ref.Namespace, ref.Name, err)
+	}
+	existing.Status = &apiextensionsv1.JSON{Raw: statusJSON}
+	if _, err := agent.itsReportedStateClient.UpdateStatus(ctx, existing, metav1.UpdateOptions{FieldManager: AgentName}); err != nil {
+		return fmt.Errorf("failed to update status of ReportedState %s: %w", name, err)
+	}
+	return nil
+}
+
+// reportedStateName returns the name of the ReportedState for the given workload object from the given WDS.
+// The name starts with a readable rendering of the object's kind, namespace and name and ends with a hash
+// of the WDS name and the object's group, kind, namespace and name, so that distinct objects
+// (and the same object from distinct WDSes) get distinct ReportedStates.
+func reportedStateName(ref AppliedObjectRef, originWds string) string {
+	parts := []string{strings.ToLower(ref.Kind)}
+	if ref.Namespace != "" {
+		parts = append(parts, ref.Namespace)
+	}
+	readable := strings.Join(append(parts, ref.Name), "-")
+	sum := sha256.Sum256([]byte(strings.Join([]string{originWds, ref.Group, ref.Kind, ref.Namespace, ref.Name}, "/")))
+	hash := hex.EncodeToString(sum[:])[:16]
+	if maxReadable := validation.DNS1123SubdomainMaxLength - len(hash) - 1; len(readable) > maxReadable {
+		readable = strings.TrimRight(readable[:maxReadable], ".-")
+	}
+	return readable + "-" + hash
+}
+
+// deleteObjects deletes the given workload objects from the WEC, and their ReportedState objects from the ITS,
+// except for objects that are in a bundle other than the named one (objects can move between bundles).
+func (agent *Agent) deleteObjects(ctx context.Context, bundleName string, refs []AppliedObjectRef) error {
+	logger := klog.FromContext(ctx)
+	if len(refs) == 0 {
+		return nil
+	}
+	elsewhere, err := agent.objectsInOtherBundles(bundleName)
+	if err != nil {
+		return err
+	}
+	for _, ref := range refs {
+		if elsewhere.Has(util.GKObjRef{GK: schema.GroupKind{Group: ref.Group, Kind: ref.Kind}, OR: klog.KRef(ref.Namespace, ref.Name)}) {
+			logger.V(4).Info("Not deleting workload object that is in another bundle", "ref", ref)
+			continue
+		}
+		if ref.ReadOnly || ref.Unadopted {
+			logger.V(2).Info("No longer observing workload object", "ref", ref)
+		} else if ref.Orphan {
+			logger.V(2).Info("Orphaning workload object", "ref", ref)
+		} else {
+			err := util.DynamicForResource(agent.wecDynamicClient, ref.GVR(), ref.Namespace).Delete(ctx, ref.Name, metav1.DeleteOptions{})
+			if err != nil && !errors.IsNotFound(err) {
+				return fmt.Errorf("failed to delete %s %s/%s: %w", ref.Kind, ref.Namespace, ref.Name, err)
+			}
+			logger.V(2).Info("Deleted workload object", "ref", ref)
+		}
+		if ref.ReportedState == "" {
+			continue
+		}
+		err := agent.itsReportedStateClient.Delete(ctx, ref.ReportedState, metav1.DeleteOptions{})
+		if err != nil && !errors.IsNotFound(err) {
+			return fmt.Errorf("failed to delete ReportedState %s: %w", ref.ReportedState, err)
+		}
+	}
+	return nil
+}
+
+// objectsInOtherBundles returns the identities of the workload objects in the bundles other than the named one.
+func (agent *Agent) objectsInOtherBundles(bundleName string) (sets.Set[util.GKObjRef], error) {
+	bundles, err := agent.mailboxLister.List(labels.SelectorFromSet(labels.Set{BundleLabelKey: "true"}))
+	if err != nil {
+		return nil, err
+	}
+	ans := sets.New[util.GKObjRef]()
+	for _, bundle := range bundles {
+		if bundle.Name == bundleName {
+			continue
+		}
+		entries, err := DecodeBundle(bundle.Data)
+		if err != nil {
+			continue
+		}
+		for _, entry := range entries {
+			ans.Insert(util.GKObjRef{GK: entry.Object.GroupVersionKind().GroupKind(), OR: klog.KObj(entry.Object)})
+		}
+	}
+	return ans, nil
+}
+
+// writeAppliedRecord creates or updates the applied record of the named bundle, if necessary.
+// `record` is the current record, or nil if there is none.
+func (agent *Agent) writeAppliedRecord(ctx context.Context, bundleName string, record *corev1.ConfigMap, applied []AppliedObjectRef, applyErrs []string) error {
+	appliedJSON, err := json.Marshal(applied)
+	if err != nil {
+		return err
+	}
+	data := map[string]string{AppliedDataKey: string(appliedJSON)}
+	if len(applyErrs) > 0 {
+		errsJSON, err := json.Marshal(applyErrs)
+		if err != nil {
+			return err
+		}
+		data[ErrorsDataKey] = string(errsJSON)
+	}
+	if record == nil {
+		record = &corev1.ConfigMap{
+			ObjectMeta: metav1.ObjectMeta{
+				Namespace: agent.wecName,
+				Name:      bundleName + AppliedRecordNameSuffix,
+				Labels:    map[string]string{AppliedRecordLabelKey: "true"},
+			},
+			Data: data,
+		}
+		_, err = agent.itsConfigMapClient.Create(ctx, record, metav1.CreateOptions{FieldManager: AgentName})
+		return err
+	}
+	if apiequality.Semantic.DeepEqual(record.Data, data) {
+		return nil
+	}
+	record = record.DeepCopy()
+	record.Data = data
+	_, err = agent.itsConfigMapClient.Update(ctx, record, metav1.UpdateOptions{FieldManager: AgentName})
+	return err
+}
@@ -0,0 +1,322 @@
+/*
+Copyright 2024 The KubeStellar Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package configmap
+
+import (
+	"context"
+	"encoding/json"
+	"testing"
+	"time"
+
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/errors"
+	"k8s.io/apimachinery/pkg/api/meta"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+	"k8s.io/apimachinery/pkg/types"
+	"k8s.io/apimachinery/pkg/util/wait"
+	dynamicfake "k8s.io/client-go/dynamic/fake"
+	k8sinformers "k8s.io/client-go/informers"
+	k8sfake "k8s.io/client-go/kubernetes/fake"
+	clienttesting "k8s.io/client-go/testing"
+	"k8s.io/klog/v2/ktesting"
+
+	"github.com/kubestellar/kubestellar/api/control/v1alpha1"
+	ksfake "github.com/kubestellar/kubestellar/pkg/generated/clientset/versioned/fake"
+	"github.com/kubestellar/kubestellar/pkg/transport"
+	"github.com/kubestellar/kubestellar/pkg/wecreport"
+)
+
+var widgetGVR = schema.GroupVersionResource{Group: "example.com", Version: "v1", Resource: "widgets"}
+
+func newObject(apiVersion, kind, namespace, name string, extra map[string]any) *unstructured.Unstructured {
+	obj := &unstructured.Unstructured{Object: map[string]any{
+		"apiVersion": apiVersion,
+		"kind":       kind,
+		"metadata":   map[string]any{"namespace": namespace, "name": name},
+	}}
+	for key, val := range extra {
+		obj.Object[key] = val
+	}
+	return obj
+}
+
+func TestAgent(t *testing.T) {
+	logger, ctx := ktesting.NewTestContext(t)
+	ctx, cancel := context.WithCancel(ctx)
+	defer cancel()
+	const wecName = "wec1"
+	scheme := runtime.NewScheme()
+	corev1.AddToScheme(scheme)
+	mapper := meta.NewDefaultRESTMapper(nil)
+	mapper.Add(corev1.SchemeGroupVersion.WithKind("ConfigMap"), meta.RESTScopeNamespace)
+	mapper.Add(widgetGVR.GroupVersion().WithKind("Widget"), meta.RESTScopeNamespace)
+	itsK8sClient := k8sfake.NewSimpleClientset()
+	itsKsClient := ksfake.NewSimpleClientset()
+	wecDynamicClient := dynamicfake.NewSimpleDynamicClientWithCustomListKinds(scheme, map[schema.GroupVersionResource]string{widgetGVR: "WidgetList"})
+	// The fake object tracker does not support server-side apply; create-or-replace is close enough here.
+	wecDynamicClient.PrependReactor("patch", "widgets", func(action clienttesting.Action) (bool, runtime.Object, error) {
+		patch := action.(clienttesting.PatchAction)
+		if patch.GetPatchType() != types.ApplyPatchType {
+			return false, nil, nil
+		}
+		obj := &unstructured.Unstructured{}
+		if err := obj.UnmarshalJSON(patch.GetPatch()); err != nil {
+			return true, nil, err
+		}
+		tracker := wecDynamicClient.Tracker()
+		if _, err := tracker.Get(widgetGVR, patch.GetNamespace(), patch.GetName()); errors.IsNotFound(err) {
+			return true, obj, tracker.Create(widgetGVR, obj, patch.GetNamespace())
+		}
+		return true, obj, tracker.Update(widgetGVR, obj, patch.GetNamespace())
+	})
+	// Simulate the server defaulting a field, which the agent must not mistake for a reason to update.
+	defaultColor := func(action clienttesting.Action) (bool, runtime.Object, error) {
+		obj := action.(interface{ GetObject() runtime.Object }).GetObject().(*unstructured.Unstructured)
+		if _, found, _ := unstructured.NestedString(obj.Object, "spec", "color"); !found {
+			unstructured.SetNestedField(obj.Object, "blue", "spec", "color")
+		}
+		return false, nil, nil
+	}
+	wecDynamicClient.PrependReactor("create", "widgets", defaultColor)
+	wecDynamicClient.PrependReactor("update", "widgets", defaultColor)
+	informerFactory := k8sinformers.NewSharedInformerFactoryWithOptions(itsK8sClient, 0, k8sinformers.WithNamespace(wecName))
+	agent := NewAgent(ctx, wecName, itsK8sClient.CoreV1().ConfigMaps(wecName), itsKsClient.ControlV1alpha1().ReportedStates(wecName),
+		informerFactory.Core().V1().ConfigMaps(), wecDynamicClient, mapper)
+	informerFactory.Start(ctx.Done())
+	go agent.Run(ctx, 2)
+
+	cm := newObject("v1", "ConfigMap", "ns1", "cm1", map[string]any{"data": map[string]any{"a": "b"}})
+	widget := newObject("example.com/v1", "Widget", "ns1", "w1", map[string]any{
+		"spec":   map[string]any{"size": int64(3)},
+		"status": map[string]any{"ready": true}})
+	cmt := NewConfigMapTransport()
+	wrapAndWrite := func(create bool, wrapees ...transport.Wrapee) {
+		bundle := cmt.WrapObjects(wrapees, nil).(*corev1.ConfigMap)
+		bundle.Namespace = wecName
+		bundle.Name = "b1"
+		bundle.Labels[OriginWdsLabelKey] = "wds1"
+		bundle.Labels[wecreport.BindingLabelKey] = "b1"
+		gloss, err := cmt.UnwrapObjects(bundle, nil)
+		if err != nil || len(gloss) != len(wrapees) {
+			t.Fatalf("Unwrap returned gloss=%v, err=%v", gloss, err)
+		}
+		if create {
+			_, err = itsK8sClient.CoreV1().ConfigMaps(wecName).Create(ctx, bundle, metav1.CreateOptions{})
+		} else {
+			_, err = itsK8sClient.CoreV1().ConfigMaps(wecName).Update(ctx, bundle, metav1.UpdateOptions{})
+		}
+		if err != nil {
+			t.Fatalf("Failed to write bundle: %v", err)
+		}
+	}
+	exists := func(gvr schema.GroupVersionResource, namespace, name string) bool {
+		_, err := wecDynamicClient.Resource(gvr).Namespace(namespace).Get(ctx, name, metav1.GetOptions{})
+		if err != nil && !errors.IsNotFound(err) {
+			t.Fatalf("Failed to get %v %s/%s: %v", gvr, namespace, name, err)
+		}
+		return err == nil
+	}
+	widgetRS := func(name string) string {
+		return reportedStateName(AppliedObjectRef{Group: widgetGVR.Group, Kind: "Widget", Namespace: "ns1", Name: name}, "wds1")
+	}
+	reportedStateExists := func(name string) bool {
+		_, err := itsKsClient.ControlV1alpha1().ReportedStates(wecName).Get(ctx, name, metav1.GetOptions{})
+		if err != nil && !errors.IsNotFound(err) {
+			t.Fatalf("Failed to get ReportedState %s: %v", name, err)
+		}
+		return err == nil
+	}
+	recordExists := func() bool {
+		_, err := itsK8sClient.CoreV1().ConfigMaps(wecName).Get(ctx, "b1"+AppliedRecordNameSuffix, metav1.GetOptions{})
+		return err == nil
+	}
+	cmGVR := corev1.SchemeGroupVersion.WithResource("configmaps")
+	expect := func(what string, cond func() bool) {
+		if err := wait.PollUntilContextTimeout(ctx, 10*time.Millisecond, 10*time.Second, true, func(context.Context) (bool, error) { return cond(), nil }); err != nil {
+			t.Fatalf("Timed out waiting for %s", what)
+		}
+		logger.Info("Got expected state", "what", what)
+	}
+
+	wrapAndWrite(true, transport.NewWrapee(cm, v1alpha1.UpdateStrategyUpdate, false), transport.NewWrapee(widget, v1alpha1.UpdateStrategyUpdate, false))
+	expect("objects applied", func() bool {
+		return exists(cmGVR, "ns1", "cm1") && exists(widgetGVR, "ns1", "w1") &&
+			reportedStateExists(widgetRS("w1")) && recordExists()
+	})
+	reportedState, err := itsKsClient.ControlV1alpha1().ReportedStates(wecName).Get(ctx, widgetRS("w1"), metav1.GetOptions{})
+	if err != nil {
+		t.Fatalf("Failed to get ReportedState: %v", err)
+	}
+	if reportedState.Status == nil || string(reportedState.Status.Raw) != `{"ready":true}` {
+		t.Errorf("ReportedState does not report the Widget's status: %#v", reportedState.Status)
+	}
+	if reportedState.Spec.SourceRef.Resource != "widgets" {
+		t.Errorf("ReportedState has wrong sourceRef: %#v", reportedState.Spec.SourceRef)
+	}
+	if reportedState.Labels[OriginWdsLabelKey] != "wds1" {
+		t.Errorf("ReportedState has wrong labels: %v", reportedState.Labels)
+	}
+	countWidgetUpdates := func() int {
+		count := 0
+		for _, action := range wecDynamicClient.Actions() {
+			if action.Matches("update", "widgets") {
+				count++
+			}
+		}
+		return count
+	}
+	numUpdates := countWidgetUpdates()
+	if err := agent.syncBundle(ctx, "b1"); err != nil {
+		t.Fatalf("Failed to resync bundle: %v", err)
+	}
+	if count := countWidgetUpdates(); count != numUpdates {
+		t.Errorf("Resync of an unchanged bundle updated the Widget %d times", count-numUpdates)
+	}
+	if err := cmt.(transport.WrapeeChecker).CheckWrapee(transport.NewWrapee(newObject("v1", "Secret", "ns1", "s1", nil), v1alpha1.UpdateStrategyUpdate, false)); err == nil {
+		t.Error("ConfigMap transport accepted a Secret")
+	}
+	if err := cmt.(transport.WrapeeChecker).CheckWrapee(transport.NewWrapee(cm, v1alpha1.UpdateStrategyUpdate, false)); err != nil {
+		t.Errorf("ConfigMap transport rejected a ConfigMap: %v", err)
+	}
+
+	observed := newObject("example.com/v1", "Widget", "ns1", "w2", map[string]any{
+		"spec":   map[string]any{"size": int64(1)},
+		"status": map[string]any{"ready": false}})
+	if _, err := wecDynamicClient.Resource(widgetGVR).Namespace("ns1").Create(ctx, observed, metav1.CreateOptions{}); err != nil {
+		t.Fatalf("Failed to create unmanaged Widget: %v", err)
+	}
+	desiredObserved := observed.DeepCopy()
+	desiredObserved.Object["spec"] = map[string]any{"size": int64(9)}
+	applied := transport.NewWrapee(newObject("example.com/v1", "Widget", "ns1", "w3", map[string]any{
+		"spec": map[string]any{"size": int64(4)}}), v1alpha1.UpdateStrategyServerSideApply, false)
+	applied.ServerSideApply = v1alpha1.ServerSideApplyOptions{FieldManager: "tester", Force: true}
+	wrapAndWrite(false, transport.NewWrapee(cm, v1alpha1.UpdateStrategyUpdate, false), transport.NewWrapee(widget, v1alpha1.UpdateStrategyUpdate, false),
+		transport.NewWrapee(desiredObserved, v1alpha1.UpdateStrategyReadOnly, false), applied)
+	expect("read-only object observed", func() bool { return reportedStateExists(widgetRS("w2")) })
+	expect("server-side applied object", func() bool { return exists(widgetGVR, "ns1", "w3") && reportedStateExists(widgetRS("w3")) })
+	if got, err := wecDynamicClient.Resource(widgetGVR).Namespace("ns1").Get(ctx, "w2", metav1.GetOptions{}); err != nil {
+		t.Fatalf("Failed to get unmanaged Widget: %v", err)
+	} else if size, _, _ := unstructured.NestedInt64(got.Object, "spec", "size"); size != 1 {
+		t.Errorf("Read-only Widget was written, spec.size=%d", size)
+	}
+
+	preexisting := newObject("example.com/v1", "Widget", "ns1", "w4", map[string]any{"spec": map[string]any{"size": int64(1)}})
+	if _, err := wecDynamicClient.Resource(widgetGVR).Namespace("ns1").Create(ctx, preexisting, metav1.CreateOptions{}); err != nil {
+		t.Fatalf("Failed to create pre-existing Widget: %v", err)
+	}
+	desiredPreexisting := preexisting.DeepCopy()
+	desiredPreexisting.Object["spec"] = map[string]any{"size": int64(5)}
+	guarded := transport.NewWrapee(desiredPreexisting, v1alpha1.UpdateStrategyUpdate, false)
+	guarded.ReportAdoption = true
+	wrapAndWrite(false, transport.NewWrapee(cm, v1alpha1.UpdateStrategyUpdate, false), transport.NewWrapee(widget, v1alpha1.UpdateStrategyUpdate, false), guarded)
+	getReport := func() *wecreport.Report {
+		reportCM, err := itsK8sClient.CoreV1().ConfigMaps(wecName).Get(ctx, "b1"+wecreport.ReportNameSuffix, metav1.GetOptions{})
+		if errors.IsNotFound(err) {
+			return nil
+		} else if err != nil {
+			t.Fatalf("Failed to get report: %v", err)
+		}
+		if reportCM.Labels[wecreport.BindingLabelKey] != "b1" || reportCM.Labels[wecreport.OriginWdsLabelKey] != "wds1" {
+			t.Errorf("Report has wrong labels: %v", reportCM.Labels)
+		}
+		report, err := wecreport.DecodeReport(reportCM.Data)
+		if err != nil {
+			t.Fatalf("Failed to decode report: %v", err)
+		}
+		return report
+	}
+	expect("pre-existing object reported", func() bool { return getReport() != nil })
+	if report := getReport(); len(report.Adoption) != 1 || report.Adoption[0].SourceRef.Name != "w4" ||
+		len(report.Adoption[0].Differences) != 1 || report.Adoption[0].Differences[0] != "spec.size" {
+		t.Errorf("Wrong adoption report: %#v", report)
+	}
+	getSize := func(name string) int64 {
+		got, err := wecDynamicClient.Resource(widgetGVR).Namespace("ns1").Get(ctx, name, metav1.GetOptions{})
+		if err != nil {
+			t.Fatalf("Failed to get Widget %s: %v", name, err)
+		}
+		size, _, _ := unstructured.NestedInt64(got.Object, "spec", "size")
+		return size
+	}
+	if size := getSize("w4"); size != 1 {
+		t.Errorf("Unadopted Widget was written, spec.size=%d", size)
+	}
+	wrapAndWrite(false, transport.NewWrapee(cm, v1alpha1.UpdateStrategyUpdate, false), transport.NewWrapee(widget, v1alpha1.UpdateStrategyUpdate, false),
+		transport.NewWrapee(desiredPreexisting, v1alpha1.UpdateStrategyUpdate, false))
+	expect("adopted object updated and report deleted", func() bool { return getSize("w4") == 5 && getReport() == nil })
+
+	watched := transport.NewWrapee(desiredPreexisting, v1alpha1.UpdateStrategyUpdate, false)
+	watched.DriftPolicy = v1alpha1.DriftPolicyReport
+	writeWatched := func() {
+		wrapAndWrite(false, transport.NewWrapee(cm, v1alpha1.UpdateStrategyUpdate, false), transport.NewWrapee(widget, v1alpha1.UpdateStrategyUpdate, false), watched)
+	}
+	writeWatched()
+	expect("desired object fingerprinted", func() bool {
+		record, err := itsK8sClient.CoreV1().ConfigMaps(wecName).Get(ctx, "b1"+AppliedRecordNameSuffix, metav1.GetOptions{})
+		if err != nil {
+			return false
+		}
+		var refs []AppliedObjectRef
+		if err := json.Unmarshal([]byte(record.Data[AppliedDataKey]), &refs); err != nil {
+			t.Fatalf("Failed to parse applied record: %v", err)
+		}
+		ref := findRef(refs, AppliedObjectRef{Group: widgetGVR.Group, Resource: widgetGVR.Resource, Namespace: "ns1", Name: "w4"})
+		return ref != nil && ref.DesiredHash != ""
+	})
+	edited, err := wecDynamicClient.Resource(widgetGVR).Namespace("ns1").Get(ctx, "w4", metav1.GetOptions{})
+	if err != nil {
+		t.Fatalf("Failed to get Widget w4: %v", err)
+	}
+	edited.Object["spec"] = map[string]any{"size": int64(7)}
+	if _, err := wecDynamicClient.Resource(widgetGVR).Namespace("ns1").Update(ctx, edited, metav1.UpdateOptions{}); err != nil {
+		t.Fatalf("Failed to edit Widget w4: %v", err)
+	}
+	driftIs := func(remediated bool) bool {
+		report := getReport()
+		return report != nil && len(report.Drift) == 1 && report.Drift[0].SourceRef.Name == "w4" &&
+			len(report.Drift[0].Differences) == 1 && report.Drift[0].Differences[0] == "spec.size" && report.Drift[0].Remediated == remediated
+	}
+	writeWatched()
+	expect("drift reported", func() bool { return driftIs(false) })
+	if size := getSize("w4"); size != 7 {
+		t.Errorf("Drift was remediated although only to be reported, spec.size=%d", size)
+	}
+	watched.DriftPolicy = v1alpha1.DriftPolicyRemediate
+	writeWatched()
+	expect("drift remediated", func() bool { return getSize("w4") == 5 && driftIs(true) })
+	writeWatched()
+	expect("remediated drift still reported", func() bool { return driftIs(true) })
+	desiredPreexisting.Object["spec"] = map[string]any{"size": int64(6)}
+	writeWatched()
+	expect("changed object updated and drift no longer reported", func() bool { return getSize("w4") == 6 && getReport() == nil })
+
+	wrapAndWrite(false, transport.NewWrapee(widget, v1alpha1.UpdateStrategyUpdate, true))
+	expect("removed object deleted", func() bool {
+		return !exists(cmGVR, "ns1", "cm1") && !reportedStateExists(reportedStateName(AppliedObjectRef{Kind: "ConfigMap", Namespace: "ns1", Name: "cm1"}, "wds1")) && exists(widgetGVR, "ns1", "w1") &&
+			exists(widgetGVR, "ns1", "w2") && !reportedStateExists(widgetRS("w2")) && !exists(widgetGVR, "ns1", "w4")
+	})
+
+	if err := itsK8sClient.CoreV1().ConfigMaps(wecName).Delete(ctx, "b1", metav1.DeleteOptions{}); err != nil {
+		t.Fatalf("Failed to delete bundle: %v", err)
+	}
+	expect("everything deleted except the orphan", func() bool {
+		return exists(widgetGVR, "ns1", "w1") && !reportedStateExists(widgetRS("w1")) && !recordExists()
+	})
+}
@@ -0,0 +1,146 @@
+/*
+Copyright 2024 The KubeStellar Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+// Package configmap is a transport that does not need OCM.
+// A bundle of workload objects is a ConfigMap in the WEC's mailbox namespace in the ITS,
+// and a pull agent running in (or with access to) the WEC applies the bundle there.
+package configmap
+
+import (
+	"encoding/json"
+	"fmt"
+
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+	utilruntime "k8s.io/apimachinery/pkg/util/runtime"
+	"k8s.io/klog/v2"
+
+	"github.com/kubestellar/kubestellar/api/control/v1alpha1"
+	"github.com/kubestellar/kubestellar/pkg/transport"
+	"github.com/kubestellar/kubestellar/pkg/util"
+)
+
+const (
+	// BundleLabelKey is the key of a label, with value "true", on every bundle ConfigMap.
+	BundleLabelKey = "transport.kubestellar.io/bundle"
+
+	// ManifestsDataKey is the key, in the data of a bundle ConfigMap, whose value is
+	// the JSON rendering of the list of BundleEntry.
+	ManifestsDataKey = "manifests"
+)
+
+// BundleEntry is one workload object in a bundle.
+// An empty UpdateStrategy means Update, and an empty DriftPolicy means Ignore.
+type BundleEntry struct {
+	Object          *unstructured.Unstructured       `json:"object"`
+	UpdateStrategy  v1alpha1.UpdateStrategy          `json:"updateStrategy,omitempty"`
+	ServerSideApply *v1alpha1.ServerSideApplyOptions `json:"serverSideApply,omitempty"`
+	Orphan          bool                             `json:"orphan,omitempty"`
+	ReportAdoption  bool                             `json:"reportAdoption,omitempty"`
+	DriftPolicy     v1alpha1.DriftPolicy             `json:"driftPolicy,omitempty"`
+}
+
+func NewConfigMapTransport() transport.Transport {
+	return &configMapTransport{}
+}
+
+type configMapTransport struct {
+}
+
+var _ transport.WrapeeChecker = &configMapTransport{}
+
+// CheckWrapee rejects Secrets, because a bundle would hold them in plaintext in a ConfigMap in the ITS.
+func (cmt *configMapTransport) CheckWrapee(wrapee transport.Wrapee) error {
+	if gvk := wrapee.Object.GroupVersionKind(); gvk.Group == "" && gvk.Kind == "Secret" {
+		return fmt.Errorf("the ConfigMap transport does not carry Secrets, because it would hold them in plaintext in ConfigMaps in the ITS; Secret %s/%s can not be propagated",
+			wrapee.Object.GetNamespace(), wrapee.Object.GetName())
+	}
+	return nil
+}
+
+func (cmt *configMapTransport) WrapObjects(wrapees []transport.Wrapee, kindToResource func(schema.GroupKind) string) runtime.Object {
+	entries := make([]BundleEntry, 0, len(wrapees))
+	for _, wrapee := range wrapees {
+		entry := BundleEntry{Object: wrapee.Object, Orphan: wrapee.Orphan, ReportAdoption: wrapee.ReportAdoption,
+			DriftPolicy: wrapee.DriftPolicy}
+		if wrapee.UpdateStrategy != v1alpha1.UpdateStrategyUpdate {
+			entry.UpdateStrategy = wrapee.UpdateStrategy
+		}
+		if wrapee.UpdateStrategy == v1alpha1.UpdateStrategyServerSideApply {
+			ssa := wrapee.ServerSideApply
+			entry.ServerSideApply = &ssa
+		}
+		entries = append(entries, entry)
+	}
+	manifests, err := json.Marshal(entries)
+	if err != nil {
+		// Can not happen, the objects came from JSON
+		utilruntime.HandleError(fmt.Errorf("failed to render bundle as JSON: %w", err))
+		manifests = []byte("[]")
+	}
+	return &corev1.ConfigMap{
+		TypeMeta: metav1.TypeMeta{
+			Kind:       "ConfigMap",
+			APIVersion: corev1.SchemeGroupVersion.String(),
+		},
+		ObjectMeta: metav1.ObjectMeta{
+			Labels: map[string]string{BundleLabelKey: "true"},
+		},
+		Data: map[string]string{ManifestsDataKey: string(manifests)},
+	}
+}
+
+func (cmt *configMapTransport) UnwrapObjects(wrapped runtime.Object, kindToResource func(schema.GroupKind) (string, bool)) (transport.Gloss, error) {
+	var data map[string]string
+	switch typed := wrapped.(type) {
+	case *corev1.ConfigMap:
+		data = typed.Data
+	case *unstructured.Unstructured:
+		var err error
+		data, _, err = unstructured.NestedStringMap(typed.Object, "data")
+		if err != nil {
+			return nil, fmt.Errorf("failed to extract data from bundle ConfigMap: %w", err)
+		}
+	default:
+		return nil, fmt.Errorf("wrapped object is a %T, not a ConfigMap", wrapped)
+	}
+	entries, err := DecodeBundle(data)
+	if err != nil {
+		return nil, err
+	}
+	gloss := transport.Gloss{}
+	for _, entry := range entries {
+		gloss.Insert(util.GKObjRef{GK: entry.Object.GroupVersionKind().GroupKind(), OR: klog.KObj(entry.Object)})
+	}
+	return gloss, nil
+}
+
+// DecodeBundle parses the data of a bundle ConfigMap.
+func DecodeBundle(data map[string]string) ([]BundleEntry, error) {
+	var entries []BundleEntry
+	if err := json.Unmarshal([]byte(data[ManifestsDataKey]), &entries); err != nil {
+		return nil, fmt.Errorf("failed to parse %s of bundle: %w", ManifestsDataKey, err)
+	}
+	for idx, entry := range entries {
+		if entry.Object == nil {
+			return nil, fmt.Errorf("%s[%d] has no object", ManifestsDataKey, idx)
+		}
+	}
+	return entries, nil
+}
@@ -0,0 +1,112 @@
+/*
+Copyright 2024 The KubeStellar Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package configmap
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+
+	corev1 "k8s.io/api/core/v1"
+	apiequality "k8s.io/apimachinery/pkg/api/equality"
+	"k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+
+	"github.com/kubestellar/kubestellar/pkg/wecreport"
+)
+
+// putThereByAgent tells whether the given existing object in the WEC was created or
+// taken over by the pull agent, judging by what was applied for the bundle last time
+// (`previous` is nil if the object was not in it) and by the object's field managers.
+func putThereByAgent(existing *unstructured.Unstructured, previous *AppliedObjectRef, entry BundleEntry) bool {
+	if previous != nil && !previous.Unadopted && !previous.ReadOnly {
+		return true
+	}
+	for _, managedField := range existing.GetManagedFields() {
+		if managedField.Manager == AgentName || entry.ServerSideApply != nil && managedField.Manager == entry.ServerSideApply.FieldManager {
+			return true
+		}
+	}
+	return false
+}
+
+// desiredHash fingerprints the given desired object, so that the agent can tell
+// whether a difference in the WEC comes from a change of the desired object or from drift.
+func desiredHash(obj *unstructured.Unstructured) string {
+	// Can not fail, the object came from JSON.
+	data, _ := json.Marshal(obj.Object)
+	sum := sha256.Sum256(data)
+	return hex.EncodeToString(sum[:])
+}
+
+// writeReport makes the report of the named bundle say what is to be reported about
+// the given applied objects, deleting the report when there is nothing to say.
+// `bundle` is nil if the bundle does not exist.
+func (agent *Agent) writeReport(ctx context.Context, bundleName string, bundle *corev1.ConfigMap, applied []AppliedObjectRef) error {
+	report := &wecreport.Report{}
+	for _, ref := range applied {
+		objDiffs := wecreport.ObjectDifferences{SourceRef: ref.SourceRef(), Differences: ref.Differences}
+		if ref.Unadopted {
+			report.Adoption = append(report.Adoption, objDiffs)
+		}
+		if ref.Drifted {
+			report.Drift = append(report.Drift, wecreport.ObjectDrift{ObjectDifferences: objDiffs, Remediated: ref.Remediated})
+		}
+	}
+	name := bundleName + wecreport.ReportNameSuffix
+	existing, err := agent.mailboxLister.Get(name)
+	if err != nil && !errors.IsNotFound(err) {
+		return err
+	}
+	if report.IsEmpty() {
+		if existing == nil {
+			return nil
+		}
+		err := agent.itsConfigMapClient.Delete(ctx, name, metav1.DeleteOptions{})
+		if errors.IsNotFound(err) {
+			return nil
+		}
+		return err
+	}
+	data, err := report.Encode()
+	if err != nil {
+		return err
+	}
+	labels := map[string]string{
+		wecreport.ReportLabelKey:    "true",
+		wecreport.OriginWdsLabelKey: bundle.Labels[OriginWdsLabelKey],
+		wecreport.BindingLabelKey:   bundle.Labels[wecreport.BindingLabelKey],
+	}
+	if existing == nil {
+		desired := &corev1.ConfigMap{
+			ObjectMeta: metav1.ObjectMeta{Namespace: agent.wecName, Name: name, Labels: labels},
+			Data:       data,
+		}
+		_, err = agent.itsConfigMapClient.Create(ctx, desired, metav1.CreateOptions{FieldManager: AgentName})
+		return err
+	}
+	if apiequality.Semantic.DeepEqual(existing.Data, data) && apiequality.Semantic.DeepEqual(existing.Labels, labels) {
+		return nil
+	}
+	desired := existing.DeepCopy()
+	desired.Labels = labels
+	desired.Data = data
+	_, err = agent.itsConfigMapClient.Update(ctx, desired, metav1.UpdateOptions{FieldManager: AgentName})
+	return err
+}
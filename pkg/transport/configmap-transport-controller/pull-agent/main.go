@@ -0,0 +1,129 @@
+/*
+Copyright 2024 The KubeStellar Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+// This is the pull agent for the ConfigMap transport.
+// It runs with access to one WEC and to that WEC's mailbox namespace in the ITS.
+// It also runs the upsync agent for that WEC.
+package main
+
+import (
+	"context"
+	"flag"
+	"os"
+	"time"
+
+	"github.com/spf13/pflag"
+
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/client-go/discovery/cached/memory"
+	"k8s.io/client-go/dynamic"
+	k8sinformers "k8s.io/client-go/informers"
+	"k8s.io/client-go/kubernetes"
+	"k8s.io/client-go/restmapper"
+	"k8s.io/klog/v2"
+
+	ksopts "github.com/kubestellar/kubestellar/options"
+	ksclient "github.com/kubestellar/kubestellar/pkg/generated/clientset/versioned"
+	configmap "github.com/kubestellar/kubestellar/pkg/transport/configmap-transport-controller/pkg"
+	"github.com/kubestellar/kubestellar/pkg/upsync"
+)
+
+func main() {
+	logger := klog.Background().WithName(configmap.AgentName)
+	ctx := klog.NewContext(context.Background(), logger)
+
+	wecName := ""
+	concurrency := 4
+	resyncPeriod := time.Minute
+	itsClientOpts := ksopts.NewClientOptions[*pflag.FlagSet]("its", "accessing the ITS")
+	wecClientOpts := ksopts.NewClientOptions[*pflag.FlagSet]("wec", "accessing the WEC")
+	fs := pflag.NewFlagSet(configmap.AgentName, pflag.ExitOnError)
+	klog.InitFlags(flag.CommandLine)
+	fs.AddGoFlagSet(flag.CommandLine)
+	fs.StringVar(&wecName, "wec-name", wecName, "name of the WEC's inventory object, which is also the name of its mailbox namespace")
+	fs.IntVar(&concurrency, "concurrency", concurrency, "number of concurrent workers to run in parallel")
+	fs.DurationVar(&resyncPeriod, "resync-period", resyncPeriod, "period for re-applying bundles, refreshing reported status, and refreshing upsync records")
+	itsClientOpts.AddFlags(fs)
+	wecClientOpts.AddFlags(fs)
+	fs.Parse(os.Args[1:])
+
+	fs.VisitAll(func(flg *pflag.Flag) {
+		logger.Info("Command line flag", "name", flg.Name, "value", flg.Value) // log all arguments
+	})
+	if wecName == "" {
+		logger.Error(nil, "The --wec-name flag is required")
+		klog.FlushAndExit(klog.ExitFlushTimeout, 1)
+	}
+
+	itsRestConfig, err := itsClientOpts.ToRESTConfig()
+	if err != nil {
+		logger.Error(err, "Unable to build ITS kubeconfig")
+		klog.FlushAndExit(klog.ExitFlushTimeout, 1)
+	}
+	itsRestConfig.UserAgent = configmap.AgentName
+	wecRestConfig, err := wecClientOpts.ToRESTConfig()
+	if err != nil {
+		logger.Error(err, "Unable to build WEC kubeconfig")
+		klog.FlushAndExit(klog.ExitFlushTimeout, 1)
+	}
+	wecRestConfig.UserAgent = configmap.AgentName
+
+	itsClientset, err := kubernetes.NewForConfig(itsRestConfig)
+	if err != nil {
+		logger.Error(err, "Failed to create k8s clientset for the ITS")
+		klog.FlushAndExit(klog.ExitFlushTimeout, 1)
+	}
+	itsKsClientset, err := ksclient.NewForConfig(itsRestConfig)
+	if err != nil {
+		logger.Error(err, "Failed to create KubeStellar clientset for the ITS")
+		klog.FlushAndExit(klog.ExitFlushTimeout, 1)
+	}
+	wecClientset, err := kubernetes.NewForConfig(wecRestConfig)
+	if err != nil {
+		logger.Error(err, "Failed to create k8s clientset for the WEC")
+		klog.FlushAndExit(klog.ExitFlushTimeout, 1)
+	}
+	wecDynamicClient, err := dynamic.NewForConfig(wecRestConfig)
+	if err != nil {
+		logger.Error(err, "Failed to create dynamic k8s clientset for the WEC")
+		klog.FlushAndExit(klog.ExitFlushTimeout, 1)
+	}
+	wecMapper := restmapper.NewDeferredDiscoveryRESTMapper(memory.NewMemCacheClient(wecClientset.Discovery()))
+
+	mailboxInformerFactory := k8sinformers.NewSharedInformerFactoryWithOptions(itsClientset, resyncPeriod, k8sinformers.WithNamespace(wecName))
+	agent := configmap.NewAgent(ctx, wecName, itsClientset.CoreV1().ConfigMaps(wecName), itsKsClientset.ControlV1alpha1().ReportedStates(wecName),
+		mailboxInformerFactory.Core().V1().ConfigMaps(), wecDynamicClient, wecMapper)
+	// Only the upsync records among the Secrets in the mailbox namespace are of interest
+	upsyncSecretInformerFactory := k8sinformers.NewSharedInformerFactoryWithOptions(itsClientset, resyncPeriod, k8sinformers.WithNamespace(wecName),
+		k8sinformers.WithTweakListOptions(func(opts *metav1.ListOptions) { opts.LabelSelector = upsync.RoleLabelKey }))
+	upsyncAgent := upsync.NewAgent(ctx, wecName, itsClientset.CoreV1().ConfigMaps(wecName), itsClientset.CoreV1().Secrets(wecName),
+		mailboxInformerFactory.Core().V1().ConfigMaps(), upsyncSecretInformerFactory.Core().V1().Secrets(), wecDynamicClient, wecMapper)
+	mailboxInformerFactory.Start(ctx.Done())
+	upsyncSecretInformerFactory.Start(ctx.Done())
+
+	go func() {
+		if err := upsyncAgent.Run(ctx, concurrency); err != nil {
+			logger.Error(err, "Failed to run upsync agent")
+			klog.FlushAndExit(klog.ExitFlushTimeout, 1)
+		}
+	}()
+
+	if err := agent.Run(ctx, concurrency); err != nil {
+		logger.Error(err, "Failed to run pull agent")
+		klog.FlushAndExit(klog.ExitFlushTimeout, 1)
+	}
+	logger.Info("Pull agent stopped")
+}
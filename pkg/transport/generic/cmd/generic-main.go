@@ -34,6 +34,7 @@ import (
 	_ "k8s.io/component-base/metrics/prometheus/version"
 	"k8s.io/klog/v2"
 
+	"github.com/kubestellar/kubestellar/api/control/v1alpha1"
 	ksctlr "github.com/kubestellar/kubestellar/pkg/controller"
 	ksclientset "github.com/kubestellar/kubestellar/pkg/generated/clientset/versioned"
 	ksinformers "github.com/kubestellar/kubestellar/pkg/generated/informers/externalversions"
@@ -49,6 +50,7 @@ import (
 // Binding added/updated/deleted events.
 // In order to use the GenericMain function, one has to call it in the following format:
 // GenericMain(YourTransportSpecificImplementation())
+// When the first command line argument is "preview", GenericMain instead runs PreviewMain.
 
 // Example for this can be seen here:
 // https://github.com/kubestellar/ocm-transport-plugin/blob/main/cmd/main.go
@@ -58,6 +60,10 @@ const (
 )
 
 func GenericMain(transportImplementation transport.Transport) {
+	if len(os.Args) > 1 && os.Args[1] == PreviewCommand {
+		PreviewMain(transportImplementation, os.Args[2:])
+		return
+	}
 	logger := klog.Background().WithName(transportgeneric.ControllerName)
 	ctx := klog.NewContext(context.Background(), logger)
 
@@ -74,6 +80,34 @@ func GenericMain(transportImplementation transport.Transport) {
 
 	ksctlr.Start(ctx, options.ProcessOptions)
 
+	transportController, startInformers := setupTransportController(ctx, options, transportImplementation)
+	transportController.RegisterMetrics(legacyregistry.Register)
+
+	// notice that there is no need to run Start method in a separate goroutine.
+	// Start method is non-blocking and runs each of the factory's informers in its own dedicated goroutine.
+	startInformers(ctx.Done())
+
+	if err := transportController.Run(ctx, options.Concurrency); err != nil {
+		logger.Error(err, "failed to run transport controller")
+		klog.FlushAndExit(klog.ExitFlushTimeout, 1)
+	}
+
+	logger.Info("Transport controller stopped")
+}
+
+// transportController is the part of the generic transport controller's behavior that is used here.
+type transportController interface {
+	RegisterMetrics(reg ksmetrics.RegisterFn)
+	Run(ctx context.Context, workersCount int) error
+	DisableStatusWrites()
+	Preview(ctx context.Context, bindingName string, destination v1alpha1.Destination) (*transportgeneric.PreviewResult, error)
+}
+
+// setupTransportController makes the clients and informers that the generic transport controller
+// needs, and the controller itself. The returned func starts the informers.
+// Failures are fatal.
+func setupTransportController(ctx context.Context, options *TransportOptions, transportImplementation transport.Transport) (transportController, func(stopCh <-chan struct{})) {
+	logger := klog.FromContext(ctx)
 	// get the config for WDS
 	wdsRestConfig, err := options.WdsClientOptions.ToRESTConfig()
 	if err != nil {
@@ -131,26 +165,17 @@ func GenericMain(transportImplementation transport.Transport) {
 	itsK8sInformerFactory := k8sinformers.NewSharedInformerFactory(transportClientset, defaultResyncPeriod)
 
 	transportController, err := transportgeneric.NewTransportController(ctx, wdsClientMetrics, itsClientMetrics, inventoryPreInformer,
-		wdsClientset.ControlV1alpha1().Bindings(), wdsControlInformers.Bindings(),
-		wdsControlInformers.CustomTransforms(),
+		wdsClientset.ControlV1alpha1().Bindings(), wdsControlInformers.Bindings(), wdsControlInformers.BindingPolicies(),
+		wdsControlInformers.CustomTransforms(), wdsControlInformers.CombinedStatuses(),
 		transportImplementation, wdsClientset, wdsDynamicClient, transportClientset.CoreV1().Namespaces(), itsK8sInformerFactory.Core().V1().ConfigMaps(),
 		transportClientset, transportDynamicClient, options.MaxSizeWrapped, options.MaxNumWrapped, options.WdsName)
 	if err != nil {
 		logger.Error(err, "failed to construct transport controller")
 		klog.FlushAndExit(klog.ExitFlushTimeout, 1)
 	}
-	transportController.RegisterMetrics(legacyregistry.Register)
-
-	// notice that there is no need to run Start method in a separate goroutine.
-	// Start method is non-blocking and runs each of the factory's informers in its own dedicated goroutine.
-	ocmInformerFactory.Start(ctx.Done())
-	itsK8sInformerFactory.Start(ctx.Done())
-	wdsKsInformerFactory.Start(ctx.Done())
-
-	if err := transportController.Run(ctx, options.Concurrency); err != nil {
-		logger.Error(err, "failed to run transport controller")
-		klog.FlushAndExit(klog.ExitFlushTimeout, 1)
+	return transportController, func(stopCh <-chan struct{}) {
+		ocmInformerFactory.Start(stopCh)
+		itsK8sInformerFactory.Start(stopCh)
+		wdsKsInformerFactory.Start(stopCh)
 	}
-
-	logger.Info("Transport controller stopped")
 }
@@ -0,0 +1,106 @@
+/*
+Copyright 2024 The KubeStellar Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package cmd
+
+import (
+	"context"
+	"flag"
+	"fmt"
+	"io"
+	"os"
+
+	"github.com/spf13/pflag"
+	"sigs.k8s.io/yaml"
+
+	"k8s.io/klog/v2"
+
+	"github.com/kubestellar/kubestellar/api/control/v1alpha1"
+	"github.com/kubestellar/kubestellar/pkg/transport"
+	transportgeneric "github.com/kubestellar/kubestellar/pkg/transport/generic"
+)
+
+// PreviewCommand is the first command line argument that selects PreviewMain in GenericMain.
+const PreviewCommand = "preview"
+
+// PreviewMain prints, as a stream of YAML documents, the workload objects that a given WEC
+// would receive from a given Binding, after all transformation and customization.
+// The user errors that would be reported in the Binding's status are printed to stderr,
+// and cause a non-zero exit code.
+// Nothing is written to the WDS or the ITS.
+func PreviewMain(transportImplementation transport.Transport, args []string) {
+	logger := klog.Background().WithName(transportgeneric.ControllerName + "-" + PreviewCommand)
+	ctx := klog.NewContext(context.Background(), logger)
+
+	options := NewTransportOptions()
+	var bindingName, destinationName string
+	fs := pflag.NewFlagSet(transportgeneric.ControllerName+" "+PreviewCommand, pflag.ExitOnError)
+	klog.InitFlags(flag.CommandLine)
+	fs.AddGoFlagSet(flag.CommandLine)
+	options.WdsClientOptions.AddFlags(fs)
+	options.TransportClientOptions.AddFlags(fs)
+	fs.StringVar(&bindingName, "binding", bindingName, "name of the Binding (equivalently, BindingPolicy) to preview")
+	fs.StringVar(&destinationName, "destination", destinationName, "name of the inventory object of the WEC to preview")
+	fs.Parse(args)
+	if bindingName == "" || destinationName == "" {
+		fmt.Fprintln(os.Stderr, "Both --binding and --destination must be given")
+		fs.Usage()
+		os.Exit(2)
+	}
+
+	transportController, startInformers := setupTransportController(ctx, options, transportImplementation)
+	transportController.DisableStatusWrites()
+	startInformers(ctx.Done())
+
+	result, err := transportController.Preview(ctx, bindingName, v1alpha1.Destination{ClusterId: destinationName})
+	if err != nil {
+		logger.Error(err, "Failed to preview", "binding", bindingName, "destination", destinationName)
+		klog.FlushAndExit(klog.ExitFlushTimeout, 1)
+	}
+	if err := writePreview(os.Stdout, os.Stderr, bindingName, destinationName, result); err != nil {
+		logger.Error(err, "Failed to write preview")
+		klog.FlushAndExit(klog.ExitFlushTimeout, 1)
+	}
+	if len(result.BindingErrors) > 0 {
+		klog.FlushAndExit(klog.ExitFlushTimeout, 1)
+	}
+	klog.Flush()
+}
+
+func writePreview(out, errOut io.Writer, bindingName, destinationName string, result *transportgeneric.PreviewResult) error {
+	if !result.IsDestination {
+		fmt.Fprintf(errOut, "Note: %q is not currently a destination of Binding %q\n", destinationName, bindingName)
+	}
+	if result.Suspended {
+		fmt.Fprintf(errOut, "Note: Binding %q is suspended, so nothing is propagated from it until it is resumed\n", bindingName)
+	}
+	if result.Held {
+		fmt.Fprintf(errOut, "Note: %q is in a later wave of the rollout of Binding %q, so it keeps what it currently has until its wave comes\n", destinationName, bindingName)
+	}
+	for _, bindingError := range result.BindingErrors {
+		fmt.Fprintf(errOut, "Binding error: %s\n", bindingError)
+	}
+	for _, obj := range result.Objects {
+		objYAML, err := yaml.Marshal(obj.Object)
+		if err != nil {
+			return fmt.Errorf("failed to render %s %s/%s as YAML: %w", obj.GetKind(), obj.GetNamespace(), obj.GetName(), err)
+		}
+		if _, err := fmt.Fprintf(out, "---\n%s", objYAML); err != nil {
+			return err
+		}
+	}
+	return nil
+}
@@ -19,14 +19,20 @@ package transport
 import (
 	"context"
 	"fmt"
+	"sort"
 	"sync"
 
+	apiequality "k8s.io/apimachinery/pkg/api/equality"
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/labels"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/util/json"
 	"k8s.io/apimachinery/pkg/util/sets"
 	"k8s.io/klog/v2"
 
 	"github.com/kubestellar/kubestellar/api/control/v1alpha1"
 	"github.com/kubestellar/kubestellar/pkg/abstract"
+	"github.com/kubestellar/kubestellar/pkg/customize"
 	"github.com/kubestellar/kubestellar/pkg/jsonpath"
 	ksmetrics "github.com/kubestellar/kubestellar/pkg/metrics"
 )
@@ -34,8 +40,8 @@ import (
 // customTransformCollection digests CustomTransform objects and caches the results.
 type customTransformCollection interface {
 	// getCustomTransformChanges notes the use of the given GroupResource by the named Binding and
-	// returns the customTransformChanges to use for that GroupResource.
-	getCustomTransformChanges(ctx context.Context, groupResource metav1.GroupResource, bindingName string) customTransformChanges
+	// returns the custom transforms to use for that GroupResource in that Binding.
+	getCustomTransformChanges(ctx context.Context, groupResource metav1.GroupResource, bindingName string) boundCustomTransforms
 
 	// noteCustomTransform reacts to a notification of a create/update/delete of a CustomTransform.
 	noteCustomTransform(ctx context.Context, name string, ct *v1alpha1.CustomTransform)
@@ -47,6 +53,99 @@ type customTransformCollection interface {
 
 type customTransformChanges struct {
 	removes []jsonpath.Query // immutable
+	sets    []setChange      // immutable
+
+	// mergePatches are JSON merge patches, each a JSON object. Immutable.
+	mergePatches []map[string]any
+}
+
+func (changes customTransformChanges) isEmpty() bool {
+	return len(changes.removes) == 0 && len(changes.sets) == 0 && len(changes.mergePatches) == 0
+}
+
+func (changes *customTransformChanges) append(more customTransformChanges) {
+	changes.removes = append(changes.removes, more.removes...)
+	changes.sets = append(changes.sets, more.sets...)
+	changes.mergePatches = append(changes.mergePatches, more.mergePatches...)
+}
+
+// boundCustomTransforms is the custom transforms that apply to a given GroupResource in a given Binding.
+type boundCustomTransforms struct {
+	// common is the changes from the CustomTransforms that have no clusterSelector.
+	common customTransformChanges
+
+	// scoped is the CustomTransforms in the BeforeCustomization phase that have a clusterSelector,
+	// in order of name. Immutable.
+	scoped []*digestedCustomTransform
+
+	// afterCustomization is the CustomTransforms in the AfterCustomization phase,
+	// in order of name. Immutable.
+	afterCustomization []*digestedCustomTransform
+}
+
+// destinationDependent tells whether any of these transforms can vary from one destination to another.
+func (bct boundCustomTransforms) destinationDependent() bool {
+	return len(bct.scoped) > 0 || len(bct.afterCustomization) > 0
+}
+
+// forDestination returns the changes from the scoped CustomTransforms
+// that apply to the destination having the given labels.
+func (bct boundCustomTransforms) forDestination(destLabels labels.Set) customTransformChanges {
+	var ans customTransformChanges
+	for _, dct := range bct.scoped {
+		if dct.clusterSelector.Matches(destLabels) {
+			ans.append(dct.changes)
+		}
+	}
+	return ans
+}
+
+// afterCustomizationForDestination returns the changes from the CustomTransforms in the
+// AfterCustomization phase that apply to the destination having the given labels and properties.
+// Also returned are the errors from template expansion and parsing, for reporting in the Binding's status.
+// The given path is used to identify the templates in error messages.
+func (bct boundCustomTransforms) afterCustomizationForDestination(destLabels labels.Set, path string, props clusterProperties) (customTransformChanges, []string) {
+	var ans customTransformChanges
+	var errs []string
+	for _, dct := range bct.afterCustomization {
+		if dct.clusterSelector != nil && !dct.clusterSelector.Matches(destLabels) {
+			continue
+		}
+		expanded, expandErrs := dct.afterCustomization.expand(path+"/CustomTransform "+dct.name, props)
+		if len(expandErrs) > 0 {
+			errs = append(errs, expandErrs...)
+			continue
+		}
+		changes, parseErrs, _ := expanded.parse()
+		for _, parseErr := range parseErrs {
+			errs = append(errs, fmt.Sprintf("%s/CustomTransform %s: %s", path, dct.name, parseErr))
+		}
+		ans.append(changes)
+	}
+	return ans, errs
+}
+
+// digestedCustomTransform is the parsed content of one CustomTransform. Immutable.
+type digestedCustomTransform struct {
+	name string
+
+	// bindingPolicySelector is nil if the CustomTransform applies to all BindingPolicies.
+	bindingPolicySelector labels.Selector
+
+	// clusterSelector is nil if the CustomTransform applies to all destinations.
+	clusterSelector labels.Selector
+
+	// changes is empty for a CustomTransform in the AfterCustomization phase.
+	changes customTransformChanges
+
+	// afterCustomization is nil for a CustomTransform in the BeforeCustomization phase.
+	afterCustomization *customTransformTemplate
+}
+
+// setChange says to set the parts identified by `query` to `value`.
+type setChange struct {
+	query jsonpath.Query
+	value any // immutable, copy before use
 }
 
 // customTransformCollectionImpl implements customTransformCollection
@@ -59,6 +158,10 @@ type customTransformCollectionImpl struct {
 	// It is used to get the CustomTransform objects relevant to a given GroupResource.
 	getTransformObjects func(indexName, indexedValue string) ([]any, error)
 
+	// getBindingPolicyLabels returns the labels of the BindingPolicy with the given name,
+	// and whether that BindingPolicy exists.
+	getBindingPolicyLabels func(name string) (labels.Set, bool)
+
 	// enqueue is used to add a reference to a Binding that needs to be re-processed because
 	// of a change to a CustomTransform that the Binding is sensitive to.
 	enqueue func(any)
@@ -90,13 +193,16 @@ var _ customTransformCollection = &customTransformCollectionImpl{}
 type groupResourceTransformData struct {
 	bindingsThatCare sets.Set[string /*Binding name*/] // not empty
 	ctNames          sets.Set[string /* CustomTransform name*/]
-	changes          customTransformChanges
+
+	// transforms holds the digested CustomTransforms, in order of name
+	transforms []*digestedCustomTransform
 }
 
-func newCustomTransformCollection(client ksmetrics.ClientModNamespace[*v1alpha1.CustomTransform, *v1alpha1.CustomTransformList], getTransformObjects func(indexName, indexedValue string) ([]any, error), enqueue func(any)) customTransformCollection {
+func newCustomTransformCollection(client ksmetrics.ClientModNamespace[*v1alpha1.CustomTransform, *v1alpha1.CustomTransformList], getTransformObjects func(indexName, indexedValue string) ([]any, error), getBindingPolicyLabels func(name string) (labels.Set, bool), enqueue func(any)) customTransformCollection {
 	return &customTransformCollectionImpl{
 		client:                      client,
 		getTransformObjects:         getTransformObjects,
+		getBindingPolicyLabels:      getBindingPolicyLabels,
 		enqueue:                     enqueue,
 		grToTransformData:           make(map[metav1.GroupResource]*groupResourceTransformData),
 		ctNameToSpec:                make(map[string]v1alpha1.CustomTransformSpec),
@@ -104,19 +210,53 @@ func newCustomTransformCollection(client ksmetrics.ClientModNamespace[*v1alpha1.
 	}
 }
 
-// getCustomTransformData returns the customTransformChanges to use
-// for the given GroupResource and notes that the result is relevant to the named Binding.
-// This method returns a cached answer if one is available, otherwise
-// digests the relevant CustomTransform object(s) and caches the result.
+// getCustomTransformChanges returns the custom transforms to use
+// for the given GroupResource in the named Binding and notes that the result is relevant to that Binding.
+// The digested CustomTransform objects are cached per GroupResource; this method
+// digests the relevant CustomTransform object(s) if there is no cached entry.
+// The selection by bindingPolicySelector is done here, based on the labels of the
+// BindingPolicy that has the same name as the Binding;
+// the selection by clusterSelector is left to boundCustomTransforms.forDestination.
 // Always records the fact that the given binding depends on the answer.
-func (ctc *customTransformCollectionImpl) getCustomTransformChanges(ctx context.Context, groupResource metav1.GroupResource, bindingName string) customTransformChanges {
-	logger := klog.FromContext(ctx)
+func (ctc *customTransformCollectionImpl) getCustomTransformChanges(ctx context.Context, groupResource metav1.GroupResource, bindingName string) boundCustomTransforms {
 	ctc.mutex.Lock()
 	defer ctc.mutex.Unlock()
+	grTransformData := ctc.getGroupResourceTransformDataLocked(ctx, groupResource, bindingName)
+	var ans boundCustomTransforms
+	var policyLabels labels.Set
+	var policyLabelsFetched bool
+	for _, dct := range grTransformData.transforms {
+		if dct.bindingPolicySelector != nil {
+			if !policyLabelsFetched {
+				policyLabels, _ = ctc.getBindingPolicyLabels(bindingName)
+				policyLabelsFetched = true
+			}
+			if !dct.bindingPolicySelector.Matches(policyLabels) {
+				continue
+			}
+		}
+		if dct.afterCustomization != nil {
+			ans.afterCustomization = append(ans.afterCustomization, dct)
+		} else if dct.clusterSelector != nil {
+			ans.scoped = append(ans.scoped, dct)
+		} else {
+			ans.common.append(dct.changes)
+		}
+	}
+	return ans
+}
+
+// getGroupResourceTransformDataLocked returns the groupResourceTransformData for the given GroupResource,
+// after noting that the named Binding cares about it.
+// This method returns a cached answer if one is available, otherwise
+// digests the relevant CustomTransform object(s) and caches the result.
+// Caller asserts that the ctc's mutex is locked.
+func (ctc *customTransformCollectionImpl) getGroupResourceTransformDataLocked(ctx context.Context, groupResource metav1.GroupResource, bindingName string) *groupResourceTransformData {
+	logger := klog.FromContext(ctx)
 	grTransformData, ok := ctc.grToTransformData[groupResource]
 	if ok {
 		grTransformData.bindingsThatCare.Insert(bindingName)
-		return grTransformData.changes
+		return grTransformData
 	}
 	ctKey := customTransformDomainKey(groupResource.Group, groupResource.Resource)
 	ctAnys, err := ctc.getTransformObjects(customTransformDomainIndexName, ctKey)
@@ -128,30 +268,41 @@ func (ctc *customTransformCollectionImpl) getCustomTransformChanges(ctx context.
 	}
 
 	cts := abstract.SliceMap(ctAnys, func(ctAny any) *v1alpha1.CustomTransform { return ctAny.(*v1alpha1.CustomTransform) })
+	sort.Slice(cts, func(i, j int) bool { return cts[i].Name < cts[j].Name })
 	grTransformData = &groupResourceTransformData{
 		bindingsThatCare: sets.New(bindingName),
 		ctNames:          abstract.SliceMapToK8sSet(cts, (*v1alpha1.CustomTransform).GetName),
 	}
-	var commonWarnings []string // warnings common to all the ct
-	if len(cts) > 1 {
-		commonWarnings = []string{fmt.Sprintf("multiple CustomTransform objects specify the same GroupResource; their names are %v", grTransformData.ctNames)}
+	var commonWarnings []string // warnings common to all the unscoped ct
+	unscopedNames := sets.New[string]()
+	for _, ct := range cts {
+		if ct.Spec.BindingPolicySelector == nil && ct.Spec.ClusterSelector == nil {
+			unscopedNames.Insert(ct.Name)
+		}
+	}
+	if len(unscopedNames) > 1 {
+		commonWarnings = []string{fmt.Sprintf("multiple unscoped CustomTransform objects specify the same GroupResource; their names are %v", sets.List(unscopedNames))}
 	}
-	// Digest each relevant CustomTransform, accumulating remove instructions in groupResourceTransformData.removes.
+	// Digest each relevant CustomTransform, accumulating them in groupResourceTransformData.transforms.
 	// Invalidate cache entry for each CustomTransform that changed its Spec's .Group or .Resource.
 	for _, ct := range cts {
-		removes := ctc.digestCustomTransformLocked(ctx, groupResource, bindingName, ct, commonWarnings)
-		grTransformData.changes.removes = append(grTransformData.changes.removes, removes...)
+		var warnings []string
+		if unscopedNames.Has(ct.Name) {
+			warnings = commonWarnings
+		}
+		dct := ctc.digestCustomTransformLocked(ctx, groupResource, bindingName, ct, warnings)
+		grTransformData.transforms = append(grTransformData.transforms, dct)
 	}
 	ctc.grToTransformData[groupResource] = grTransformData
-	return grTransformData.changes
+	return grTransformData
 }
 
 // digestCustomTransformLocked digests one CustomTransform.
 // This done in the context of processing a Binding, whose name is a parameter (for the sake of logging).
 // Caller asserts that grToTransformData does not have an entry for this GroupResource.
 // Caller asserts that the ctc's mutex is locked.
-func (ctc *customTransformCollectionImpl) digestCustomTransformLocked(ctx context.Context, groupResource metav1.GroupResource, bindingName string, ct *v1alpha1.CustomTransform, commonWarnings []string) []jsonpath.Query {
-	removes := ctc.parseRemovesAndUpdateStatus(ctx, ct, commonWarnings)
+func (ctc *customTransformCollectionImpl) digestCustomTransformLocked(ctx context.Context, groupResource metav1.GroupResource, bindingName string, ct *v1alpha1.CustomTransform, commonWarnings []string) *digestedCustomTransform {
+	dct := ctc.parseRemovesAndUpdateStatus(ctx, ct, commonWarnings)
 	// Invalidate cache if ct.Spec changed its .Group or .Resource since last processed in this method
 	oldSpec, had := ctc.ctNameToSpec[ct.Name]
 	if had {
@@ -163,36 +314,175 @@ func (ctc *customTransformCollectionImpl) digestCustomTransformLocked(ctx contex
 		}
 	}
 	ctc.ctNameToSpec[ct.Name] = ct.Spec
-	return removes
+	return dct
+}
+
+// identityFieldPaths are the JSONPath expressions, in their usual form, for the fields that identify an object.
+var identityFieldPaths = sets.New("$.apiVersion", "$.kind", "$.metadata.name", "$.metadata.namespace")
+
+func mergePatchChangesIdentity(patch map[string]any) bool {
+	if _, has := patch["apiVersion"]; has {
+		return true
+	}
+	if _, has := patch["kind"]; has {
+		return true
+	}
+	metadata, isObj := patch["metadata"].(map[string]any)
+	if !isObj {
+		return patch["metadata"] != nil
+	}
+	_, hasName := metadata["name"]
+	_, hasNamespace := metadata["namespace"]
+	return hasName || hasNamespace
 }
 
 func ctSpecGroupResource(spec v1alpha1.CustomTransformSpec) metav1.GroupResource {
 	return metav1.GroupResource{Group: spec.APIGroup, Resource: spec.Resource}
 }
 
-func (ctc *customTransformCollectionImpl) parseRemovesAndUpdateStatus(ctx context.Context, ct *v1alpha1.CustomTransform, commonWarnings []string) (removes []jsonpath.Query) {
+// parseRemovesAndUpdateStatus parses and validates the selectors, removals, assignments and merge patch
+// of the given CustomTransform, and writes the resulting errors and warnings in its status.
+// A CustomTransform with a malformed selector applies to nothing.
+// For a CustomTransform in the AfterCustomization phase, the parsing of the
+// removals, assignments and merge patch is deferred until after template expansion.
+func (ctc *customTransformCollectionImpl) parseRemovesAndUpdateStatus(ctx context.Context, ct *v1alpha1.CustomTransform, commonWarnings []string) *digestedCustomTransform {
 	logger := klog.FromContext(ctx)
 	ctCopy := ct.DeepCopy()
 	ctCopy.Status = v1alpha1.CustomTransformStatus{ObservedGeneration: ct.Generation, Warnings: commonWarnings}
-	for idx, queryS := range ct.Spec.Remove {
-		query, err := jsonpath.ParseQuery(queryS)
+	dct := &digestedCustomTransform{name: ct.Name}
+	parseSelector := func(fieldName string, selector *metav1.LabelSelector) labels.Selector {
+		if selector == nil {
+			return nil
+		}
+		parsed, err := metav1.LabelSelectorAsSelector(selector)
 		if err != nil {
-			ctCopy.Status.Errors = append(ctCopy.Status.Errors, fmt.Sprintf("Error in spec.remove[%d]: %s", idx, err.Error()))
-		} else if len(query) == 0 {
-			ctCopy.Status.Errors = append(ctCopy.Status.Errors, fmt.Sprintf("Invalid spec.remove[%d]: it identifies the whole object", idx))
+			ctCopy.Status.Errors = append(ctCopy.Status.Errors, fmt.Sprintf("Error in spec.%s: %s", fieldName, err.Error()))
+			return labels.Nothing()
+		}
+		return parsed
+	}
+	dct.bindingPolicySelector = parseSelector("bindingPolicySelector", ct.Spec.BindingPolicySelector)
+	dct.clusterSelector = parseSelector("clusterSelector", ct.Spec.ClusterSelector)
+	template := &customTransformTemplate{removes: ct.Spec.Remove}
+	for idx, set := range ct.Spec.Set {
+		var value any
+		err := json.Unmarshal(set.Value.Raw, &value)
+		if err != nil {
+			ctCopy.Status.Errors = append(ctCopy.Status.Errors, fmt.Sprintf("Error in spec.set[%d].value: %s", idx, err.Error()))
+		}
+		template.sets = append(template.sets, setTemplate{path: set.Path, value: value, valueOK: err == nil})
+	}
+	if ct.Spec.MergePatch != nil {
+		var patch any
+		if err := json.Unmarshal(ct.Spec.MergePatch.Raw, &patch); err != nil {
+			ctCopy.Status.Errors = append(ctCopy.Status.Errors, fmt.Sprintf("Error in spec.mergePatch: %s", err.Error()))
 		} else {
-			removes = append(removes, query)
+			template.mergePatch = patch
 		}
 	}
+	if ct.Spec.Phase == v1alpha1.CustomTransformPhaseAfterCustomization {
+		dct.afterCustomization = template
+	} else {
+		var errs, warnings []string
+		dct.changes, errs, warnings = template.parse()
+		ctCopy.Status.Errors = append(ctCopy.Status.Errors, errs...)
+		ctCopy.Status.Warnings = append(ctCopy.Status.Warnings, warnings...)
+	}
 	ctEcho, err := ctc.client.UpdateStatus(ctx, ctCopy, metav1.UpdateOptions{FieldManager: ControllerName})
 	if err != nil {
 		logger.Error(err, "Failed to write status of CustomTransform", "name", ct.Name, "resourceVersion", ct.ResourceVersion, "status", ctCopy.Status)
 	} else {
 		logger.V(2).Info("Wrote status of CustomTransform", "name", ct.Name, "resourceVersion", ctEcho.ResourceVersion, "observedGeneration", ctCopy.Status.ObservedGeneration)
 	}
+	return dct
+}
+
+// customTransformTemplate is the removals, assignments and merge patch of a CustomTransform
+// before parsing of the JSONPath expressions. The JSON values have been unmarshaled. Immutable.
+type customTransformTemplate struct {
+	removes    []string
+	sets       []setTemplate
+	mergePatch any // nil if absent
+}
+
+type setTemplate struct {
+	path  string
+	value any
+
+	// valueOK is false if the value failed to unmarshal,
+	// in which case the error has already been reported.
+	valueOK bool
+}
+
+// parse parses and validates the JSONPath expressions and the merge patch.
+// The returned errors and warnings are written in terms of the CustomTransformSpec.
+func (tmpl *customTransformTemplate) parse() (changes customTransformChanges, errs, warnings []string) {
+	for idx, queryS := range tmpl.removes {
+		query, err := jsonpath.ParseQuery(queryS)
+		if err != nil {
+			errs = append(errs, fmt.Sprintf("Error in spec.remove[%d]: %s", idx, err.Error()))
+		} else if len(query) == 0 {
+			errs = append(errs, fmt.Sprintf("Invalid spec.remove[%d]: it identifies the whole object", idx))
+		} else {
+			changes.removes = append(changes.removes, query)
+		}
+	}
+	for idx, set := range tmpl.sets {
+		query, err := jsonpath.ParseQuery(set.path)
+		if err != nil {
+			errs = append(errs, fmt.Sprintf("Error in spec.set[%d].path: %s", idx, err.Error()))
+			continue
+		} else if len(query) == 0 {
+			errs = append(errs, fmt.Sprintf("Invalid spec.set[%d].path: it identifies the whole object", idx))
+			continue
+		}
+		if !set.valueOK {
+			continue
+		}
+		if query.IsSingular() && identityFieldPaths.Has(set.path) {
+			warnings = append(warnings, fmt.Sprintf("spec.set[%d] changes the identity of the object", idx))
+		}
+		changes.sets = append(changes.sets, setChange{query: query, value: set.value})
+	}
+	if tmpl.mergePatch != nil {
+		if patchObj, isObj := tmpl.mergePatch.(map[string]any); !isObj {
+			errs = append(errs, "Invalid spec.mergePatch: it is not a JSON object")
+		} else {
+			if mergePatchChangesIdentity(patchObj) {
+				warnings = append(warnings, "spec.mergePatch changes the identity of the object")
+			}
+			changes.mergePatches = append(changes.mergePatches, patchObj)
+		}
+	}
 	return
 }
 
+// expand returns a copy of this template in which every leaf string has been
+// subject to template expansion using the given properties, along with the errors from that.
+// The given path is used to identify the templates in error messages.
+func (tmpl *customTransformTemplate) expand(path string, props clusterProperties) (*customTransformTemplate, []string) {
+	var errs []string
+	expand := func(subPath string, input any) any {
+		output, _, expErrs := customize.ExpandTemplates(path+subPath, runtime.DeepCopyJSONValue(input), props)
+		errs = append(errs, expErrs...)
+		return output
+	}
+	ans := &customTransformTemplate{}
+	for idx, remove := range tmpl.removes {
+		ans.removes = append(ans.removes, expand(fmt.Sprintf(".spec.remove[%d]", idx), remove).(string))
+	}
+	for idx, set := range tmpl.sets {
+		ans.sets = append(ans.sets, setTemplate{
+			path:    expand(fmt.Sprintf(".spec.set[%d].path", idx), set.path).(string),
+			value:   expand(fmt.Sprintf(".spec.set[%d].value", idx), set.value),
+			valueOK: set.valueOK})
+	}
+	if tmpl.mergePatch != nil {
+		ans.mergePatch = expand(".spec.mergePatch", tmpl.mergePatch)
+	}
+	return ans, errs
+}
+
 // invalidateCacheEntryLocked removes the cached entry for the given GroupResource.
 // Caller asserts that this is being done because of some change to the CustomTransform having the given name.
 // `shouldHave` asserts that ctc.ctNameToSpec has an entry for this name.
@@ -242,8 +532,7 @@ func (ctc *customTransformCollectionImpl) noteCustomTransform(ctx context.Contex
 		theGroupResource = newGroupResource
 	}
 	if ct != nil && hadSpec &&
-		oldGroupResource == newGroupResource &&
-		sets.New(oldSpec.Remove...).Equal(sets.New(ct.Spec.Remove...)) {
+		apiequality.Semantic.DeepEqual(oldSpec, ct.Spec) {
 		return // unchanged
 	}
 	if ct != nil && hadSpec && oldGroupResource != newGroupResource {
@@ -0,0 +1,255 @@
+/*
+Copyright 2024 The KubeStellar Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package transport
+
+import (
+	"encoding/json"
+	"testing"
+
+	apiextensionsv1 "k8s.io/apiextensions-apiserver/pkg/apis/apiextensions/v1"
+	apiequality "k8s.io/apimachinery/pkg/api/equality"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/labels"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/klog/v2/ktesting"
+
+	ksapi "github.com/kubestellar/kubestellar/api/control/v1alpha1"
+	"github.com/kubestellar/kubestellar/pkg/abstract"
+	ksclientfake "github.com/kubestellar/kubestellar/pkg/generated/clientset/versioned/fake"
+	"github.com/kubestellar/kubestellar/pkg/jsonpath"
+)
+
+func TestCustomTransformScoping(t *testing.T) {
+	_, ctx := ktesting.NewTestContext(t)
+	groupResource := metav1.GroupResource{Group: "apps", Resource: "deployments"}
+	newCT := func(name string, policySelector, clusterSelector *metav1.LabelSelector) *ksapi.CustomTransform {
+		return &ksapi.CustomTransform{
+			ObjectMeta: metav1.ObjectMeta{Name: name},
+			Spec: ksapi.CustomTransformSpec{
+				APIGroup:              groupResource.Group,
+				Resource:              groupResource.Resource,
+				BindingPolicySelector: policySelector,
+				ClusterSelector:       clusterSelector,
+				Remove:                []string{"$.spec." + name},
+			}}
+	}
+	prodSelector := &metav1.LabelSelector{MatchLabels: map[string]string{"env": "prod"}}
+	edgeSelector := &metav1.LabelSelector{MatchLabels: map[string]string{"edge": "true"}}
+	badSelector := &metav1.LabelSelector{MatchExpressions: []metav1.LabelSelectorRequirement{{Key: "env", Operator: "Bogus"}}}
+	cts := []any{
+		newCT("d", nil, edgeSelector),
+		newCT("a", nil, nil),
+		newCT("c", prodSelector, edgeSelector),
+		newCT("b", prodSelector, nil),
+		newCT("e", badSelector, nil),
+	}
+	client := ksclientfake.NewSimpleClientset()
+	for _, ct := range cts {
+		if _, err := client.ControlV1alpha1().CustomTransforms().Create(ctx, ct.(*ksapi.CustomTransform), metav1.CreateOptions{}); err != nil {
+			t.Fatalf("Failed to create CustomTransform: %v", err)
+		}
+	}
+	policyLabels := map[string]labels.Set{"prod-policy": {"env": "prod"}, "dev-policy": {"env": "dev"}}
+	ctc := newCustomTransformCollection(client.ControlV1alpha1().CustomTransforms(),
+		func(indexName, indexedValue string) ([]any, error) { return cts, nil },
+		func(name string) (labels.Set, bool) { ans, have := policyLabels[name]; return ans, have },
+		func(any) {})
+	removedFields := func(changes customTransformChanges) []string {
+		ans := []string{}
+		for _, query := range changes.removes {
+			ans = append(ans, string(query[len(query)-1].Selectors[0].(jsonpath.NameSelector)))
+		}
+		return ans
+	}
+	for _, testCase := range []struct {
+		bindingName    string
+		destLabels     labels.Set
+		expectCommon   []string
+		expectForDest  []string
+		expectNumScope int
+	}{
+		{"prod-policy", labels.Set{"edge": "true"}, []string{"a", "b"}, []string{"c", "d"}, 2},
+		{"prod-policy", labels.Set{"edge": "false"}, []string{"a", "b"}, []string{}, 2},
+		{"dev-policy", labels.Set{"edge": "true"}, []string{"a"}, []string{"d"}, 1},
+		{"no-policy", labels.Set{}, []string{"a"}, []string{}, 1},
+	} {
+		bound := ctc.getCustomTransformChanges(ctx, groupResource, testCase.bindingName)
+		if actual := removedFields(bound.common); !abstract.SliceEqual(actual, testCase.expectCommon) {
+			t.Errorf("For binding %q, expected common removals %v but got %v", testCase.bindingName, testCase.expectCommon, actual)
+		}
+		if len(bound.scoped) != testCase.expectNumScope {
+			t.Errorf("For binding %q, expected %d scoped transforms but got %d", testCase.bindingName, testCase.expectNumScope, len(bound.scoped))
+		}
+		if actual := removedFields(bound.forDestination(testCase.destLabels)); !abstract.SliceEqual(actual, testCase.expectForDest) {
+			t.Errorf("For binding %q and destination labels %v, expected removals %v but got %v", testCase.bindingName, testCase.destLabels, testCase.expectForDest, actual)
+		}
+	}
+	ctE, err := client.ControlV1alpha1().CustomTransforms().Get(ctx, "e", metav1.GetOptions{})
+	if err != nil {
+		t.Fatalf("Failed to get CustomTransform: %v", err)
+	}
+	if len(ctE.Status.Errors) != 1 {
+		t.Errorf("Expected one error in status of CustomTransform with bad selector, got %v", ctE.Status.Errors)
+	}
+}
+
+func TestCustomTransformAfterCustomization(t *testing.T) {
+	_, ctx := ktesting.NewTestContext(t)
+	groupResource := metav1.GroupResource{Group: "apps", Resource: "deployments"}
+	cts := []any{&ksapi.CustomTransform{
+		ObjectMeta: metav1.ObjectMeta{Name: "drop-sidecar"},
+		Spec: ksapi.CustomTransformSpec{
+			APIGroup: groupResource.Group,
+			Resource: groupResource.Resource,
+			Phase:    ksapi.CustomTransformPhaseAfterCustomization,
+			Remove:   []string{"$.spec.containers[?@.name == 'sidecar' && '{{ .edge }}' == 'true']"},
+			Set: []ksapi.CustomTransformSet{{
+				Path:  "$.metadata.labels.region",
+				Value: apiextensionsv1.JSON{Raw: []byte(`"{{ .region }}"`)}}},
+		}}}
+	client := ksclientfake.NewSimpleClientset(cts[0].(*ksapi.CustomTransform))
+	ctc := newCustomTransformCollection(client.ControlV1alpha1().CustomTransforms(),
+		func(indexName, indexedValue string) ([]any, error) { return cts, nil },
+		func(name string) (labels.Set, bool) { return nil, false },
+		func(any) {})
+	bound := ctc.getCustomTransformChanges(ctx, groupResource, "b1")
+	if !bound.common.isEmpty() || len(bound.afterCustomization) != 1 || !bound.destinationDependent() {
+		t.Fatalf("Wrong bound transforms %#v", bound)
+	}
+	newObj := func() *unstructured.Unstructured {
+		return &unstructured.Unstructured{Object: map[string]any{
+			"metadata": map[string]any{"name": "d1"},
+			"spec": map[string]any{"containers": []any{
+				map[string]any{"name": "main"},
+				map[string]any{"name": "sidecar"},
+			}}}}
+	}
+	for _, testCase := range []struct {
+		props          clusterProperties
+		expectErrors   bool
+		expectNumConts int
+	}{
+		{clusterProperties{"edge": "true", "region": "west"}, false, 1},
+		{clusterProperties{"edge": "false", "region": "east"}, false, 2},
+		{clusterProperties{"edge": "true"}, true, 2},
+	} {
+		changes, errs := bound.afterCustomizationForDestination(nil, "wec1/d1", testCase.props)
+		if (len(errs) > 0) != testCase.expectErrors {
+			t.Errorf("For props %v, expected errors=%v but got %v", testCase.props, testCase.expectErrors, errs)
+		}
+		obj := applyCustomTransformChanges(newObj(), changes)
+		containers, _, _ := unstructured.NestedSlice(obj.Object, "spec", "containers")
+		if len(containers) != testCase.expectNumConts {
+			t.Errorf("For props %v, expected %d containers but got %v", testCase.props, testCase.expectNumConts, containers)
+		}
+		if region := obj.GetLabels()["region"]; !testCase.expectErrors && region != testCase.props["region"] {
+			t.Errorf("For props %v, expected region label %q but got %q", testCase.props, testCase.props["region"], region)
+		}
+	}
+}
+
+func TestCustomTransformSetAndPatchErrors(t *testing.T) {
+	_, ctx := ktesting.NewTestContext(t)
+	groupResource := metav1.GroupResource{Group: "apps", Resource: "deployments"}
+	for _, testCase := range []struct {
+		name           string
+		set            []ksapi.CustomTransformSet
+		mergePatch     string
+		expectErrors   int
+		expectWarnings int
+		expectSets     int
+		expectPatches  int
+	}{
+		{name: "good", set: []ksapi.CustomTransformSet{{Path: "$.spec.replicas", Value: apiextensionsv1.JSON{Raw: []byte(`2`)}}},
+			mergePatch: `{"metadata": {"labels": {"a": "b"}}}`, expectSets: 1, expectPatches: 1},
+		{name: "bad-path", set: []ksapi.CustomTransformSet{{Path: "$.spec[", Value: apiextensionsv1.JSON{Raw: []byte(`2`)}}},
+			expectErrors: 1},
+		{name: "whole-object", set: []ksapi.CustomTransformSet{{Path: "$", Value: apiextensionsv1.JSON{Raw: []byte(`{}`)}}},
+			expectErrors: 1},
+		{name: "bad-value", set: []ksapi.CustomTransformSet{{Path: "$.spec.replicas", Value: apiextensionsv1.JSON{Raw: []byte(`{`)}}},
+			expectErrors: 1},
+		{name: "identity", set: []ksapi.CustomTransformSet{{Path: "$.metadata.name", Value: apiextensionsv1.JSON{Raw: []byte(`"x"`)}}},
+			mergePatch: `{"metadata": {"namespace": "y"}}`, expectWarnings: 2, expectSets: 1, expectPatches: 1},
+		{name: "patch-not-object", mergePatch: `[1]`, expectErrors: 1},
+		{name: "patch-not-json", mergePatch: `{`, expectErrors: 1},
+	} {
+		ct := &ksapi.CustomTransform{
+			ObjectMeta: metav1.ObjectMeta{Name: testCase.name},
+			Spec: ksapi.CustomTransformSpec{
+				APIGroup: groupResource.Group,
+				Resource: groupResource.Resource,
+				Set:      testCase.set,
+			}}
+		if testCase.mergePatch != "" {
+			ct.Spec.MergePatch = &apiextensionsv1.JSON{Raw: []byte(testCase.mergePatch)}
+		}
+		client := ksclientfake.NewSimpleClientset(ct)
+		ctc := newCustomTransformCollection(client.ControlV1alpha1().CustomTransforms(),
+			func(indexName, indexedValue string) ([]any, error) { return []any{ct}, nil },
+			func(name string) (labels.Set, bool) { return nil, false },
+			func(any) {})
+		changes := ctc.getCustomTransformChanges(ctx, groupResource, "b1").common
+		if len(changes.sets) != testCase.expectSets || len(changes.mergePatches) != testCase.expectPatches {
+			t.Errorf("For %s, expected %d sets and %d patches but got %#v", testCase.name, testCase.expectSets, testCase.expectPatches, changes)
+		}
+		ctEcho, err := client.ControlV1alpha1().CustomTransforms().Get(ctx, testCase.name, metav1.GetOptions{})
+		if err != nil {
+			t.Fatalf("Failed to get CustomTransform: %v", err)
+		}
+		if len(ctEcho.Status.Errors) != testCase.expectErrors || len(ctEcho.Status.Warnings) != testCase.expectWarnings {
+			t.Errorf("For %s, expected %d errors and %d warnings but got errors=%v, warnings=%v", testCase.name,
+				testCase.expectErrors, testCase.expectWarnings, ctEcho.Status.Errors, ctEcho.Status.Warnings)
+		}
+	}
+}
+
+func TestApplyMergePatch(t *testing.T) {
+	// The cases are from the examples in RFC 7386.
+	for _, testCase := range []struct{ target, patch, expected string }{
+		{`{"a":"b"}`, `{"a":"c"}`, `{"a":"c"}`},
+		{`{"a":"b"}`, `{"b":"c"}`, `{"a":"b","b":"c"}`},
+		{`{"a":"b"}`, `{"a":null}`, `{}`},
+		{`{"a":"b","b":"c"}`, `{"a":null}`, `{"b":"c"}`},
+		{`{"a":["b"]}`, `{"a":"c"}`, `{"a":"c"}`},
+		{`{"a":"c"}`, `{"a":["b"]}`, `{"a":["b"]}`},
+		{`{"a":{"b":"c"}}`, `{"a":{"b":"d","c":null}}`, `{"a":{"b":"d"}}`},
+		{`{"a":[{"b":"c"}]}`, `{"a":[1]}`, `{"a":[1]}`},
+		{`["a","b"]`, `["c","d"]`, `["c","d"]`},
+		{`{"a":"b"}`, `["c"]`, `["c"]`},
+		{`{"e":null}`, `{"a":1}`, `{"e":null,"a":1}`},
+		{`[1,2]`, `{"a":"b","c":null}`, `{"a":"b"}`},
+		{`{}`, `{"a":{"bb":{"ccc":null}}}`, `{"a":{"bb":{}}}`},
+	} {
+		var target, patch, expected any
+		for _, parse := range []struct {
+			doc  string
+			into *any
+		}{{testCase.target, &target}, {testCase.patch, &patch}, {testCase.expected, &expected}} {
+			if err := json.Unmarshal([]byte(parse.doc), parse.into); err != nil {
+				t.Fatalf("Failed to parse %q: %v", parse.doc, err)
+			}
+		}
+		patchCopy := runtime.DeepCopyJSONValue(patch)
+		if actual := applyMergePatch(target, patch); !apiequality.Semantic.DeepEqual(actual, expected) {
+			t.Errorf("Patching %s with %s: expected %#v but got %#v", testCase.target, testCase.patch, expected, actual)
+		}
+		if !apiequality.Semantic.DeepEqual(patch, patchCopy) {
+			t.Errorf("Patching %s with %s modified the patch", testCase.target, testCase.patch)
+		}
+	}
+}
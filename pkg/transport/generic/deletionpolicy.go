@@ -0,0 +1,58 @@
+/*
+Copyright 2024 The KubeStellar Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package transport
+
+import (
+	"fmt"
+
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+
+	"github.com/kubestellar/kubestellar/api/control/v1alpha1"
+)
+
+// orphanOf tells whether the given workload object is to be orphaned in the WECs,
+// given the deletion policy of the Binding's clause for it.
+// The object's deletion policy annotation, when present, overrides the clause.
+// An unrecognized annotation is reported in the error, along with the clause's answer.
+func orphanOf(obj *unstructured.Unstructured, clausePolicy v1alpha1.DeletionPolicy) (bool, error) {
+	clauseOrphan := clausePolicy == v1alpha1.DeletionPolicyOrphan
+	annotation, found := obj.GetAnnotations()[v1alpha1.DeletionPolicyAnnotationKey]
+	if !found {
+		return clauseOrphan, nil
+	}
+	switch v1alpha1.DeletionPolicy(annotation) {
+	case v1alpha1.DeletionPolicyDelete:
+		return false, nil
+	case v1alpha1.DeletionPolicyOrphan:
+		return true, nil
+	default:
+		return clauseOrphan, fmt.Errorf("the %s annotation of %s %s/%s, %q, is neither %q nor %q",
+			v1alpha1.DeletionPolicyAnnotationKey, obj.GetKind(), obj.GetNamespace(), obj.GetName(), annotation,
+			v1alpha1.DeletionPolicyDelete, v1alpha1.DeletionPolicyOrphan)
+	}
+}
+
+// deletionPolicyErrors returns the user errors in the deletion policy annotations of the given objects.
+func deletionPolicyErrors(wrapees []WrapeeWithUID) []string {
+	var errs []string
+	for _, wrapee := range wrapees {
+		if _, err := orphanOf(wrapee.Object, ""); err != nil {
+			errs = append(errs, err.Error())
+		}
+	}
+	return errs
+}
@@ -20,6 +20,8 @@ import (
 	"context"
 	"fmt"
 	"go/token"
+	"slices"
+	"strconv"
 	"sync"
 	"time"
 
@@ -28,11 +30,14 @@ import (
 	clusterlisters "open-cluster-management.io/api/client/cluster/listers/cluster/v1"
 
 	corev1 "k8s.io/api/core/v1"
+	apiequality "k8s.io/apimachinery/pkg/api/equality"
 	"k8s.io/apimachinery/pkg/api/errors"
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/labels"
 	"k8s.io/apimachinery/pkg/runtime"
 	"k8s.io/apimachinery/pkg/runtime/schema"
+	"k8s.io/apimachinery/pkg/util/json"
 	utilruntime "k8s.io/apimachinery/pkg/util/runtime"
 	"k8s.io/apimachinery/pkg/util/sets"
 	"k8s.io/apimachinery/pkg/util/wait"
@@ -60,7 +65,9 @@ import (
 	ksmetrics "github.com/kubestellar/kubestellar/pkg/metrics"
 	"github.com/kubestellar/kubestellar/pkg/transport"
 	"github.com/kubestellar/kubestellar/pkg/transport/generic/filtering"
+	"github.com/kubestellar/kubestellar/pkg/upsync"
 	"github.com/kubestellar/kubestellar/pkg/util"
+	"github.com/kubestellar/kubestellar/pkg/wecreport"
 )
 
 const (
@@ -85,7 +92,9 @@ func NewTransportController(ctx context.Context,
 	inventoryPreInformer clusterinformers.ManagedClusterInformer,
 	bindingClient controlclient.BindingInterface,
 	bindingInformer controlv1alpha1informers.BindingInformer,
+	bindingPolicyInformer controlv1alpha1informers.BindingPolicyInformer,
 	customTransformInformer controlv1alpha1informers.CustomTransformInformer,
+	combinedStatusInformer controlv1alpha1informers.CombinedStatusInformer,
 	transportInstance transport.Transport,
 	wdsClientset ksclientset.Interface,
 	wdsDynamicClient dynamic.Interface,
@@ -99,7 +108,7 @@ func NewTransportController(ctx context.Context,
 	if err != nil {
 		return nil, fmt.Errorf("failed to get wrapped object GVR - %w", err)
 	}
-	return NewTransportControllerForWrappedObjectGVR(ctx, wdsClientMetrics, itsClientMetrics, inventoryPreInformer, bindingClient, bindingInformer, customTransformInformer, transportInstance, wdsClientset, wdsDynamicClient, itsNSClient, propCfgMapPreInformer, transportDynamicClient, maxSizeWrapped, maxNumWrapped, wdsName, wrappedObjectGVR), nil
+	return NewTransportControllerForWrappedObjectGVR(ctx, wdsClientMetrics, itsClientMetrics, inventoryPreInformer, bindingClient, bindingInformer, bindingPolicyInformer, customTransformInformer, combinedStatusInformer, transportInstance, wdsClientset, wdsDynamicClient, itsNSClient, propCfgMapPreInformer, transportDynamicClient, maxSizeWrapped, maxNumWrapped, wdsName, wrappedObjectGVR), nil
 }
 
 // NewTransportControllerForWrappedObjectGVR returns a new transport controller.
@@ -109,7 +118,9 @@ func NewTransportControllerForWrappedObjectGVR(ctx context.Context,
 	inventoryPreInformer clusterinformers.ManagedClusterInformer,
 	bindingClient controlclient.BindingInterface,
 	bindingInformer controlv1alpha1informers.BindingInformer,
+	bindingPolicyInformer controlv1alpha1informers.BindingPolicyInformer,
 	customTransformInformer controlv1alpha1informers.CustomTransformInformer,
+	combinedStatusInformer controlv1alpha1informers.CombinedStatusInformer,
 	transportInstance transport.Transport,
 	wdsClientset ksclientset.Interface,
 	wdsDynamicClient dynamic.Interface,
@@ -124,6 +135,15 @@ func NewTransportControllerForWrappedObjectGVR(ctx context.Context,
 	measuredITSDynamicClient := ksmetrics.NewWrappedDynamicClient(itsClientMetrics, transportDynamicClient)
 	dynamicInformerFactory := dynamicinformer.NewDynamicSharedInformerFactory(measuredITSDynamicClient, 0)
 	wrappedObjectGenericInformer := dynamicInformerFactory.ForResource(wrappedObjectGVR)
+	upsyncInformerFactory := dynamicinformer.NewFilteredDynamicSharedInformerFactory(measuredITSDynamicClient, 0, metav1.NamespaceAll, func(opts *metav1.ListOptions) {
+		opts.LabelSelector = fmt.Sprintf("%s,%s=%s", upsync.RoleLabelKey, upsync.OriginWdsLabelKey, wdsName)
+	})
+	upsyncGenericInformer := upsyncInformerFactory.ForResource(configMapGVR)
+	upsyncSecretGenericInformer := upsyncInformerFactory.ForResource(secretGVR)
+	wecReportInformerFactory := dynamicinformer.NewFilteredDynamicSharedInformerFactory(measuredITSDynamicClient, 0, metav1.NamespaceAll, func(opts *metav1.ListOptions) {
+		opts.LabelSelector = fmt.Sprintf("%s=true,%s=%s", wecreport.ReportLabelKey, wecreport.OriginWdsLabelKey, wdsName)
+	})
+	wecReportGenericInformer := wecReportInformerFactory.ForResource(configMapGVR)
 	customTransformInformer.Informer().AddIndexers(map[string]cache.IndexFunc{customTransformDomainIndexName: customTransformToDomain})
 	customTransformsClient := wdsClientset.ControlV1alpha1().CustomTransforms()
 	measuredCustomTransformClient := ksmetrics.NewWrappedClusterScopedClient[*v1alpha1.CustomTransform, *v1alpha1.CustomTransformList](wdsClientMetrics, v1alpha1.GroupVersion.WithResource("customtransforms"), customTransformsClient)
@@ -137,12 +157,22 @@ func NewTransportControllerForWrappedObjectGVR(ctx context.Context,
 		bindingClient:                 measuredBindingClient,
 		bindingLister:                 bindingInformer.Lister(),
 		bindingInformerSynced:         bindingInformer.Informer().HasSynced,
+		bindingPolicyLister:           bindingPolicyInformer.Lister(),
+		bindingPolicyInformerSynced:   bindingPolicyInformer.Informer().HasSynced,
 		itsNSClient:                   measuredITSNSClient,
 		propCfgMapLister:              propCfgMapPreInformer.Lister().ConfigMaps(v1alpha1.PropertyConfigMapNamespace),
 		propCfgMapInformerSynced:      propCfgMapPreInformer.Informer().HasSynced,
 		wrappedObjectInformerSynced:   wrappedObjectGenericInformer.Informer().HasSynced,
+		upsyncLister:                  upsyncGenericInformer.Lister(),
+		upsyncInformerSynced:          upsyncGenericInformer.Informer().HasSynced,
+		upsyncSecretLister:            upsyncSecretGenericInformer.Lister(),
+		upsyncSecretInformerSynced:    upsyncSecretGenericInformer.Informer().HasSynced,
+		wecReportLister:               wecReportGenericInformer.Lister(),
+		wecReportInformerSynced:       wecReportGenericInformer.Informer().HasSynced,
 		customTransformLister:         customTransformInformer.Lister(),
 		customTransformInformerSynced: customTransformInformer.Informer().HasSynced,
+		combinedStatusLister:          combinedStatusInformer.Lister(),
+		combinedStatusInformerSynced:  combinedStatusInformer.Informer().HasSynced,
 		wecSampler: ksmetrics.NewListLenSampler(inventoryPreInformer.Informer().GetStore().List,
 			&k8smetrics.KubeOpts{Namespace: "kubestellar", Subsystem: "transport_controller",
 				Name: "wecs", Help: "number of inventory objects", StabilityLevel: k8smetrics.ALPHA}),
@@ -158,6 +188,9 @@ func NewTransportControllerForWrappedObjectGVR(ctx context.Context,
 		wrappedSampler: ksmetrics.NewListLenSampler(wrappedObjectGenericInformer.Informer().GetStore().List,
 			&k8smetrics.KubeOpts{Namespace: "kubestellar", Subsystem: "transport_controller",
 				Name: "wrapped_objects", Help: "number of wrapped objects", StabilityLevel: k8smetrics.ALPHA}),
+		driftSampler: ksmetrics.NewSampler(func() float64 { return countUnremediatedDrift(wecReportGenericInformer.Lister()) },
+			&k8smetrics.KubeOpts{Namespace: "kubestellar", Subsystem: "transport_controller",
+				Name: "drifted_objects", Help: "number of workload objects, summed over WECs, with unremediated drift", StabilityLevel: k8smetrics.ALPHA}),
 		bindingWhatsHist: k8smetrics.NewHistogram(&k8smetrics.HistogramOpts{
 			Namespace: "kubestellar", Subsystem: "transport_controller", Name: "binding_whats",
 			Help:           "number of workload objects referenced by a Binding",
@@ -183,10 +216,12 @@ func NewTransportControllerForWrappedObjectGVR(ctx context.Context,
 		wdsName:                      wdsName,
 		bindingSensitiveDestinations: make(map[string]sets.Set[v1alpha1.Destination]),
 		destinationProperties:        make(map[v1alpha1.Destination]clusterProperties),
-		customTransformCollection: newCustomTransformCollection(measuredCustomTransformClient,
-			customTransformInformer.Informer().GetIndexer().ByIndex,
-			workqueue.Add),
+		destinationLabels:            make(map[v1alpha1.Destination]labels.Set),
 	}
+	transportController.customTransformCollection = newCustomTransformCollection(measuredCustomTransformClient,
+		customTransformInformer.Informer().GetIndexer().ByIndex,
+		transportController.getBindingPolicyLabels,
+		workqueue.Add)
 
 	transportController.logger.Info("Setting up event handlers")
 	// Set up an event handler for when Binding resources change
@@ -205,6 +240,23 @@ func NewTransportControllerForWrappedObjectGVR(ctx context.Context,
 		},
 	})
 
+	// The labels of a BindingPolicy matter to CustomTransforms that have a bindingPolicySelector.
+	bindingPolicyInformer.Informer().AddEventHandler(cache.ResourceEventHandlerFuncs{
+		AddFunc: func(obj any) { transportController.handleBindingPolicy(obj, "add") },
+		UpdateFunc: func(old, new any) {
+			oldPolicy, newPolicy := old.(*v1alpha1.BindingPolicy), new.(*v1alpha1.BindingPolicy)
+			if !abstract.PrimitiveMapEqual(oldPolicy.Labels, newPolicy.Labels) {
+				transportController.handleBindingPolicy(new, "update")
+			}
+		},
+		DeleteFunc: func(obj any) {
+			if dfsu, is := obj.(cache.DeletedFinalStateUnknown); is {
+				obj = dfsu.Obj
+			}
+			transportController.handleBindingPolicy(obj, "delete")
+		},
+	})
+
 	customTransformInformer.Informer().AddEventHandler(cache.ResourceEventHandlerFuncs{
 		AddFunc: func(obj any) {
 			transportController.handleCustomTransform(obj, "add")
@@ -220,6 +272,18 @@ func NewTransportControllerForWrappedObjectGVR(ctx context.Context,
 		},
 	})
 
+	// The CombinedStatus objects matter to the progress of rollouts.
+	combinedStatusInformer.Informer().AddEventHandler(cache.ResourceEventHandlerFuncs{
+		AddFunc:    func(obj any) { transportController.handleCombinedStatus(obj, "add") },
+		UpdateFunc: func(_, obj any) { transportController.handleCombinedStatus(obj, "update") },
+		DeleteFunc: func(obj any) {
+			if deletedStateUnknown, ok := obj.(cache.DeletedFinalStateUnknown); ok {
+				obj = deletedStateUnknown.Obj
+			}
+			transportController.handleCombinedStatus(obj, "delete")
+		},
+	})
+
 	// Set up event handlers for when WrappedObject resources change. The handlers will lookup the origin Binding
 	// of the given WrappedObject and enqueue that Binding object for processing.
 	// This way, we don't need to implement custom logic for handling WrappedObject resources. More info on this pattern:
@@ -240,6 +304,20 @@ func NewTransportControllerForWrappedObjectGVR(ctx context.Context,
 			transportController.wrappedSampler.Prod()
 		},
 	})
+	// The upsync requests and records in the ITS matter to the Bindings that have upsync clauses.
+	upsyncHandlers := cache.ResourceEventHandlerFuncs{
+		AddFunc:    func(obj any) { transportController.handleUpsyncObject(obj, "add") },
+		UpdateFunc: func(_, obj any) { transportController.handleUpsyncObject(obj, "update") },
+		DeleteFunc: func(obj any) { transportController.handleUpsyncObject(obj, "delete") },
+	}
+	upsyncGenericInformer.Informer().AddEventHandler(upsyncHandlers)
+	upsyncSecretGenericInformer.Informer().AddEventHandler(upsyncHandlers)
+	// The reports from the agents in the WECs feed the adoption and drift reports in the Bindings' status.
+	wecReportGenericInformer.Informer().AddEventHandler(cache.ResourceEventHandlerFuncs{
+		AddFunc:    func(obj any) { transportController.handleWECReport(obj, "add") },
+		UpdateFunc: func(_, obj any) { transportController.handleWECReport(obj, "update") },
+		DeleteFunc: func(obj any) { transportController.handleWECReport(obj, "delete") },
+	})
 	inventoryPreInformer.Informer().AddEventHandler(cache.ResourceEventHandlerFuncs{
 		AddFunc: func(obj any) {
 			transportController.handlePropertiesEvent(obj, "add")
@@ -273,13 +351,15 @@ func NewTransportControllerForWrappedObjectGVR(ctx context.Context,
 		},
 	})
 	dynamicInformerFactory.Start(ctx.Done())
+	upsyncInformerFactory.Start(ctx.Done())
+	wecReportInformerFactory.Start(ctx.Done())
 
 	return transportController
 }
 
 func (c *genericTransportController) RegisterMetrics(reg ksmetrics.RegisterFn) {
 	ksmetrics.MustRegister(reg,
-		c.wecSampler, c.bindingSampler, c.transformSampler, c.propMapSampler, c.wrappedSampler,
+		c.wecSampler, c.bindingSampler, c.transformSampler, c.propMapSampler, c.wrappedSampler, c.driftSampler,
 	)
 	ksmetrics.MustRegisterAbles(reg,
 		c.bindingWhatsHist, c.bindingWheresHist, c.bindingAreaHist,
@@ -313,7 +393,8 @@ func getGvrFromWrappedObject(clientset kubernetes.Interface, wrappedObject runti
 
 // clusterProperties holds the (name, value) pairs that are the properties
 // of a given WEC, for input to customization.
-type clusterProperties = map[string]string
+// The values are unmarshaled JSON, with integers represented as int64.
+type clusterProperties = map[string]any
 
 type genericTransportController struct {
 	logger logr.Logger
@@ -323,13 +404,24 @@ type genericTransportController struct {
 	bindingClient               ksmetrics.ClientModNamespace[*v1alpha1.Binding, *v1alpha1.BindingList]
 	bindingLister               controlv1alpha1listers.BindingLister
 	bindingInformerSynced       cache.InformerSynced
+	bindingPolicyLister         controlv1alpha1listers.BindingPolicyLister
+	bindingPolicyInformerSynced cache.InformerSynced
 	itsNSClient                 ksmetrics.ClientModNamespace[*corev1.Namespace, *corev1.NamespaceList]
 	propCfgMapLister            corev1listers.ConfigMapNamespaceLister
 	propCfgMapInformerSynced    cache.InformerSynced
 	wrappedObjectInformerSynced cache.InformerSynced
+	upsyncLister                cache.GenericLister
+	upsyncInformerSynced        cache.InformerSynced
+	upsyncSecretLister          cache.GenericLister
+	upsyncSecretInformerSynced  cache.InformerSynced
+	wecReportLister             cache.GenericLister
+	wecReportInformerSynced     cache.InformerSynced
+	driftSampler                ksmetrics.Sampler
 
 	customTransformLister                                                        controlv1alpha1listers.CustomTransformLister
 	customTransformInformerSynced                                                cache.InformerSynced
+	combinedStatusLister                                                         controlv1alpha1listers.CombinedStatusLister
+	combinedStatusInformerSynced                                                 cache.InformerSynced
 	wecSampler, bindingSampler, transformSampler, propMapSampler, wrappedSampler ksmetrics.Sampler
 	bindingWhatsHist, bindingWheresHist, bindingAreaHist                         *k8smetrics.Histogram
 
@@ -365,6 +457,12 @@ type genericTransportController struct {
 	// deletion of the destination's property ConfigMap.
 	// Every `clusterProperties` that appears here is immutable from the time that it arrived.
 	destinationProperties map[v1alpha1.Destination]clusterProperties
+
+	// destinationLabels maps a destination to the labels of its inventory object,
+	// for use with the clusterSelector of a CustomTransform.
+	// Access only while holding RWMutex and keep consistent with bindingSensitiveDestinations.
+	// Every `labels.Set` that appears here is immutable from the time that it arrived.
+	destinationLabels map[v1alpha1.Destination]labels.Set
 }
 
 // enqueueBinding takes an Binding resource and
@@ -376,6 +474,27 @@ func (c *genericTransportController) handleBinding(obj interface{}, event string
 	c.workqueue.Add(binding.Name)
 }
 
+// handleBindingPolicy enqueues a reference to the Binding that corresponds to the given BindingPolicy,
+// because the BindingPolicy's labels may determine which CustomTransforms apply.
+func (c *genericTransportController) handleBindingPolicy(obj any, event string) {
+	bindingPolicy := obj.(*v1alpha1.BindingPolicy)
+	c.logger.V(5).Info("Enqueuing reference to Binding due to informer event about its BindingPolicy", "name", bindingPolicy.Name, "resourceVersion", bindingPolicy.ResourceVersion, "event", event)
+	c.workqueue.Add(bindingPolicy.Name)
+}
+
+// getBindingPolicyLabels returns the labels of the BindingPolicy with the given name,
+// and whether that BindingPolicy exists.
+func (c *genericTransportController) getBindingPolicyLabels(name string) (labels.Set, bool) {
+	bindingPolicy, err := c.bindingPolicyLister.Get(name)
+	if err != nil {
+		if !errors.IsNotFound(err) { // listers do not fail
+			c.logger.Error(err, "Inconceivable failure to fetch BindingPolicy", "name", name)
+		}
+		return nil, false
+	}
+	return bindingPolicy.Labels, true
+}
+
 func (c *genericTransportController) handleCustomTransform(obj any, event string) {
 	ct := obj.(*v1alpha1.CustomTransform)
 	ref := customTransformReference(ct.Name)
@@ -417,7 +536,7 @@ func (c *genericTransportController) Run(ctx context.Context, workersCount int)
 	// Wait for the caches to be synced before starting workers
 	c.logger.Info("waiting for informer caches to sync")
 
-	if ok := cache.WaitForCacheSync(ctx.Done(), c.inventoryInformerSynced, c.bindingInformerSynced, c.wrappedObjectInformerSynced, c.propCfgMapInformerSynced, c.customTransformInformerSynced); !ok {
+	if ok := cache.WaitForCacheSync(ctx.Done(), c.inventoryInformerSynced, c.bindingInformerSynced, c.bindingPolicyInformerSynced, c.wrappedObjectInformerSynced, c.propCfgMapInformerSynced, c.customTransformInformerSynced, c.combinedStatusInformerSynced, c.upsyncInformerSynced, c.upsyncSecretInformerSynced, c.wecReportInformerSynced); !ok {
 		return fmt.Errorf("failed to wait for caches to sync")
 	}
 
@@ -552,23 +671,29 @@ func (c *genericTransportController) process(ctx context.Context, obj interface{
 	}
 }
 
-// syncProperties checks whether the properties of a WEC have changed and, if so,
+// syncProperties checks whether the properties or labels of a WEC have changed and, if so,
 // enqueues references to all the Bindings to which that matters.
 func (c *genericTransportController) syncProperties(ctx context.Context, invName string) {
 	logger := klog.FromContext(ctx)
 	newProps := c.collectPropertiesForDestination(logger, invName)
+	newLabels := c.collectLabelsForDestination(logger, invName)
 	c.propsMutex.Lock()
 	defer c.propsMutex.Unlock()
 	dest := v1alpha1.Destination{ClusterId: invName}
-	oldProps, have := c.destinationProperties[dest]
-	if !have { // not cached, nobody cares
+	changed := false
+	if oldProps, have := c.destinationProperties[dest]; have && !apiequality.Semantic.DeepEqual(oldProps, newProps) {
+		c.logger.V(5).Info("syncProperties", "dest", dest, "props", newProps)
+		c.destinationProperties[dest] = newProps
+		changed = true
+	}
+	if oldLabels, have := c.destinationLabels[dest]; have && !abstract.PrimitiveMapEqual(oldLabels, newLabels) {
+		c.logger.V(5).Info("syncProperties", "dest", dest, "labels", newLabels)
+		c.destinationLabels[dest] = newLabels
+		changed = true
+	}
+	if !changed { // not cached (nobody cares) or not changed
 		return
 	}
-	if abstract.PrimitiveMapEqual(oldProps, newProps) {
-		return
-	}
-	c.logger.V(5).Info("syncProperties", "dest", dest, "props", newProps)
-	c.destinationProperties[dest] = newProps
 	for bindingName, dests := range c.bindingSensitiveDestinations {
 		if dests.Has(dest) {
 			c.logger.V(5).Info("Enqueuing reference to Binding that depends on changed destination properties", "binding", bindingName, "destination", dest)
@@ -614,6 +739,10 @@ func (c *genericTransportController) syncBinding(ctx context.Context, objectName
 		c.setBindingSensitivities(binding.Name, nil)
 		return c.deleteWrappedObjectsAndFinalizer(ctx, binding)
 	}
+	if binding.Spec.Suspend {
+		klog.FromContext(ctx).V(4).Info("Not propagating suspended Binding", "binding", binding.Name)
+		return nil
+	}
 	// otherwise, object was not deleted and no error occurered while reading the object.
 	return c.updateWrappedObjectsAndFinalizer(ctx, binding)
 }
@@ -631,6 +760,9 @@ func (c *genericTransportController) deleteWrappedObjectsAndFinalizer(ctx contex
 		return fmt.Errorf("failed to get current wrapped objects that are owned by Binding '%s' - %w", binding.GetName(), err)
 	}
 	c.customTransformCollection.setBindingGroupResources(binding.Name, sets.New[metav1.GroupResource]())
+	if _, err := c.syncUpsync(ctx, binding.Name, nil, nil); err != nil {
+		return fmt.Errorf("failed to delete upsync requests and copies of Binding '%s' - %w", binding.GetName(), err)
+	}
 	for _, destination := range binding.Spec.Destinations {
 		for {
 			currentWrappedObject := c.popWrappedObjectByNamespace(currentWrappedObjectList, destination.ClusterId)
@@ -689,15 +821,39 @@ func (c *genericTransportController) updateWrappedObjectsAndFinalizer(ctx contex
 		return fmt.Errorf("failed to get current wrapped objects that are owned by Binding '%s' - %w", binding.GetName(), err)
 	}
 	// calculate desired state
-	destToDesiredWrappedObjects, kindToResource, bindingErrors, groupResources, err := c.computeDestToWrappedObjects(ctx, binding)
+	customized, err := c.customizeBinding(ctx, binding)
+	if err != nil {
+		return fmt.Errorf("failed to get objects to propagate to WECs from Binding object '%s' - %w", binding.GetName(), err)
+	}
+	destToDesiredWrappedObjects, err := c.computeDestToWrappedObjects(customized, binding)
 	if err != nil {
 		return fmt.Errorf("failed to build wrapped object(s) from Binding '%s' - %w", binding.GetName(), err)
 	}
-	if binding.Status.ObservedGeneration != binding.Generation || !abstract.SliceEqual(binding.Status.Errors, bindingErrors) {
+	kindToResource, replicaAssignments, bindingErrors := customized.kindToResource, customized.replicaAssignments, customized.bindingErrors
+	rollout, heldDestinations := customized.rollout, customized.heldDestinations
+	upsyncConflicts, err := c.syncUpsync(ctx, binding.Name, binding.Spec.Upsync, binding.Spec.Destinations)
+	if err != nil {
+		return fmt.Errorf("failed to upsync for Binding '%s' - %w", binding.GetName(), err)
+	}
+	adoptionReports, driftReports, err := c.wecReports(binding)
+	if err != nil {
+		return err
+	}
+	if binding.Status.ObservedGeneration != binding.Generation || !abstract.SliceEqual(binding.Status.Errors, bindingErrors) ||
+		!apiequality.Semantic.DeepEqual(binding.Status.ReplicaAssignments, replicaAssignments) ||
+		!apiequality.Semantic.DeepEqual(binding.Status.Rollout, rollout) ||
+		!apiequality.Semantic.DeepEqual(binding.Status.UpsyncConflicts, upsyncConflicts) ||
+		!apiequality.Semantic.DeepEqual(binding.Status.AdoptionReports, adoptionReports) ||
+		!apiequality.Semantic.DeepEqual(binding.Status.DriftReports, driftReports) {
 		bindingCopy := binding.DeepCopy()
 		bindingCopy.Status = v1alpha1.BindingStatus{
 			ObservedGeneration: binding.Generation,
 			Errors:             bindingErrors,
+			ReplicaAssignments: replicaAssignments,
+			Rollout:            rollout,
+			UpsyncConflicts:    upsyncConflicts,
+			AdoptionReports:    adoptionReports,
+			DriftReports:       driftReports,
 		}
 		binding2, err := c.bindingClient.UpdateStatus(ctx, bindingCopy, metav1.UpdateOptions{FieldManager: ControllerName})
 		if err != nil {
@@ -706,10 +862,24 @@ func (c *genericTransportController) updateWrappedObjectsAndFinalizer(ctx contex
 			klog.FromContext(ctx).V(2).Info("Updated Binding.Status", "bindingName", binding.Name, "resourceVersion", binding2.ResourceVersion)
 		}
 	}
-	c.customTransformCollection.setBindingGroupResources(binding.Name, groupResources)
+	c.customTransformCollection.setBindingGroupResources(binding.Name, customized.groupResources)
 	// converge actual state to the desired state
 	if len(bindingErrors) == 0 {
-		if err := c.propagateWrappedObjectToClusters(ctx, destToDesiredWrappedObjects, kindToResource, currentWrappedObjectList, binding.Spec.Destinations); err != nil {
+		destinations := binding.Spec.Destinations
+		if heldDestinations.Len() > 0 {
+			// the destinations in later waves of the rollout keep their current wrapped objects
+			destinations = make([]v1alpha1.Destination, 0, len(binding.Spec.Destinations))
+			for _, destination := range binding.Spec.Destinations {
+				if !heldDestinations.Has(destination) {
+					destinations = append(destinations, destination)
+					continue
+				}
+				for c.popWrappedObjectByNamespace(currentWrappedObjectList, destination.ClusterId) != nil {
+				}
+			}
+			klog.FromContext(ctx).V(4).Info("Holding back destinations in later rollout waves", "binding", binding.Name, "currentWave", rollout.CurrentWave, "numHeld", heldDestinations.Len())
+		}
+		if err := c.propagateWrappedObjectToClusters(ctx, destToDesiredWrappedObjects, kindToResource, currentWrappedObjectList, destinations); err != nil {
 			return fmt.Errorf("failed to propagate wrapped object(s) for binding '%s' to all required WECs - %w", binding.GetName(), err)
 		}
 	} else {
@@ -733,13 +903,21 @@ func (c *genericTransportController) getWrapeesFromWDS(ctx context.Context, bind
 	groupResources := sets.New[metav1.GroupResource]()
 	wrapees := make([]WrapeeWithUID, 0)
 	kindToResource := map[schema.GroupKind]string{}
-	appendObj := func(gvr metav1.GroupVersionResource, object *unstructured.Unstructured, createOnly bool) {
+	appendObj := func(gvr metav1.GroupVersionResource, object *unstructured.Unstructured, modulation v1alpha1.DownsyncModulation) {
 		gr := metav1.GroupResource{Group: gvr.Group, Resource: gvr.Resource}
 		groupResources.Insert(gr)
 		kindToResource[object.GroupVersionKind().GroupKind()] = gvr.Resource
-		wrapees = append(wrapees, WrapeeWithUID{
-			transport.NewWrapee(TransformObject(ctx, c.customTransformCollection, gr, object, binding.Name), createOnly),
-			string(object.GetUID())})
+		transformed := TransformObject(ctx, c.customTransformCollection, gr, object, binding.Name)
+		orphan, _ := orphanOf(transformed, modulation.DeletionPolicy)
+		wrapee := transport.NewWrapee(transformed, modulation.EffectiveUpdateStrategy(), orphan)
+		wrapee.ReportAdoption, _ = reportAdoptionOf(transformed, modulation.AdoptionPolicy)
+		if modulation.DriftPolicy != v1alpha1.DriftPolicyIgnore {
+			wrapee.DriftPolicy = modulation.DriftPolicy
+		}
+		if wrapee.UpdateStrategy == v1alpha1.UpdateStrategyServerSideApply && modulation.ServerSideApply != nil {
+			wrapee.ServerSideApply = *modulation.ServerSideApply
+		}
+		wrapees = append(wrapees, WrapeeWithUID{wrapee, string(object.GetUID())})
 	}
 	// add cluster-scoped objects to the 'objectsToPropagate' slice
 	for _, clause := range binding.Spec.Workload.ClusterScope {
@@ -748,7 +926,7 @@ func (c *genericTransportController) getWrapeesFromWDS(ctx context.Context, bind
 		if err != nil {
 			return nil, nil, groupResources, fmt.Errorf("failed to get required cluster-scoped object '%s' with gvr %s from WDS - %w", clause.Name, gvr, err)
 		}
-		appendObj(clause.GroupVersionResource, object, clause.CreateOnly)
+		appendObj(clause.GroupVersionResource, object, clause.DownsyncModulation)
 	}
 	// add namespace-scoped objects to the 'objectsToPropagate' slice
 	for _, clause := range binding.Spec.Workload.NamespaceScope {
@@ -758,95 +936,204 @@ func (c *genericTransportController) getWrapeesFromWDS(ctx context.Context, bind
 			return nil, nil, groupResources, fmt.Errorf("failed to get required namespace-scoped object '%s' in namespace '%s' with gvr '%s' from WDS - %w", clause.Name,
 				clause.Namespace, gvr, err)
 		}
-		appendObj(clause.GroupVersionResource, object, clause.CreateOnly)
+		appendObj(clause.GroupVersionResource, object, clause.DownsyncModulation)
 	}
 
 	return wrapees, abstract.PrimitiveMapGet(kindToResource), groupResources, nil
 }
 
-// computeDestToWrappedObjects returns the following five things.
-//   - the destToWrappedObject function. This maps a destination to the slice of transportTask
-//     for that destination. This func also returns a `bool` that is false when
-//     the function has no answer for the given destination.
-//   - the function that maps every GroupKind appearing in the workload objects to the corresponding "resource".
-//   - the slice of strings describing user errors in the Binding.
-//   - the set of GroupResource that appear among the workload objects.
-//   - an error if something transient went wrong.
-func (c *genericTransportController) computeDestToWrappedObjects(ctx context.Context, binding *v1alpha1.Binding) (
-	func(v1alpha1.Destination) ([]transportTask, bool), func(schema.GroupKind) (string, bool), []string, sets.Set[metav1.GroupResource], error) {
-	wrapeesToPropagate, kindToResource, grs, err := c.getWrapeesFromWDS(ctx, binding)
-	if err != nil {
-		return nil, nil, nil, grs, fmt.Errorf("failed to get objects to propagate to WECs from Binding object '%s' - %w", binding.GetName(), err)
-	}
+// customizedBinding is what a Binding calls for, before wrapping: the workload objects for each destination,
+// along with the user errors and the state of the rollout.
+// Both propagation and Preview compute this, by customizeBinding.
+type customizedBinding struct {
+	// wrapees are the workload objects after destination-independent transformation.
+	wrapees []WrapeeWithUID
+
+	// destToCustomizedWrapees maps each destination to its customized workload objects.
+	// This is nil if no object needed customization, in which case every destination gets `wrapees`.
+	destToCustomizedWrapees map[v1alpha1.Destination][]WrapeeWithUID
 
-	if len(wrapeesToPropagate) == 0 {
-		return nil, nil, nil, grs, nil // if no objects were found in the workload section, return nil so that we don't distribute an empty wrapped object.
+	// kindToResource maps every GroupKind appearing in the workload objects to the corresponding "resource".
+	kindToResource func(schema.GroupKind) (string, bool)
+
+	// groupResources is the set of GroupResource that appear among the workload objects.
+	groupResources sets.Set[metav1.GroupResource]
+
+	// replicaAssignments is the division of replicas among destinations,
+	// for the workload objects whose replicas were divided.
+	replicaAssignments []v1alpha1.ReplicaAssignment
+
+	// rollout is the status of the Binding's rollout, nil if it has no rollout strategy.
+	rollout *v1alpha1.RolloutStatus
+
+	// heldDestinations are the destinations in later waves of the rollout,
+	// which keep their current wrapped objects.
+	heldDestinations sets.Set[v1alpha1.Destination]
+
+	// bindingErrors are the user errors found in the Binding.
+	// When there are any, nothing is propagated from the Binding.
+	bindingErrors []string
+}
+
+// wrapeesFor returns the workload objects for the given destination.
+func (cb *customizedBinding) wrapeesFor(destination v1alpha1.Destination) []WrapeeWithUID {
+	if cb.destToCustomizedWrapees != nil {
+		return cb.destToCustomizedWrapees[destination]
 	}
+	return cb.wrapees
+}
 
-	destToCustomizedObjects, bindingErrors := c.computeDestToCustomizedObjects(wrapeesToPropagate, binding)
-	// This will be constant if no object needed customization, otherwise a map's get func
-	var destToTasks func(v1alpha1.Destination) ([]transportTask, bool)
+// customizeBinding reads the workload objects of the given Binding from the WDS, transforms and customizes them,
+// and collects all the user errors in the Binding and the state of its rollout.
+// The returned error reports something transient that went wrong.
+func (c *genericTransportController) customizeBinding(ctx context.Context, binding *v1alpha1.Binding) (*customizedBinding, error) {
+	wrapees, kindToResource, grs, err := c.getWrapeesFromWDS(ctx, binding)
+	if err != nil {
+		return nil, err
+	}
+	customized := &customizedBinding{wrapees: wrapees, kindToResource: kindToResource, groupResources: grs}
+	if len(wrapees) != 0 {
+		customized.destToCustomizedWrapees, customized.replicaAssignments, customized.bindingErrors = c.computeDestToCustomizedObjects(ctx, wrapees, kindToResource, binding)
+		customized.bindingErrors = append(customized.bindingErrors, syncWaveErrors(wrapees)...)
+		customized.bindingErrors = append(customized.bindingErrors, deletionPolicyErrors(wrapees)...)
+		customized.bindingErrors = append(customized.bindingErrors, adoptionPolicyErrors(wrapees)...)
+		if checker, canCheck := c.transport.(transport.WrapeeChecker); canCheck {
+			for _, wrapee := range wrapees {
+				if err := checker.CheckWrapee(wrapee.Wrapee); err != nil {
+					customized.bindingErrors = append(customized.bindingErrors, err.Error())
+				}
+			}
+		}
+	}
+	var rolloutErrors []string
+	customized.rollout, customized.heldDestinations, rolloutErrors = c.computeRollout(binding)
+	customized.bindingErrors = append(customized.bindingErrors, rolloutErrors...)
+	return customized, nil
+}
 
-	if destToCustomizedObjects != nil {
+// computeDestToWrappedObjects returns the destToWrappedObject function for the given customized Binding.
+// This maps a destination to the slice of transportTask for that destination.
+// This func also returns a `bool` that is false when the function has no answer for the given destination.
+// The function is nil if the Binding has no workload objects, so that we don't distribute an empty wrapped object.
+func (c *genericTransportController) computeDestToWrappedObjects(customized *customizedBinding, binding *v1alpha1.Binding) (
+	func(v1alpha1.Destination) ([]transportTask, bool), error) {
+	if len(customized.wrapees) == 0 {
+		return nil, nil
+	}
+	if customized.destToCustomizedWrapees != nil {
 		asMap := map[v1alpha1.Destination][]transportTask{}
-		for dest, objects := range destToCustomizedObjects {
-			wrappedObjects, err := c.wrap(objects, kindToResource, binding)
+		for dest, objects := range customized.destToCustomizedWrapees {
+			wrappedObjects, err := c.wrap(objects, customized.kindToResource, binding)
 			if err != nil {
-				return nil, nil, nil, grs, fmt.Errorf("failure wrapping for destination %q: %w", binding.Name, err)
+				return nil, fmt.Errorf("failure wrapping for destination %q: %w", binding.Name, err)
 			}
 			asMap[dest] = wrappedObjects
 		}
-		destToTasks = abstract.PrimitiveMapGet(asMap)
-	} else {
-		wrappedObjects, err := c.wrap(wrapeesToPropagate, kindToResource, binding)
-		if err != nil {
-			return nil, nil, nil, grs, fmt.Errorf("failed to convert wrapped object to unstructured - %w", err)
-		}
-		destToTasks = func(v1alpha1.Destination) ([]transportTask, bool) { return wrappedObjects, true }
+		return abstract.PrimitiveMapGet(asMap), nil
 	}
-
-	return destToTasks, kindToResource, bindingErrors, grs, nil
+	wrappedObjects, err := c.wrap(customized.wrapees, customized.kindToResource, binding)
+	if err != nil {
+		return nil, fmt.Errorf("failed to convert wrapped object to unstructured - %w", err)
+	}
+	return func(v1alpha1.Destination) ([]transportTask, bool) { return wrappedObjects, true }, nil
 }
 
-// computeDestToCustomizedObjects returns the following two things.
+// computeDestToCustomizedObjects returns the following three things.
 //   - a map from destination to slice of customized workload objects.
 //     This map will be nil if customization is not needed for the given slice of objects.
+//   - the division of replicas among destinations, for the workload objects whose replicas were divided.
 //   - the slice of strings containing the user errors found in the given Binding.
 //
 // This func also updates c.bindingSensitiveDestinations for the given Binding.
 // The input Wrapees have been subject to destination-independent transformation.
-func (c *genericTransportController) computeDestToCustomizedObjects(uncustomizedWrapees []WrapeeWithUID, binding *v1alpha1.Binding) (map[v1alpha1.Destination][]WrapeeWithUID, []string) {
+// The customization here consists of applying the CustomTransforms in the BeforeCustomization phase
+// that have a clusterSelector, then template expansion, then applying the CustomTransforms
+// in the AfterCustomization phase, then setting `spec.replicas` if the Binding calls for
+// dividing replicas among the destinations.
+func (c *genericTransportController) computeDestToCustomizedObjects(ctx context.Context, uncustomizedWrapees []WrapeeWithUID, kindToResource func(schema.GroupKind) (string, bool), binding *v1alpha1.Binding) (map[v1alpha1.Destination][]WrapeeWithUID, []v1alpha1.ReplicaAssignment, []string) {
 	// This will become non-nil if any object to propagate needs customization
 	var destToCustomizedWrapees map[v1alpha1.Destination][]WrapeeWithUID
+	var replicaAssignments []v1alpha1.ReplicaAssignment
 
 	bindingErrors := []string{}
 
+	// This will be non-nil if the Binding calls for dividing replicas among destinations
+	replicaWeights, weightErrors := c.getReplicaWeights(binding)
+	bindingErrors = append(bindingErrors, weightErrors...)
+
 	// Look through the objects to propagate to see if any needs customization.
 	// If any needs customization then catch up destToCustomizedObjects and proceed from there.
 	for objIdx, wrapee := range uncustomizedWrapees {
 		objToPropagate := wrapee.Object
 		objAnnotations := objToPropagate.GetAnnotations()
-		objRequestsExpansion := objAnnotations[v1alpha1.TemplateExpansionAnnotationKey] == "true"
+		expansionMode := objAnnotations[v1alpha1.TemplateExpansionAnnotationKey]
+		objRequestsExpansion := expansionMode == "true" || expansionMode == v1alpha1.TemplateExpansionStructured
+		expandToStructures := expansionMode == v1alpha1.TemplateExpansionStructured
 		customizeThisObject := false
 		reportedSomeErrors := false
 		objRefStr := util.RefToRuntimeObj(objToPropagate).String()
+		groupKind := objToPropagate.GroupVersionKind().GroupKind()
+		resource, _ := kindToResource(groupKind)
+		transforms := c.customTransformCollection.getCustomTransformChanges(ctx, metav1.GroupResource{Group: groupKind.Group, Resource: resource}, binding.Name)
+		var destToReplicas map[v1alpha1.Destination]int64
+		if total, ok := getReplicas(objToPropagate); ok && replicaWeights != nil {
+			destToReplicas = splitReplicas(total, replicaWeights)
+			assignment := v1alpha1.ReplicaAssignment{
+				GroupResource: metav1.GroupResource{Group: groupKind.Group, Resource: resource},
+				Namespace:     objToPropagate.GetNamespace(),
+				Name:          objToPropagate.GetName(),
+				Replicas:      make(map[string]int32, len(destToReplicas)),
+			}
+			for dest, replicas := range destToReplicas {
+				assignment.Replicas[dest.ClusterId] = int32(replicas)
+			}
+			replicaAssignments = append(replicaAssignments, assignment)
+		}
 		for destIdx, dest := range binding.Spec.Destinations {
-			objC := objToPropagate
+			objD := objToPropagate
+			var destLabels labels.Set
+			if transforms.destinationDependent() {
+				destLabels = c.getLabelsForDestination(binding.Name, dest)
+			}
+			if changes := transforms.forDestination(destLabels); !changes.isEmpty() {
+				objD = applyCustomTransformChanges(objToPropagate.DeepCopy(), changes)
+			}
+			objC := objD
 			var customizationErrors []string
 			if objRequestsExpansion && (destIdx == 0 || customizeThisObject) {
 				defs := c.getPropertiesForDestination(binding.Name, dest)
 				// customizeThisObject does not vary with destination, for a given objToPropagate
-				objC, customizationErrors, customizeThisObject = c.customizeForDestination(objToPropagate, dest.ClusterId+"/"+objRefStr, defs)
+				objC, customizationErrors, customizeThisObject = c.customizeForDestination(objD, dest.ClusterId+"/"+objRefStr, defs, expandToStructures)
 				if len(customizationErrors) != 0 && !reportedSomeErrors {
 					// Let's not overwhelm the user, only report errors from the first troubled destination
 					reportedSomeErrors = true
 					bindingErrors = append(bindingErrors, customizationErrors...)
 				}
 				if !customizeThisObject {
-					objC = objToPropagate
+					objC = objD
+				}
+			}
+			if len(transforms.afterCustomization) > 0 {
+				defs := c.getPropertiesForDestination(binding.Name, dest)
+				changes, transformErrors := transforms.afterCustomizationForDestination(destLabels, dest.ClusterId+"/"+objRefStr, defs)
+				if len(transformErrors) != 0 && !reportedSomeErrors {
+					reportedSomeErrors = true
+					bindingErrors = append(bindingErrors, transformErrors...)
+				}
+				if !changes.isEmpty() {
+					if objC == objToPropagate {
+						objC = objC.DeepCopy()
+					}
+					objC = applyCustomTransformChanges(objC, changes)
 				}
 			}
-			if customizeThisObject && destToCustomizedWrapees == nil {
+			if destToReplicas != nil {
+				if objC == objToPropagate {
+					objC = objC.DeepCopy()
+				}
+				_ = unstructured.SetNestedField(objC.Object, destToReplicas[dest], "spec", "replicas")
+			}
+			if (customizeThisObject || transforms.destinationDependent() || destToReplicas != nil) && destToCustomizedWrapees == nil {
 				destToCustomizedWrapees = map[v1alpha1.Destination][]WrapeeWithUID{}
 				for _, dest := range binding.Spec.Destinations {
 					destToCustomizedWrapees[dest] = abstract.SliceCopy(uncustomizedWrapees[:objIdx])
@@ -854,7 +1141,9 @@ func (c *genericTransportController) computeDestToCustomizedObjects(uncustomized
 			}
 			if destToCustomizedWrapees != nil {
 				customizedObjectsSoFar := destToCustomizedWrapees[dest]
-				customizedObjectsSoFar = append(customizedObjectsSoFar, WrapeeWithUID{transport.NewWrapee(objC, wrapee.CreateOnly), wrapee.UID})
+				customizedWrapee := wrapee.Wrapee
+				customizedWrapee.Object = objC
+				customizedObjectsSoFar = append(customizedObjectsSoFar, WrapeeWithUID{customizedWrapee, wrapee.UID})
 				destToCustomizedWrapees[dest] = customizedObjectsSoFar
 			}
 		}
@@ -868,12 +1157,13 @@ func (c *genericTransportController) computeDestToCustomizedObjects(uncustomized
 	}
 	c.setBindingSensitivities(binding.Name, cares) // forget about now-irrelevant destinations
 
-	return destToCustomizedWrapees, bindingErrors
+	return destToCustomizedWrapees, replicaAssignments, bindingErrors
 }
 
 // wrapBatch invokes the transport's WrapObjects.
 // uidToPropagate is the UID (in the WDS) of one of the objects in batchToPropagate.
-func (c *genericTransportController) wrapBatch(batchToPropagate []transport.Wrapee, uidToPropagate string, kindToResource func(schema.GroupKind) (string, bool), binding *v1alpha1.Binding, numShard int) (*unstructured.Unstructured, error) {
+// shardName distinguishes the wrapped object among those of the binding when there can be several objects in one.
+func (c *genericTransportController) wrapBatch(batchToPropagate []transport.Wrapee, uidToPropagate string, kindToResource func(schema.GroupKind) (string, bool), binding *v1alpha1.Binding, shardName string) (*unstructured.Unstructured, error) {
 	wrapped := c.transport.WrapObjects(batchToPropagate, abstract.DropOK11(kindToResource))
 	wrappedObject, err := convertObjectToUnstructured(wrapped)
 	if err != nil {
@@ -884,11 +1174,11 @@ func (c *genericTransportController) wrapBatch(batchToPropagate []transport.Wrap
 		// Make the name a function of the content, to get stability.
 		wrapperName = fmt.Sprintf("%s-%s-%s", binding.UID, c.wdsName, uidToPropagate)
 	} else {
-		// wrapped object name is (Binding.GetName()-WdsName-numShard).
+		// wrapped object name is (Binding.GetName()-WdsName-shardName).
 		// pay attention - we cannot use the Binding object name, cause we might have duplicate names coming from different WDS spaces.
 		// we add WdsName to the object name to assure name uniqueness,
 		// in order to easily get the origin Binding object name and wds, we add it as an annotations.
-		wrapperName = fmt.Sprintf("%s-%s-%d", binding.GetName(), c.wdsName, numShard)
+		wrapperName = fmt.Sprintf("%s-%s-%s", binding.GetName(), c.wdsName, shardName)
 	}
 	wrappedObject.SetName(wrapperName)
 	setLabel(wrappedObject, originOwnerReferenceLabel, binding.GetName())
@@ -907,6 +1197,8 @@ type WrapeeWithUID struct {
 type transportTask struct {
 	ObjU  *unstructured.Unstructured
 	Gloss transport.Gloss
+	// Wave is the sync wave of all the workload objects in this wrapped object
+	Wave int
 }
 
 func (c *genericTransportController) wrap(wrapeesToPropagate []WrapeeWithUID, kindToResource func(schema.GroupKind) (string, bool), binding *v1alpha1.Binding) ([]transportTask, error) {
@@ -919,7 +1211,19 @@ func (c *genericTransportController) wrap(wrapeesToPropagate []WrapeeWithUID, ki
 	numShard := 0
 	var batchSize int = 0
 	var batchCount int = 0
-	for _, wrapee := range wrapeesToPropagate {
+	// A wrapped object holds objects of only one sync wave, and the wrapped objects are in order of wave.
+	// Unless every object is in wave 0, the shards are numbered within each wave
+	// so that adding or removing objects in one wave does not rename the wrapped objects of another.
+	wrapeesToPropagate, waves := sortBySyncWave(wrapeesToPropagate)
+	usesWaves := slices.ContainsFunc(waves, func(wave int) bool { return wave != 0 })
+	shardName := func(wave int) string {
+		if usesWaves {
+			return fmt.Sprintf("wave%d-%d", wave, numShard)
+		}
+		return strconv.Itoa(numShard)
+	}
+	var batchWave int
+	for idx, wrapee := range wrapeesToPropagate {
 		bytes, err := wrapee.Object.MarshalJSON()
 		if err != nil {
 			return nil, err
@@ -928,30 +1232,35 @@ func (c *genericTransportController) wrap(wrapeesToPropagate []WrapeeWithUID, ki
 		if objSize > maxSize {
 			return nil, fmt.Errorf("failed to wrap object that is larger than max size")
 		}
-		if (objSize+batchSize >= maxSize) || (batchCount+1 > maxCount) {
-			wrappedObject, err := c.wrapBatch(batchToPropagate, uidToPropagate, kindToResource, binding, numShard)
+		if batchToPropagate != nil && ((objSize+batchSize >= maxSize) || (batchCount+1 > maxCount) || waves[idx] != batchWave) {
+			wrappedObject, err := c.wrapBatch(batchToPropagate, uidToPropagate, kindToResource, binding, shardName(batchWave))
 			if err != nil {
 				return nil, err
 			}
-			numShard += 1
-			transportTasks = append(transportTasks, transportTask{wrappedObject, gloss})
+			if waves[idx] != batchWave {
+				numShard = 0
+			} else {
+				numShard += 1
+			}
+			transportTasks = append(transportTasks, transportTask{wrappedObject, gloss, batchWave})
 			batchToPropagate = nil
 			gloss = transport.Gloss{}
 			batchSize = 0
 			batchCount = 0
 		}
 		batchToPropagate = append(batchToPropagate, wrapee.Wrapee)
+		batchWave = waves[idx]
 		uidToPropagate = wrapee.UID
 		gloss.Insert(wrapee.GetID())
 		batchSize += objSize
 		batchCount += 1
 	}
 	if batchToPropagate != nil {
-		wrappedObject, err := c.wrapBatch(batchToPropagate, uidToPropagate, kindToResource, binding, numShard)
+		wrappedObject, err := c.wrapBatch(batchToPropagate, uidToPropagate, kindToResource, binding, shardName(batchWave))
 		if err != nil {
 			return nil, err
 		}
-		transportTasks = append(transportTasks, transportTask{wrappedObject, gloss})
+		transportTasks = append(transportTasks, transportTask{wrappedObject, gloss, batchWave})
 	}
 	return transportTasks, nil
 }
@@ -978,10 +1287,42 @@ func (c *genericTransportController) getPropertiesForDestination(bindingName str
 	return props
 }
 
+// getLabelsForDestination returns the labels of the inventory object for the given destination and notes
+// that the given binding is sensitive to the fact that the destination has those labels.
+func (c *genericTransportController) getLabelsForDestination(bindingName string, dest v1alpha1.Destination) labels.Set {
+	c.propsMutex.Lock()
+	defer c.propsMutex.Unlock()
+	dests := c.bindingSensitiveDestinations[bindingName]
+	if dests == nil {
+		dests = sets.New[v1alpha1.Destination](dest)
+		c.bindingSensitiveDestinations[bindingName] = dests
+	} else {
+		dests.Insert(dest)
+	}
+	destLabels, have := c.destinationLabels[dest]
+	if have {
+		return destLabels
+	}
+	destLabels = c.collectLabelsForDestination(c.logger.WithValues("forBinding", bindingName), dest.ClusterId)
+	c.destinationLabels[dest] = destLabels
+	return destLabels
+}
+
+// collectLabelsForDestination fetches the labels of the inventory object with the given name
+func (c *genericTransportController) collectLabelsForDestination(logger logr.Logger, invName string) labels.Set {
+	invObj, err := c.inventoryLister.Get(invName)
+	if err == nil && invObj != nil {
+		return invObj.Labels
+	} else if err != nil && !errors.IsNotFound(err) { // listers do not fail
+		logger.Error(err, "Inconceivable failure to fetch inventory object", "dest", invName)
+	}
+	return labels.Set{}
+}
+
 // collectPropertiesForDestination computes the properties for the given destination
 func (c *genericTransportController) collectPropertiesForDestination(logger logr.Logger, invName string) clusterProperties {
 	props := clusterProperties{"clusterName": invName}
-	collectProperty := func(key, val string) bool {
+	collectProperty := func(key string, val any) bool {
 		props[key] = val
 		return true
 	}
@@ -994,19 +1335,39 @@ func (c *genericTransportController) collectPropertiesForDestination(logger logr
 	}
 	propCfgMap, err := c.propCfgMapLister.Get(invName)
 	if err == nil && propCfgMap != nil {
-		enumeratePropsInConfigMap(propCfgMap)(collectProperty)
+		enumeratePropsInConfigMap(logger, propCfgMap)(collectProperty)
 	} else if err != nil && !errors.IsNotFound(err) { // listers do not fail
 		logger.Error(err, "Inconceivable failure to fetch property ConfigMap", "dest", invName)
 	}
 	return props
 }
 
-func enumeratePropsInConfigMap(propCfgMap *corev1.ConfigMap) func(yield func(key, val string) bool) {
-	return func(yield func(key, val string) bool) {
+// enumeratePropsInConfigMap enumerates the properties defined by the given ConfigMap.
+// If the ConfigMap has the annotation that says its data are JSON then each string data item
+// that parses as JSON defines a property whose value is the unmarshaled JSON
+// (with integers represented as int64); otherwise a data item defines a string-valued property.
+func enumeratePropsInConfigMap(logger logr.Logger, propCfgMap *corev1.ConfigMap) func(yield func(key string, val any) bool) {
+	return func(yield func(key string, val any) bool) {
 		if propCfgMap == nil {
 			return
 		}
-		enumeratePropertiesInMapStringToString(propCfgMap.Data)(yield)
+		if propCfgMap.Annotations[v1alpha1.PropertyConfigMapJSONAnnotationKey] == "true" {
+			for key, val := range propCfgMap.Data {
+				if !token.IsIdentifier(key) {
+					continue
+				}
+				var parsed any
+				if err := json.Unmarshal([]byte(val), &parsed); err != nil {
+					logger.V(2).Info("Property ConfigMap data item is not valid JSON, using it as a string", "configMap", propCfgMap.Name, "key", key, "err", err)
+					parsed = val
+				}
+				if !yield(key, parsed) {
+					return
+				}
+			}
+		} else {
+			enumeratePropertiesInMapStringToString(propCfgMap.Data)(yield)
+		}
 		for key, val := range propCfgMap.BinaryData {
 			if token.IsIdentifier(key) && !yield(key, string(val)) {
 				return
@@ -1015,8 +1376,8 @@ func enumeratePropsInConfigMap(propCfgMap *corev1.ConfigMap) func(yield func(key
 	}
 }
 
-func enumeratePropertiesInMapStringToString(theMap map[string]string) func(yield func(key, val string) bool) {
-	return func(yield func(key, val string) bool) {
+func enumeratePropertiesInMapStringToString(theMap map[string]string) func(yield func(key string, val any) bool) {
+	return func(yield func(key string, val any) bool) {
 		for key, val := range theMap {
 			if token.IsIdentifier(key) && !yield(key, val) {
 				return
@@ -1046,10 +1407,15 @@ func (c *genericTransportController) handlePropertiesEvent(triggerObj interface{
 // customizeForDestination customizes the given object for the given destination,
 // if any customization is called for. The returned boolean indicates whether
 // any customization was called for.
-func (c *genericTransportController) customizeForDestination(object *unstructured.Unstructured, destination string, properties clusterProperties) (*unstructured.Unstructured, []string, bool) {
+// `toStructures` selects customize.ExpandTemplatesToStructures rather than customize.ExpandTemplates.
+func (c *genericTransportController) customizeForDestination(object *unstructured.Unstructured, destination string, properties clusterProperties, toStructures bool) (*unstructured.Unstructured, []string, bool) {
 	objectCopy := object.DeepCopy()
 	objectData := objectCopy.UnstructuredContent()
-	objectDataExpanded, wantedChange, errs := customize.ExpandTemplates(destination, objectData, properties)
+	expand := customize.ExpandTemplates
+	if toStructures {
+		expand = customize.ExpandTemplatesToStructures
+	}
+	objectDataExpanded, wantedChange, errs := expand(destination, objectData, properties)
 	if wantedChange {
 		objectData = objectDataExpanded.(map[string]any)
 		objectCopy.SetUnstructuredContent(objectData)
@@ -1072,11 +1438,24 @@ func (c *genericTransportController) propagateWrappedObjectToClusters(ctx contex
 	logger := klog.FromContext(ctx)
 	logger.V(5).Info("In propagateWrappedObjectToClusters", "destinations", destinations)
 
+	appliedChecker, canCheckApplied := c.transport.(transport.AppliedChecker)
 	for _, destination := range destinations {
 		tasks, _ := destToDesiredWrappedObjects(destination)
+		// The tasks are in order of sync wave. Once some wrapped object is not known to be applied,
+		// the wrapped objects of later waves are left as they are.
+		pendingWave, havePending := 0, false
+		notePending := func(wave int) {
+			if canCheckApplied && !havePending {
+				pendingWave, havePending = wave, true
+			}
+		}
 		for _, task := range tasks {
 			wrappedID := klog.ObjectRef{Namespace: destination.ClusterId, Name: task.ObjU.GetName()}
 			currentWrappedObject := popUnstructuredByID(currentWrappedObjectList, wrappedID)
+			if havePending && task.Wave > pendingWave {
+				logger.V(5).Info("Holding wrapped object until the earlier sync waves are applied", "id", wrappedID, "wave", task.Wave, "pendingWave", pendingWave)
+				continue
+			}
 			if currentWrappedObject == nil {
 				logger.V(5).Info("No current wrapped object has sought ID", "id", wrappedID, "currentWrappedObjectList", currentWrappedObjectList)
 			} else {
@@ -1086,13 +1465,16 @@ func (c *genericTransportController) propagateWrappedObjectToClusters(ctx contex
 				}
 				desiredGeneration := task.ObjU.GetAnnotations()[originOwnerGenerationAnnotation]
 				actualGeneration := currentWrappedObject.GetAnnotations()[originOwnerGenerationAnnotation]
-				// This test covers workload object ResourceVersion and the create-only bit.
+				// This test covers workload object ResourceVersion, the create-only bit and the deletion policy.
 				// This test is also an imperfect test for consistency in customization.
 				// It does not take into account the effects of absence of, or changes in, CustomTransform objects.
 				generationMatch := actualGeneration == desiredGeneration
 				glossEqual := abstract.PrimitiveMapEqual(task.Gloss, gloss)
 				if generationMatch && glossEqual {
 					logger.V(5).Info("No need to change wrapped object", "id", wrappedID)
+					if canCheckApplied && !appliedChecker.IsApplied(currentWrappedObject) {
+						notePending(task.Wave)
+					}
 					continue
 				}
 				if glossEqual {
@@ -1104,6 +1486,7 @@ func (c *genericTransportController) propagateWrappedObjectToClusters(ctx contex
 			if err := c.createOrUpdateWrappedObject(ctx, destination.ClusterId, task.ObjU); err != nil {
 				return fmt.Errorf("failed to propagate wrapped object to cluster mailbox namespace '%s' - %w", destination.ClusterId, err)
 			}
+			notePending(task.Wave)
 		}
 	}
 
@@ -1275,7 +1658,8 @@ func setLabel(object metav1.Object, key string, value any) {
 // There are three sorts of content removal done here:
 // 1. Removal that is common for all API objects;
 // 2. Removal that is specific to a Kind of object and fixed in KubeStellar code;
-// 3. Removal that is specific to a Kind of object and configured by API object(s).
+// 3. Changes that are specific to a Kind of object and configured by API object(s)
+// that do not have a clusterSelector.
 func TransformObject(ctx context.Context, ctc customTransformCollection, groupResource metav1.GroupResource, object *unstructured.Unstructured, bindingName string) *unstructured.Unstructured {
 	objectCopy := object.DeepCopy() // don't modify object directly. create a copy before zeroing fields
 	objectCopy.SetManagedFields(nil)
@@ -1297,20 +1681,57 @@ func TransformObject(ctx context.Context, ctc customTransformCollection, groupRe
 	// clean fields specific to the concrete object.
 	objectsFilter.CleanObjectSpecifics(objectCopy)
 
-	customChanges := ctc.getCustomTransformChanges(ctx, groupResource, bindingName)
+	customChanges := ctc.getCustomTransformChanges(ctx, groupResource, bindingName).common
+	return applyCustomTransformChanges(objectCopy, customChanges)
+}
 
-	if len(customChanges.removes) > 0 {
+// applyCustomTransformChanges applies the given changes to the given object,
+// which may be side-effected, and returns the result.
+func applyCustomTransformChanges(objectCopy *unstructured.Unstructured, customChanges customTransformChanges) *unstructured.Unstructured {
+	if len(customChanges.removes) > 0 || len(customChanges.sets) > 0 {
 		objectData := objectCopy.UnstructuredContent()
 		var objectDataAny any = objectData
 		rootNode := jsonpath.RootNode{Value: &objectDataAny}
 		for _, query := range customChanges.removes {
-			jsonpath.QueryValue(query, &rootNode, jsonpath.Node.Remove)
+			jsonpath.RemoveAll(query, &rootNode)
+		}
+		for _, set := range customChanges.sets {
+			jsonpath.SetAll(set.query, &rootNode, func() jsonpath.JSONValue { return runtime.DeepCopyJSONValue(set.value) })
+		}
+		objectCopy.SetUnstructuredContent(objectData)
+	}
+	if len(customChanges.mergePatches) > 0 {
+		objectData := objectCopy.UnstructuredContent()
+		for _, patch := range customChanges.mergePatches {
+			objectData = applyMergePatch(objectData, patch).(map[string]any)
 		}
 		objectCopy.SetUnstructuredContent(objectData)
 	}
 	return objectCopy
 }
 
+// applyMergePatch applies a JSON merge patch (RFC 7386) to the given target,
+// which may be side-effected, and returns the result.
+// The patch is not modified.
+func applyMergePatch(target any, patch any) any {
+	patchObj, isObj := patch.(map[string]any)
+	if !isObj {
+		return runtime.DeepCopyJSONValue(patch)
+	}
+	targetObj, isObj := target.(map[string]any)
+	if !isObj {
+		targetObj = map[string]any{}
+	}
+	for key, patchVal := range patchObj {
+		if patchVal == nil {
+			delete(targetObj, key)
+		} else {
+			targetObj[key] = applyMergePatch(targetObj[key], patchVal)
+		}
+	}
+	return targetObj
+}
+
 func customTransformToDomain(obj any) ([]string, error) {
 	ct := obj.(*v1alpha1.CustomTransform)
 	return []string{customTransformDomainKey(ct.Spec.APIGroup, ct.Spec.Resource)}, nil
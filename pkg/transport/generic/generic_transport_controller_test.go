@@ -34,11 +34,14 @@ import (
 	k8score "k8s.io/api/core/v1"
 	k8snetv1 "k8s.io/api/networking/v1"
 	rbacv1 "k8s.io/api/rbac/v1"
+	apiextensionsv1 "k8s.io/apiextensions-apiserver/pkg/apis/apiextensions/v1"
 	apiequality "k8s.io/apimachinery/pkg/api/equality"
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/labels"
 	"k8s.io/apimachinery/pkg/runtime"
 	k8sschema "k8s.io/apimachinery/pkg/runtime/schema"
+	"k8s.io/apimachinery/pkg/util/sets"
 	"k8s.io/apimachinery/pkg/util/wait"
 	dynamicfake "k8s.io/client-go/dynamic/fake"
 	k8sinformers "k8s.io/client-go/informers"
@@ -173,18 +176,17 @@ type bindingCase struct {
 	ExpectedKeys []any // JSON equivalent of keys of expect, for logging
 }
 
-func newClusterScope(gvr metav1.GroupVersionResource, name, resourceVersion string, createOnly bool) ksapi.ClusterScopeDownsyncClause {
+func newClusterScope(gvr metav1.GroupVersionResource, name, resourceVersion string, modulation ksapi.DownsyncModulation) ksapi.ClusterScopeDownsyncClause {
 	return ksapi.ClusterScopeDownsyncClause{
 		ClusterScopeDownsyncObject: ksapi.ClusterScopeDownsyncObject{
 			GroupVersionResource: gvr,
 			Name:                 name,
 			ResourceVersion:      resourceVersion,
 		},
-		DownsyncModulation: ksapi.DownsyncModulation{
-			CreateOnly: createOnly}}
+		DownsyncModulation: modulation}
 }
 
-func newNamespaceScope(gvr metav1.GroupVersionResource, namespace, name, resourceVersion string, createOnly bool) ksapi.NamespaceScopeDownsyncClause {
+func newNamespaceScope(gvr metav1.GroupVersionResource, namespace, name, resourceVersion string, modulation ksapi.DownsyncModulation) ksapi.NamespaceScopeDownsyncClause {
 	return ksapi.NamespaceScopeDownsyncClause{
 		NamespaceScopeDownsyncObject: ksapi.NamespaceScopeDownsyncObject{
 			GroupVersionResource: gvr,
@@ -192,11 +194,10 @@ func newNamespaceScope(gvr metav1.GroupVersionResource, namespace, name, resourc
 			Name:                 name,
 			ResourceVersion:      resourceVersion,
 		},
-		DownsyncModulation: ksapi.DownsyncModulation{
-			CreateOnly: createOnly}}
+		DownsyncModulation: modulation}
 }
 
-func (bc *bindingCase) Add(obj mrObjRsc, createOnly bool) {
+func (bc *bindingCase) Add(obj mrObjRsc, modulation ksapi.DownsyncModulation, orphan bool) {
 	key := util.RefToRuntimeObj(obj.MRObject)
 	gvr := metav1.GroupVersionResource{
 		Group:    key.GK.Group,
@@ -212,14 +213,18 @@ func (bc *bindingCase) Add(obj mrObjRsc, createOnly bool) {
 	}
 
 	if objNS == "" {
-		clusterObj := newClusterScope(gvr, objName, objRV, createOnly)
+		clusterObj := newClusterScope(gvr, objName, objRV, modulation)
 		bc.Binding.Spec.Workload.ClusterScope = append(bc.Binding.Spec.Workload.ClusterScope, clusterObj)
 	} else {
-		namespaceObj := newNamespaceScope(gvr, objNS, objName, objRV, createOnly)
+		namespaceObj := newNamespaceScope(gvr, objNS, objName, objRV, modulation)
 		bc.Binding.Spec.Workload.NamespaceScope = append(bc.Binding.Spec.Workload.NamespaceScope, namespaceObj)
 	}
 
-	bc.expect[key] = jsonMapToWrap{jm, createOnly}
+	expectedDriftPolicy := modulation.DriftPolicy
+	if expectedDriftPolicy == ksapi.DriftPolicyIgnore {
+		expectedDriftPolicy = ""
+	}
+	bc.expect[key] = jsonMapToWrap{jm, modulation.EffectiveUpdateStrategy(), orphan, modulation.AdoptionPolicy == ksapi.AdoptionPolicyReport, expectedDriftPolicy}
 	bc.ExpectedKeys = append(bc.ExpectedKeys, key.String())
 }
 
@@ -234,9 +239,23 @@ func (rg *generator) generateBindingCase(name string, objs []mrObjRsc) bindingCa
 	}
 	for _, obj := range objs {
 		if rg.Intn(10) < 7 {
-			createOnly := rg.Intn(2) == 0
-			klog.FromContext(rg.ctx).V(3).Info("Adding to bindingCase", "case", name, "obj", util.RefToRuntimeObj(obj.MRObject), "createOnly", createOnly)
-			bc.Add(obj, createOnly)
+			updateStrategy := []ksapi.UpdateStrategy{"", ksapi.UpdateStrategyUpdate, ksapi.UpdateStrategyCreateOnly,
+				ksapi.UpdateStrategyServerSideApply, ksapi.UpdateStrategyReadOnly}[rg.Intn(5)]
+			deletionPolicy := []ksapi.DeletionPolicy{"", ksapi.DeletionPolicyDelete, ksapi.DeletionPolicyOrphan}[rg.Intn(3)]
+			orphan := deletionPolicy == ksapi.DeletionPolicyOrphan
+			if rg.Intn(4) == 0 { // the annotation overrides the clause
+				orphan = rg.Intn(2) == 0
+				annotation := ksapi.DeletionPolicyDelete
+				if orphan {
+					annotation = ksapi.DeletionPolicyOrphan
+				}
+				obj.MRObject.SetAnnotations(map[string]string{ksapi.DeletionPolicyAnnotationKey: string(annotation)})
+			}
+			modulation := ksapi.DownsyncModulation{UpdateStrategy: updateStrategy, DeletionPolicy: deletionPolicy,
+				AdoptionPolicy: []ksapi.AdoptionPolicy{"", ksapi.AdoptionPolicyAdopt, ksapi.AdoptionPolicyReport}[rg.Intn(3)],
+				DriftPolicy:    []ksapi.DriftPolicy{"", ksapi.DriftPolicyIgnore, ksapi.DriftPolicyReport, ksapi.DriftPolicyRemediate}[rg.Intn(4)]}
+			klog.FromContext(rg.ctx).V(3).Info("Adding to bindingCase", "case", name, "obj", util.RefToRuntimeObj(obj.MRObject), "modulation", modulation, "orphan", orphan)
+			bc.Add(obj, modulation, orphan)
 		}
 	}
 	return bc
@@ -244,8 +263,11 @@ func (rg *generator) generateBindingCase(name string, objs []mrObjRsc) bindingCa
 
 type jsonMap = map[string]any
 type jsonMapToWrap struct {
-	jm         jsonMap
-	createOnly bool
+	jm             jsonMap
+	updateStrategy ksapi.UpdateStrategy
+	orphan         bool
+	reportAdoption bool
+	driftPolicy    ksapi.DriftPolicy
 }
 
 type testTransport struct {
@@ -266,21 +288,32 @@ type testTransport struct {
 func (tt *testTransport) WrapObjects(wrapees []transport.Wrapee, kindToResource func(k8sschema.GroupKind) string) runtime.Object {
 	tt.Lock()
 	defer tt.Unlock()
-	tt.wrapped = true
-	tt.missed = map[string]any{}
-	for key, val := range tt.expect {
-		tt.missed[key.String()] = fmt.Sprintf("%#v", val)
+	// The objects are spread over multiple calls, one or more per sync wave.
+	if !tt.wrapped {
+		tt.missed = map[string]any{}
+		for key, val := range tt.expect {
+			tt.missed[key.String()] = fmt.Sprintf("%#v", val)
+		}
+		tt.wrong = map[string]any{}
+		tt.extra = []any{}
 	}
-	tt.wrong = map[string]any{}
-	tt.extra = []any{}
+	tt.wrapped = true
 	for _, wrapee := range wrapees {
 		obj := wrapee.Object
-		// TODO: test wrapee.CreateOnly
 		key := util.RefToRuntimeObj(obj)
 		delete(tt.missed, key.String())
 		if expectedJMTW, found := tt.expect[key]; found {
-			if wrapee.CreateOnly != expectedJMTW.createOnly {
-				tt.t.Errorf("Expected createOnly=%v, got %v obj=%v", expectedJMTW.createOnly, wrapee.CreateOnly, key)
+			if wrapee.UpdateStrategy != expectedJMTW.updateStrategy {
+				tt.t.Errorf("Expected updateStrategy=%v, got %v obj=%v", expectedJMTW.updateStrategy, wrapee.UpdateStrategy, key)
+			}
+			if wrapee.ReportAdoption != expectedJMTW.reportAdoption {
+				tt.t.Errorf("Expected reportAdoption=%v, got %v obj=%v", expectedJMTW.reportAdoption, wrapee.ReportAdoption, key)
+			}
+			if wrapee.DriftPolicy != expectedJMTW.driftPolicy {
+				tt.t.Errorf("Expected driftPolicy=%q, got %q obj=%v", expectedJMTW.driftPolicy, wrapee.DriftPolicy, key)
+			}
+			if wrapee.Orphan != expectedJMTW.orphan {
+				tt.t.Errorf("Expected orphan=%v, got %v obj=%v", expectedJMTW.orphan, wrapee.Orphan, key)
 			}
 			objM := obj.UnstructuredContent()
 			apiVersion := obj.GetAPIVersion()
@@ -294,20 +327,26 @@ func (tt *testTransport) WrapObjects(wrapees []transport.Wrapee, kindToResource
 				panic(fmt.Errorf("No mapping for %v", groupKind))
 			}
 			groupResource := metav1.GroupResource{Group: groupKind.Group, Resource: resource}
-			// clean expected object since transport objects are cleaned
+			// clean expected object since transport objects are cleaned; the generic cleaning
+			// is done without custom transforms, whose effects are spelled out here.
 			uncleanedExpectedObj := &unstructured.Unstructured{Object: expectedJMTW.jm}
-			cleanedExpectedObjU := TransformObject(tt.ctx, tt.ctc, groupResource, uncleanedExpectedObj, tt.bindingName)
-			cleanedExpectedObj := cleanedExpectedObjU.Object
-			cleanable := obj.GetKind() == "ClusterRole"
-			hadLabel := uncleanedExpectedObj.GetLabels()["test.kubestellar.io/delete-me"] != ""
-			hasLabel := cleanedExpectedObjU.GetLabels()["test.kubestellar.io/delete-me"] != ""
-			expectRemoval := cleanable && hadLabel
-			actualRemoval := hadLabel && !hasLabel
-			if expectRemoval != actualRemoval {
-				tt.t.Errorf("Expected removal=%v, actual removal=%v, obj=%v", expectRemoval, actualRemoval, key)
-			} else if expectRemoval {
-				tt.t.Logf("Saw a removal, obj=%v", key)
+			cleanedExpectedObjU := TransformObject(tt.ctx, noCustomTransforms{}, groupResource, uncleanedExpectedObj, tt.bindingName)
+			if obj.GetKind() == "ClusterRole" {
+				objLabels := cleanedExpectedObjU.GetLabels()
+				if _, had := objLabels["test.kubestellar.io/delete-me"]; had {
+					tt.t.Logf("Expecting a removal, obj=%v", key)
+				}
+				delete(objLabels, "test.kubestellar.io/delete-me")
+				objLabels["test.kubestellar.io/set-me"] = "set"
+				cleanedExpectedObjU.SetLabels(objLabels)
+				annotations := cleanedExpectedObjU.GetAnnotations()
+				if annotations == nil {
+					annotations = map[string]string{}
+				}
+				annotations["test.kubestellar.io/patched"] = "yes"
+				cleanedExpectedObjU.SetAnnotations(annotations)
 			}
+			cleanedExpectedObj := cleanedExpectedObjU.Object
 			equal := apiequality.Semantic.DeepEqual(objM, cleanedExpectedObj)
 			if !equal {
 				tt.wrong[key.String()] = obj
@@ -329,6 +368,19 @@ func (tt *testTransport) UnwrapObjects(wrapped runtime.Object, kindToResource fu
 	return transport.Gloss{}, nil
 }
 
+// noCustomTransforms is a customTransformCollection in which there are no CustomTransforms.
+type noCustomTransforms struct{}
+
+func (noCustomTransforms) getCustomTransformChanges(ctx context.Context, groupResource metav1.GroupResource, bindingName string) boundCustomTransforms {
+	return boundCustomTransforms{}
+}
+
+func (noCustomTransforms) noteCustomTransform(ctx context.Context, name string, ct *ksapi.CustomTransform) {
+}
+
+func (noCustomTransforms) setBindingGroupResources(bindingName string, newGroupResources sets.Set[metav1.GroupResource]) {
+}
+
 func TestGenericController(t *testing.T) {
 	rg := rand.New(rand.NewSource(42))
 	rg.Uint64()
@@ -351,6 +403,10 @@ func TestGenericController(t *testing.T) {
 			APIGroup: rbacv1.SchemeGroupVersion.Group,
 			Resource: "clusterroles",
 			Remove:   []string{`$.metadata.labels["test.kubestellar.io/delete-me"]`},
+			Set: []ksapi.CustomTransformSet{{
+				Path:  `$.metadata.labels["test.kubestellar.io/set-me"]`,
+				Value: apiextensionsv1.JSON{Raw: []byte(`"set"`)}}},
+			MergePatch: &apiextensionsv1.JSON{Raw: []byte(`{"metadata": {"annotations": {"test.kubestellar.io/patched": "yes"}}}`)},
 		}}
 	wdsK8sObjs := []runtime.Object{}
 	for i := 0; i < 3; i++ {
@@ -382,6 +438,7 @@ func TestGenericController(t *testing.T) {
 		ctx:         ctx,
 		ctc: newCustomTransformCollection(wdsKsClientFake.ControlV1alpha1().CustomTransforms(),
 			ctIndexer.ByIndex,
+			func(string) (labels.Set, bool) { return nil, false },
 			func(any) {}),
 		kindToResource: map[metav1.GroupKind]string{
 			{Group: "", Kind: "ConfigMap"}:                                          "configmaps",
@@ -403,7 +460,8 @@ func TestGenericController(t *testing.T) {
 	itsClientMetrics := spacesClientMetrics.MetricsForSpace("its")
 	ctlr := NewTransportControllerForWrappedObjectGVR(ctx, wdsClientMetrics, itsClientMetrics,
 		inventoryPreInformer, wdsKsClientFake.ControlV1alpha1().Bindings(),
-		wdsControlInformers.Bindings(), wdsControlInformers.CustomTransforms(),
+		wdsControlInformers.Bindings(), wdsControlInformers.BindingPolicies(), wdsControlInformers.CustomTransforms(),
+		wdsControlInformers.CombinedStatuses(),
 		transport,
 		wdsKsClientFake,
 		wdsDynamicClient,
@@ -424,7 +482,7 @@ func TestGenericController(t *testing.T) {
 		if !transport.wrapped {
 			logger.Info("No wrapping done yet")
 		} else {
-			logger.Info("Last wrapping was bad", "missed", transport.missed, "wrong", transport.wrong, "extra", transport.extra)
+			logger.Info("Wrapping so far was bad", "missed", transport.missed, "wrong", transport.wrong, "extra", transport.extra)
 		}
 		return false, nil
 	})
@@ -0,0 +1,105 @@
+/*
+Copyright 2024 The KubeStellar Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package transport
+
+import (
+	"context"
+	"fmt"
+	"slices"
+
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/client-go/tools/cache"
+
+	"github.com/kubestellar/kubestellar/api/control/v1alpha1"
+	ksmetrics "github.com/kubestellar/kubestellar/pkg/metrics"
+)
+
+// PreviewResult is what a given destination would receive from a given Binding.
+type PreviewResult struct {
+	// Objects are the workload objects after transformation and customization.
+	Objects []*unstructured.Unstructured
+
+	// BindingErrors are the user errors that would be reported in the Binding's status.
+	// When there are any, nothing would be propagated from the Binding.
+	BindingErrors []string
+
+	// IsDestination tells whether the destination is currently among the Binding's destinations.
+	IsDestination bool
+
+	// Suspended tells whether the Binding is suspended, in which case nothing would be propagated
+	// from it until it is resumed.
+	Suspended bool
+
+	// Held tells whether the destination is in a later wave of the Binding's rollout,
+	// in which case it would keep what it currently has until its wave comes.
+	Held bool
+}
+
+// DisableStatusWrites makes this controller refrain from writing the status of CustomTransform objects.
+// This is meant for a controller that is used only for Preview; call it before starting the informers.
+func (c *genericTransportController) DisableStatusWrites() {
+	ctc := c.customTransformCollection.(*customTransformCollectionImpl)
+	ctc.client = statusDiscardingClient{ctc.client}
+}
+
+// Preview computes the workload objects that the given destination would receive from the named Binding,
+// by running the same transformation and customization that propagation does, without writing anything
+// to the WDS (provided DisableStatusWrites has been called) or the ITS.
+// The destination need not currently be among the Binding's destinations.
+// This is meant for a controller that is not Run; the caller must start the informers
+// (including the CombinedStatus one, which a rollout is judged from),
+// and this method waits for them to sync.
+func (c *genericTransportController) Preview(ctx context.Context, bindingName string, destination v1alpha1.Destination) (*PreviewResult, error) {
+	if ok := cache.WaitForCacheSync(ctx.Done(), c.inventoryInformerSynced, c.bindingInformerSynced, c.bindingPolicyInformerSynced, c.propCfgMapInformerSynced, c.customTransformInformerSynced, c.combinedStatusInformerSynced); !ok {
+		return nil, fmt.Errorf("failed to wait for caches to sync")
+	}
+	binding, err := c.bindingLister.Get(bindingName)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get Binding %q: %w", bindingName, err)
+	}
+	result := &PreviewResult{
+		IsDestination: slices.Contains(binding.Spec.Destinations, destination),
+		Suspended:     binding.Spec.Suspend,
+	}
+	bindingCopy := binding.DeepCopy()
+	if !result.IsDestination {
+		// Customize as if the given destination were the only one.
+		// For an actual destination, customize for all of them because
+		// the division of replicas depends on the whole set.
+		bindingCopy.Spec.Destinations = []v1alpha1.Destination{destination}
+	}
+	customized, err := c.customizeBinding(ctx, bindingCopy)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get objects to propagate from Binding %q: %w", bindingName, err)
+	}
+	for _, wrapee := range customized.wrapeesFor(destination) {
+		result.Objects = append(result.Objects, wrapee.Object)
+	}
+	result.BindingErrors = customized.bindingErrors
+	result.Held = customized.heldDestinations.Has(destination)
+	return result, nil
+}
+
+// statusDiscardingClient is a CustomTransform client that pretends to write status.
+type statusDiscardingClient struct {
+	ksmetrics.ClientModNamespace[*v1alpha1.CustomTransform, *v1alpha1.CustomTransformList]
+}
+
+func (statusDiscardingClient) UpdateStatus(ctx context.Context, object *v1alpha1.CustomTransform, opts metav1.UpdateOptions) (*v1alpha1.CustomTransform, error) {
+	return object, nil
+}
@@ -0,0 +1,150 @@
+/*
+Copyright 2024 The KubeStellar Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package transport
+
+import (
+	"testing"
+	"time"
+
+	clusterclientfake "open-cluster-management.io/api/client/cluster/clientset/versioned/fake"
+	clusterinformers "open-cluster-management.io/api/client/cluster/informers/externalversions"
+	clusterapi "open-cluster-management.io/api/cluster/v1"
+	workapi "open-cluster-management.io/api/work/v1"
+
+	k8score "k8s.io/api/core/v1"
+	apiequality "k8s.io/apimachinery/pkg/api/equality"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	dynamicfake "k8s.io/client-go/dynamic/fake"
+	k8sinformers "k8s.io/client-go/informers"
+	k8sfake "k8s.io/client-go/kubernetes/fake"
+	k8smetrics "k8s.io/component-base/metrics"
+	"k8s.io/klog/v2/ktesting"
+
+	ksapi "github.com/kubestellar/kubestellar/api/control/v1alpha1"
+	ksclientfake "github.com/kubestellar/kubestellar/pkg/generated/clientset/versioned/fake"
+	ksinformers "github.com/kubestellar/kubestellar/pkg/generated/informers/externalversions"
+	ksmetrics "github.com/kubestellar/kubestellar/pkg/metrics"
+)
+
+func TestPreview(t *testing.T) {
+	_, ctx := ktesting.NewTestContext(t)
+	scheme := runtime.NewScheme()
+	k8score.AddToScheme(scheme)
+	workapi.AddToScheme(scheme)
+	cm := &k8score.ConfigMap{
+		TypeMeta: typeMeta("ConfigMap", k8score.SchemeGroupVersion),
+		ObjectMeta: metav1.ObjectMeta{Namespace: "ns1", Name: "cm1", ResourceVersion: "1",
+			Annotations: map[string]string{ksapi.TemplateExpansionAnnotationKey: "true"}},
+		Data: map[string]string{"greeting": "hello {{ .clusterName }}", "debug": "yes"},
+	}
+	ct := &ksapi.CustomTransform{
+		ObjectMeta: metav1.ObjectMeta{Name: "drop-debug", Generation: 1},
+		Spec: ksapi.CustomTransformSpec{
+			Resource:        "configmaps",
+			ClusterSelector: &metav1.LabelSelector{MatchLabels: map[string]string{"edge": "true"}},
+			Remove:          []string{"$.data.debug"},
+		}}
+	binding := &ksapi.Binding{
+		ObjectMeta: metav1.ObjectMeta{Name: "b1"},
+		Spec: ksapi.BindingSpec{
+			Workload: ksapi.DownsyncObjectClauses{
+				NamespaceScope: []ksapi.NamespaceScopeDownsyncClause{
+					newNamespaceScope(metav1.GroupVersionResource{Version: "v1", Resource: "configmaps"}, "ns1", "cm1", "1", ksapi.DownsyncModulation{})}},
+			Destinations: []ksapi.Destination{{ClusterId: "wec1"}},
+		}}
+	cm2 := &k8score.ConfigMap{
+		TypeMeta: typeMeta("ConfigMap", k8score.SchemeGroupVersion),
+		ObjectMeta: metav1.ObjectMeta{Namespace: "ns1", Name: "cm2", ResourceVersion: "1",
+			Annotations: map[string]string{ksapi.SyncWaveAnnotationKey: "first"}},
+	}
+	binding2 := &ksapi.Binding{
+		ObjectMeta: metav1.ObjectMeta{Name: "b2", Generation: 1},
+		Spec: ksapi.BindingSpec{
+			Workload: ksapi.DownsyncObjectClauses{
+				NamespaceScope: []ksapi.NamespaceScopeDownsyncClause{
+					newNamespaceScope(metav1.GroupVersionResource{Version: "v1", Resource: "configmaps"}, "ns1", "cm2", "1", ksapi.DownsyncModulation{})}},
+			Destinations: []ksapi.Destination{{ClusterId: "wec1"}, {ClusterId: "wec2"}},
+			Suspend:      true,
+			Rollout: &ksapi.RolloutStrategy{
+				Waves: []ksapi.RolloutWave{{ClusterSelector: &metav1.LabelSelector{MatchLabels: map[string]string{"edge": "true"}}}},
+			},
+		}}
+	wec1 := &clusterapi.ManagedCluster{ObjectMeta: metav1.ObjectMeta{Name: "wec1", Labels: map[string]string{"edge": "true"}}}
+	wdsKsClientFake := ksclientfake.NewSimpleClientset(binding, binding2, ct)
+	wdsKsInformerFactory := ksinformers.NewSharedInformerFactory(wdsKsClientFake, 0*time.Minute)
+	wdsControlInformers := wdsKsInformerFactory.Control().V1alpha1()
+	inventoryInformerFactory := clusterinformers.NewSharedInformerFactory(clusterclientfake.NewSimpleClientset(wec1), 0*time.Minute)
+	itsK8sClientFake := k8sfake.NewSimpleClientset()
+	itsK8sInformerFactory := k8sinformers.NewSharedInformerFactory(itsK8sClientFake, 0*time.Minute)
+	spacesClientMetrics := ksmetrics.NewMultiSpaceClientMetrics()
+	ksmetrics.MustRegister(k8smetrics.NewKubeRegistry().Register, spacesClientMetrics)
+	ctlr := NewTransportControllerForWrappedObjectGVR(ctx,
+		spacesClientMetrics.MetricsForSpace("wds"), spacesClientMetrics.MetricsForSpace("its"),
+		inventoryInformerFactory.Cluster().V1().ManagedClusters(), wdsKsClientFake.ControlV1alpha1().Bindings(),
+		wdsControlInformers.Bindings(), wdsControlInformers.BindingPolicies(), wdsControlInformers.CustomTransforms(),
+		wdsControlInformers.CombinedStatuses(),
+		&testTransport{t: t},
+		wdsKsClientFake,
+		dynamicfake.NewSimpleDynamicClient(scheme, cm, cm2),
+		itsK8sClientFake.CoreV1().Namespaces(), itsK8sInformerFactory.Core().V1().ConfigMaps(),
+		dynamicfake.NewSimpleDynamicClient(scheme), 500*1024, 500*1024, "test-wds", workapi.GroupVersion.WithResource("manifestworks"))
+	ctlr.DisableStatusWrites()
+	inventoryInformerFactory.Start(ctx.Done())
+	wdsKsInformerFactory.Start(ctx.Done())
+	itsK8sInformerFactory.Start(ctx.Done())
+
+	for _, testCase := range []struct {
+		destination   string
+		isDestination bool
+		expectedData  map[string]any
+	}{
+		{"wec1", true, map[string]any{"greeting": "hello wec1"}},
+		{"wec2", false, map[string]any{"greeting": "hello wec2", "debug": "yes"}},
+	} {
+		result, err := ctlr.Preview(ctx, binding.Name, ksapi.Destination{ClusterId: testCase.destination})
+		if err != nil {
+			t.Fatalf("Preview for %q failed: %v", testCase.destination, err)
+		}
+		if result.IsDestination != testCase.isDestination || len(result.BindingErrors) != 0 || len(result.Objects) != 1 {
+			t.Fatalf("Wrong preview for %q: %#v", testCase.destination, result)
+		}
+		if data := result.Objects[0].Object["data"]; !apiequality.Semantic.DeepEqual(data, testCase.expectedData) {
+			t.Errorf("For %q expected data %v but got %v", testCase.destination, testCase.expectedData, data)
+		}
+	}
+	for _, testCase := range []struct {
+		destination string
+		held        bool
+	}{
+		{"wec1", false},
+		{"wec2", true},
+	} {
+		result, err := ctlr.Preview(ctx, binding2.Name, ksapi.Destination{ClusterId: testCase.destination})
+		if err != nil {
+			t.Fatalf("Preview of %q for %q failed: %v", binding2.Name, testCase.destination, err)
+		}
+		if !result.Suspended || result.Held != testCase.held || len(result.BindingErrors) != 1 || len(result.Objects) != 1 {
+			t.Errorf("Wrong preview of %q for %q: %#v", binding2.Name, testCase.destination, result)
+		}
+	}
+	if ctEcho, err := wdsKsClientFake.ControlV1alpha1().CustomTransforms().Get(ctx, ct.Name, metav1.GetOptions{}); err != nil {
+		t.Fatalf("Failed to get CustomTransform: %v", err)
+	} else if ctEcho.Status.ObservedGeneration != 0 || ctEcho.ResourceVersion != ct.ResourceVersion {
+		t.Errorf("Preview wrote the CustomTransform: %#v", ctEcho)
+	}
+}
@@ -0,0 +1,150 @@
+/*
+Copyright 2024 The KubeStellar Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package transport
+
+import (
+	"fmt"
+	"math"
+	"sort"
+	"strconv"
+
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/labels"
+
+	"github.com/kubestellar/kubestellar/api/control/v1alpha1"
+)
+
+// getReplicaWeights returns the weight of each of the Binding's destinations,
+// or nil if the Binding does not call for dividing replicas.
+// It also returns the user errors found while computing the weights.
+func (c *genericTransportController) getReplicaWeights(binding *v1alpha1.Binding) (map[v1alpha1.Destination]int64, []string) {
+	scheduling := binding.Spec.ReplicaScheduling
+	if scheduling == nil || len(binding.Spec.Destinations) == 0 {
+		return nil, nil
+	}
+	weights := map[v1alpha1.Destination]int64{}
+	switch scheduling.Mode {
+	case v1alpha1.ReplicaSchedulingStaticWeighted:
+		selectors := make([]labels.Selector, len(scheduling.StaticWeights))
+		for idx, cw := range scheduling.StaticWeights {
+			selector, err := metav1.LabelSelectorAsSelector(&cw.Selector)
+			if err != nil {
+				return nil, []string{fmt.Sprintf("invalid selector in replicaScheduling.staticWeights[%d]: %s", idx, err)}
+			}
+			selectors[idx] = selector
+		}
+		for _, dest := range binding.Spec.Destinations {
+			destLabels := c.getLabelsForDestination(binding.Name, dest)
+			weights[dest] = 0
+			for idx, selector := range selectors {
+				if selector.Matches(destLabels) {
+					weights[dest] = int64(scheduling.StaticWeights[idx].Weight)
+					break
+				}
+			}
+		}
+	case v1alpha1.ReplicaSchedulingPropertyWeighted:
+		if scheduling.WeightProperty == "" {
+			return nil, []string{"replicaScheduling.weightProperty must be set when mode is " + string(v1alpha1.ReplicaSchedulingPropertyWeighted)}
+		}
+		for _, dest := range binding.Spec.Destinations {
+			props := c.getPropertiesForDestination(binding.Name, dest)
+			weight, err := propertyAsWeight(props[scheduling.WeightProperty])
+			if err != nil {
+				return nil, []string{fmt.Sprintf("property %q of destination %q: %s", scheduling.WeightProperty, dest.ClusterId, err)}
+			}
+			weights[dest] = weight
+		}
+	default:
+		return nil, nil
+	}
+	return weights, nil
+}
+
+// propertyAsWeight converts the value of a cluster property to a weight.
+// An absent property (nil) has weight zero. A weight is at most MaxInt32,
+// so that products and sums of weights and replica counts do not overflow.
+func propertyAsWeight(val any) (int64, error) {
+	var weight int64
+	switch typed := val.(type) {
+	case nil:
+		return 0, nil
+	case int64:
+		if typed > math.MaxInt32 {
+			return 0, fmt.Errorf("value %d is too large", typed)
+		}
+		weight = typed
+	case float64:
+		if typed != math.Trunc(typed) || typed > math.MaxInt32 {
+			return 0, fmt.Errorf("value %v is not a suitable integer", typed)
+		}
+		weight = int64(typed)
+	case string:
+		var err error
+		weight, err = strconv.ParseInt(typed, 10, 32)
+		if err != nil {
+			return 0, fmt.Errorf("value %q does not parse as an integer", typed)
+		}
+	default:
+		return 0, fmt.Errorf("value has unsuitable type %T", val)
+	}
+	if weight < 0 {
+		return 0, fmt.Errorf("value %d is negative", weight)
+	}
+	return weight, nil
+}
+
+// splitReplicas divides the given total among the destinations in proportion to their weights.
+// Each destination gets the floor of its share, and the remaining replicas go one at a time to
+// the destinations with the largest remainders, ties broken by ClusterId.
+// If all the weights are zero then the total is divided evenly.
+func splitReplicas(total int64, weights map[v1alpha1.Destination]int64) map[v1alpha1.Destination]int64 {
+	dests := make([]v1alpha1.Destination, 0, len(weights))
+	var weightSum int64
+	for dest, weight := range weights {
+		dests = append(dests, dest)
+		weightSum += weight
+	}
+	sort.Slice(dests, func(i, j int) bool { return dests[i].ClusterId < dests[j].ClusterId })
+	weightOf := func(dest v1alpha1.Destination) int64 { return weights[dest] }
+	if weightSum == 0 {
+		weightOf = func(v1alpha1.Destination) int64 { return 1 }
+		weightSum = int64(len(dests))
+	}
+	ans := make(map[v1alpha1.Destination]int64, len(dests))
+	remainders := make(map[v1alpha1.Destination]int64, len(dests))
+	var assigned int64
+	for _, dest := range dests {
+		product := total * weightOf(dest)
+		ans[dest] = product / weightSum
+		remainders[dest] = product % weightSum
+		assigned += ans[dest]
+	}
+	// sort.SliceStable keeps the ClusterId order among equal remainders
+	sort.SliceStable(dests, func(i, j int) bool { return remainders[dests[i]] > remainders[dests[j]] })
+	for idx := int64(0); idx < total-assigned; idx++ {
+		ans[dests[idx]]++
+	}
+	return ans
+}
+
+// getReplicas returns the value of `spec.replicas` if the object has an integer there.
+func getReplicas(obj *unstructured.Unstructured) (int64, bool) {
+	replicas, found, err := unstructured.NestedInt64(obj.Object, "spec", "replicas")
+	return replicas, found && err == nil
+}
@@ -0,0 +1,76 @@
+/*
+Copyright 2024 The KubeStellar Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package transport
+
+import (
+	"math"
+	"testing"
+
+	apiequality "k8s.io/apimachinery/pkg/api/equality"
+
+	ksapi "github.com/kubestellar/kubestellar/api/control/v1alpha1"
+)
+
+func TestSplitReplicas(t *testing.T) {
+	a, b, c := ksapi.Destination{ClusterId: "a"}, ksapi.Destination{ClusterId: "b"}, ksapi.Destination{ClusterId: "c"}
+	for _, testCase := range []struct {
+		name     string
+		total    int64
+		weights  map[ksapi.Destination]int64
+		expected map[ksapi.Destination]int64
+	}{
+		{"even", 6, map[ksapi.Destination]int64{a: 1, b: 1, c: 1}, map[ksapi.Destination]int64{a: 2, b: 2, c: 2}},
+		{"remainder by name", 5, map[ksapi.Destination]int64{c: 1, b: 1, a: 1}, map[ksapi.Destination]int64{a: 2, b: 2, c: 1}},
+		{"weighted", 10, map[ksapi.Destination]int64{a: 1, b: 2, c: 2}, map[ksapi.Destination]int64{a: 2, b: 4, c: 4}},
+		{"largest remainder", 7, map[ksapi.Destination]int64{a: 1, b: 1, c: 3}, map[ksapi.Destination]int64{a: 2, b: 1, c: 4}},
+		{"zero weight", 3, map[ksapi.Destination]int64{a: 0, b: 1}, map[ksapi.Destination]int64{a: 0, b: 3}},
+		{"all zero", 3, map[ksapi.Destination]int64{a: 0, b: 0}, map[ksapi.Destination]int64{a: 2, b: 1}},
+		{"none", 0, map[ksapi.Destination]int64{a: 2, b: 1}, map[ksapi.Destination]int64{a: 0, b: 0}},
+		{"largest weights", math.MaxInt32, map[ksapi.Destination]int64{a: math.MaxInt32, b: math.MaxInt32, c: 0},
+			map[ksapi.Destination]int64{a: math.MaxInt32/2 + 1, b: math.MaxInt32 / 2, c: 0}},
+	} {
+		actual := splitReplicas(testCase.total, testCase.weights)
+		if !apiequality.Semantic.DeepEqual(testCase.expected, actual) {
+			t.Errorf("Case %q: expected %v, got %v", testCase.name, testCase.expected, actual)
+		}
+	}
+}
+
+func TestPropertyAsWeight(t *testing.T) {
+	for _, testCase := range []struct {
+		val      any
+		expected int64
+		ok       bool
+	}{
+		{nil, 0, true},
+		{int64(3), 3, true},
+		{float64(4), 4, true},
+		{"5", 5, true},
+		{2.5, 0, false},
+		{"x", 0, false},
+		{int64(-1), 0, false},
+		{int64(math.MaxInt32), math.MaxInt32, true},
+		{int64(math.MaxInt32) + 1, 0, false},
+		{int64(math.MaxInt64), 0, false},
+		{true, 0, false},
+	} {
+		actual, err := propertyAsWeight(testCase.val)
+		if (err == nil) != testCase.ok || actual != testCase.expected {
+			t.Errorf("For %#v: expected (%d, ok=%v), got (%d, %v)", testCase.val, testCase.expected, testCase.ok, actual, err)
+		}
+	}
+}
@@ -0,0 +1,254 @@
+/*
+Copyright 2024 The KubeStellar Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package transport
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+	"time"
+
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/labels"
+	"k8s.io/apimachinery/pkg/util/intstr"
+	"k8s.io/apimachinery/pkg/util/sets"
+
+	"github.com/kubestellar/kubestellar/api/control/v1alpha1"
+)
+
+const (
+	// rolloutWECColumn and rolloutHealthColumn are the names of the columns
+	// that the rollout's StatusCollector must select.
+	rolloutWECColumn    = "wec"
+	rolloutHealthColumn = "health"
+
+	rolloutHealthy = "Healthy"
+	rolloutFailed  = "Failed"
+
+	// combinedStatusBindingPolicyLabel is the label of a CombinedStatus
+	// that holds the name of the BindingPolicy (and Binding).
+	combinedStatusBindingPolicyLabel = "status.kubestellar.io/binding-policy"
+
+	// defaultRolloutWaveInterval is used when the rollout strategy has no `waveInterval`.
+	defaultRolloutWaveInterval = 30 * time.Second
+)
+
+// waveHealth summarizes the health of the destinations of a rollout wave.
+type waveHealth int
+
+const (
+	waveHealthUnknown waveHealth = iota
+	waveHealthy
+	waveFailed
+)
+
+// handleCombinedStatus enqueues a reference to the Binding that the given CombinedStatus
+// is associated with, if that Binding is being rolled out.
+func (c *genericTransportController) handleCombinedStatus(obj any, event string) {
+	combinedStatus := obj.(*v1alpha1.CombinedStatus)
+	bindingName, found := combinedStatus.Labels[combinedStatusBindingPolicyLabel]
+	if !found {
+		return
+	}
+	binding, err := c.bindingLister.Get(bindingName)
+	if err != nil || binding.Spec.Rollout == nil {
+		return
+	}
+	c.logger.V(5).Info("Enqueuing reference to Binding due to informer event about its CombinedStatus", "bindingName", bindingName, "combinedStatus", combinedStatus.Name, "resourceVersion", combinedStatus.ResourceVersion, "event", event)
+	c.workqueue.Add(bindingName)
+}
+
+// computeRollout determines how far the rollout of the Binding's current generation has got.
+// Aborted and completed rollouts stay that way until the Binding's generation changes.
+// It returns the rollout status, the destinations that must not be given the change yet,
+// and the user errors found in the rollout strategy.
+// When the current wave is still soaking, this enqueues the Binding to be reconsidered later.
+func (c *genericTransportController) computeRollout(binding *v1alpha1.Binding) (*v1alpha1.RolloutStatus, sets.Set[v1alpha1.Destination], []string) {
+	strategy := binding.Spec.Rollout
+	if strategy == nil {
+		return nil, nil, nil
+	}
+	waves, err := groupIntoWaves(strategy.Waves, binding.Spec.Destinations, func(dest v1alpha1.Destination) labels.Set {
+		return c.getLabelsForDestination(binding.Name, dest)
+	})
+	if err != nil {
+		return nil, nil, []string{err.Error()}
+	}
+	now := metav1.Now()
+	status := &v1alpha1.RolloutStatus{
+		Generation:           binding.Generation,
+		NumWaves:             int32(len(waves)),
+		Phase:                v1alpha1.RolloutProgressing,
+		CurrentWaveStartTime: now,
+	}
+	if len(waves) == 0 {
+		status.Phase = v1alpha1.RolloutCompleted
+		return status, nil, nil
+	}
+	if prev := binding.Status.Rollout; prev != nil && prev.Generation == binding.Generation {
+		// the number of waves may have shrunk since, because of changes in the destinations' labels
+		status.CurrentWave = min(prev.CurrentWave, status.NumWaves-1)
+		status.CurrentWaveStartTime = prev.CurrentWaveStartTime
+		if prev.Phase == v1alpha1.RolloutAborted || prev.Phase == v1alpha1.RolloutCompleted {
+			status.Phase, status.Message = prev.Phase, prev.Message
+		}
+	}
+	if status.Phase == v1alpha1.RolloutProgressing {
+		interval := defaultRolloutWaveInterval
+		if strategy.WaveInterval != nil {
+			interval = strategy.WaveInterval.Duration
+		}
+		if soak := status.CurrentWaveStartTime.Add(interval).Sub(now.Time); soak > 0 {
+			status.Message = fmt.Sprintf("wave %d is soaking", status.CurrentWave)
+			c.workqueue.AddAfter(binding.Name, soak)
+		} else {
+			health, message := c.getWaveHealth(binding.Name, strategy.StatusCollector, waves[status.CurrentWave])
+			status.Message = message
+			switch {
+			case health == waveHealthy && status.CurrentWave+1 < status.NumWaves:
+				status.CurrentWave++
+				status.CurrentWaveStartTime = now
+				status.Message = ""
+			case health == waveHealthy:
+				status.Phase = v1alpha1.RolloutCompleted
+			case health == waveFailed && strategy.OnFailure == v1alpha1.RolloutAbort:
+				status.Phase = v1alpha1.RolloutAborted
+			case health == waveFailed:
+				status.Phase = v1alpha1.RolloutPaused
+			}
+		}
+	}
+	held := sets.New[v1alpha1.Destination]()
+	for _, wave := range waves[status.CurrentWave+1:] {
+		held.Insert(wave...)
+	}
+	return status, held, nil
+}
+
+// groupIntoWaves returns the non-empty waves that the given destinations fall into,
+// each sorted by ClusterId. Destinations admitted by no wave form a last wave of their own.
+func groupIntoWaves(waves []v1alpha1.RolloutWave, destinations []v1alpha1.Destination, labelsOf func(v1alpha1.Destination) labels.Set) ([][]v1alpha1.Destination, error) {
+	remaining := make([]v1alpha1.Destination, len(destinations))
+	copy(remaining, destinations)
+	sort.Slice(remaining, func(i, j int) bool { return remaining[i].ClusterId < remaining[j].ClusterId })
+	ans := [][]v1alpha1.Destination{}
+	for idx, wave := range waves {
+		selector := labels.Everything()
+		if wave.ClusterSelector != nil {
+			var err error
+			selector, err = metav1.LabelSelectorAsSelector(wave.ClusterSelector)
+			if err != nil {
+				return nil, fmt.Errorf("invalid clusterSelector in rollout.waves[%d]: %w", idx, err)
+			}
+		}
+		limit := len(remaining)
+		if wave.Size != nil {
+			var err error
+			limit, err = intstr.GetScaledValueFromIntOrPercent(wave.Size, len(destinations), true)
+			if err != nil {
+				return nil, fmt.Errorf("invalid size in rollout.waves[%d]: %w", idx, err)
+			}
+			if limit < 0 {
+				return nil, fmt.Errorf("invalid size in rollout.waves[%d]: %s is negative", idx, wave.Size.String())
+			}
+		}
+		admitted, rest := []v1alpha1.Destination{}, []v1alpha1.Destination{}
+		for _, dest := range remaining {
+			if len(admitted) < limit && selector.Matches(labelsOf(dest)) {
+				admitted = append(admitted, dest)
+			} else {
+				rest = append(rest, dest)
+			}
+		}
+		if len(admitted) > 0 {
+			ans = append(ans, admitted)
+		}
+		remaining = rest
+	}
+	if len(remaining) > 0 {
+		ans = append(ans, remaining)
+	}
+	return ans, nil
+}
+
+// getWaveHealth judges the health of the given wave from the Binding's CombinedStatus objects.
+// It also returns a message explaining a result other than waveHealthy.
+func (c *genericTransportController) getWaveHealth(bindingName, statusCollectorName string, wave []v1alpha1.Destination) (waveHealth, string) {
+	combinedStatuses, err := c.combinedStatusLister.List(labels.SelectorFromSet(labels.Set{combinedStatusBindingPolicyLabel: bindingName}))
+	if err != nil { // listers do not fail
+		return waveHealthUnknown, err.Error()
+	}
+	results := []v1alpha1.NamedStatusCombination{}
+	for _, combinedStatus := range combinedStatuses {
+		for _, result := range combinedStatus.Results {
+			if result.Name == statusCollectorName {
+				results = append(results, result)
+			}
+		}
+	}
+	return judgeWaveHealth(statusCollectorName, results, wave)
+}
+
+// judgeWaveHealth judges the health of the given wave from the given results of the rollout's StatusCollector.
+// A destination that some result reports as "Failed" is failed; a destination is healthy when
+// every result reports it as "Healthy".
+func judgeWaveHealth(statusCollectorName string, results []v1alpha1.NamedStatusCombination, wave []v1alpha1.Destination) (waveHealth, string) {
+	if len(results) == 0 {
+		return waveHealthUnknown, fmt.Sprintf("no CombinedStatus has results from StatusCollector %q", statusCollectorName)
+	}
+	failed, pending := sets.New[string](), sets.New[string]()
+	for _, result := range results {
+		wecIdx, healthIdx := -1, -1
+		for idx, name := range result.ColumnNames {
+			switch name {
+			case rolloutWECColumn:
+				wecIdx = idx
+			case rolloutHealthColumn:
+				healthIdx = idx
+			}
+		}
+		if wecIdx < 0 || healthIdx < 0 {
+			return waveHealthUnknown, fmt.Sprintf("StatusCollector %q does not select both a %q and a %q column", statusCollectorName, rolloutWECColumn, rolloutHealthColumn)
+		}
+		wecToHealth := map[string]string{}
+		for _, row := range result.Rows {
+			if len(row.Columns) <= wecIdx || len(row.Columns) <= healthIdx {
+				continue
+			}
+			wec, health := row.Columns[wecIdx], row.Columns[healthIdx]
+			if wec.Type == v1alpha1.TypeString && wec.String != nil && health.Type == v1alpha1.TypeString && health.String != nil {
+				wecToHealth[*wec.String] = *health.String
+			}
+		}
+		for _, dest := range wave {
+			switch wecToHealth[dest.ClusterId] {
+			case rolloutHealthy:
+			case rolloutFailed:
+				failed.Insert(dest.ClusterId)
+			default:
+				pending.Insert(dest.ClusterId)
+			}
+		}
+	}
+	if failed.Len() > 0 {
+		return waveFailed, "failed: " + strings.Join(sets.List(failed), ", ")
+	}
+	if pending.Len() > 0 {
+		return waveHealthUnknown, "waiting for: " + strings.Join(sets.List(pending), ", ")
+	}
+	return waveHealthy, ""
+}
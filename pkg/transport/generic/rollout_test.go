@@ -0,0 +1,90 @@
+/*
+Copyright 2024 The KubeStellar Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package transport
+
+import (
+	"testing"
+
+	apiequality "k8s.io/apimachinery/pkg/api/equality"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/labels"
+	"k8s.io/apimachinery/pkg/util/intstr"
+
+	ksapi "github.com/kubestellar/kubestellar/api/control/v1alpha1"
+)
+
+func TestGroupIntoWaves(t *testing.T) {
+	a, b, c, d := ksapi.Destination{ClusterId: "a"}, ksapi.Destination{ClusterId: "b"}, ksapi.Destination{ClusterId: "c"}, ksapi.Destination{ClusterId: "d"}
+	destLabels := map[ksapi.Destination]labels.Set{a: {"stage": "canary"}, b: {}, c: {"stage": "canary"}, d: {}}
+	labelsOf := func(dest ksapi.Destination) labels.Set { return destLabels[dest] }
+	canary := &metav1.LabelSelector{MatchLabels: map[string]string{"stage": "canary"}}
+	one, half := intstr.FromInt32(1), intstr.FromString("50%")
+	for _, testCase := range []struct {
+		name     string
+		waves    []ksapi.RolloutWave
+		expected [][]ksapi.Destination
+	}{
+		{"all", []ksapi.RolloutWave{{}}, [][]ksapi.Destination{{a, b, c, d}}},
+		{"selector", []ksapi.RolloutWave{{ClusterSelector: canary}}, [][]ksapi.Destination{{a, c}, {b, d}}},
+		{"selector and size", []ksapi.RolloutWave{{ClusterSelector: canary, Size: &one}, {}}, [][]ksapi.Destination{{a}, {b, c, d}}},
+		{"percentage", []ksapi.RolloutWave{{Size: &half}, {Size: &one}}, [][]ksapi.Destination{{a, b}, {c}, {d}}},
+		{"empty wave", []ksapi.RolloutWave{{ClusterSelector: canary}, {ClusterSelector: canary}, {}}, [][]ksapi.Destination{{a, c}, {b, d}}},
+	} {
+		actual, err := groupIntoWaves(testCase.waves, []ksapi.Destination{d, c, b, a}, labelsOf)
+		if err != nil {
+			t.Errorf("Case %q: unexpected error %v", testCase.name, err)
+		} else if !apiequality.Semantic.DeepEqual(testCase.expected, actual) {
+			t.Errorf("Case %q: expected %v, got %v", testCase.name, testCase.expected, actual)
+		}
+	}
+	negative := intstr.FromInt32(-1)
+	if _, err := groupIntoWaves([]ksapi.RolloutWave{{Size: &negative}}, []ksapi.Destination{a}, labelsOf); err == nil {
+		t.Error("Expected an error for a negative size")
+	}
+}
+
+func TestJudgeWaveHealth(t *testing.T) {
+	str := func(val string) ksapi.Value { return ksapi.Value{Type: ksapi.TypeString, String: &val} }
+	result := func(rows ...[2]string) ksapi.NamedStatusCombination {
+		ans := ksapi.NamedStatusCombination{Name: "health", ColumnNames: []string{"wec", "health"}}
+		for _, row := range rows {
+			ans.Rows = append(ans.Rows, ksapi.StatusCombinationRow{Columns: []ksapi.Value{str(row[0]), str(row[1])}})
+		}
+		return ans
+	}
+	wave := []ksapi.Destination{{ClusterId: "a"}, {ClusterId: "b"}}
+	for _, testCase := range []struct {
+		name     string
+		results  []ksapi.NamedStatusCombination
+		expected waveHealth
+	}{
+		{"no results", nil, waveHealthUnknown},
+		{"healthy", []ksapi.NamedStatusCombination{result([2]string{"a", "Healthy"}, [2]string{"b", "Healthy"}, [2]string{"c", "Failed"})}, waveHealthy},
+		{"missing row", []ksapi.NamedStatusCombination{result([2]string{"a", "Healthy"})}, waveHealthUnknown},
+		{"progressing", []ksapi.NamedStatusCombination{result([2]string{"a", "Healthy"}, [2]string{"b", "Progressing"})}, waveHealthUnknown},
+		{"failed", []ksapi.NamedStatusCombination{result([2]string{"a", "Failed"}, [2]string{"b", "Progressing"})}, waveFailed},
+		{"one object unhealthy", []ksapi.NamedStatusCombination{
+			result([2]string{"a", "Healthy"}, [2]string{"b", "Healthy"}),
+			result([2]string{"a", "Healthy"})}, waveHealthUnknown},
+		{"missing column", []ksapi.NamedStatusCombination{{Name: "health", ColumnNames: []string{"wec"}}}, waveHealthUnknown},
+	} {
+		actual, message := judgeWaveHealth("health", testCase.results, wave)
+		if actual != testCase.expected {
+			t.Errorf("Case %q: expected %v, got %v (%s)", testCase.name, testCase.expected, actual, message)
+		}
+	}
+}
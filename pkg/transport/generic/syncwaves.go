@@ -0,0 +1,90 @@
+/*
+Copyright 2024 The KubeStellar Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package transport
+
+import (
+	"fmt"
+	"sort"
+	"strconv"
+
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+
+	"github.com/kubestellar/kubestellar/api/control/v1alpha1"
+)
+
+// defaultSyncWaves gives the sync wave of a workload object that lacks the
+// sync wave annotation, by kind. Every other kind is in wave 0.
+var defaultSyncWaves = map[schema.GroupKind]int{
+	{Group: "", Kind: "Namespace"}:                                    -4,
+	{Group: "apiextensions.k8s.io", Kind: "CustomResourceDefinition"}: -3,
+	{Group: "", Kind: "ServiceAccount"}:                               -2,
+	{Group: "rbac.authorization.k8s.io", Kind: "ClusterRole"}:         -2,
+	{Group: "rbac.authorization.k8s.io", Kind: "ClusterRoleBinding"}:  -2,
+	{Group: "rbac.authorization.k8s.io", Kind: "Role"}:                -2,
+	{Group: "rbac.authorization.k8s.io", Kind: "RoleBinding"}:         -2,
+	{Group: "", Kind: "ConfigMap"}:                                    -1,
+	{Group: "", Kind: "Secret"}:                                       -1,
+}
+
+// syncWaveOf returns the sync wave of the given workload object.
+// An unparsable annotation is reported in the error, along with the default wave.
+func syncWaveOf(obj *unstructured.Unstructured) (int, error) {
+	defaultWave := defaultSyncWaves[obj.GroupVersionKind().GroupKind()]
+	annotation, found := obj.GetAnnotations()[v1alpha1.SyncWaveAnnotationKey]
+	if !found {
+		return defaultWave, nil
+	}
+	wave, err := strconv.ParseInt(annotation, 10, 32)
+	if err != nil {
+		return defaultWave, fmt.Errorf("the %s annotation of %s %s/%s, %q, is not an integer",
+			v1alpha1.SyncWaveAnnotationKey, obj.GetKind(), obj.GetNamespace(), obj.GetName(), annotation)
+	}
+	return int(wave), nil
+}
+
+// syncWaveErrors returns the user errors in the sync wave annotations of the given objects.
+func syncWaveErrors(wrapees []WrapeeWithUID) []string {
+	var errs []string
+	for _, wrapee := range wrapees {
+		if _, err := syncWaveOf(wrapee.Object); err != nil {
+			errs = append(errs, err.Error())
+		}
+	}
+	return errs
+}
+
+// sortBySyncWave returns a copy of the given slice, stably sorted by sync wave,
+// along with the sync wave of each member of the result.
+func sortBySyncWave(wrapees []WrapeeWithUID) ([]WrapeeWithUID, []int) {
+	type waveAndWrapee struct {
+		wave   int
+		wrapee WrapeeWithUID
+	}
+	pairs := make([]waveAndWrapee, len(wrapees))
+	for idx, wrapee := range wrapees {
+		wave, _ := syncWaveOf(wrapee.Object)
+		pairs[idx] = waveAndWrapee{wave, wrapee}
+	}
+	sort.SliceStable(pairs, func(i, j int) bool { return pairs[i].wave < pairs[j].wave })
+	sorted := make([]WrapeeWithUID, len(pairs))
+	waves := make([]int, len(pairs))
+	for idx, pair := range pairs {
+		sorted[idx], waves[idx] = pair.wrapee, pair.wave
+	}
+	return sorted, waves
+}
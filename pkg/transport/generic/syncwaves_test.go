@@ -0,0 +1,149 @@
+/*
+Copyright 2024 The KubeStellar Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package transport
+
+import (
+	"testing"
+
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+
+	ksapi "github.com/kubestellar/kubestellar/api/control/v1alpha1"
+	"github.com/kubestellar/kubestellar/pkg/transport"
+)
+
+func newWaveTestWrapee(apiVersion, kind, name string, annotations map[string]string) WrapeeWithUID {
+	obj := &unstructured.Unstructured{Object: map[string]any{"apiVersion": apiVersion, "kind": kind}}
+	obj.SetName(name)
+	obj.SetAnnotations(annotations)
+	return WrapeeWithUID{Wrapee: transport.NewWrapee(obj, ksapi.UpdateStrategyUpdate, false)}
+}
+
+func TestSyncWaveOf(t *testing.T) {
+	for _, testCase := range []struct {
+		wrapee   WrapeeWithUID
+		expected int
+		ok       bool
+	}{
+		{newWaveTestWrapee("v1", "Namespace", "ns1", nil), -4, true},
+		{newWaveTestWrapee("apiextensions.k8s.io/v1", "CustomResourceDefinition", "crd1", nil), -3, true},
+		{newWaveTestWrapee("rbac.authorization.k8s.io/v1", "RoleBinding", "rb1", nil), -2, true},
+		{newWaveTestWrapee("v1", "ConfigMap", "cm1", nil), -1, true},
+		{newWaveTestWrapee("apps/v1", "Deployment", "d1", nil), 0, true},
+		{newWaveTestWrapee("apps/v1", "Deployment", "d2", map[string]string{ksapi.SyncWaveAnnotationKey: "-5"}), -5, true},
+		{newWaveTestWrapee("v1", "ConfigMap", "cm2", map[string]string{ksapi.SyncWaveAnnotationKey: "3"}), 3, true},
+		{newWaveTestWrapee("v1", "ConfigMap", "cm3", map[string]string{ksapi.SyncWaveAnnotationKey: "first"}), -1, false},
+	} {
+		actual, err := syncWaveOf(testCase.wrapee.Object)
+		if actual != testCase.expected || (err == nil) != testCase.ok {
+			t.Errorf("For %s %s: expected (%d, ok=%v), got (%d, %v)", testCase.wrapee.Object.GetKind(), testCase.wrapee.Object.GetName(), testCase.expected, testCase.ok, actual, err)
+		}
+	}
+}
+
+func TestSortBySyncWave(t *testing.T) {
+	wrapees := []WrapeeWithUID{
+		newWaveTestWrapee("apps/v1", "Deployment", "d1", nil),
+		newWaveTestWrapee("v1", "ConfigMap", "cm1", nil),
+		newWaveTestWrapee("apps/v1", "Deployment", "d2", nil),
+		newWaveTestWrapee("v1", "Namespace", "ns1", nil),
+		newWaveTestWrapee("batch/v1", "Job", "j1", map[string]string{ksapi.SyncWaveAnnotationKey: "1"}),
+	}
+	sorted, waves := sortBySyncWave(wrapees)
+	expectedNames := []string{"ns1", "cm1", "d1", "d2", "j1"}
+	expectedWaves := []int{-4, -1, 0, 0, 1}
+	for idx, wrapee := range sorted {
+		if wrapee.Object.GetName() != expectedNames[idx] || waves[idx] != expectedWaves[idx] {
+			t.Errorf("At %d: expected %s in wave %d, got %s in wave %d", idx, expectedNames[idx], expectedWaves[idx], wrapee.Object.GetName(), waves[idx])
+		}
+	}
+	if wrapees[0].Object.GetName() != "d1" {
+		t.Error("sortBySyncWave modified its input")
+	}
+	if errs := syncWaveErrors(wrapees); len(errs) != 0 {
+		t.Errorf("Expected no errors, got %v", errs)
+	}
+}
+
+// configMapTransport wraps objects in an empty ConfigMap, for testing how they are sharded.
+type configMapTransport struct{}
+
+func (configMapTransport) WrapObjects(wrapees []transport.Wrapee, kindToResource func(schema.GroupKind) string) runtime.Object {
+	return &corev1.ConfigMap{TypeMeta: metav1.TypeMeta{APIVersion: "v1", Kind: "ConfigMap"}}
+}
+
+func (configMapTransport) UnwrapObjects(wrapped runtime.Object, kindToResource func(schema.GroupKind) (string, bool)) (transport.Gloss, error) {
+	return transport.Gloss{}, nil
+}
+
+func TestWrapShardNames(t *testing.T) {
+	controller := &genericTransportController{transport: configMapTransport{}, MaxSizeWrapped: 1 << 20, MaxNumWrapped: 2, wdsName: "wds1"}
+	binding := &ksapi.Binding{ObjectMeta: metav1.ObjectMeta{Name: "b1"}}
+	kindToResource := func(schema.GroupKind) (string, bool) { return "things", true }
+	for _, testCase := range []struct {
+		name          string
+		wrapees       []WrapeeWithUID
+		expectedNames []string
+		expectedWaves []int
+	}{
+		{name: "all in wave 0",
+			wrapees: []WrapeeWithUID{
+				newWaveTestWrapee("apps/v1", "Deployment", "d1", nil),
+				newWaveTestWrapee("apps/v1", "Deployment", "d2", nil),
+				newWaveTestWrapee("batch/v1", "Job", "j1", nil),
+			},
+			expectedNames: []string{"b1-wds1-0", "b1-wds1-1"},
+			expectedWaves: []int{0, 0},
+		},
+		{name: "default waves without annotations",
+			wrapees: []WrapeeWithUID{
+				newWaveTestWrapee("apps/v1", "Deployment", "d1", nil),
+				newWaveTestWrapee("v1", "Namespace", "ns1", nil),
+				newWaveTestWrapee("v1", "ConfigMap", "cm1", nil),
+			},
+			expectedNames: []string{"b1-wds1-wave-4-0", "b1-wds1-wave-1-0", "b1-wds1-wave0-0"},
+			expectedWaves: []int{-4, -1, 0},
+		},
+		{name: "some sync-wave annotation",
+			wrapees: []WrapeeWithUID{
+				newWaveTestWrapee("apps/v1", "Deployment", "d1", nil),
+				newWaveTestWrapee("v1", "Namespace", "ns1", nil),
+				newWaveTestWrapee("apps/v1", "Deployment", "d2", nil),
+				newWaveTestWrapee("apps/v1", "Deployment", "d3", nil),
+				newWaveTestWrapee("batch/v1", "Job", "j1", map[string]string{ksapi.SyncWaveAnnotationKey: "2"}),
+			},
+			expectedNames: []string{"b1-wds1-wave-4-0", "b1-wds1-wave0-0", "b1-wds1-wave0-1", "b1-wds1-wave2-0"},
+			expectedWaves: []int{-4, 0, 0, 2},
+		},
+	} {
+		tasks, err := controller.wrap(testCase.wrapees, kindToResource, binding)
+		if err != nil {
+			t.Fatalf("For %s: unexpected error %v", testCase.name, err)
+		}
+		if len(tasks) != len(testCase.expectedNames) {
+			t.Fatalf("For %s: expected %d wrapped objects, got %d", testCase.name, len(testCase.expectedNames), len(tasks))
+		}
+		for idx, task := range tasks {
+			if task.ObjU.GetName() != testCase.expectedNames[idx] || task.Wave != testCase.expectedWaves[idx] {
+				t.Errorf("For %s at %d: expected %s in wave %d, got %s in wave %d", testCase.name, idx, testCase.expectedNames[idx], testCase.expectedWaves[idx], task.ObjU.GetName(), task.Wave)
+			}
+		}
+	}
+}
@@ -0,0 +1,489 @@
+/*
+Copyright 2024 The KubeStellar Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package transport
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"sort"
+
+	corev1 "k8s.io/api/core/v1"
+	apiequality "k8s.io/apimachinery/pkg/api/equality"
+	"k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/labels"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+	"k8s.io/apimachinery/pkg/util/sets"
+	"k8s.io/client-go/tools/cache"
+	"k8s.io/klog/v2"
+
+	"github.com/kubestellar/kubestellar/api/control/v1alpha1"
+	"github.com/kubestellar/kubestellar/pkg/upsync"
+	"github.com/kubestellar/kubestellar/pkg/util"
+)
+
+var configMapGVR = corev1.SchemeGroupVersion.WithResource("configmaps")
+
+var secretGVR = corev1.SchemeGroupVersion.WithResource("secrets")
+
+var namespaceGVR = corev1.SchemeGroupVersion.WithResource("namespaces")
+
+// upsyncCopyKey identifies a copy in the WDS.
+type upsyncCopyKey struct {
+	GVR schema.GroupVersionResource
+	cache.ObjectName
+}
+
+// upsyncCopy is the desired state of one copy in the WDS.
+type upsyncCopy struct {
+	cluster string
+	source  v1alpha1.SourceObjectReference
+	object  *unstructured.Unstructured
+}
+
+// handleUpsyncObject enqueues a reference to the Binding that the given upsync request or record is for.
+func (c *genericTransportController) handleUpsyncObject(obj any, event string) {
+	if dfsu, is := obj.(cache.DeletedFinalStateUnknown); is {
+		obj = dfsu.Obj
+	}
+	cm, ok := obj.(metav1.Object)
+	if !ok {
+		return
+	}
+	bindingName, found := cm.GetLabels()[upsync.BindingLabelKey]
+	if !found {
+		return
+	}
+	c.logger.V(5).Info("Enqueuing reference to Binding due to informer event about upsync request or record", "bindingName", bindingName, "object", cache.MetaObjectToName(cm), "resourceVersion", cm.GetResourceVersion(), "event", event)
+	c.workqueue.Add(bindingName)
+}
+
+// listUpsyncConfigMaps returns the upsync requests or records, of the given role, for the named Binding.
+func (c *genericTransportController) listUpsyncConfigMaps(bindingName, role string) ([]*corev1.ConfigMap, error) {
+	objs, err := c.upsyncLister.List(labels.SelectorFromSet(labels.Set{upsync.RoleLabelKey: role, upsync.BindingLabelKey: bindingName}))
+	if err != nil {
+		return nil, err
+	}
+	ans := make([]*corev1.ConfigMap, 0, len(objs))
+	for _, obj := range objs {
+		cm := &corev1.ConfigMap{}
+		if err := runtime.DefaultUnstructuredConverter.FromUnstructured(obj.(*unstructured.Unstructured).Object, cm); err != nil {
+			return nil, err
+		}
+		ans = append(ans, cm)
+	}
+	return ans, nil
+}
+
+// listUpsyncRecords returns the upsync records for the named Binding.
+// The records that are Secrets are returned in the form of ConfigMap records.
+func (c *genericTransportController) listUpsyncRecords(bindingName string) ([]*corev1.ConfigMap, error) {
+	ans, err := c.listUpsyncConfigMaps(bindingName, upsync.RoleRecord)
+	if err != nil {
+		return nil, err
+	}
+	objs, err := c.upsyncSecretLister.List(labels.SelectorFromSet(labels.Set{upsync.RoleLabelKey: upsync.RoleRecord, upsync.BindingLabelKey: bindingName}))
+	if err != nil {
+		return nil, err
+	}
+	for _, obj := range objs {
+		secret := &corev1.Secret{}
+		if err := runtime.DefaultUnstructuredConverter.FromUnstructured(obj.(*unstructured.Unstructured).Object, secret); err != nil {
+			return nil, err
+		}
+		ans = append(ans, &corev1.ConfigMap{ObjectMeta: secret.ObjectMeta, Data: upsync.SecretRecordData(secret.Data)})
+	}
+	return ans, nil
+}
+
+// syncUpsync makes the upsync requests in the mailbox namespaces of the given destinations, and
+// the copies in the WDS, agree with the given upsync clauses of the named Binding.
+// It returns the conflicts found, sorted.
+// With no clauses, this deletes all the upsync requests and copies of the Binding.
+func (c *genericTransportController) syncUpsync(ctx context.Context, bindingName string, clauses []v1alpha1.UpsyncClause, destinations []v1alpha1.Destination) ([]v1alpha1.UpsyncConflict, error) {
+	logger := klog.FromContext(ctx)
+	requestName := upsync.RequestName(bindingName, c.wdsName)
+	clusters := sets.New[string]()
+	if len(clauses) > 0 {
+		for _, destination := range destinations {
+			clusters.Insert(destination.ClusterId)
+		}
+	}
+	requests, err := c.listUpsyncConfigMaps(bindingName, upsync.RoleRequest)
+	if err != nil {
+		return nil, err
+	}
+	records, err := c.listUpsyncRecords(bindingName)
+	if err != nil {
+		return nil, err
+	}
+	// Every GVR of a copy that may exist, for finding the stale ones
+	knownGVRs := sets.New[schema.GroupVersionResource]()
+	for _, request := range requests {
+		knownGVRs.Insert(decodeGVRs(request.Data[upsync.ResourcesDataKey])...)
+	}
+
+	// Compute the desired copies
+	sort.Slice(records, func(i, j int) bool {
+		return records[i].Namespace < records[j].Namespace || records[i].Namespace == records[j].Namespace && records[i].Name < records[j].Name
+	})
+	desired := map[upsyncCopyKey]upsyncCopy{}
+	keys := []upsyncCopyKey{}
+	conflicts := []v1alpha1.UpsyncConflict{}
+	for _, recordCM := range records {
+		cluster := recordCM.Namespace
+		record, err := upsync.DecodeRecord(recordCM.Data)
+		if err != nil {
+			logger.Error(err, "Ignoring malformed upsync record", "namespace", cluster, "name", recordCM.Name)
+			continue
+		}
+		gvr := schema.GroupVersionResource{Group: record.SourceRef.Group, Version: record.SourceRef.Version, Resource: record.SourceRef.Resource}
+		knownGVRs.Insert(gvr)
+		if !clusters.Has(cluster) || recordCM.Labels[upsync.RequestLabelKey] != requestName {
+			continue
+		}
+		namespace, name := upsync.CopyNamespaceAndName(record.Naming, cluster, record.SourceRef.Namespace, record.SourceRef.Name)
+		key := upsyncCopyKey{GVR: gvr, ObjectName: cache.ObjectName{Namespace: namespace, Name: name}}
+		if other, has := desired[key]; has {
+			conflicts = append(conflicts, v1alpha1.UpsyncConflict{Cluster: cluster, SourceRef: record.SourceRef,
+				Message: fmt.Sprintf("the copy would be named %s, the same as the copy of %s/%s from cluster %s", key.ObjectName, other.source.Namespace, other.source.Name, other.cluster)})
+			continue
+		}
+		desired[key] = upsyncCopy{cluster: cluster, source: record.SourceRef, object: record.Object}
+		keys = append(keys, key)
+	}
+
+	// Create or update the desired copies
+	copiedGVRs := map[string]sets.Set[schema.GroupVersionResource]{}
+	for _, key := range keys {
+		upsyncCopy := desired[key]
+		conflict, err := c.writeUpsyncCopy(ctx, bindingName, key, upsyncCopy)
+		if err != nil {
+			return nil, err
+		}
+		if conflict != "" {
+			delete(desired, key)
+			conflicts = append(conflicts, v1alpha1.UpsyncConflict{Cluster: upsyncCopy.cluster, SourceRef: upsyncCopy.source, Message: conflict})
+			continue
+		}
+		if copiedGVRs[upsyncCopy.cluster] == nil {
+			copiedGVRs[upsyncCopy.cluster] = sets.New[schema.GroupVersionResource]()
+		}
+		copiedGVRs[upsyncCopy.cluster].Insert(key.GVR)
+	}
+
+	// Delete the stale copies
+	for gvr := range knownGVRs {
+		list, err := c.wdsDynamicClient.Resource(gvr).List(ctx, metav1.ListOptions{
+			LabelSelector: labels.SelectorFromSet(labels.Set{upsync.BindingLabelKey: bindingName}).String(),
+		})
+		if errors.IsNotFound(err) { // the resource is not defined in the WDS
+			continue
+		} else if err != nil {
+			return nil, fmt.Errorf("failed to list copies of %v in the WDS: %w", gvr, err)
+		}
+		for idx := range list.Items {
+			obj := &list.Items[idx]
+			if _, keep := desired[upsyncCopyKey{GVR: gvr, ObjectName: cache.MetaObjectToName(obj)}]; keep {
+				continue
+			}
+			err := util.DynamicForResource(c.wdsDynamicClient, gvr, obj.GetNamespace()).Delete(ctx, obj.GetName(), metav1.DeleteOptions{})
+			if err != nil && !errors.IsNotFound(err) {
+				return nil, fmt.Errorf("failed to delete stale copy %s/%s of %v from the WDS: %w", obj.GetNamespace(), obj.GetName(), gvr, err)
+			}
+			logger.V(2).Info("Deleted stale upsync copy", "binding", bindingName, "gvr", gvr, "namespace", obj.GetNamespace(), "name", obj.GetName())
+		}
+	}
+
+	// Release the namespaces that were created to hold copies and no longer hold any of this Binding's
+	if len(requests) > 0 || len(records) > 0 {
+		inUse := sets.New[string]()
+		for key := range desired {
+			if key.Namespace != metav1.NamespaceNone {
+				inUse.Insert(key.Namespace)
+			}
+		}
+		if err := c.releaseUpsyncNamespaces(ctx, bindingName, inUse); err != nil {
+			return nil, err
+		}
+	}
+
+	// Make the upsync requests agree with the desired state
+	for _, request := range requests {
+		if request.Name == requestName && clusters.Has(request.Namespace) {
+			continue
+		}
+		err := c.transportClient.Resource(configMapGVR).Namespace(request.Namespace).Delete(ctx, request.Name, metav1.DeleteOptions{})
+		if err != nil && !errors.IsNotFound(err) {
+			return nil, fmt.Errorf("failed to delete upsync request %s/%s: %w", request.Namespace, request.Name, err)
+		}
+		logger.V(2).Info("Deleted upsync request", "namespace", request.Namespace, "name", request.Name)
+	}
+	for cluster := range clusters {
+		data := upsync.EncodeClauses(clauses)
+		data[upsync.ResourcesDataKey] = encodeGVRs(copiedGVRs[cluster])
+		if err := c.writeUpsyncRequest(ctx, bindingName, cluster, requestName, requests, data); err != nil {
+			return nil, err
+		}
+	}
+
+	sort.Slice(conflicts, func(i, j int) bool { return upsyncConflictLess(conflicts[i], conflicts[j]) })
+	if len(conflicts) == 0 {
+		return nil, nil
+	}
+	return conflicts, nil
+}
+
+// writeUpsyncCopy creates or updates the given copy in the WDS.
+// It returns a non-empty message if the copy conflicts with something else in the WDS.
+func (c *genericTransportController) writeUpsyncCopy(ctx context.Context, bindingName string, key upsyncCopyKey, upsyncCopy upsyncCopy) (string, error) {
+	logger := klog.FromContext(ctx)
+	sourceJSON, err := json.Marshal(upsyncCopy.source)
+	if err != nil {
+		return "", err
+	}
+	desired := upsyncCopy.object.DeepCopy()
+	desired.SetNamespace(key.Namespace)
+	desired.SetName(key.Name)
+	setLabel(desired, upsync.ClusterLabelKey, upsyncCopy.cluster)
+	setLabel(desired, upsync.BindingLabelKey, bindingName)
+	setAnnotation(desired, upsync.SourceAnnotationKey, string(sourceJSON))
+	client := util.DynamicForResource(c.wdsDynamicClient, key.GVR, key.Namespace)
+	existing, err := client.Get(ctx, key.Name, metav1.GetOptions{})
+	if err == nil {
+		if existing.GetLabels()[upsync.BindingLabelKey] != bindingName || existing.GetLabels()[upsync.ClusterLabelKey] != upsyncCopy.cluster ||
+			existing.GetAnnotations()[upsync.SourceAnnotationKey] != string(sourceJSON) {
+			return fmt.Sprintf("%s %s already exists in the WDS and is not the copy of this object for this Binding", desired.GetKind(), key.ObjectName), nil
+		}
+		if !upsyncCopyNeedsUpdate(existing, desired) {
+			return "", nil
+		}
+		desired.SetResourceVersion(existing.GetResourceVersion())
+		if status, has := existing.Object["status"]; has {
+			desired.Object["status"] = status
+		}
+		if _, err := client.Update(ctx, desired, metav1.UpdateOptions{FieldManager: ControllerName}); err != nil {
+			return "", fmt.Errorf("failed to update upsync copy %s of %v in the WDS: %w", key.ObjectName, key.GVR, err)
+		}
+		logger.V(4).Info("Updated upsync copy", "binding", bindingName, "gvr", key.GVR, "copy", key.ObjectName)
+		return "", nil
+	} else if !errors.IsNotFound(err) {
+		return "", fmt.Errorf("failed to get %s of %v from the WDS: %w", key.ObjectName, key.GVR, err)
+	}
+	if key.Namespace != metav1.NamespaceNone {
+		if err := c.ensureUpsyncNamespace(ctx, key.Namespace, upsyncCopy.cluster, bindingName); err != nil {
+			return "", err
+		}
+	}
+	_, err = client.Create(ctx, desired, metav1.CreateOptions{FieldManager: ControllerName})
+	switch {
+	case err == nil:
+		logger.V(2).Info("Created upsync copy", "binding", bindingName, "gvr", key.GVR, "copy", key.ObjectName)
+		return "", nil
+	case errors.IsNotFound(err) || errors.IsInvalid(err) || errors.IsBadRequest(err) || errors.IsAlreadyExists(err):
+		return fmt.Sprintf("failed to create the copy %s in the WDS: %s", key.ObjectName, err.Error()), nil
+	default:
+		return "", fmt.Errorf("failed to create upsync copy %s of %v in the WDS: %w", key.ObjectName, key.GVR, err)
+	}
+}
+
+// ensureUpsyncNamespace creates the given namespace in the WDS, if it does not already exist,
+// labeled as holding copies from the given cluster, and notes that the given Binding has copies in it.
+// A namespace that was not created to hold copies is left alone.
+func (c *genericTransportController) ensureUpsyncNamespace(ctx context.Context, namespace, cluster, bindingName string) error {
+	client := c.wdsDynamicClient.Resource(namespaceGVR)
+	existing, err := client.Get(ctx, namespace, metav1.GetOptions{})
+	if err == nil {
+		bindings := upsyncNamespaceBindings(existing)
+		if !upsync.IsCopy(existing) || bindings.Has(bindingName) {
+			return nil
+		}
+		setUpsyncNamespaceBindings(existing, bindings.Insert(bindingName))
+		if _, err := client.Update(ctx, existing, metav1.UpdateOptions{FieldManager: ControllerName}); err != nil {
+			return fmt.Errorf("failed to note Binding %s in namespace %s for upsync copies in the WDS: %w", bindingName, namespace, err)
+		}
+		return nil
+	} else if !errors.IsNotFound(err) {
+		return err
+	}
+	ns := &unstructured.Unstructured{}
+	ns.SetAPIVersion("v1")
+	ns.SetKind("Namespace")
+	ns.SetName(namespace)
+	ns.SetLabels(map[string]string{upsync.ClusterLabelKey: cluster})
+	setUpsyncNamespaceBindings(ns, sets.New(bindingName))
+	_, err = client.Create(ctx, ns, metav1.CreateOptions{FieldManager: ControllerName})
+	if errors.IsAlreadyExists(err) { // created concurrently, try again to note the Binding
+		return c.ensureUpsyncNamespace(ctx, namespace, cluster, bindingName)
+	} else if err != nil {
+		return fmt.Errorf("failed to create namespace %s for upsync copies in the WDS: %w", namespace, err)
+	}
+	klog.FromContext(ctx).V(2).Info("Created namespace for upsync copies", "namespace", namespace, "cluster", cluster)
+	return nil
+}
+
+// releaseUpsyncNamespaces removes the note of the given Binding from every namespace that was created
+// in the WDS to hold copies, except those in the given set, and deletes the namespaces that are
+// left with no Binding noted. Deletion is conditioned on the namespace not having changed since it was read,
+// so that a concurrent noting of another Binding is not lost.
+func (c *genericTransportController) releaseUpsyncNamespaces(ctx context.Context, bindingName string, inUse sets.Set[string]) error {
+	logger := klog.FromContext(ctx)
+	client := c.wdsDynamicClient.Resource(namespaceGVR)
+	list, err := client.List(ctx, metav1.ListOptions{LabelSelector: upsync.ClusterLabelKey})
+	if err != nil {
+		return fmt.Errorf("failed to list namespaces for upsync copies in the WDS: %w", err)
+	}
+	for idx := range list.Items {
+		ns := &list.Items[idx]
+		bindings := upsyncNamespaceBindings(ns)
+		if !bindings.Has(bindingName) || inUse.Has(ns.GetName()) {
+			continue
+		}
+		bindings.Delete(bindingName)
+		if bindings.Len() > 0 {
+			setUpsyncNamespaceBindings(ns, bindings)
+			if _, err := client.Update(ctx, ns, metav1.UpdateOptions{FieldManager: ControllerName}); err != nil {
+				return fmt.Errorf("failed to remove Binding %s from namespace %s for upsync copies in the WDS: %w", bindingName, ns.GetName(), err)
+			}
+			continue
+		}
+		resourceVersion := ns.GetResourceVersion()
+		err := client.Delete(ctx, ns.GetName(), metav1.DeleteOptions{Preconditions: &metav1.Preconditions{ResourceVersion: &resourceVersion}})
+		if err != nil && !errors.IsNotFound(err) {
+			return fmt.Errorf("failed to delete namespace %s for upsync copies from the WDS: %w", ns.GetName(), err)
+		}
+		logger.V(2).Info("Deleted namespace for upsync copies", "namespace", ns.GetName(), "binding", bindingName)
+	}
+	return nil
+}
+
+// upsyncNamespaceBindings returns the names of the Bindings noted in the given namespace for upsync copies.
+func upsyncNamespaceBindings(ns metav1.Object) sets.Set[string] {
+	var names []string
+	if err := json.Unmarshal([]byte(ns.GetAnnotations()[upsync.BindingsAnnotationKey]), &names); err != nil {
+		return sets.New[string]()
+	}
+	return sets.New(names...)
+}
+
+// setUpsyncNamespaceBindings notes the given set of Binding names in the given namespace for upsync copies.
+func setUpsyncNamespaceBindings(ns metav1.Object, bindings sets.Set[string]) {
+	namesJSON, _ := json.Marshal(sets.List(bindings)) // can not fail
+	setAnnotation(ns, upsync.BindingsAnnotationKey, string(namesJSON))
+}
+
+// writeUpsyncRequest creates or updates, if necessary, the upsync request in the given cluster's mailbox namespace.
+func (c *genericTransportController) writeUpsyncRequest(ctx context.Context, bindingName, cluster, requestName string, requests []*corev1.ConfigMap, data map[string]string) error {
+	var existing *corev1.ConfigMap
+	for _, request := range requests {
+		if request.Namespace == cluster && request.Name == requestName {
+			existing = request
+		}
+	}
+	if existing != nil && apiequality.Semantic.DeepEqual(existing.Data, data) {
+		return nil
+	}
+	request := &corev1.ConfigMap{
+		TypeMeta: metav1.TypeMeta{APIVersion: "v1", Kind: "ConfigMap"},
+		ObjectMeta: metav1.ObjectMeta{
+			Namespace: cluster,
+			Name:      requestName,
+			Labels: map[string]string{
+				upsync.RoleLabelKey:      upsync.RoleRequest,
+				upsync.BindingLabelKey:   bindingName,
+				upsync.OriginWdsLabelKey: c.wdsName,
+			},
+		},
+		Data: data,
+	}
+	if existing != nil {
+		request.ResourceVersion = existing.ResourceVersion
+	}
+	requestMap, err := runtime.DefaultUnstructuredConverter.ToUnstructured(request)
+	if err != nil {
+		return err
+	}
+	client := c.transportClient.Resource(configMapGVR).Namespace(cluster)
+	if existing == nil {
+		_, err = client.Create(ctx, &unstructured.Unstructured{Object: requestMap}, metav1.CreateOptions{FieldManager: ControllerName})
+	} else {
+		_, err = client.Update(ctx, &unstructured.Unstructured{Object: requestMap}, metav1.UpdateOptions{FieldManager: ControllerName})
+	}
+	if err != nil {
+		return fmt.Errorf("failed to write upsync request %s/%s: %w", cluster, requestName, err)
+	}
+	klog.FromContext(ctx).V(2).Info("Wrote upsync request", "namespace", cluster, "name", requestName)
+	return nil
+}
+
+// upsyncCopyNeedsUpdate tells whether the existing copy in the WDS differs from the desired one,
+// ignoring status and the metadata that is maintained by the server.
+func upsyncCopyNeedsUpdate(existing, desired *unstructured.Unstructured) bool {
+	for key, val := range desired.Object {
+		if key != "metadata" && key != "status" && !apiequality.Semantic.DeepEqual(existing.Object[key], val) {
+			return true
+		}
+	}
+	return !apiequality.Semantic.DeepEqual(existing.GetLabels(), desired.GetLabels()) ||
+		!apiequality.Semantic.DeepEqual(existing.GetAnnotations(), desired.GetAnnotations())
+}
+
+func encodeGVRs(gvrs sets.Set[schema.GroupVersionResource]) string {
+	list := make([]metav1.GroupVersionResource, 0, gvrs.Len())
+	for gvr := range gvrs {
+		list = append(list, metav1.GroupVersionResource{Group: gvr.Group, Version: gvr.Version, Resource: gvr.Resource})
+	}
+	sort.Slice(list, func(i, j int) bool { return list[i].String() < list[j].String() })
+	listJSON, _ := json.Marshal(list) // can not fail
+	return string(listJSON)
+}
+
+func decodeGVRs(listJSON string) []schema.GroupVersionResource {
+	var list []metav1.GroupVersionResource
+	if err := json.Unmarshal([]byte(listJSON), &list); err != nil {
+		return nil
+	}
+	ans := make([]schema.GroupVersionResource, 0, len(list))
+	for _, gvr := range list {
+		ans = append(ans, schema.GroupVersionResource{Group: gvr.Group, Version: gvr.Version, Resource: gvr.Resource})
+	}
+	return ans
+}
+
+func upsyncConflictLess(a, b v1alpha1.UpsyncConflict) bool {
+	if a.Cluster != b.Cluster {
+		return a.Cluster < b.Cluster
+	}
+	return sourceRefLess(a.SourceRef, b.SourceRef)
+}
+
+// sourceRefLess orders references by group, resource, namespace and then name.
+func sourceRefLess(aRef, bRef v1alpha1.SourceObjectReference) bool {
+	if aRef.Group != bRef.Group {
+		return aRef.Group < bRef.Group
+	}
+	if aRef.Resource != bRef.Resource {
+		return aRef.Resource < bRef.Resource
+	}
+	if aRef.Namespace != bRef.Namespace {
+		return aRef.Namespace < bRef.Namespace
+	}
+	return aRef.Name < bRef.Name
+}
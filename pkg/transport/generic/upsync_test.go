@@ -0,0 +1,83 @@
+/*
+Copyright 2024 The KubeStellar Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package transport
+
+import (
+	"testing"
+
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/util/sets"
+	dynamicfake "k8s.io/client-go/dynamic/fake"
+	"k8s.io/klog/v2/ktesting"
+)
+
+func TestUpsyncNamespaces(t *testing.T) {
+	_, ctx := ktesting.NewTestContext(t)
+	scheme := runtime.NewScheme()
+	corev1.AddToScheme(scheme)
+	userNS := &corev1.Namespace{TypeMeta: metav1.TypeMeta{APIVersion: "v1", Kind: "Namespace"}, ObjectMeta: metav1.ObjectMeta{Name: "user"}}
+	c := &genericTransportController{wdsDynamicClient: dynamicfake.NewSimpleDynamicClient(scheme, userNS)}
+	getBindings := func(namespace string) (sets.Set[string], bool) {
+		ns, err := c.wdsDynamicClient.Resource(namespaceGVR).Get(ctx, namespace, metav1.GetOptions{})
+		if errors.IsNotFound(err) {
+			return nil, false
+		} else if err != nil {
+			t.Fatalf("Failed to get namespace %s: %v", namespace, err)
+		}
+		return upsyncNamespaceBindings(ns), true
+	}
+
+	for _, bindingName := range []string{"b1", "b2"} {
+		if err := c.ensureUpsyncNamespace(ctx, "wec1-ns1", "wec1", bindingName); err != nil {
+			t.Fatalf("Failed to ensure namespace for %s: %v", bindingName, err)
+		}
+		if err := c.ensureUpsyncNamespace(ctx, "user", "wec1", bindingName); err != nil {
+			t.Fatalf("Failed to ensure user namespace for %s: %v", bindingName, err)
+		}
+	}
+	if bindings, found := getBindings("wec1-ns1"); !found || !bindings.Equal(sets.New("b1", "b2")) {
+		t.Errorf("Expected namespace noting b1 and b2, got found=%v, %v", found, bindings)
+	}
+	if bindings, _ := getBindings("user"); bindings.Len() != 0 {
+		t.Errorf("Expected the user's namespace to be left alone, got %v", bindings)
+	}
+
+	if err := c.releaseUpsyncNamespaces(ctx, "b1", sets.New("wec1-ns1")); err != nil {
+		t.Fatalf("Failed to release namespaces of b1: %v", err)
+	}
+	if bindings, found := getBindings("wec1-ns1"); !found || bindings.Len() != 2 {
+		t.Errorf("Expected namespace in use to keep noting b1 and b2, got found=%v, %v", found, bindings)
+	}
+	if err := c.releaseUpsyncNamespaces(ctx, "b1", nil); err != nil {
+		t.Fatalf("Failed to release namespaces of b1: %v", err)
+	}
+	if bindings, found := getBindings("wec1-ns1"); !found || !bindings.Equal(sets.New("b2")) {
+		t.Errorf("Expected namespace noting only b2, got found=%v, %v", found, bindings)
+	}
+	if err := c.releaseUpsyncNamespaces(ctx, "b2", nil); err != nil {
+		t.Fatalf("Failed to release namespaces of b2: %v", err)
+	}
+	if _, found := getBindings("wec1-ns1"); found {
+		t.Error("Expected namespace to be deleted once no Binding has copies in it")
+	}
+	if _, found := getBindings("user"); !found {
+		t.Error("Expected the user's namespace to remain")
+	}
+}
@@ -0,0 +1,128 @@
+/*
+Copyright 2024 The KubeStellar Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package transport
+
+import (
+	"fmt"
+	"sort"
+
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/labels"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/util/sets"
+	"k8s.io/client-go/tools/cache"
+
+	"github.com/kubestellar/kubestellar/api/control/v1alpha1"
+	"github.com/kubestellar/kubestellar/pkg/wecreport"
+)
+
+// handleWECReport enqueues a reference to the Binding that the given report is for.
+func (c *genericTransportController) handleWECReport(obj any, event string) {
+	c.driftSampler.Prod()
+	if dfsu, is := obj.(cache.DeletedFinalStateUnknown); is {
+		obj = dfsu.Obj
+	}
+	cm, ok := obj.(metav1.Object)
+	if !ok {
+		return
+	}
+	bindingName, found := cm.GetLabels()[wecreport.BindingLabelKey]
+	if !found {
+		return
+	}
+	c.logger.V(5).Info("Enqueuing reference to Binding due to informer event about WEC report", "bindingName", bindingName, "configMap", cache.MetaObjectToName(cm), "resourceVersion", cm.GetResourceVersion(), "event", event)
+	c.workqueue.Add(bindingName)
+}
+
+// decodeWECReport returns the name of the WEC that the given report ConfigMap is from, and its content.
+func decodeWECReport(obj runtime.Object) (string, *wecreport.Report, error) {
+	cm := &corev1.ConfigMap{}
+	if err := runtime.DefaultUnstructuredConverter.FromUnstructured(obj.(*unstructured.Unstructured).Object, cm); err != nil {
+		return "", nil, err
+	}
+	report, err := wecreport.DecodeReport(cm.Data)
+	return cm.Namespace, report, err
+}
+
+// wecReports returns the adoption and drift reports, from the current destinations of the
+// given Binding, ordered by cluster and then object. Malformed reports are logged and skipped.
+func (c *genericTransportController) wecReports(binding *v1alpha1.Binding) ([]v1alpha1.AdoptionReport, []v1alpha1.DriftReport, error) {
+	objs, err := c.wecReportLister.List(labels.SelectorFromSet(labels.Set{wecreport.BindingLabelKey: binding.Name}))
+	if err != nil {
+		return nil, nil, fmt.Errorf("failed to list WEC reports for Binding %q: %w", binding.Name, err)
+	}
+	destinations := sets.New[string]()
+	for _, destination := range binding.Spec.Destinations {
+		destinations.Insert(destination.ClusterId)
+	}
+	var adoption []v1alpha1.AdoptionReport
+	var drift []v1alpha1.DriftReport
+	for _, obj := range objs {
+		cluster, report, err := decodeWECReport(obj)
+		if err != nil {
+			c.logger.Error(err, "Ignoring malformed WEC report", "binding", binding.Name, "cluster", cluster)
+			continue
+		}
+		if !destinations.Has(cluster) {
+			continue
+		}
+		for _, objDiffs := range report.Adoption {
+			adoption = append(adoption, v1alpha1.AdoptionReport{Cluster: cluster, SourceRef: objDiffs.SourceRef, Differences: objDiffs.Differences})
+		}
+		for _, objDrift := range report.Drift {
+			drift = append(drift, v1alpha1.DriftReport{Cluster: cluster, SourceRef: objDrift.SourceRef, Differences: objDrift.Differences, Remediated: objDrift.Remediated})
+		}
+	}
+	sort.Slice(adoption, func(i, j int) bool {
+		return clusterThenSourceRefLess(adoption[i].Cluster, adoption[i].SourceRef, adoption[j].Cluster, adoption[j].SourceRef)
+	})
+	sort.Slice(drift, func(i, j int) bool {
+		return clusterThenSourceRefLess(drift[i].Cluster, drift[i].SourceRef, drift[j].Cluster, drift[j].SourceRef)
+	})
+	return adoption, drift, nil
+}
+
+func clusterThenSourceRefLess(leftCluster string, leftRef v1alpha1.SourceObjectReference, rightCluster string, rightRef v1alpha1.SourceObjectReference) bool {
+	if leftCluster != rightCluster {
+		return leftCluster < rightCluster
+	}
+	return sourceRefLess(leftRef, rightRef)
+}
+
+// countUnremediatedDrift returns the number of workload objects, summed over the WECs,
+// that the given reports say have drifted and not been remediated.
+func countUnremediatedDrift(lister cache.GenericLister) float64 {
+	objs, err := lister.List(labels.Everything())
+	if err != nil {
+		return 0
+	}
+	var count int
+	for _, obj := range objs {
+		_, report, err := decodeWECReport(obj)
+		if err != nil {
+			continue
+		}
+		for _, objDrift := range report.Drift {
+			if !objDrift.Remediated {
+				count++
+			}
+		}
+	}
+	return float64(count)
+}
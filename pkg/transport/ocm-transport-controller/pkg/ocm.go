@@ -18,6 +18,7 @@ package ocm
 
 import (
 	"fmt"
+	"strings"
 
 	workv1 "open-cluster-management.io/api/work/v1"
 
@@ -27,6 +28,7 @@ import (
 	"k8s.io/apimachinery/pkg/runtime/schema"
 	"k8s.io/klog/v2"
 
+	"github.com/kubestellar/kubestellar/api/control/v1alpha1"
 	"github.com/kubestellar/kubestellar/pkg/transport"
 	"github.com/kubestellar/kubestellar/pkg/util"
 )
@@ -43,26 +45,86 @@ func NewOCMTransport() transport.Transport {
 type ocm struct {
 }
 
+var _ transport.AppliedChecker = &ocm{}
+
+var _ transport.WrapeeChecker = &ocm{}
+
 var createOnlyStrategy = workv1.UpdateStrategy{Type: workv1.UpdateStrategyTypeCreateOnly}
 
+// workAgentFieldManager is the field manager that the work agent uses by default,
+// and the required prefix of any other field manager that it is given.
+const workAgentFieldManager = "work-agent"
+
+func serverSideApplyStrategy(options v1alpha1.ServerSideApplyOptions) *workv1.UpdateStrategy {
+	fieldManager := options.FieldManager
+	if fieldManager != "" && !strings.HasPrefix(fieldManager, workAgentFieldManager) {
+		fieldManager = workAgentFieldManager + "-" + fieldManager
+	}
+	return &workv1.UpdateStrategy{
+		Type:            workv1.UpdateStrategyTypeServerSideApply,
+		ServerSideApply: &workv1.ServerSideApplyConfig{Force: options.Force, FieldManager: fieldManager},
+	}
+}
+
+// CheckWrapee rejects what the version of the OCM work agent that KubeStellar uses can not do.
+// That version has no update strategy for only observing an object,
+// always adopts an object that already exists in the WEC, and does not look for drift.
+func (ocm *ocm) CheckWrapee(wrapee transport.Wrapee) error {
+	if wrapee.UpdateStrategy == v1alpha1.UpdateStrategyReadOnly {
+		return fmt.Errorf("the OCM transport does not support the %s update strategy; %s %s/%s can not be propagated",
+			v1alpha1.UpdateStrategyReadOnly, wrapee.Object.GetKind(), wrapee.Object.GetNamespace(), wrapee.Object.GetName())
+	}
+	if wrapee.ReportAdoption {
+		return fmt.Errorf("the OCM transport does not support the %s adoption policy; %s %s/%s can not be propagated",
+			v1alpha1.AdoptionPolicyReport, wrapee.Object.GetKind(), wrapee.Object.GetNamespace(), wrapee.Object.GetName())
+	}
+	if wrapee.DriftPolicy != "" && wrapee.DriftPolicy != v1alpha1.DriftPolicyIgnore {
+		return fmt.Errorf("the OCM transport does not support the %s drift policy; %s %s/%s can not be propagated",
+			wrapee.DriftPolicy, wrapee.Object.GetKind(), wrapee.Object.GetNamespace(), wrapee.Object.GetName())
+	}
+	return nil
+}
+
 func (ocm *ocm) WrapObjects(wrapees []transport.Wrapee, kindToResource func(schema.GroupKind) string) runtime.Object {
 	manifests := make([]workv1.Manifest, len(wrapees))
 	var configs []workv1.ManifestConfigOption
+	var orphaningRules []workv1.OrphaningRule
 	for i, wrapee := range wrapees {
 		manifests[i].RawExtension = runtime.RawExtension{Object: wrapee.Object}
-		if wrapee.CreateOnly {
-			gvk := wrapee.Object.GroupVersionKind()
-			rsc := kindToResource(gvk.GroupKind())
+		if !wrapee.Orphan && (wrapee.UpdateStrategy == v1alpha1.UpdateStrategyUpdate || wrapee.UpdateStrategy == "") {
+			continue
+		}
+		gvk := wrapee.Object.GroupVersionKind()
+		resourceIdentifier := workv1.ResourceIdentifier{
+			Group:     gvk.Group,
+			Resource:  kindToResource(gvk.GroupKind()),
+			Namespace: wrapee.Object.GetNamespace(),
+			Name:      wrapee.Object.GetName(),
+		}
+		switch wrapee.UpdateStrategy {
+		case v1alpha1.UpdateStrategyCreateOnly:
+			configs = append(configs, workv1.ManifestConfigOption{
+				ResourceIdentifier: resourceIdentifier,
+				UpdateStrategy:     &createOnlyStrategy,
+			})
+		case v1alpha1.UpdateStrategyServerSideApply:
 			configs = append(configs, workv1.ManifestConfigOption{
-				ResourceIdentifier: workv1.ResourceIdentifier{
-					Group:     gvk.Group,
-					Resource:  rsc,
-					Namespace: wrapee.Object.GetNamespace(),
-					Name:      wrapee.Object.GetName(),
-				},
-				UpdateStrategy: &createOnlyStrategy,
+				ResourceIdentifier: resourceIdentifier,
+				UpdateStrategy:     serverSideApplyStrategy(wrapee.ServerSideApply),
 			})
 		}
+		if wrapee.Orphan {
+			orphaningRules = append(orphaningRules, workv1.OrphaningRule(resourceIdentifier))
+		}
+	}
+	var deleteOption *workv1.DeleteOption
+	if len(orphaningRules) > 0 {
+		// The work agent leaves these objects in the WEC when they are removed from
+		// the ManifestWork or the ManifestWork is deleted.
+		deleteOption = &workv1.DeleteOption{
+			PropagationPolicy: workv1.DeletePropagationPolicyTypeSelectivelyOrphan,
+			SelectivelyOrphan: &workv1.SelectivelyOrphan{OrphaningRules: orphaningRules},
+		}
 	}
 	return &workv1.ManifestWork{
 		TypeMeta: metav1.TypeMeta{
@@ -74,6 +136,7 @@ func (ocm *ocm) WrapObjects(wrapees []transport.Wrapee, kindToResource func(sche
 				Manifests: manifests,
 			},
 			ManifestConfigs: configs,
+			DeleteOption:    deleteOption,
 		},
 	}
 }
@@ -109,6 +172,23 @@ func (ocm *ocm) UnwrapObjects(wrapped runtime.Object, kindToResource func(schema
 	return gloss, nil
 }
 
+// IsApplied tells whether the ManifestWork's Applied condition is true for its current generation.
+func (ocm *ocm) IsApplied(wrapped *unstructured.Unstructured) bool {
+	conditions, _, err := unstructured.NestedSlice(wrapped.Object, "status", "conditions")
+	if err != nil {
+		return false
+	}
+	for _, condition := range conditions {
+		conditionM, ok := condition.(map[string]any)
+		if !ok || conditionM["type"] != workv1.WorkApplied {
+			continue
+		}
+		observedGeneration, found, _ := unstructured.NestedInt64(conditionM, "observedGeneration")
+		return conditionM["status"] == string(metav1.ConditionTrue) && (!found || observedGeneration == wrapped.GetGeneration())
+	}
+	return false
+}
+
 func ManifestConfigOptionResourceIdentifier(mc workv1.ManifestConfigOption) workv1.ResourceIdentifier {
 	return mc.ResourceIdentifier
 }
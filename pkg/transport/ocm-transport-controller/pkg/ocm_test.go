@@ -0,0 +1,50 @@
+/*
+Copyright 2024 The KubeStellar Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package ocm
+
+import (
+	"testing"
+
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+
+	"github.com/kubestellar/kubestellar/api/control/v1alpha1"
+	"github.com/kubestellar/kubestellar/pkg/transport"
+)
+
+func TestCheckWrapee(t *testing.T) {
+	obj := &unstructured.Unstructured{Object: map[string]any{"apiVersion": "apps/v1", "kind": "Deployment"}}
+	obj.SetNamespace("ns1")
+	obj.SetName("d1")
+	checker := NewOCMTransport().(transport.WrapeeChecker)
+	for _, testCase := range []struct {
+		name   string
+		wrapee transport.Wrapee
+		ok     bool
+	}{
+		{"Update", transport.NewWrapee(obj, v1alpha1.UpdateStrategyUpdate, false), true},
+		{"CreateOnly and orphan", transport.NewWrapee(obj, v1alpha1.UpdateStrategyCreateOnly, true), true},
+		{"ReadOnly", transport.NewWrapee(obj, v1alpha1.UpdateStrategyReadOnly, false), false},
+		{"ReportAdoption", transport.Wrapee{Object: obj, UpdateStrategy: v1alpha1.UpdateStrategyUpdate, ReportAdoption: true}, false},
+		{"Ignore drift", transport.Wrapee{Object: obj, UpdateStrategy: v1alpha1.UpdateStrategyUpdate, DriftPolicy: v1alpha1.DriftPolicyIgnore}, true},
+		{"Report drift", transport.Wrapee{Object: obj, UpdateStrategy: v1alpha1.UpdateStrategyUpdate, DriftPolicy: v1alpha1.DriftPolicyReport}, false},
+		{"Remediate drift", transport.Wrapee{Object: obj, UpdateStrategy: v1alpha1.UpdateStrategyUpdate, DriftPolicy: v1alpha1.DriftPolicyRemediate}, false},
+	} {
+		if err := checker.CheckWrapee(testCase.wrapee); (err == nil) != testCase.ok {
+			t.Errorf("For %s: expected ok=%v, got %v", testCase.name, testCase.ok, err)
+		}
+	}
+}
@@ -23,6 +23,7 @@ import (
 	"k8s.io/apimachinery/pkg/util/sets"
 	"k8s.io/klog/v2"
 
+	"github.com/kubestellar/kubestellar/api/control/v1alpha1"
 	"github.com/kubestellar/kubestellar/pkg/util"
 )
 
@@ -39,10 +40,44 @@ type Transport interface {
 	UnwrapObjects(wrapped runtime.Object, kindToResource func(schema.GroupKind) (string, bool)) (Gloss, error)
 }
 
-// Wrapee is a workload object to wrap and its associated create-only bit
+// AppliedChecker is optionally implemented by a Transport that can tell, from the returned status
+// of a wrapped object, whether the wrapped object's current contents have been applied in the WEC.
+// The generic transport controller uses this to hold back the later sync waves of a Binding
+// until the earlier ones have been applied.
+type AppliedChecker interface {
+	// IsApplied tells whether the given wrapped object, as read from the ITS, reports that its
+	// current contents have been applied in the WEC.
+	IsApplied(wrapped *unstructured.Unstructured) bool
+}
+
+// WrapeeChecker is optionally implemented by a Transport that can not carry every Wrapee faithfully.
+// The generic transport controller reports the errors in the status of the Binding involved,
+// and then propagates nothing from that Binding, rather than let the transport silently
+// do something other than what the Binding asks for.
+type WrapeeChecker interface {
+	// CheckWrapee returns an error, meant for the user, if the transport can not carry the given Wrapee.
+	CheckWrapee(wrapee Wrapee) error
+}
+
+// Wrapee is a workload object to wrap and its associated update strategy, orphan and adoption bits.
+// UpdateStrategy is never empty; see v1alpha1.UpdateStrategy for the meaning of each value.
+// ServerSideApply is meaningful only when UpdateStrategy is ServerSideApply.
+// Orphan means that the object is to be left in the WEC, rather than deleted,
+// when it stops being propagated there.
+// ReportAdoption means that, if the object already exists in the WEC and was not put there
+// by KubeStellar, the existing object is left alone and its differences from this object are
+// reported (see package wecreport), rather than the existing object being taken over.
+// A transport whose agent can not make that comparison takes the object over as usual.
+// DriftPolicy is empty or Report or Remediate, and says whether the agent is to look for
+// drift of the object in the WEC (see package wecreport) and whether to correct it.
+// A transport whose agent can not look for drift ignores this.
 type Wrapee struct {
-	Object     *unstructured.Unstructured
-	CreateOnly bool
+	Object          *unstructured.Unstructured
+	UpdateStrategy  v1alpha1.UpdateStrategy
+	ServerSideApply v1alpha1.ServerSideApplyOptions
+	Orphan          bool
+	ReportAdoption  bool
+	DriftPolicy     v1alpha1.DriftPolicy
 }
 
 // Gloss is a set of identities of workload objects
@@ -57,6 +92,6 @@ func (wr Wrapee) GetID() util.GKObjRef {
 	}
 }
 
-func NewWrapee(object *unstructured.Unstructured, createOnly bool) Wrapee {
-	return Wrapee{object, createOnly}
+func NewWrapee(object *unstructured.Unstructured, updateStrategy v1alpha1.UpdateStrategy, orphan bool) Wrapee {
+	return Wrapee{Object: object, UpdateStrategy: updateStrategy, Orphan: orphan}
 }
@@ -0,0 +1,475 @@
+/*
+Copyright 2024 The KubeStellar Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package upsync
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"time"
+
+	"github.com/go-logr/logr"
+
+	corev1 "k8s.io/api/core/v1"
+	apiequality "k8s.io/apimachinery/pkg/api/equality"
+	"k8s.io/apimachinery/pkg/api/errors"
+	"k8s.io/apimachinery/pkg/api/meta"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/labels"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+	utilruntime "k8s.io/apimachinery/pkg/util/runtime"
+	"k8s.io/apimachinery/pkg/util/sets"
+	"k8s.io/apimachinery/pkg/util/wait"
+	"k8s.io/client-go/dynamic"
+	corev1informers "k8s.io/client-go/informers/core/v1"
+	corev1client "k8s.io/client-go/kubernetes/typed/core/v1"
+	corev1listers "k8s.io/client-go/listers/core/v1"
+	"k8s.io/client-go/tools/cache"
+	"k8s.io/client-go/util/workqueue"
+	"k8s.io/klog/v2"
+
+	"github.com/kubestellar/kubestellar/api/control/v1alpha1"
+	configmap "github.com/kubestellar/kubestellar/pkg/transport/configmap-transport-controller/pkg"
+	"github.com/kubestellar/kubestellar/pkg/util"
+)
+
+// AgentName is the name of the upsync agent, used as its field manager.
+const AgentName = "upsync-agent"
+
+// appliedManifestWorkKind is the kind of the owner that the OCM work agent puts on
+// the objects that it creates, which are therefore downsynced rather than created in the WEC.
+const appliedManifestWorkKind = "AppliedManifestWork"
+
+// downsyncedRef identifies an object in the WEC that was put there by downsync.
+type downsyncedRef struct {
+	GR schema.GroupResource
+	cache.ObjectName
+}
+
+// Agent is the upsync agent for one WEC.
+// It watches the upsync requests in the WEC's mailbox namespace in the ITS and
+// maintains an upsync record for each WEC object that matches a request's clauses.
+// Objects that were downsynced into the WEC are not upsynced.
+type Agent struct {
+	logger                logr.Logger
+	wecName               string
+	itsConfigMapClient    corev1client.ConfigMapInterface
+	itsSecretClient       corev1client.SecretInterface
+	mailboxLister         corev1listers.ConfigMapNamespaceLister
+	mailboxInformerSynced cache.InformerSynced
+	secretLister          corev1listers.SecretNamespaceLister
+	secretInformerSynced  cache.InformerSynced
+	wecDynamicClient      dynamic.Interface
+	wecMapper             meta.RESTMapper
+
+	// workqueue holds the names of upsync requests to sync.
+	workqueue workqueue.RateLimitingInterface
+}
+
+// NewAgent makes a new upsync agent.
+// `mailboxInformer` and `secretInformer` must be limited to the WEC's mailbox namespace,
+// which has the same name as the WEC's inventory object; `secretInformer` had better
+// also be limited to objects with the RoleLabelKey label.
+// The agent does not watch the WEC; a non-zero resync period of the mailboxInformer
+// is what makes the agent periodically refresh the upsync records.
+func NewAgent(ctx context.Context, wecName string,
+	itsConfigMapClient corev1client.ConfigMapInterface,
+	itsSecretClient corev1client.SecretInterface,
+	mailboxInformer corev1informers.ConfigMapInformer,
+	secretInformer corev1informers.SecretInformer,
+	wecDynamicClient dynamic.Interface, wecMapper meta.RESTMapper) *Agent {
+	agent := &Agent{
+		logger:                klog.FromContext(ctx),
+		wecName:               wecName,
+		itsConfigMapClient:    itsConfigMapClient,
+		itsSecretClient:       itsSecretClient,
+		mailboxLister:         mailboxInformer.Lister().ConfigMaps(wecName),
+		mailboxInformerSynced: mailboxInformer.Informer().HasSynced,
+		secretLister:          secretInformer.Lister().Secrets(wecName),
+		secretInformerSynced:  secretInformer.Informer().HasSynced,
+		wecDynamicClient:      wecDynamicClient,
+		wecMapper:             wecMapper,
+		workqueue:             workqueue.NewNamedRateLimitingQueue(workqueue.DefaultControllerRateLimiter(), AgentName),
+	}
+	handlers := cache.ResourceEventHandlerFuncs{
+		AddFunc:    agent.handleMailboxObject,
+		UpdateFunc: func(_, new any) { agent.handleMailboxObject(new) },
+		DeleteFunc: agent.handleMailboxObject,
+	}
+	mailboxInformer.Informer().AddEventHandler(handlers)
+	secretInformer.Informer().AddEventHandler(handlers)
+	return agent
+}
+
+// handleMailboxObject enqueues the name of the upsync request that the given ConfigMap or Secret is or is a record for.
+func (agent *Agent) handleMailboxObject(obj any) {
+	if dfsu, is := obj.(cache.DeletedFinalStateUnknown); is {
+		obj = dfsu.Obj
+	}
+	mObj, ok := obj.(metav1.Object)
+	if !ok {
+		return
+	}
+	_, isConfigMap := obj.(*corev1.ConfigMap)
+	switch mObj.GetLabels()[RoleLabelKey] {
+	case RoleRequest:
+		if isConfigMap {
+			agent.workqueue.Add(mObj.GetName())
+		}
+	case RoleRecord:
+		// This catches requests that were deleted while the agent was not running
+		if requestName, has := mObj.GetLabels()[RequestLabelKey]; has {
+			agent.workqueue.Add(requestName)
+		}
+	}
+}
+
+func (agent *Agent) Run(ctx context.Context, workersCount int) error {
+	defer utilruntime.HandleCrash()
+	defer agent.workqueue.ShutDown()
+
+	agent.logger.Info("Starting upsync agent", "wec", agent.wecName)
+	if ok := cache.WaitForCacheSync(ctx.Done(), agent.mailboxInformerSynced, agent.secretInformerSynced); !ok {
+		return fmt.Errorf("failed to wait for caches to sync")
+	}
+	for i := 1; i <= workersCount; i++ {
+		workerId := i
+		go wait.UntilWithContext(ctx, func(ctx context.Context) { agent.runWorker(ctx, workerId) }, time.Second)
+	}
+	agent.logger.Info("Started upsync workers", "count", workersCount)
+	<-ctx.Done()
+	agent.logger.Info("Shutting down upsync workers")
+	return nil
+}
+
+func (agent *Agent) runWorker(ctx context.Context, workerId int) {
+	logger := klog.FromContext(ctx).WithValues("workerID", workerId)
+	ctx = klog.NewContext(ctx, logger)
+	for agent.processNextWorkItem(ctx) {
+	}
+}
+
+func (agent *Agent) processNextWorkItem(ctx context.Context) bool {
+	logger := klog.FromContext(ctx)
+	obj, shutdown := agent.workqueue.Get()
+	if shutdown {
+		return false
+	}
+	defer agent.workqueue.Done(obj)
+	requestName := obj.(string)
+	if err := agent.syncRequest(ctx, requestName); err != nil {
+		agent.workqueue.AddRateLimited(obj)
+		logger.V(2).Info("Failed to sync upsync request, will retry", "request", requestName, "err", err)
+	} else {
+		agent.workqueue.Forget(obj)
+	}
+	return true
+}
+
+// syncRequest makes the upsync records of the named request, which may not exist,
+// agree with the current state of the WEC.
+func (agent *Agent) syncRequest(ctx context.Context, requestName string) error {
+	logger := klog.FromContext(ctx).WithValues("request", requestName)
+	desired := map[string]desiredRecord{}
+	request, err := agent.mailboxLister.Get(requestName)
+	if err != nil && !errors.IsNotFound(err) {
+		return err
+	}
+	if request != nil {
+		clauses, err := DecodeClauses(request.Data)
+		if err != nil {
+			// Retrying will not help; keep the current records.
+			logger.Error(err, "Ignoring malformed upsync request")
+			return nil
+		}
+		desired, err = agent.computeRecords(ctx, requestName, clauses)
+		if err != nil {
+			return err
+		}
+	}
+	for _, store := range []recordStore{configMapRecords{agent}, secretRecords{agent}} {
+		records, err := store.list(requestName)
+		if err != nil {
+			return err
+		}
+		for recordName, data := range records {
+			want, keep := desired[recordName]
+			if !keep || want.secret != store.holdsSecrets() {
+				if err := store.delete(ctx, recordName); err != nil && !errors.IsNotFound(err) {
+					return fmt.Errorf("failed to delete upsync record %s: %w", recordName, err)
+				}
+				logger.V(2).Info("Deleted upsync record", "record", recordName)
+				continue
+			}
+			delete(desired, recordName)
+			if apiequality.Semantic.DeepEqual(data, want.data) {
+				continue
+			}
+			if err := store.update(ctx, recordName, want.data); err != nil {
+				return fmt.Errorf("failed to update upsync record %s: %w", recordName, err)
+			}
+			logger.V(4).Info("Updated upsync record", "record", recordName)
+		}
+	}
+	for recordName, want := range desired {
+		objMeta := metav1.ObjectMeta{
+			Namespace: agent.wecName,
+			Name:      recordName,
+			Labels: map[string]string{
+				RoleLabelKey:      RoleRecord,
+				RequestLabelKey:   requestName,
+				BindingLabelKey:   request.Labels[BindingLabelKey],
+				OriginWdsLabelKey: request.Labels[OriginWdsLabelKey],
+			},
+		}
+		var store recordStore = configMapRecords{agent}
+		if want.secret {
+			store = secretRecords{agent}
+		}
+		if err := store.create(ctx, objMeta, want.data); err != nil {
+			return fmt.Errorf("failed to create upsync record %s: %w", recordName, err)
+		}
+		logger.V(2).Info("Created upsync record", "record", recordName)
+	}
+	return nil
+}
+
+// desiredRecord is the desired state of one upsync record.
+type desiredRecord struct {
+	data map[string]string
+	// secret tells whether the record is a Secret rather than a ConfigMap
+	secret bool
+}
+
+// recordStore is access to the upsync records, of one kind, in the WEC's mailbox namespace.
+type recordStore interface {
+	holdsSecrets() bool
+	// list returns the data of the records of the named request, indexed by record name
+	list(requestName string) (map[string]map[string]string, error)
+	create(ctx context.Context, objMeta metav1.ObjectMeta, data map[string]string) error
+	update(ctx context.Context, recordName string, data map[string]string) error
+	delete(ctx context.Context, recordName string) error
+}
+
+// configMapRecords is the recordStore of the ConfigMap records.
+type configMapRecords struct{ agent *Agent }
+
+var _ recordStore = configMapRecords{}
+
+func (configMapRecords) holdsSecrets() bool { return false }
+
+func (store configMapRecords) list(requestName string) (map[string]map[string]string, error) {
+	records, err := store.agent.mailboxLister.List(labels.SelectorFromSet(labels.Set{RoleLabelKey: RoleRecord, RequestLabelKey: requestName}))
+	if err != nil {
+		return nil, err
+	}
+	ans := make(map[string]map[string]string, len(records))
+	for _, record := range records {
+		ans[record.Name] = record.Data
+	}
+	return ans, nil
+}
+
+func (store configMapRecords) create(ctx context.Context, objMeta metav1.ObjectMeta, data map[string]string) error {
+	_, err := store.agent.itsConfigMapClient.Create(ctx, &corev1.ConfigMap{ObjectMeta: objMeta, Data: data}, metav1.CreateOptions{FieldManager: AgentName})
+	return err
+}
+
+func (store configMapRecords) update(ctx context.Context, recordName string, data map[string]string) error {
+	record, err := store.agent.mailboxLister.Get(recordName)
+	if err != nil {
+		return err
+	}
+	record = record.DeepCopy()
+	record.Data = data
+	_, err = store.agent.itsConfigMapClient.Update(ctx, record, metav1.UpdateOptions{FieldManager: AgentName})
+	return err
+}
+
+func (store configMapRecords) delete(ctx context.Context, recordName string) error {
+	return store.agent.itsConfigMapClient.Delete(ctx, recordName, metav1.DeleteOptions{})
+}
+
+// secretRecords is the recordStore of the Secret records.
+type secretRecords struct{ agent *Agent }
+
+var _ recordStore = secretRecords{}
+
+func (secretRecords) holdsSecrets() bool { return true }
+
+func (store secretRecords) list(requestName string) (map[string]map[string]string, error) {
+	records, err := store.agent.secretLister.List(labels.SelectorFromSet(labels.Set{RoleLabelKey: RoleRecord, RequestLabelKey: requestName}))
+	if err != nil {
+		return nil, err
+	}
+	ans := make(map[string]map[string]string, len(records))
+	for _, record := range records {
+		ans[record.Name] = SecretRecordData(record.Data)
+	}
+	return ans, nil
+}
+
+func (store secretRecords) create(ctx context.Context, objMeta metav1.ObjectMeta, data map[string]string) error {
+	record := &corev1.Secret{ObjectMeta: objMeta, Type: corev1.SecretTypeOpaque, Data: secretData(data)}
+	_, err := store.agent.itsSecretClient.Create(ctx, record, metav1.CreateOptions{FieldManager: AgentName})
+	return err
+}
+
+func (store secretRecords) update(ctx context.Context, recordName string, data map[string]string) error {
+	record, err := store.agent.secretLister.Get(recordName)
+	if err != nil {
+		return err
+	}
+	record = record.DeepCopy()
+	record.Data = secretData(data)
+	_, err = store.agent.itsSecretClient.Update(ctx, record, metav1.UpdateOptions{FieldManager: AgentName})
+	return err
+}
+
+func (store secretRecords) delete(ctx context.Context, recordName string) error {
+	return store.agent.itsSecretClient.Delete(ctx, recordName, metav1.DeleteOptions{})
+}
+
+func secretData(data map[string]string) map[string][]byte {
+	ans := make(map[string][]byte, len(data))
+	for key, val := range data {
+		ans[key] = []byte(val)
+	}
+	return ans
+}
+
+// computeRecords returns the upsync records that the named request should have,
+// indexed by record name. An object that matches several clauses is named by the first.
+// Resources that are not known in the WEC are skipped.
+func (agent *Agent) computeRecords(ctx context.Context, requestName string, clauses []v1alpha1.UpsyncClause) (map[string]desiredRecord, error) {
+	logger := klog.FromContext(ctx)
+	downsynced, err := agent.downsyncedObjects()
+	if err != nil {
+		return nil, err
+	}
+	ans := map[string]desiredRecord{}
+	for idx := range clauses {
+		clause := &clauses[idx]
+		for _, resource := range clause.Resources {
+			gvr, namespaced, err := agent.mapResource(schema.GroupResource{Group: clause.APIGroup, Resource: resource})
+			if err != nil {
+				logger.V(4).Info("Skipping resource not known in the WEC", "group", clause.APIGroup, "resource", resource, "err", err)
+				continue
+			}
+			namespaces := []string{metav1.NamespaceAll}
+			if namespaced && len(clause.Namespaces) > 0 {
+				namespaces = clause.Namespaces
+			}
+			for _, namespace := range namespaces {
+				list, err := util.DynamicForResource(agent.wecDynamicClient, gvr, namespace).List(ctx, metav1.ListOptions{})
+				if err != nil {
+					return nil, fmt.Errorf("failed to list %v in namespace %q: %w", gvr, namespace, err)
+				}
+				for itemIdx := range list.Items {
+					obj := &list.Items[itemIdx]
+					if isDownsynced(obj, gvr.GroupResource(), downsynced) {
+						continue
+					}
+					matches, err := ClauseMatches(clause, gvr.Group, gvr.Resource, obj)
+					if err != nil {
+						logger.Error(err, "Ignoring upsync clause with malformed objectSelectors", "index", idx)
+						break
+					}
+					if !matches {
+						continue
+					}
+					sourceRef := v1alpha1.SourceObjectReference{
+						Group:     gvr.Group,
+						Version:   gvr.Version,
+						Resource:  gvr.Resource,
+						Kind:      obj.GetKind(),
+						Namespace: obj.GetNamespace(),
+						Name:      obj.GetName(),
+					}
+					recordName := RecordName(requestName, sourceRef)
+					if _, has := ans[recordName]; has {
+						continue
+					}
+					record := Record{SourceRef: sourceRef, Object: StripObject(obj), Naming: clause.Naming}
+					data, err := record.Encode()
+					if err != nil {
+						return nil, fmt.Errorf("failed to encode %s %s/%s: %w", sourceRef.Kind, sourceRef.Namespace, sourceRef.Name, err)
+					}
+					ans[recordName] = desiredRecord{data: data, secret: IsSecret(gvr.Group, gvr.Resource)}
+				}
+			}
+		}
+	}
+	return ans, nil
+}
+
+// mapResource returns the preferred version of the given resource in the WEC and whether it is namespaced.
+func (agent *Agent) mapResource(gr schema.GroupResource) (schema.GroupVersionResource, bool, error) {
+	lookup := func() (schema.GroupVersionResource, bool, error) {
+		gvk, err := agent.wecMapper.KindFor(gr.WithVersion(""))
+		if err != nil {
+			return schema.GroupVersionResource{}, false, err
+		}
+		mapping, err := agent.wecMapper.RESTMapping(gvk.GroupKind(), gvk.Version)
+		if err != nil {
+			return schema.GroupVersionResource{}, false, err
+		}
+		return mapping.Resource, mapping.Scope.Name() == meta.RESTScopeNameNamespace, nil
+	}
+	gvr, namespaced, err := lookup()
+	if err != nil && meta.IsNoMatchError(err) {
+		if resettable, ok := agent.wecMapper.(meta.ResettableRESTMapper); ok {
+			resettable.Reset()
+			gvr, namespaced, err = lookup()
+		}
+	}
+	return gvr, namespaced, err
+}
+
+// downsyncedObjects returns the objects that the ConfigMap transport's pull agent has applied to the WEC.
+func (agent *Agent) downsyncedObjects() (sets.Set[downsyncedRef], error) {
+	appliedRecords, err := agent.mailboxLister.List(labels.SelectorFromSet(labels.Set{configmap.AppliedRecordLabelKey: "true"}))
+	if err != nil {
+		return nil, err
+	}
+	ans := sets.New[downsyncedRef]()
+	for _, appliedRecord := range appliedRecords {
+		var refs []configmap.AppliedObjectRef
+		if err := json.Unmarshal([]byte(appliedRecord.Data[configmap.AppliedDataKey]), &refs); err != nil {
+			continue
+		}
+		for _, ref := range refs {
+			if ref.ReadOnly { // observed, not downsynced
+				continue
+			}
+			ans.Insert(downsyncedRef{GR: schema.GroupResource{Group: ref.Group, Resource: ref.Resource},
+				ObjectName: cache.ObjectName{Namespace: ref.Namespace, Name: ref.Name}})
+		}
+	}
+	return ans, nil
+}
+
+// isDownsynced tells whether the given WEC object was put there by downsync,
+// either by the OCM work agent or by the ConfigMap transport's pull agent.
+func isDownsynced(obj metav1.Object, gr schema.GroupResource, downsynced sets.Set[downsyncedRef]) bool {
+	for _, owner := range obj.GetOwnerReferences() {
+		if owner.Kind == appliedManifestWorkKind {
+			return true
+		}
+	}
+	return downsynced.Has(downsyncedRef{GR: gr, ObjectName: cache.MetaObjectToName(obj)})
+}
@@ -0,0 +1,239 @@
+/*
+Copyright 2024 The KubeStellar Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+// Package upsync copies selected objects from the WECs into the WDS.
+// The copying goes through ConfigMaps in the WEC's mailbox namespace in the ITS.
+// For each Binding that has upsync clauses, the transport controller maintains
+// an upsync request in the mailbox namespace of each destination; the request holds the clauses.
+// An Agent with access to the WEC maintains, for each request, one upsync record per
+// matching WEC object; the record holds the object. The record of a Secret is itself a Secret,
+// so that the contents of a Secret are never put in a ConfigMap; every other record is a ConfigMap.
+// The transport controller then maintains a copy of each recorded object in the WDS,
+// and deletes the namespaces that it created to hold copies once they hold none.
+package upsync
+
+import (
+	"encoding/json"
+	"fmt"
+	"hash/fnv"
+	"slices"
+
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/labels"
+
+	"github.com/kubestellar/kubestellar/api/control/v1alpha1"
+)
+
+const (
+	// RoleLabelKey is the key of a label on every upsync request and upsync record;
+	// its value is RoleRequest or RoleRecord.
+	RoleLabelKey = "upsync.kubestellar.io/role"
+
+	RoleRequest = "request"
+	RoleRecord  = "record"
+
+	// OriginWdsLabelKey is the key of the label, on upsync requests and records,
+	// that identifies the WDS that the request came from.
+	OriginWdsLabelKey = "transport.kubestellar.io/originWdsName"
+
+	// BindingLabelKey is the key of the label, on upsync requests and records and on
+	// the copies in the WDS, whose value is the name of the Binding.
+	BindingLabelKey = "upsync.kubestellar.io/binding"
+
+	// RequestLabelKey is the key of the label, on an upsync record,
+	// whose value is the name of the upsync request.
+	RequestLabelKey = "upsync.kubestellar.io/request"
+
+	// ClusterLabelKey is the key of the label, on a copy in the WDS (and on a namespace
+	// created in the WDS to hold copies), whose value is the name of the WEC that the
+	// object was copied from. Objects with this label are never downsynced.
+	ClusterLabelKey = "upsync.kubestellar.io/cluster"
+
+	// BindingsAnnotationKey is the key of the annotation, on a namespace created in the WDS
+	// to hold copies, whose value is the JSON rendering of the list of names of the Bindings
+	// that have copies in that namespace. The namespace is deleted once the list becomes empty.
+	BindingsAnnotationKey = "upsync.kubestellar.io/bindings"
+
+	// SourceAnnotationKey is the key of the annotation, on a copy in the WDS,
+	// whose value is the JSON rendering of the SourceObjectReference of the object in the WEC.
+	SourceAnnotationKey = "upsync.kubestellar.io/source"
+
+	// ClausesDataKey is the key, in the data of an upsync request,
+	// whose value is the JSON rendering of the list of UpsyncClause.
+	ClausesDataKey = "clauses"
+
+	// ResourcesDataKey is the key, in the data of an upsync request,
+	// whose value is the JSON rendering of the list of GroupVersionResource of the
+	// copies that the transport controller has made in the WDS for the request.
+	// The transport controller uses this to find stale copies; the Agent ignores it.
+	ResourcesDataKey = "resources"
+
+	// SourceRefDataKey is the key, in the data of an upsync record,
+	// whose value is the JSON rendering of the SourceObjectReference of the object.
+	SourceRefDataKey = "sourceRef"
+
+	// ObjectDataKey is the key, in the data of an upsync record,
+	// whose value is the JSON rendering of the object, without status and
+	// without the metadata that is maintained by the server.
+	ObjectDataKey = "object"
+
+	// NamingDataKey is the key, in the data of an upsync record,
+	// whose value is the UpsyncNaming of the first clause that the object matches.
+	NamingDataKey = "naming"
+)
+
+// RequestName returns the name of the upsync request for the given Binding from the given WDS.
+func RequestName(bindingName, wdsName string) string {
+	return bindingName + "-" + wdsName + ".upsync"
+}
+
+// RecordName returns the name of the upsync record of the given object for the given upsync request.
+func RecordName(requestName string, sourceRef v1alpha1.SourceObjectReference) string {
+	hasher := fnv.New64a()
+	hasher.Write([]byte(sourceRef.Group + "/" + sourceRef.Resource + "/" + sourceRef.Namespace + "/" + sourceRef.Name))
+	return fmt.Sprintf("%s.%016x", requestName, hasher.Sum64())
+}
+
+// CopyNamespaceAndName returns the namespace and name of the WDS copy of the object
+// with the given namespace and name in the given WEC.
+func CopyNamespaceAndName(naming v1alpha1.UpsyncNaming, cluster, namespace, name string) (string, string) {
+	if naming == v1alpha1.UpsyncPrefixNamespace && namespace != metav1.NamespaceNone {
+		return cluster + "-" + namespace, name
+	}
+	return namespace, cluster + "-" + name
+}
+
+// IsSecret tells whether the given API group and resource are those of core Secrets,
+// whose upsync records are Secrets rather than ConfigMaps.
+func IsSecret(group, resource string) bool {
+	return group == "" && resource == "secrets"
+}
+
+// IsCopy tells whether the given WDS object is an upsynced copy (or a namespace created to hold some).
+func IsCopy(obj metav1.Object) bool {
+	_, has := obj.GetLabels()[ClusterLabelKey]
+	return has
+}
+
+// ClauseMatches tells whether the given object, of the given API group and resource, matches the given clause.
+// An error is returned if the clause has a malformed label selector.
+func ClauseMatches(clause *v1alpha1.UpsyncClause, group, resource string, obj metav1.Object) (bool, error) {
+	if clause.APIGroup != group || !slices.Contains(clause.Resources, resource) {
+		return false, nil
+	}
+	if len(clause.Namespaces) > 0 && !slices.Contains(clause.Namespaces, obj.GetNamespace()) {
+		return false, nil
+	}
+	if len(clause.ObjectNames) > 0 && !slices.Contains(clause.ObjectNames, obj.GetName()) {
+		return false, nil
+	}
+	if len(clause.ObjectSelectors) == 0 {
+		return true, nil
+	}
+	for idx := range clause.ObjectSelectors {
+		selector, err := metav1.LabelSelectorAsSelector(&clause.ObjectSelectors[idx])
+		if err != nil {
+			return false, err
+		}
+		if selector.Matches(labels.Set(obj.GetLabels())) {
+			return true, nil
+		}
+	}
+	return false, nil
+}
+
+// EncodeClauses returns the data of an upsync request for the given clauses.
+func EncodeClauses(clauses []v1alpha1.UpsyncClause) map[string]string {
+	clausesJSON, err := json.Marshal(clauses)
+	if err != nil { // can not happen, the clauses came from JSON
+		clausesJSON = []byte("[]")
+	}
+	return map[string]string{ClausesDataKey: string(clausesJSON)}
+}
+
+// DecodeClauses returns the clauses in the data of an upsync request.
+func DecodeClauses(data map[string]string) ([]v1alpha1.UpsyncClause, error) {
+	var clauses []v1alpha1.UpsyncClause
+	if err := json.Unmarshal([]byte(data[ClausesDataKey]), &clauses); err != nil {
+		return nil, fmt.Errorf("failed to parse %s of upsync request: %w", ClausesDataKey, err)
+	}
+	return clauses, nil
+}
+
+// Record is the content of an upsync record.
+type Record struct {
+	SourceRef v1alpha1.SourceObjectReference
+	Object    *unstructured.Unstructured
+	Naming    v1alpha1.UpsyncNaming
+}
+
+// Encode returns the data of an upsync record.
+func (record *Record) Encode() (map[string]string, error) {
+	sourceRefJSON, err := json.Marshal(record.SourceRef)
+	if err != nil {
+		return nil, err
+	}
+	objectJSON, err := record.Object.MarshalJSON()
+	if err != nil {
+		return nil, err
+	}
+	return map[string]string{
+		SourceRefDataKey: string(sourceRefJSON),
+		ObjectDataKey:    string(objectJSON),
+		NamingDataKey:    string(record.Naming),
+	}, nil
+}
+
+// DecodeRecord returns the content of the given data of an upsync record.
+func DecodeRecord(data map[string]string) (*Record, error) {
+	record := &Record{Object: &unstructured.Unstructured{}, Naming: v1alpha1.UpsyncNaming(data[NamingDataKey])}
+	if err := json.Unmarshal([]byte(data[SourceRefDataKey]), &record.SourceRef); err != nil {
+		return nil, fmt.Errorf("failed to parse %s of upsync record: %w", SourceRefDataKey, err)
+	}
+	if err := record.Object.UnmarshalJSON([]byte(data[ObjectDataKey])); err != nil {
+		return nil, fmt.Errorf("failed to parse %s of upsync record: %w", ObjectDataKey, err)
+	}
+	return record, nil
+}
+
+// SecretRecordData returns the data of an upsync record that is a Secret,
+// in the form of the data of an upsync record that is a ConfigMap.
+func SecretRecordData(data map[string][]byte) map[string]string {
+	ans := make(map[string]string, len(data))
+	for key, val := range data {
+		ans[key] = string(val)
+	}
+	return ans
+}
+
+// StripObject returns a copy of the given WEC object without its status and
+// without the metadata that is maintained by the server or is specific to the WEC.
+func StripObject(obj *unstructured.Unstructured) *unstructured.Unstructured {
+	stripped := obj.DeepCopy()
+	delete(stripped.Object, "status")
+	stripped.SetUID("")
+	stripped.SetResourceVersion("")
+	stripped.SetGeneration(0)
+	stripped.SetCreationTimestamp(metav1.Time{})
+	stripped.SetDeletionTimestamp(nil)
+	stripped.SetDeletionGracePeriodSeconds(nil)
+	stripped.SetManagedFields(nil)
+	stripped.SetOwnerReferences(nil)
+	stripped.SetFinalizers(nil)
+	stripped.SetSelfLink("")
+	return stripped
+}
@@ -0,0 +1,202 @@
+/*
+Copyright 2024 The KubeStellar Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package upsync
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/meta"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/labels"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+	"k8s.io/apimachinery/pkg/util/wait"
+	dynamicfake "k8s.io/client-go/dynamic/fake"
+	k8sinformers "k8s.io/client-go/informers"
+	k8sfake "k8s.io/client-go/kubernetes/fake"
+	"k8s.io/klog/v2/ktesting"
+
+	"github.com/kubestellar/kubestellar/api/control/v1alpha1"
+)
+
+var widgetGVR = schema.GroupVersionResource{Group: "example.com", Version: "v1", Resource: "widgets"}
+
+func newWidget(namespace, name string, labels map[string]any) *unstructured.Unstructured {
+	return &unstructured.Unstructured{Object: map[string]any{
+		"apiVersion": "example.com/v1",
+		"kind":       "Widget",
+		"metadata":   map[string]any{"namespace": namespace, "name": name, "labels": labels, "uid": "u-" + name, "resourceVersion": "7"},
+		"spec":       map[string]any{"size": int64(3)},
+		"status":     map[string]any{"ready": true},
+	}}
+}
+
+func TestCopyNamespaceAndName(t *testing.T) {
+	for _, testCase := range []struct {
+		naming                   v1alpha1.UpsyncNaming
+		namespace, name          string
+		expectedNS, expectedName string
+	}{
+		{"", "ns1", "w1", "ns1", "wec1-w1"},
+		{v1alpha1.UpsyncPrefixName, "ns1", "w1", "ns1", "wec1-w1"},
+		{v1alpha1.UpsyncPrefixNamespace, "ns1", "w1", "wec1-ns1", "w1"},
+		{v1alpha1.UpsyncPrefixNamespace, "", "w1", "", "wec1-w1"},
+	} {
+		namespace, name := CopyNamespaceAndName(testCase.naming, "wec1", testCase.namespace, testCase.name)
+		if namespace != testCase.expectedNS || name != testCase.expectedName {
+			t.Errorf("For %#v got %s/%s", testCase, namespace, name)
+		}
+	}
+}
+
+func TestClauseMatches(t *testing.T) {
+	widget := newWidget("ns1", "w1", map[string]any{"app": "a"})
+	for _, testCase := range []struct {
+		clause   v1alpha1.UpsyncClause
+		expected bool
+	}{
+		{v1alpha1.UpsyncClause{APIGroup: "example.com", Resources: []string{"widgets"}}, true},
+		{v1alpha1.UpsyncClause{APIGroup: "", Resources: []string{"widgets"}}, false},
+		{v1alpha1.UpsyncClause{APIGroup: "example.com", Resources: []string{"gadgets"}}, false},
+		{v1alpha1.UpsyncClause{APIGroup: "example.com", Resources: []string{"widgets"}, Namespaces: []string{"ns2"}}, false},
+		{v1alpha1.UpsyncClause{APIGroup: "example.com", Resources: []string{"widgets"}, ObjectNames: []string{"w1"}}, true},
+		{v1alpha1.UpsyncClause{APIGroup: "example.com", Resources: []string{"widgets"},
+			ObjectSelectors: []metav1.LabelSelector{{MatchLabels: map[string]string{"app": "b"}}, {MatchLabels: map[string]string{"app": "a"}}}}, true},
+		{v1alpha1.UpsyncClause{APIGroup: "example.com", Resources: []string{"widgets"},
+			ObjectSelectors: []metav1.LabelSelector{{MatchLabels: map[string]string{"app": "b"}}}}, false},
+	} {
+		actual, err := ClauseMatches(&testCase.clause, "example.com", "widgets", widget)
+		if err != nil || actual != testCase.expected {
+			t.Errorf("For %#v expected %v, got %v and err=%v", testCase.clause, testCase.expected, actual, err)
+		}
+	}
+}
+
+func TestRecordRoundTrip(t *testing.T) {
+	record := &Record{
+		SourceRef: v1alpha1.SourceObjectReference{Group: "example.com", Version: "v1", Resource: "widgets", Kind: "Widget", Namespace: "ns1", Name: "w1"},
+		Object:    StripObject(newWidget("ns1", "w1", nil)),
+		Naming:    v1alpha1.UpsyncPrefixNamespace,
+	}
+	if _, has := record.Object.Object["status"]; has || record.Object.GetUID() != "" || record.Object.GetResourceVersion() != "" {
+		t.Errorf("StripObject left status or server metadata: %v", record.Object.Object)
+	}
+	data, err := record.Encode()
+	if err != nil {
+		t.Fatalf("Failed to encode: %v", err)
+	}
+	decoded, err := DecodeRecord(data)
+	if err != nil {
+		t.Fatalf("Failed to decode: %v", err)
+	}
+	if decoded.SourceRef != record.SourceRef || decoded.Naming != record.Naming || decoded.Object.GetName() != "w1" {
+		t.Errorf("Round trip changed the record: %#v", decoded)
+	}
+}
+
+func TestAgent(t *testing.T) {
+	logger, ctx := ktesting.NewTestContext(t)
+	ctx, cancel := context.WithCancel(ctx)
+	defer cancel()
+	const wecName = "wec1"
+	scheme := runtime.NewScheme()
+	corev1.AddToScheme(scheme)
+	mapper := meta.NewDefaultRESTMapper(nil)
+	mapper.Add(widgetGVR.GroupVersion().WithKind("Widget"), meta.RESTScopeNamespace)
+	mapper.Add(corev1.SchemeGroupVersion.WithKind("Secret"), meta.RESTScopeNamespace)
+	secret := &corev1.Secret{
+		TypeMeta:   metav1.TypeMeta{APIVersion: "v1", Kind: "Secret"},
+		ObjectMeta: metav1.ObjectMeta{Namespace: "ns1", Name: "s1"},
+		Data:       map[string][]byte{"password": []byte("hunter2")},
+	}
+	downsynced := newWidget("ns1", "w3", map[string]any{"app": "a"})
+	downsynced.SetOwnerReferences([]metav1.OwnerReference{{APIVersion: "work.open-cluster-management.io/v1", Kind: appliedManifestWorkKind, Name: "x", UID: "y"}})
+	itsK8sClient := k8sfake.NewSimpleClientset()
+	wecDynamicClient := dynamicfake.NewSimpleDynamicClientWithCustomListKinds(scheme, map[schema.GroupVersionResource]string{widgetGVR: "WidgetList"},
+		newWidget("ns1", "w1", map[string]any{"app": "a"}), newWidget("ns1", "w2", map[string]any{"app": "b"}), downsynced, secret)
+	informerFactory := k8sinformers.NewSharedInformerFactoryWithOptions(itsK8sClient, 0, k8sinformers.WithNamespace(wecName))
+	agent := NewAgent(ctx, wecName, itsK8sClient.CoreV1().ConfigMaps(wecName), itsK8sClient.CoreV1().Secrets(wecName),
+		informerFactory.Core().V1().ConfigMaps(), informerFactory.Core().V1().Secrets(), wecDynamicClient, mapper)
+	informerFactory.Start(ctx.Done())
+	go agent.Run(ctx, 2)
+
+	requestName := RequestName("b1", "wds1")
+	clauses := []v1alpha1.UpsyncClause{
+		{APIGroup: "example.com", Resources: []string{"widgets"},
+			ObjectSelectors: []metav1.LabelSelector{{MatchLabels: map[string]string{"app": "a"}}}},
+		{Resources: []string{"secrets"}, Namespaces: []string{"ns1"}},
+	}
+	request := &corev1.ConfigMap{
+		ObjectMeta: metav1.ObjectMeta{Namespace: wecName, Name: requestName,
+			Labels: map[string]string{RoleLabelKey: RoleRequest, BindingLabelKey: "b1", OriginWdsLabelKey: "wds1"}},
+		Data: EncodeClauses(clauses),
+	}
+	if _, err := itsK8sClient.CoreV1().ConfigMaps(wecName).Create(ctx, request, metav1.CreateOptions{}); err != nil {
+		t.Fatalf("Failed to create request: %v", err)
+	}
+	listRecords := func() []corev1.ConfigMap {
+		list, err := itsK8sClient.CoreV1().ConfigMaps(wecName).List(ctx, metav1.ListOptions{
+			LabelSelector: labels.SelectorFromSet(labels.Set{RoleLabelKey: RoleRecord, RequestLabelKey: requestName}).String()})
+		if err != nil {
+			t.Fatalf("Failed to list records: %v", err)
+		}
+		return list.Items
+	}
+	listSecretRecords := func() []corev1.Secret {
+		list, err := itsK8sClient.CoreV1().Secrets(wecName).List(ctx, metav1.ListOptions{
+			LabelSelector: labels.SelectorFromSet(labels.Set{RoleLabelKey: RoleRecord, RequestLabelKey: requestName}).String()})
+		if err != nil {
+			t.Fatalf("Failed to list Secret records: %v", err)
+		}
+		return list.Items
+	}
+	expect := func(what string, cond func() bool) {
+		if err := wait.PollUntilContextTimeout(ctx, 10*time.Millisecond, 10*time.Second, true, func(context.Context) (bool, error) { return cond(), nil }); err != nil {
+			t.Fatalf("Timed out waiting for %s", what)
+		}
+		logger.Info("Got expected state", "what", what)
+	}
+
+	expect("one record of each kind", func() bool { return len(listRecords()) == 1 && len(listSecretRecords()) == 1 })
+	recordCM := listRecords()[0]
+	if recordCM.Labels[BindingLabelKey] != "b1" || recordCM.Labels[OriginWdsLabelKey] != "wds1" {
+		t.Errorf("Record has wrong labels: %v", recordCM.Labels)
+	}
+	record, err := DecodeRecord(recordCM.Data)
+	if err != nil {
+		t.Fatalf("Failed to decode record: %v", err)
+	}
+	if record.SourceRef.Name != "w1" || record.SourceRef.Resource != "widgets" || record.SourceRef.Version != "v1" {
+		t.Errorf("Record has wrong sourceRef: %#v", record.SourceRef)
+	}
+	secretRecord, err := DecodeRecord(SecretRecordData(listSecretRecords()[0].Data))
+	if err != nil {
+		t.Fatalf("Failed to decode Secret record: %v", err)
+	}
+	if secretRecord.SourceRef.Name != "s1" || secretRecord.SourceRef.Resource != "secrets" || secretRecord.Object.Object["data"] == nil {
+		t.Errorf("Secret record is wrong: %#v", secretRecord)
+	}
+
+	if err := itsK8sClient.CoreV1().ConfigMaps(wecName).Delete(ctx, requestName, metav1.DeleteOptions{}); err != nil {
+		t.Fatalf("Failed to delete request: %v", err)
+	}
+	expect("records deleted", func() bool { return len(listRecords()) == 0 && len(listSecretRecords()) == 0 })
+}
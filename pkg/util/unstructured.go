@@ -51,6 +51,9 @@ const (
 	WorkStatusVersion  = "v1alpha1"
 	WorkStatusResource = "workstatuses"
 
+	ReportedStateKind     = "ReportedState"
+	ReportedStateResource = "reportedstates"
+
 	StatusCollectorKind     = "StatusCollector"
 	StatusCollectorResource = "statuscollectors"
 	StatusCollectorGroup    = "control.kubestellar.io"
@@ -0,0 +1,183 @@
+/*
+Copyright 2024 The KubeStellar Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+// Package wecreport defines how an agent with access to a WEC reports, to the transport
+// controller, how the workload objects in the WEC compare with the desired ones:
+// those that already existed and were not adopted, and those that have drifted.
+// For each bundle of workload objects that it applies, the agent maintains a report,
+// which is a ConfigMap in the WEC's mailbox namespace in the ITS. The transport
+// controller summarizes the reports in the status of the Binding.
+package wecreport
+
+import (
+	"encoding/json"
+	"fmt"
+	"sort"
+	"strconv"
+	"strings"
+
+	apiequality "k8s.io/apimachinery/pkg/api/equality"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+
+	"github.com/kubestellar/kubestellar/api/control/v1alpha1"
+)
+
+const (
+	// ReportLabelKey is the key of a label, with value "true", on every report.
+	ReportLabelKey = "transport.kubestellar.io/wec-report"
+
+	// OriginWdsLabelKey is the key of the label, on a report, that identifies
+	// the WDS that the bundle came from.
+	OriginWdsLabelKey = "transport.kubestellar.io/originWdsName"
+
+	// BindingLabelKey is the key of the label, on a report, whose value is the name
+	// of the Binding. This is the same label that the transport controller puts on
+	// the bundles (wrapped objects), so the agent copies it from the bundle.
+	BindingLabelKey = "transport.kubestellar.io/originOwnerReferenceBindingKey"
+
+	// ReportNameSuffix is appended to the name of a bundle to get the name of its report.
+	ReportNameSuffix = ".report"
+
+	// AdoptionDataKey is the key, in the data of a report, whose value is the JSON
+	// rendering of the list of ObjectDifferences for the objects that already existed
+	// in the WEC and have not been adopted. This key is absent when there are none.
+	AdoptionDataKey = "adoption"
+
+	// DriftDataKey is the key, in the data of a report, whose value is the JSON rendering
+	// of the list of ObjectDrift for the objects that were found to have drifted.
+	// This key is absent when there are none.
+	DriftDataKey = "drift"
+)
+
+// ObjectDifferences compares one object in the WEC with the desired one.
+type ObjectDifferences struct {
+	SourceRef v1alpha1.SourceObjectReference `json:"sourceRef"`
+
+	// Differences is the sorted list of paths of the fields that differ.
+	Differences []string `json:"differences,omitempty"`
+}
+
+// ObjectDrift says how one object in the WEC has drifted from the desired one.
+type ObjectDrift struct {
+	ObjectDifferences
+
+	// Remediated tells whether the agent has written the desired object over the drift.
+	Remediated bool `json:"remediated,omitempty"`
+}
+
+// Report is the content of a report.
+type Report struct {
+	Adoption []ObjectDifferences
+	Drift    []ObjectDrift
+}
+
+// IsEmpty tells whether there is nothing to report.
+func (report *Report) IsEmpty() bool {
+	return len(report.Adoption) == 0 && len(report.Drift) == 0
+}
+
+// Encode renders the report as the data of a ConfigMap.
+func (report *Report) Encode() (map[string]string, error) {
+	data := map[string]string{}
+	if len(report.Adoption) > 0 {
+		adoption, err := json.Marshal(report.Adoption)
+		if err != nil {
+			return nil, fmt.Errorf("failed to render %s: %w", AdoptionDataKey, err)
+		}
+		data[AdoptionDataKey] = string(adoption)
+	}
+	if len(report.Drift) > 0 {
+		drift, err := json.Marshal(report.Drift)
+		if err != nil {
+			return nil, fmt.Errorf("failed to render %s: %w", DriftDataKey, err)
+		}
+		data[DriftDataKey] = string(drift)
+	}
+	return data, nil
+}
+
+// DecodeReport parses the data of a report.
+func DecodeReport(data map[string]string) (*Report, error) {
+	report := &Report{}
+	if adoption, found := data[AdoptionDataKey]; found {
+		if err := json.Unmarshal([]byte(adoption), &report.Adoption); err != nil {
+			return nil, fmt.Errorf("failed to parse %s of report: %w", AdoptionDataKey, err)
+		}
+	}
+	if drift, found := data[DriftDataKey]; found {
+		if err := json.Unmarshal([]byte(drift), &report.Drift); err != nil {
+			return nil, fmt.Errorf("failed to parse %s of report: %w", DriftDataKey, err)
+		}
+	}
+	return report, nil
+}
+
+// Differences returns the sorted paths of the fields whose values in actual differ from
+// those in desired. Only the fields that are present in desired are compared, so the fields
+// that are defaulted by the server do not count. Of the metadata, only the labels and
+// annotations are compared; the status is ignored.
+func Differences(desired, actual *unstructured.Unstructured) []string {
+	var diffs []string
+	for key, val := range desired.Object {
+		switch key {
+		case "apiVersion", "kind", "status":
+		case "metadata":
+			desiredMeta, _ := val.(map[string]any)
+			actualMeta, _ := actual.Object["metadata"].(map[string]any)
+			for _, metaKey := range []string{"labels", "annotations"} {
+				if desiredVal, found := desiredMeta[metaKey]; found {
+					diffs = appendDifferences(diffs, "metadata."+metaKey, desiredVal, actualMeta[metaKey])
+				}
+			}
+		default:
+			diffs = appendDifferences(diffs, key, val, actual.Object[key])
+		}
+	}
+	sort.Strings(diffs)
+	return diffs
+}
+
+func appendDifferences(diffs []string, path string, desired, actual any) []string {
+	switch typed := desired.(type) {
+	case map[string]any:
+		if actualMap, ok := actual.(map[string]any); ok {
+			for key, val := range typed {
+				diffs = appendDifferences(diffs, fieldPath(path, key), val, actualMap[key])
+			}
+			return diffs
+		}
+	case []any:
+		if actualList, ok := actual.([]any); ok && len(actualList) == len(typed) {
+			for idx, val := range typed {
+				diffs = appendDifferences(diffs, path+"["+strconv.Itoa(idx)+"]", val, actualList[idx])
+			}
+			return diffs
+		}
+	}
+	if apiequality.Semantic.DeepEqual(desired, actual) {
+		return diffs
+	}
+	return append(diffs, path)
+}
+
+// fieldPath extends the given path with the given key, quoting keys (such as
+// label keys) that contain a dot.
+func fieldPath(path, key string) string {
+	if strings.Contains(key, ".") {
+		return path + "[" + strconv.Quote(key) + "]"
+	}
+	return path + "." + key
+}
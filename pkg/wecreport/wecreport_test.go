@@ -0,0 +1,82 @@
+/*
+Copyright 2024 The KubeStellar Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package wecreport
+
+import (
+	"reflect"
+	"testing"
+
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+
+	"github.com/kubestellar/kubestellar/api/control/v1alpha1"
+	"github.com/kubestellar/kubestellar/pkg/abstract"
+)
+
+func TestDifferences(t *testing.T) {
+	desired := &unstructured.Unstructured{Object: map[string]any{
+		"apiVersion": "apps/v1",
+		"kind":       "Deployment",
+		"metadata":   map[string]any{"namespace": "ns1", "name": "d1", "labels": map[string]any{"app.kubernetes.io/name": "x"}},
+		"spec": map[string]any{
+			"replicas": int64(2),
+			"template": map[string]any{"spec": map[string]any{"containers": []any{
+				map[string]any{"name": "c", "image": "nginx:1"}}}},
+		},
+	}}
+	actual := desired.DeepCopy()
+	actual.SetUID("u1")
+	actual.SetAnnotations(map[string]string{"a": "b"})
+	actual.Object["status"] = map[string]any{"replicas": int64(1)}
+	unstructured.SetNestedField(actual.Object, "Always", "spec", "template", "spec", "restartPolicy")
+	containers, _, _ := unstructured.NestedSlice(actual.Object, "spec", "template", "spec", "containers")
+	containers[0].(map[string]any)["imagePullPolicy"] = "IfNotPresent"
+	unstructured.SetNestedSlice(actual.Object, containers, "spec", "template", "spec", "containers")
+	if diffs := Differences(desired, actual); len(diffs) != 0 {
+		t.Errorf("Expected no differences for defaulted fields, got %v", diffs)
+	}
+
+	containers[0].(map[string]any)["image"] = "nginx:2"
+	unstructured.SetNestedSlice(actual.Object, containers, "spec", "template", "spec", "containers")
+	unstructured.SetNestedField(actual.Object, int64(3), "spec", "replicas")
+	actual.SetLabels(map[string]string{"app.kubernetes.io/name": "y"})
+	expected := []string{`metadata.labels["app.kubernetes.io/name"]`, "spec.replicas", "spec.template.spec.containers[0].image"}
+	if diffs := Differences(desired, actual); !abstract.SliceEqual(diffs, expected) {
+		t.Errorf("Expected %v, got %v", expected, diffs)
+	}
+}
+
+func TestReportRoundTrip(t *testing.T) {
+	objDiffs := ObjectDifferences{
+		SourceRef:   v1alpha1.SourceObjectReference{Group: "apps", Version: "v1", Resource: "deployments", Kind: "Deployment", Namespace: "ns1", Name: "d1"},
+		Differences: []string{"spec.replicas"},
+	}
+	report := &Report{Adoption: []ObjectDifferences{objDiffs}, Drift: []ObjectDrift{{ObjectDifferences: objDiffs, Remediated: true}}}
+	data, err := report.Encode()
+	if err != nil {
+		t.Fatalf("Failed to encode: %v", err)
+	}
+	decoded, err := DecodeReport(data)
+	if err != nil {
+		t.Fatalf("Failed to decode: %v", err)
+	}
+	if !reflect.DeepEqual(decoded, report) {
+		t.Errorf("Round trip changed the report: %#v", decoded)
+	}
+	if empty, err := (&Report{}).Encode(); err != nil || len(empty) != 0 {
+		t.Errorf("Empty report encoded as %v, err=%v", empty, err)
+	}
+}